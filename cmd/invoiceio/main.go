@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"fyne.io/fyne/v2"
 	fyneApp "fyne.io/fyne/v2/app"
 
+	"github.com/janmarkuslanger/invoiceio/internal/cli"
+	"github.com/janmarkuslanger/invoiceio/internal/server"
 	"github.com/janmarkuslanger/invoiceio/internal/storage"
 	"github.com/janmarkuslanger/invoiceio/internal/ui"
 )
 
 func main() {
 	dataDirFlag := flag.String("data", "data", "directory used to store application data")
+	apiAddr := flag.String("api", "", "serve the REST API on this address instead of opening the GUI")
+	apiToken := flag.String("api-token", os.Getenv(server.TokenEnv), "token for -api (default $"+server.TokenEnv+")")
 	flag.Parse()
 
 	dataDir, err := filepath.Abs(*dataDirFlag)
@@ -29,10 +37,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	if flag.NArg() > 0 {
+		err := cli.Run(store, flag.Args(), os.Stdout, os.Stderr)
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, cli.ErrUsage):
+			os.Exit(2)
+		default:
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *apiAddr != "" {
+		srv, err := server.New(store, *apiToken)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "start API: %v\n", err)
+			os.Exit(1)
+		}
+		// The GUI keeps its own copy of the data, so the API runs on its
+		// own rather than next to a window that would overwrite its writes.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Fprintf(os.Stderr, "serving API on http://%s/api\n", *apiAddr)
+		if err := srv.ListenAndServe(ctx, *apiAddr); err != nil {
+			fmt.Fprintf(os.Stderr, "serve API: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app := fyneApp.NewWithID("invoiceio")
 	window := app.NewWindow("InvoiceIO")
 	uiLayer := ui.New(store, window)
 	window.SetContent(uiLayer.Build())
 	window.Resize(fyne.NewSize(960, 640))
-	window.ShowAndRun()
+	window.Show()
+	uiLayer.RunDueSubscriptions()
+	app.Run()
 }
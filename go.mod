@@ -2,7 +2,10 @@ module github.com/janmarkuslanger/invoiceio
 
 go 1.24.0
 
-require github.com/janmarkuslanger/jsonstore v0.0.1
+require (
+	github.com/janmarkuslanger/jsonstore v0.0.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	fyne.io/fyne/v2 v2.7.0
@@ -37,5 +40,4 @@ require (
 	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,174 @@
+// Package cli implements the headless subcommands of invoiceio. They work on
+// the same data directory as the GUI and share its calculation, validation
+// and PDF code.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+// ErrUsage is returned for unknown commands; the usage text has already been
+// written to the error output.
+var ErrUsage = errors.New("cli: invalid usage")
+
+const usage = `Usage: invoiceio [-data DIR] <command> <subcommand> [flags]
+
+Commands:
+  invoice list [--json]
+  invoice show [--json] <id|number>
+  invoice create --profile P --customer C --item "description|quantity|price[|tax]" ... [--net-days N] [--discount "percent:days"] ... [--draft] [flags]
+  invoice finalize <id>
+  invoice history [--json] <id|number>
+  invoice import [--dry-run] [--json] FILE...
+  invoice render [--output FILE] <id|number>
+  invoice pay --amount AMOUNT [--date YYYY-MM-DD] [--method M] [--reference R] [--note N] <id|number>
+  invoice mark-paid [--date YYYY-MM-DD] <id|number>
+  dunning list [--json]
+  dunning send [--only] [--date YYYY-MM-DD] <id|number>
+  dunning interest [--date YYYY-MM-DD] [--json] <id|number>
+  directdebit list --profile P [--json]
+  directdebit export --profile P [--date YYYY-MM-DD] [<id|number>...]
+  directdebit cancel <id|number>
+  subscription list [--json]
+  subscription add --name NAME --profile P --customer C --item "description|quantity|price[|tax]" ... [--interval I] [--day N] [--start YYYY-MM-DD] [--end YYYY-MM-DD] [flags]
+  subscription run [--date YYYY-MM-DD] [--dry-run] [--json]
+  subscription delete <id|name>
+  statement import [--apply] [--json] [CSV flags] FILE
+  customer list [--json]
+  customer add --name NAME [flags]
+  profile list [--json]
+  serve [--addr HOST:PORT] [--token TOKEN]
+
+Run "invoiceio <command> <subcommand> -h" to list the flags of a subcommand.
+Without a command the graphical interface is started.
+`
+
+type command struct {
+	store  *storage.Storage
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run executes the subcommand named by the first two arguments.
+func Run(store *storage.Storage, args []string, stdout, stderr io.Writer) error {
+	c := &command{store: store, stdout: stdout, stderr: stderr}
+	handlers := map[string]map[string]func([]string) error{
+		"invoice": {
+			"list":      c.invoiceList,
+			"show":      c.invoiceShow,
+			"create":    c.invoiceCreate,
+			"finalize":  c.invoiceFinalize,
+			"history":   c.invoiceHistory,
+			"import":    c.invoiceImport,
+			"render":    c.invoiceRender,
+			"pay":       c.invoicePay,
+			"mark-paid": c.invoiceMarkPaid,
+		},
+		"dunning": {
+			"list":     c.dunningList,
+			"send":     c.dunningSend,
+			"interest": c.dunningInterest,
+		},
+		"directdebit": {
+			"list":   c.directDebitList,
+			"export": c.directDebitExport,
+			"cancel": c.directDebitCancel,
+		},
+		"subscription": {
+			"list":   c.subscriptionList,
+			"add":    c.subscriptionAdd,
+			"run":    c.subscriptionRun,
+			"delete": c.subscriptionDelete,
+		},
+		"statement": {
+			"import": c.statementImport,
+		},
+		"customer": {
+			"list": c.customerList,
+			"add":  c.customerAdd,
+		},
+		"profile": {
+			"list": c.profileList,
+		},
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		return c.serve(args[1:])
+	}
+	if len(args) < 2 {
+		fmt.Fprint(stderr, usage)
+		return ErrUsage
+	}
+	handler, ok := handlers[args[0]][args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", strings.Join(args[:2], " "), usage)
+		return ErrUsage
+	}
+	return handler(args[2:])
+}
+
+func (c *command) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("invoiceio "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// parse accepts flags before and after positional arguments and returns the
+// positional ones.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// single returns the only positional argument.
+func single(fs *flag.FlagSet, args []string, what string) (string, error) {
+	positional, err := parse(fs, args)
+	if err != nil {
+		return "", err
+	}
+	if len(positional) != 1 {
+		return "", fmt.Errorf("cli: %s expects exactly one %s", fs.Name(), what)
+	}
+	return positional[0], nil
+}
+
+func (c *command) writeJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *command) table(columns ...string) *tabwriter.Writer {
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	return w
+}
+
+// stringList collects a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/server"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+func TestRun(t *testing.T) {
+	t.Setenv(server.TokenEnv, "")
+	tests := []struct {
+		name           string
+		args           []string
+		err            error
+		failed         bool
+		stdout, stderr string
+	}{
+		{name: "no command", err: ErrUsage, stderr: "Usage: invoiceio"},
+		{name: "command without subcommand", args: []string{"invoice"}, err: ErrUsage, stderr: "Usage: invoiceio"},
+		{name: "unknown subcommand", args: []string{"invoice", "frobnicate"}, err: ErrUsage, stderr: `unknown command "invoice frobnicate"`},
+		{name: "unknown command", args: []string{"nope", "list"}, err: ErrUsage, stderr: `unknown command "nope list"`},
+		{name: "table", args: []string{"profile", "list"}, stdout: "p   Seller"},
+		{name: "json", args: []string{"profile", "list", "--json"}, stdout: `"display_name": "Seller"`},
+		{name: "flag after the subcommand", args: []string{"customer", "list", "--json"}, stdout: "[]"},
+		{name: "help", args: []string{"invoice", "list", "-h"}, err: flag.ErrHelp, stderr: "-json"},
+		{name: "unknown flag", args: []string{"profile", "list", "--nope"}, failed: true, stderr: "flag provided but not defined: -nope"},
+		{name: "missing argument", args: []string{"invoice", "show"}, failed: true},
+		{name: "unknown invoice", args: []string{"invoice", "show", "nope"}, err: storage.ErrNotFound},
+		{name: "serve without token", args: []string{"serve"}, err: server.ErrMissingToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := storage.New(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.SaveProfile(models.Profile{ID: "p", DisplayName: "Seller"}); err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			err = Run(store, tt.args, &stdout, &stderr)
+			switch {
+			case tt.failed:
+				if err == nil || errors.Is(err, ErrUsage) {
+					t.Errorf("err = %v, want a command error", err)
+				}
+			case !errors.Is(err, tt.err):
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.stdout)
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/sepa"
+)
+
+func (c *command) customerList(args []string) error {
+	fs := c.flags("customer list")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	customers, err := c.store.ListCustomers()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(customers)
+	}
+	w := c.table("ID", "NAME", "EMAIL", "CITY", "COUNTRY")
+	for _, cust := range customers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", cust.ID, cust.DisplayName, cust.Email, cust.City, cust.Country)
+	}
+	return w.Flush()
+}
+
+func (c *command) customerAdd(args []string) error {
+	fs := c.flags("customer add")
+	name := fs.String("name", "", "display name (required)")
+	contact := fs.String("contact", "", "contact person")
+	email := fs.String("email", "", "email address")
+	phone := fs.String("phone", "", "phone number")
+	address1 := fs.String("address1", "", "address line 1")
+	address2 := fs.String("address2", "", "address line 2")
+	city := fs.String("city", "", "city")
+	postalCode := fs.String("postal-code", "", "postal code")
+	country := fs.String("country", "", "country")
+	leitwegID := fs.String("leitweg-id", "", "Leitweg-ID for XRechnung")
+	vatID := fs.String("vat-id", "", "VAT identification number, required for reverse charge")
+	notes := fs.String("notes", "", "internal notes")
+	termsFlags := addTermsFlags(fs)
+	exemptionFlags := addExemptionFlags(fs)
+	mandateID := fs.String("mandate-id", "", "SEPA direct debit mandate reference")
+	mandateDate := fs.String("mandate-date", "", "date the mandate was signed as YYYY-MM-DD")
+	mandateSequence := fs.String("mandate-sequence", models.SequenceFirst, "sequence type of the next collection (FRST, RCUR, OOFF or FNAL)")
+	debtorIBAN := fs.String("debtor-iban", "", "IBAN the mandate allows to debit")
+	debtorBIC := fs.String("debtor-bic", "", "BIC of the debtor's bank")
+	asJSON := fs.Bool("json", false, "print the created customer as JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" {
+		return errors.New("cli: --name is required")
+	}
+	terms, _, err := termsFlags.terms()
+	if err != nil {
+		return err
+	}
+	exemption, err := exemptionFlags.exemption()
+	if err != nil {
+		return err
+	}
+	mandate := models.Mandate{
+		ID:           strings.TrimSpace(*mandateID),
+		SequenceType: strings.ToUpper(strings.TrimSpace(*mandateSequence)),
+		IBAN:         sepa.Compact(*debtorIBAN),
+		BIC:          sepa.Compact(*debtorBIC),
+	}
+	if *mandateDate != "" {
+		if mandate.SignatureDate, err = time.Parse(dateLayout, *mandateDate); err != nil {
+			return fmt.Errorf("cli: invalid --mandate-date: %w", err)
+		}
+	}
+	if err := sepa.CheckMandate(mandate); err != nil {
+		return fmt.Errorf("cli: %w", err)
+	}
+
+	now := time.Now()
+	customer := models.Customer{
+		ID:           id.New(),
+		DisplayName:  strings.TrimSpace(*name),
+		ContactName:  strings.TrimSpace(*contact),
+		Email:        strings.TrimSpace(*email),
+		Phone:        strings.TrimSpace(*phone),
+		AddressLine1: strings.TrimSpace(*address1),
+		AddressLine2: strings.TrimSpace(*address2),
+		City:         strings.TrimSpace(*city),
+		PostalCode:   strings.TrimSpace(*postalCode),
+		Country:      strings.TrimSpace(*country),
+		Notes:        strings.TrimSpace(*notes),
+		LeitwegID:    strings.TrimSpace(*leitwegID),
+		VATID:        strings.TrimSpace(*vatID),
+		Terms:        terms,
+		TaxExemption: exemption,
+		Mandate:      mandate,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := c.store.SaveCustomer(customer); err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(customer)
+	}
+	fmt.Fprintf(c.stdout, "created customer %s (%s)\n", customer.DisplayName, customer.ID)
+	return nil
+}
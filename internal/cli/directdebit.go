@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// directDebitList prints the invoices of a profile that can be collected by
+// direct debit.
+func (c *command) directDebitList(args []string) error {
+	fs := c.flags("directdebit list")
+	profileRef := fs.String("profile", "", "profile ID or name (required)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if *profileRef == "" {
+		return errors.New("cli: --profile is required")
+	}
+	profile, err := c.store.FindProfile(*profileRef)
+	if err != nil {
+		return err
+	}
+	candidates, err := invoicing.DirectDebitCandidates(c.store, profile.ID)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if candidates == nil {
+			candidates = []models.Invoice{}
+		}
+		return c.writeJSON(candidates)
+	}
+	customers, err := c.store.ListCustomers()
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]models.Customer, len(customers))
+	for _, cust := range customers {
+		byID[cust.ID] = cust
+	}
+	w := c.table("NUMBER", "CUSTOMER", "MANDATE", "SEQUENCE", "DUE", "OUTSTANDING")
+	for _, inv := range candidates {
+		cust := byID[inv.CustomerID]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", inv.Number, cust.DisplayName, cust.Mandate.ID, cust.Mandate.SequenceType, inv.DueDate.Format(dateLayout), inv.Outstanding())
+	}
+	return w.Flush()
+}
+
+// directDebitExport writes a pain.008 file for the given invoices, or for all
+// candidates of the profile if none are given.
+func (c *command) directDebitExport(args []string) error {
+	fs := c.flags("directdebit export")
+	profileRef := fs.String("profile", "", "profile ID or name (required)")
+	date := fs.String("date", "", "submission date as YYYY-MM-DD (default today)")
+	refs, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if *profileRef == "" {
+		return errors.New("cli: --profile is required")
+	}
+	now := time.Now()
+	if *date != "" {
+		if now, err = time.Parse(dateLayout, *date); err != nil {
+			return fmt.Errorf("cli: invalid --date: %w", err)
+		}
+	}
+	profile, err := c.store.FindProfile(*profileRef)
+	if err != nil {
+		return err
+	}
+	var invoices []models.Invoice
+	if len(refs) == 0 {
+		if invoices, err = invoicing.DirectDebitCandidates(c.store, profile.ID); err != nil {
+			return err
+		}
+		if len(invoices) == 0 {
+			return errors.New("cli: no invoices to collect")
+		}
+	}
+	for _, ref := range refs {
+		inv, err := c.store.FindInvoice(ref)
+		if err != nil {
+			return err
+		}
+		if inv.ProfileID != profile.ID {
+			return fmt.Errorf("cli: invoice %s does not belong to profile %s", inv.Number, profile.DisplayName)
+		}
+		invoices = append(invoices, inv)
+	}
+	updated, path, err := invoicing.ExportDirectDebit(c.store, invoices, now)
+	if err != nil {
+		return err
+	}
+	for _, inv := range updated {
+		fmt.Fprintf(c.stdout, "invoice %s: %s %s on %s\n", inv.Number, inv.Collection.SequenceType, inv.Collection.Amount, inv.Collection.Date.Format(dateLayout))
+	}
+	fmt.Fprintf(c.stdout, "wrote %s\n", path)
+	return nil
+}
+
+// directDebitCancel removes the pending collection from an invoice.
+func (c *command) directDebitCancel(args []string) error {
+	fs := c.flags("directdebit cancel")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	if _, err := invoicing.CancelCollection(c.store, inv); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "cancelled the direct debit collection of invoice %s\n", inv.Number)
+	return nil
+}
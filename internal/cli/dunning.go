@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/interest"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// dunningList prints the invoices whose next reminder is due.
+func (c *command) dunningList(args []string) error {
+	fs := c.flags("dunning list")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	now := time.Now()
+	due, err := invoicing.DueForReminder(c.store, now)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if due == nil {
+			due = []models.Invoice{}
+		}
+		return c.writeJSON(due)
+	}
+	w := c.table("NUMBER", "DUE", "DAYS OVERDUE", "OUTSTANDING", "LEVEL SENT")
+	for _, inv := range due {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\n", inv.Number, inv.DueDate.Format(dateLayout), int(now.Sub(inv.DueDate).Hours()/24), inv.Outstanding(), inv.DunningLevel())
+	}
+	return w.Flush()
+}
+
+// dunningSend sends a reminder for an invoice. The other overdue invoices of
+// the customer are listed in the same letter unless --only is given.
+func (c *command) dunningSend(args []string) error {
+	fs := c.flags("dunning send")
+	only := fs.Bool("only", false, "remind only this invoice, not the customer's other overdue invoices")
+	date := fs.String("date", "", "reminder date as YYYY-MM-DD (default today)")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if *date != "" {
+		if now, err = time.Parse(dateLayout, *date); err != nil {
+			return fmt.Errorf("cli: invalid --date: %w", err)
+		}
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	invoices := []models.Invoice{inv}
+	if !*only {
+		if invoices, err = invoicing.ReminderCandidates(c.store, inv, now); err != nil {
+			return err
+		}
+	}
+	updated, path, err := invoicing.SendReminder(c.store, invoices, now)
+	if err != nil {
+		return err
+	}
+	for _, inv := range updated {
+		r, _ := inv.LastReminder()
+		fmt.Fprintf(c.stdout, "invoice %s: reminder level %d, fee %s, pay by %s\n", inv.Number, r.Level, r.Fee, r.Deadline.Format(dateLayout))
+	}
+	fmt.Fprintf(c.stdout, "wrote %s\n", path)
+	return nil
+}
+
+// dunningInterest prints the late-payment interest accrued on an invoice,
+// split into the periods of the profile's rate schedule.
+func (c *command) dunningInterest(args []string) error {
+	fs := c.flags("dunning interest")
+	date := fs.String("date", "", "accrue up to YYYY-MM-DD (default today)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	until := time.Now()
+	if *date != "" {
+		if until, err = time.Parse(dateLayout, *date); err != nil {
+			return fmt.Errorf("cli: invalid --date: %w", err)
+		}
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	profile, err := c.store.GetProfile(inv.ProfileID)
+	if err != nil {
+		return err
+	}
+	result := interest.Calculate(inv, profile.Interest, until)
+	if *asJSON {
+		if result.Periods == nil {
+			result.Periods = []interest.Period{}
+		}
+		return c.writeJSON(result)
+	}
+	w := c.table("FROM", "TO", "DAYS", "BALANCE", "RATE", "INTEREST")
+	for _, p := range result.Periods {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s %%\t%s\n", p.From.Format(dateLayout), p.To.Format(dateLayout), p.Days, p.Balance, p.RatePercent.StringFixed(2), p.Interest)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "\ninterest until %s: %s\nflat fee: %s\ntotal: %s\n", result.Until.Format(dateLayout), result.Interest, result.FlatFee, result.Total())
+	return nil
+}
@@ -0,0 +1,533 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+const dateLayout = "2006-01-02"
+
+func (c *command) invoiceList(args []string) error {
+	fs := c.flags("invoice list")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	invoices, err := c.store.ListInvoices()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(invoices)
+	}
+	customers, err := c.store.ListCustomers()
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string, len(customers))
+	for _, cust := range customers {
+		names[cust.ID] = cust.DisplayName
+	}
+	w := c.table("NUMBER", "ISSUED", "DUE", "CUSTOMER", "TOTAL", "OUTSTANDING", "STATUS")
+	for _, inv := range invoices {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", numberOrID(inv), inv.IssueDate.Format(dateLayout), inv.DueDate.Format(dateLayout),
+			names[inv.CustomerID], inv.Total, inv.Outstanding(), invoicing.Status(inv, time.Now()))
+	}
+	return w.Flush()
+}
+
+func (c *command) invoiceShow(args []string) error {
+	fs := c.flags("invoice show")
+	asJSON := fs.Bool("json", false, "print JSON")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(inv)
+	}
+	w := c.table("FIELD", "VALUE")
+	fmt.Fprintf(w, "ID\t%s\n", inv.ID)
+	fmt.Fprintf(w, "Number\t%s\n", inv.Number)
+	fmt.Fprintf(w, "Type\t%s\n", inv.Type())
+	if inv.IsCorrection() {
+		fmt.Fprintf(w, "Corrects\t%s\n", inv.OriginalNumber)
+	}
+	fmt.Fprintf(w, "Profile\t%s\n", inv.ProfileID)
+	fmt.Fprintf(w, "Customer\t%s\n", inv.CustomerID)
+	fmt.Fprintf(w, "Issued\t%s\n", inv.IssueDate.Format(dateLayout))
+	fmt.Fprintf(w, "Due\t%s\n", inv.DueDate.Format(dateLayout))
+	fmt.Fprintf(w, "Status\t%s\n", invoicing.Status(inv, time.Now()))
+	if !inv.IsDraft() {
+		fmt.Fprintf(w, "Finalised\t%s\n", inv.FinalizedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Subtotal\t%s\n", inv.Subtotal)
+	for _, tax := range inv.TaxBreakdown {
+		fmt.Fprintf(w, "Tax %s%%\t%s\n", tax.RatePercent, tax.Tax)
+	}
+	fmt.Fprintf(w, "Total\t%s\n", inv.Total)
+	if !inv.Terms.IsZero() {
+		fmt.Fprintf(w, "Terms\t%s\n", inv.Terms)
+	}
+	for _, offer := range inv.DiscountOffers() {
+		fmt.Fprintf(w, "Discount %s%%\t%s by %s (saves %s)\n", offer.Percent, offer.Amount, offer.Deadline.Format(dateLayout), offer.Discount)
+	}
+	if !inv.IsDraft() {
+		fmt.Fprintf(w, "Paid\t%s (%s)\n", inv.AmountPaid(), inv.PaymentStatus())
+		if discount := inv.DiscountGranted(); !discount.IsZero() {
+			fmt.Fprintf(w, "Discount granted\t%s\n", discount)
+		}
+		if !inv.Credited.IsZero() {
+			fmt.Fprintf(w, "Credited\t%s\n", inv.Credited)
+		}
+		fmt.Fprintf(w, "Outstanding\t%s\n", inv.Outstanding())
+	}
+	if !inv.Collection.IsZero() {
+		fmt.Fprintf(w, "Direct debit\t%s on %s (%s, mandate %s, file %s)\n", inv.Collection.Amount, inv.Collection.Date.Format(dateLayout), inv.Collection.SequenceType, inv.Collection.MandateID, inv.Collection.MessageID)
+	}
+	fmt.Fprintf(w, "PDF\t%s\n", inv.PDFPath)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout)
+	w = c.table("DESCRIPTION", "QUANTITY", "UNIT PRICE", "TAX %", "LINE TOTAL")
+	for _, item := range inv.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Description, item.Quantity, item.UnitPrice, item.TaxRatePercent, item.LineTotal)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(inv.Payments) > 0 {
+		fmt.Fprintln(c.stdout)
+		w = c.table("PAYMENT", "DATE", "AMOUNT", "METHOD", "REFERENCE", "NOTE")
+		for _, p := range inv.Payments {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Date.Format(dateLayout), p.Amount, p.Method, p.Reference, p.Note)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(inv.Reminders) > 0 {
+		fmt.Fprintln(c.stdout)
+		w = c.table("REMINDER", "DATE", "LEVEL", "FEE", "INTEREST", "DEADLINE", "PDF")
+		for _, r := range inv.Reminders {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", r.ID, r.Date.Format(dateLayout), r.Level, r.Fee, r.Interest.Add(r.FlatFee), r.Deadline.Format(dateLayout), r.PDFPath)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if notes := strings.TrimSpace(inv.Notes); notes != "" {
+		fmt.Fprintf(c.stdout, "\n%s\n", notes)
+	}
+	return nil
+}
+
+func (c *command) invoiceCreate(args []string) error {
+	fs := c.flags("invoice create")
+	profileRef := fs.String("profile", "", "profile ID or name (required)")
+	customerRef := fs.String("customer", "", "customer ID or name (required)")
+	var itemSpecs stringList
+	fs.Var(&itemSpecs, "item", `line item as "description|quantity|unit price[|tax rate]", repeatable`)
+	taxRate := fs.String("tax", "0", "tax rate in percent for items without their own rate")
+	issueDate := fs.String("issue-date", "", "issue date as YYYY-MM-DD (default today)")
+	dueDate := fs.String("due-date", "", fmt.Sprintf("due date as YYYY-MM-DD (default issue date + net days, or + %d days)", invoicing.DefaultPaymentDays))
+	termsFlags := addTermsFlags(fs)
+	exemptionFlags := addExemptionFlags(fs)
+	currency := fs.String("currency", money.DefaultCurrency, "ISO 4217 currency code")
+	notes := fs.String("notes", "", "notes printed on the invoice")
+	draft := fs.Bool("draft", false, "store an unvalidated draft without number and PDF")
+	asJSON := fs.Bool("json", false, "print the created invoice as JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if *profileRef == "" || *customerRef == "" {
+		return errors.New("cli: --profile and --customer are required")
+	}
+
+	profile, err := c.store.FindProfile(*profileRef)
+	if err != nil {
+		return err
+	}
+	customer, err := c.store.FindCustomer(*customerRef)
+	if err != nil {
+		return err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(*currency))
+	defaultRate, err := locale.ParseDecimal(*taxRate)
+	if err != nil {
+		return fmt.Errorf("cli: invalid --tax: %w", err)
+	}
+	items := make([]models.InvoiceItem, 0, len(itemSpecs))
+	for i, spec := range itemSpecs {
+		item, err := parseItem(spec, code, defaultRate)
+		if err != nil {
+			return fmt.Errorf("cli: item %d: %w", i+1, err)
+		}
+		items = append(items, item)
+	}
+
+	issue := time.Now()
+	if *issueDate != "" {
+		if issue, err = time.Parse(dateLayout, *issueDate); err != nil {
+			return fmt.Errorf("cli: invalid --issue-date: %w", err)
+		}
+	}
+	terms, set, err := termsFlags.terms()
+	if err != nil {
+		return err
+	}
+	if !set {
+		terms = invoicing.DefaultTerms(profile, customer)
+	}
+	exemption, err := exemptionFlags.exemption()
+	if err != nil {
+		return err
+	}
+	due := invoicing.DueDate(issue, terms)
+	if *dueDate != "" {
+		if due, err = time.Parse(dateLayout, *dueDate); err != nil {
+			return fmt.Errorf("cli: invalid --due-date: %w", err)
+		}
+	}
+
+	now := time.Now()
+	invoice := models.Invoice{
+		ID:           id.New(),
+		ProfileID:    profile.ID,
+		CustomerID:   customer.ID,
+		IssueDate:    issue,
+		DueDate:      due,
+		Currency:     code,
+		Items:        items,
+		Notes:        strings.TrimSpace(*notes),
+		Terms:        terms,
+		TaxExemption: exemption,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	invoice.Recalculate()
+
+	if *draft {
+		if err := c.store.SaveInvoice(invoice); err != nil {
+			return err
+		}
+		if *asJSON {
+			return c.writeJSON(invoice)
+		}
+		fmt.Fprintf(c.stdout, "created draft %s (%s)\n", invoice.ID, invoice.Total)
+		return nil
+	}
+
+	findings := invoicing.CheckNew(profile, customer, invoice)
+	if len(findings) > 0 {
+		fmt.Fprintln(c.stderr, findings)
+	}
+	if findings.HasErrors() {
+		return invoicing.ErrIncomplete
+	}
+
+	invoice, err = invoicing.Create(c.store, profile, customer, invoice)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(invoice)
+	}
+	fmt.Fprintf(c.stdout, "created invoice %s (%s), PDF written to %s\n", invoice.Number, invoice.Total, invoice.PDFPath)
+	return nil
+}
+
+func (c *command) invoiceImport(args []string) error {
+	fs := c.flags("invoice import")
+	dryRun := fs.Bool("dry-run", false, "only report what would be created")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	paths, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("cli: invoice import expects at least one file")
+	}
+	var defs []invoicing.Definition
+	for _, path := range paths {
+		loaded, err := invoicing.LoadDefinitions(path)
+		if err != nil {
+			return err
+		}
+		defs = append(defs, loaded...)
+	}
+
+	results := invoicing.Import(c.store, defs, *dryRun)
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if *asJSON {
+		type importResult struct {
+			Source   string          `json:"source"`
+			Invoice  *models.Invoice `json:"invoice,omitempty"`
+			Findings []string        `json:"findings,omitempty"`
+			Error    string          `json:"error,omitempty"`
+		}
+		out := make([]importResult, len(results))
+		for i, res := range results {
+			out[i] = importResult{Source: res.Source}
+			for _, f := range res.Findings {
+				out[i].Findings = append(out[i].Findings, f.String())
+			}
+			if res.Err != nil {
+				out[i].Error = res.Err.Error()
+			} else {
+				out[i].Invoice = &results[i].Invoice
+			}
+		}
+		if err := c.writeJSON(out); err != nil {
+			return err
+		}
+	} else {
+		w := c.table("SOURCE", "NUMBER", "CUSTOMER", "TOTAL", "RESULT")
+		for _, res := range results {
+			outcome := "created"
+			switch {
+			case res.Err != nil:
+				outcome = "failed: " + res.Err.Error()
+			case *dryRun:
+				outcome = "would be created"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", res.Source, res.Invoice.Number, res.Customer.DisplayName, res.Invoice.Total, outcome)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		for _, res := range results {
+			for _, f := range res.Findings {
+				fmt.Fprintf(c.stderr, "%s: %s\n", res.Source, f)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("cli: %d of %d invoices failed", failed, len(results))
+	}
+	return nil
+}
+
+// parseItem reads a line item written as "description|quantity|price[|tax]".
+func parseItem(spec, currency string, defaultRate money.Decimal) (models.InvoiceItem, error) {
+	parts := strings.Split(spec, "|")
+	if len(parts) != 3 && len(parts) != 4 {
+		return models.InvoiceItem{}, fmt.Errorf("expected \"description|quantity|price[|tax]\", got %q", spec)
+	}
+	quantity, err := locale.ParseDecimal(parts[1])
+	if err != nil {
+		return models.InvoiceItem{}, fmt.Errorf("invalid quantity: %w", err)
+	}
+	price, err := locale.ParseMoney(parts[2], currency)
+	if err != nil {
+		return models.InvoiceItem{}, fmt.Errorf("invalid price: %w", err)
+	}
+	rate := defaultRate
+	if len(parts) == 4 {
+		if rate, err = locale.ParseDecimal(parts[3]); err != nil {
+			return models.InvoiceItem{}, fmt.Errorf("invalid tax rate: %w", err)
+		}
+	}
+	return models.InvoiceItem{
+		Description:    strings.TrimSpace(parts[0]),
+		Quantity:       quantity,
+		UnitPrice:      price,
+		TaxRatePercent: rate,
+	}, nil
+}
+
+func (c *command) invoiceRender(args []string) error {
+	fs := c.flags("invoice render")
+	output := fs.String("output", "", "write the PDF to this file instead of the data directory")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	if inv.IsDraft() {
+		return invoicing.ErrDraft
+	}
+	profile, err := c.store.GetProfile(inv.ProfileID)
+	if err != nil {
+		return fmt.Errorf("cli: load profile: %w", err)
+	}
+	customer, err := c.store.GetCustomer(inv.CustomerID)
+	if err != nil {
+		return fmt.Errorf("cli: load customer: %w", err)
+	}
+
+	path := *output
+	if path == "" {
+		if inv.PDFPath == "" {
+			inv.PDFPath = c.store.InvoicePDFPath(inv.Number)
+			if err := c.store.SaveInvoice(inv); err != nil {
+				return err
+			}
+		}
+		path = inv.PDFPath
+	}
+	if err := invoicing.RenderTo(path, c.store, profile, customer, inv); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, path)
+	return nil
+}
+
+// invoicePay records a single payment, e.g. an instalment or a short
+// payment.
+func (c *command) invoicePay(args []string) error {
+	fs := c.flags("invoice pay")
+	amount := fs.String("amount", "", "amount paid in the invoice currency (required, negative for refunds)")
+	date := fs.String("date", "", "payment date as YYYY-MM-DD (default today)")
+	method := fs.String("method", models.MethodBankTransfer, "payment method: "+strings.Join(models.PaymentMethods, ", "))
+	reference := fs.String("reference", "", "bank reference or receipt number")
+	note := fs.String("note", "", "internal note")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	if *amount == "" {
+		return errors.New("cli: --amount is required")
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	value, err := locale.ParseMoney(*amount, inv.Currency)
+	if err != nil {
+		return fmt.Errorf("cli: invalid --amount: %w", err)
+	}
+	payment := models.Payment{Date: time.Now(), Amount: value, Method: *method, Reference: *reference, Note: *note}
+	if *date != "" {
+		if payment.Date, err = time.Parse(dateLayout, *date); err != nil {
+			return fmt.Errorf("cli: invalid --date: %w", err)
+		}
+	}
+	inv, err = invoicing.RecordPayment(c.store, inv, payment)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "invoice %s: paid %s of %s, outstanding %s (%s)\n", inv.Number, inv.AmountPaid(), inv.Total, inv.Outstanding(), inv.PaymentStatus())
+	return nil
+}
+
+// invoiceMarkPaid records the outstanding balance as one payment.
+func (c *command) invoiceMarkPaid(args []string) error {
+	fs := c.flags("invoice mark-paid")
+	date := fs.String("date", "", "payment date as YYYY-MM-DD (default today)")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	if inv.IsDraft() {
+		return invoicing.ErrDraft
+	}
+	if inv.IsSettled() {
+		fmt.Fprintf(c.stdout, "invoice %s is already paid\n", inv.Number)
+		return nil
+	}
+	paidAt := time.Now()
+	if *date != "" {
+		if paidAt, err = time.Parse(dateLayout, *date); err != nil {
+			return fmt.Errorf("cli: invalid --date: %w", err)
+		}
+	}
+	if _, err := invoicing.RecordPayment(c.store, inv, models.Payment{Date: paidAt, Amount: inv.Outstanding()}); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "invoice %s marked as paid on %s\n", inv.Number, paidAt.Format(dateLayout))
+	return nil
+}
+
+// invoiceFinalize numbers a draft and locks it against further edits.
+func (c *command) invoiceFinalize(args []string) error {
+	fs := c.flags("invoice finalize")
+	ref, err := single(fs, args, "draft ID")
+	if err != nil {
+		return err
+	}
+	draft, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	inv, findings, err := invoicing.Finalize(c.store, draft)
+	if len(findings) > 0 {
+		fmt.Fprintln(c.stderr, findings)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "finalised invoice %s (%s), PDF written to %s\n", inv.Number, inv.Total, inv.PDFPath)
+	return nil
+}
+
+// invoiceHistory prints the revisions of an invoice, oldest first.
+func (c *command) invoiceHistory(args []string) error {
+	fs := c.flags("invoice history")
+	asJSON := fs.Bool("json", false, "print JSON")
+	ref, err := single(fs, args, "invoice")
+	if err != nil {
+		return err
+	}
+	inv, err := c.store.FindInvoice(ref)
+	if err != nil {
+		return err
+	}
+	revisions, err := c.store.InvoiceRevisions(inv.ID)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if revisions == nil {
+			revisions = []models.InvoiceRevision{}
+		}
+		return c.writeJSON(revisions)
+	}
+	w := c.table("RECORDED", "ACTION", "FIELD", "OLD", "NEW")
+	for _, rev := range revisions {
+		recorded := rev.RecordedAt.Format(time.RFC3339)
+		if rev.Action == models.RevisionCreated || len(rev.Changes) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t\t\t\n", recorded, rev.Action)
+			continue
+		}
+		for _, change := range rev.Changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", recorded, rev.Action, change.Field, change.Old, change.New)
+		}
+	}
+	return w.Flush()
+}
+
+// numberOrID identifies an invoice in listings; drafts have no number yet.
+func numberOrID(inv models.Invoice) string {
+	if inv.IsDraft() {
+		return inv.ID
+	}
+	return inv.Number
+}
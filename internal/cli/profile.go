@@ -0,0 +1,23 @@
+package cli
+
+import "fmt"
+
+func (c *command) profileList(args []string) error {
+	fs := c.flags("profile list")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	profiles, err := c.store.ListProfiles()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(profiles)
+	}
+	w := c.table("ID", "NAME", "COMPANY", "EMAIL")
+	for _, p := range profiles {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.ID, p.DisplayName, p.CompanyName, p.Email)
+	}
+	return w.Flush()
+}
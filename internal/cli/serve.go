@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/janmarkuslanger/invoiceio/internal/server"
+)
+
+func (c *command) serve(args []string) error {
+	fs := c.flags("serve")
+	addr := fs.String("addr", server.DefaultAddr, "listen address")
+	token := fs.String("token", os.Getenv(server.TokenEnv), "API token clients send as bearer token (default $"+server.TokenEnv+")")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	srv, err := server.New(c.store, *token)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "serving API on http://%s/api (description at /api/openapi.json)\n", *addr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return srv.ListenAndServe(ctx, *addr)
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/statement"
+)
+
+// statementImport matches a bank statement to open invoices. Without --apply
+// it only reports the proposals.
+func (c *command) statementImport(args []string) error {
+	fs := c.flags("statement import")
+	apply := fs.Bool("apply", false, "record the proposed matches as payments")
+	asJSON := fs.Bool("json", false, "print the matches as JSON")
+	var format statement.CSVFormat
+	delimiter := fs.String("delimiter", "", "CSV field separator (default: detected)")
+	decimalSeparator := fs.String("decimal-separator", "", "decimal separator of CSV amounts (default ,)")
+	thousandsSeparator := fs.String("thousands-separator", "", "thousands separator of CSV amounts (default . or , after a . decimal separator)")
+	fs.StringVar(&format.DateColumn, "date-column", "", "CSV header of the booking date")
+	fs.StringVar(&format.AmountColumn, "amount-column", "", "CSV header of the amount")
+	fs.StringVar(&format.CurrencyColumn, "currency-column", "", "CSV header of the currency")
+	fs.StringVar(&format.CounterpartyColumn, "counterparty-column", "", "CSV header of the payer name")
+	fs.StringVar(&format.IBANColumn, "iban-column", "", "CSV header of the payer IBAN")
+	fs.StringVar(&format.RemittanceColumn, "remittance-column", "", "CSV header of the remittance information")
+	fs.StringVar(&format.ReferenceColumn, "reference-column", "", "CSV header of the bank reference")
+	fs.StringVar(&format.DateLayout, "date-layout", "", "Go layout of CSV dates, e.g. 02.01.2006")
+	fs.StringVar(&format.Currency, "currency", "", "currency of CSV amounts without currency column (default EUR)")
+	path, err := single(fs, args, "file")
+	if err != nil {
+		return err
+	}
+	for _, sep := range []struct {
+		flag  string
+		text  string
+		value *rune
+	}{
+		{"delimiter", *delimiter, &format.Delimiter},
+		{"decimal-separator", *decimalSeparator, &format.DecimalSeparator},
+		{"thousands-separator", *thousandsSeparator, &format.ThousandsSeparator},
+	} {
+		if sep.text == "" {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(sep.text)
+		if size != len(sep.text) {
+			return fmt.Errorf("cli: --%s must be a single character, got %q", sep.flag, sep.text)
+		}
+		*sep.value = r
+	}
+
+	txs, err := statement.Load(path, format)
+	if err != nil {
+		return err
+	}
+	matches, err := invoicing.ProposeMatches(c.store, txs)
+	if err != nil {
+		return err
+	}
+
+	outcomes := make([]string, len(matches))
+	failed := 0
+	for i, m := range matches {
+		switch {
+		case m.Recorded:
+			outcomes[i] = "already recorded"
+		case m.Invoice == nil:
+			outcomes[i] = "no match"
+		case !*apply:
+			outcomes[i] = "proposed (" + strings.Join(m.Reasons, ", ") + ")"
+		default:
+			if _, err := invoicing.RecordTransaction(c.store, m.Invoice.ID, m.Transaction); err != nil {
+				outcomes[i] = "failed: " + err.Error()
+				failed++
+			} else {
+				outcomes[i] = "recorded"
+			}
+		}
+	}
+
+	if *asJSON {
+		type matchResult struct {
+			statement.Match
+			Result string `json:"result"`
+		}
+		out := make([]matchResult, len(matches))
+		for i, m := range matches {
+			out[i] = matchResult{Match: m, Result: outcomes[i]}
+		}
+		if err := c.writeJSON(out); err != nil {
+			return err
+		}
+	} else {
+		w := c.table("DATE", "AMOUNT", "COUNTERPARTY", "INVOICE", "SCORE", "RESULT")
+		for i, m := range matches {
+			number := "-"
+			if m.Invoice != nil {
+				number = m.Invoice.Number
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", m.Transaction.Date.Format(dateLayout), m.Transaction.Amount, m.Transaction.Counterparty, number, m.Score, outcomes[i])
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("cli: %d of %d payments failed", failed, len(matches))
+	}
+	return nil
+}
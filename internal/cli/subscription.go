@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func (c *command) subscriptionList(args []string) error {
+	fs := c.flags("subscription list")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	subs, err := c.store.ListSubscriptions()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(subs)
+	}
+	w := c.table("ID", "NAME", "CUSTOMER", "INTERVAL", "NEXT RUN", "RUNS")
+	for _, sub := range subs {
+		customer := sub.CustomerID
+		if cust, err := c.store.GetCustomer(sub.CustomerID); err == nil {
+			customer = cust.DisplayName
+		}
+		next := "-"
+		if date, ok := sub.NextRun(); ok && !sub.Paused {
+			next = date.Format(dateLayout)
+		} else if sub.Paused {
+			next = "paused"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", sub.ID, sub.Name, customer, sub.Interval, next, len(sub.Runs))
+	}
+	return w.Flush()
+}
+
+func (c *command) subscriptionAdd(args []string) error {
+	fs := c.flags("subscription add")
+	name := fs.String("name", "", "name of the subscription (required)")
+	profileRef := fs.String("profile", "", "profile ID or name (required)")
+	customerRef := fs.String("customer", "", "customer ID or name (required)")
+	var itemSpecs stringList
+	fs.Var(&itemSpecs, "item", `line item as "description|quantity|unit price[|tax rate]", repeatable; descriptions may use `+strings.Join(invoicing.Placeholders, " "))
+	taxRate := fs.String("tax", "0", "tax rate in percent for items without their own rate")
+	interval := fs.String("interval", models.IntervalMonthly, "weekly, monthly, quarterly or yearly")
+	dayOfMonth := fs.Int("day", 0, "day of the month to issue on, clamped to the month length (default the start day)")
+	start := fs.String("start", "", "date of the first run as YYYY-MM-DD (default today)")
+	end := fs.String("end", "", "last date a run may fall on as YYYY-MM-DD")
+	currency := fs.String("currency", money.DefaultCurrency, "ISO 4217 currency code")
+	notes := fs.String("notes", "", "notes printed on the invoices")
+	drafts := fs.Bool("drafts", false, "keep generated invoices as drafts for review")
+	asJSON := fs.Bool("json", false, "print the created subscription as JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if *profileRef == "" || *customerRef == "" {
+		return errors.New("cli: --profile and --customer are required")
+	}
+	profile, err := c.store.FindProfile(*profileRef)
+	if err != nil {
+		return err
+	}
+	customer, err := c.store.FindCustomer(*customerRef)
+	if err != nil {
+		return err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(*currency))
+	defaultRate, err := locale.ParseDecimal(*taxRate)
+	if err != nil {
+		return fmt.Errorf("cli: invalid --tax: %w", err)
+	}
+	items := make([]models.InvoiceItem, 0, len(itemSpecs))
+	for i, spec := range itemSpecs {
+		item, err := parseItem(spec, code, defaultRate)
+		if err != nil {
+			return fmt.Errorf("cli: item %d: %w", i+1, err)
+		}
+		items = append(items, item)
+	}
+
+	now := time.Now()
+	sub := models.Subscription{
+		ID:         id.New(),
+		Name:       strings.TrimSpace(*name),
+		ProfileID:  profile.ID,
+		CustomerID: customer.ID,
+		Currency:   code,
+		Items:      items,
+		Notes:      strings.TrimSpace(*notes),
+		Interval:   strings.ToLower(strings.TrimSpace(*interval)),
+		DayOfMonth: *dayOfMonth,
+		StartDate:  time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Drafts:     *drafts,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if *start != "" {
+		if sub.StartDate, err = time.Parse(dateLayout, *start); err != nil {
+			return fmt.Errorf("cli: invalid --start: %w", err)
+		}
+	}
+	if *end != "" {
+		if sub.EndDate, err = time.Parse(dateLayout, *end); err != nil {
+			return fmt.Errorf("cli: invalid --end: %w", err)
+		}
+	}
+	if err := invoicing.CheckSubscription(sub); err != nil {
+		return err
+	}
+	if err := c.store.SaveSubscription(sub); err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(sub)
+	}
+	next, _ := sub.NextRun()
+	fmt.Fprintf(c.stdout, "created subscription %s (%s), first run on %s\n", sub.Name, sub.ID, next.Format(dateLayout))
+	return nil
+}
+
+// subscriptionRun generates the invoices of all due subscription runs. It is
+// meant to be run daily, e.g. from cron; runs missed in between are caught up.
+func (c *command) subscriptionRun(args []string) error {
+	fs := c.flags("subscription run")
+	date := fs.String("date", "", "generate the runs due on this date as YYYY-MM-DD (default today)")
+	dryRun := fs.Bool("dry-run", false, "only list the due runs")
+	asJSON := fs.Bool("json", false, "print the generated invoices as JSON")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	now := time.Now()
+	if *date != "" {
+		var err error
+		if now, err = time.Parse(dateLayout, *date); err != nil {
+			return fmt.Errorf("cli: invalid --date: %w", err)
+		}
+	}
+
+	if *dryRun {
+		subs, err := c.store.ListSubscriptions()
+		if err != nil {
+			return err
+		}
+		w := c.table("SUBSCRIPTION", "DATE")
+		for _, sub := range subs {
+			for _, run := range invoicing.DueRuns(sub, now) {
+				fmt.Fprintf(w, "%s\t%s\n", sub.Name, run.Format(dateLayout))
+			}
+		}
+		return w.Flush()
+	}
+
+	generated, runErr := invoicing.RunSubscriptions(c.store, now)
+	if *asJSON {
+		invoices := make([]models.Invoice, len(generated))
+		for i, g := range generated {
+			invoices[i] = g.Invoice
+		}
+		if err := c.writeJSON(invoices); err != nil {
+			return err
+		}
+		return runErr
+	}
+	w := c.table("SUBSCRIPTION", "DATE", "NUMBER", "TOTAL", "PDF")
+	for _, g := range generated {
+		number, pdfPath := g.Invoice.Number, g.Invoice.PDFPath
+		if g.Invoice.IsDraft() {
+			number, pdfPath = "draft "+g.Invoice.ID, "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", g.Subscription.Name, g.Date.Format(dateLayout), number, g.Invoice.Total, pdfPath)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, g := range generated {
+		if g.Findings.HasErrors() {
+			fmt.Fprintf(c.stderr, "%s on %s kept as draft:\n%s\n", g.Subscription.Name, g.Date.Format(dateLayout), g.Findings)
+		}
+	}
+	return runErr
+}
+
+func (c *command) subscriptionDelete(args []string) error {
+	fs := c.flags("subscription delete")
+	ref, err := single(fs, args, "subscription")
+	if err != nil {
+		return err
+	}
+	sub, err := c.store.FindSubscription(ref)
+	if err != nil {
+		return err
+	}
+	if err := c.store.DeleteSubscription(sub.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "deleted subscription %s; %d generated invoices are kept\n", sub.Name, len(sub.Runs))
+	return nil
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// termsFlags are the payment terms flags shared by the subcommands that
+// create invoices and customers.
+type termsFlags struct {
+	netDays   *int
+	discounts stringList
+}
+
+func addTermsFlags(fs *flag.FlagSet) *termsFlags {
+	t := &termsFlags{netDays: fs.Int("net-days", 0, "payment period in days")}
+	fs.Var(&t.discounts, "discount", `early-payment discount as "percent:days", repeatable`)
+	return t
+}
+
+// terms returns the payment terms given by the flags and whether any was set.
+func (t *termsFlags) terms() (models.PaymentTerms, bool, error) {
+	terms := models.PaymentTerms{NetDays: *t.netDays}
+	if terms.NetDays < 0 {
+		return terms, false, fmt.Errorf("cli: --net-days must not be negative")
+	}
+	for _, spec := range t.discounts {
+		percent, days, ok := strings.Cut(spec, ":")
+		if !ok {
+			return terms, false, fmt.Errorf("cli: --discount: expected \"percent:days\", got %q", spec)
+		}
+		rate, err := locale.ParseDecimal(strings.TrimSuffix(strings.TrimSpace(percent), "%"))
+		if err != nil || rate.Sign() <= 0 || rate.Cmp(money.DecimalFromInt(100)) >= 0 {
+			return terms, false, fmt.Errorf("cli: --discount: invalid percentage %q", percent)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil || n < 0 {
+			return terms, false, fmt.Errorf("cli: --discount: invalid days %q", days)
+		}
+		terms.Discounts = append(terms.Discounts, models.DiscountTier{Percent: rate, Days: n})
+	}
+	return terms, !terms.IsZero(), nil
+}
+
+type exemptionFlags struct {
+	category *string
+	reason   *string
+}
+
+func addExemptionFlags(fs *flag.FlagSet) *exemptionFlags {
+	return &exemptionFlags{
+		category: fs.String("tax-category", "", "tax category of items without VAT: "+strings.Join(models.TaxCategories, ", ")+" (default Z)"),
+		reason:   fs.String("exemption-reason", "", "why items without VAT are not taxed, printed on the invoice"),
+	}
+}
+
+// exemption returns the tax exemption given by the flags.
+func (e *exemptionFlags) exemption() (models.TaxExemption, error) {
+	exemption := models.TaxExemption{
+		Category: strings.ToUpper(strings.TrimSpace(*e.category)),
+		Reason:   strings.TrimSpace(*e.reason),
+	}
+	if exemption.Category == "" {
+		return exemption, nil
+	}
+	for _, code := range models.TaxCategories {
+		if code == exemption.Category {
+			return exemption, nil
+		}
+	}
+	return exemption, fmt.Errorf("cli: --tax-category: unknown category %q", exemption.Category)
+}
@@ -0,0 +1,219 @@
+package einvoice
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// FacturXFileName is the name the CII attachment must have inside the PDF.
+const FacturXFileName = "factur-x.xml"
+
+// CII renders the invoice as an UN/CEFACT Cross Industry Invoice (D16B)
+// restricted to the elements allowed by the given Factur-X profile.
+func CII(profile models.Profile, customer models.Customer, invoice models.Invoice, level string) ([]byte, error) {
+	if !ValidLevel(level) {
+		return nil, fmt.Errorf("einvoice: unknown Factur-X profile %q", level)
+	}
+	basic := level != LevelMinimum
+	full := level == LevelEN16931
+	currency := invoice.Currency
+
+	sellerCountry, err := countryOrError("seller", profile.Country)
+	if err != nil {
+		return nil, err
+	}
+	var buyerCountry string
+	if basic {
+		if buyerCountry, err = countryOrError("buyer", customer.Country); err != nil {
+			return nil, err
+		}
+	}
+
+	root := el("rsm:CrossIndustryInvoice").
+		attr("xmlns:rsm", "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100").
+		attr("xmlns:qdt", "urn:un:unece:uncefact:data:standard:QualifiedDataType:100").
+		attr("xmlns:ram", "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100").
+		attr("xmlns:udt", "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100")
+
+	root.add(el("rsm:ExchangedDocumentContext",
+		el("ram:GuidelineSpecifiedDocumentContextParameter",
+			leaf("ram:ID", guideline(level)),
+		),
+	))
+
+	document := el("rsm:ExchangedDocument",
+		leaf("ram:ID", invoice.Number),
+		leaf("ram:TypeCode", typeCode(invoice)),
+		el("ram:IssueDateTime", ciiDate("udt:DateTimeString", invoice.IssueDate)),
+	)
+	if basic && strings.TrimSpace(invoice.Notes) != "" {
+		document.add(el("ram:IncludedNote", leaf("ram:Content", strings.TrimSpace(invoice.Notes))))
+	}
+	root.add(document)
+
+	transaction := el("rsm:SupplyChainTradeTransaction")
+	if basic {
+		for i, item := range invoice.Items {
+			transaction.add(ciiLine(i+1, invoice, item))
+		}
+	}
+
+	seller := el("ram:SellerTradeParty", leaf("ram:Name", sellerName(profile)))
+	if full {
+		seller.add(optional(el("ram:DefinedTradeContact",
+			when(sellerName(profile) != strings.TrimSpace(profile.DisplayName), leaf("ram:PersonName", strings.TrimSpace(profile.DisplayName))),
+			optional(el("ram:TelephoneUniversalCommunication", leaf("ram:CompleteNumber", strings.TrimSpace(profile.Phone)))),
+			optional(el("ram:EmailURIUniversalCommunication", leaf("ram:URIID", strings.TrimSpace(profile.Email)))),
+		)))
+	}
+	seller.add(ciiAddress(profile.PostalCode, profile.AddressLine1, profile.AddressLine2, profile.City, sellerCountry, basic))
+	if full {
+		seller.add(optional(el("ram:URIUniversalCommunication", leaf("ram:URIID", strings.TrimSpace(profile.Email), "schemeID", "EM"))))
+	}
+	if taxID := strings.TrimSpace(profile.TaxID); taxID != "" {
+		seller.add(el("ram:SpecifiedTaxRegistration", leaf("ram:ID", taxID, "schemeID", taxScheme(taxID))))
+	}
+
+	buyer := el("ram:BuyerTradeParty", leaf("ram:Name", strings.TrimSpace(customer.DisplayName)))
+	if full {
+		buyer.add(optional(el("ram:DefinedTradeContact",
+			leaf("ram:PersonName", strings.TrimSpace(customer.ContactName)),
+			optional(el("ram:TelephoneUniversalCommunication", leaf("ram:CompleteNumber", strings.TrimSpace(customer.Phone)))),
+			optional(el("ram:EmailURIUniversalCommunication", leaf("ram:URIID", strings.TrimSpace(customer.Email)))),
+		)))
+	}
+	if basic {
+		buyer.add(ciiAddress(customer.PostalCode, customer.AddressLine1, customer.AddressLine2, customer.City, buyerCountry, true))
+	}
+	if full {
+		buyer.add(optional(el("ram:URIUniversalCommunication", leaf("ram:URIID", strings.TrimSpace(customer.Email), "schemeID", "EM"))))
+	}
+	if vatID := strings.TrimSpace(customer.VATID); basic && vatID != "" {
+		buyer.add(el("ram:SpecifiedTaxRegistration", leaf("ram:ID", vatID, "schemeID", "VA")))
+	}
+
+	transaction.add(
+		el("ram:ApplicableHeaderTradeAgreement", seller, buyer),
+		el("ram:ApplicableHeaderTradeDelivery"),
+		ciiSettlement(profile, invoice, currency, basic),
+	)
+	root.add(transaction)
+	return render(root), nil
+}
+
+func ciiLine(lineID int, invoice models.Invoice, item models.InvoiceItem) *node {
+	category := taxCategory(invoice, item.TaxRatePercent)
+	return el("ram:IncludedSupplyChainTradeLineItem",
+		el("ram:AssociatedDocumentLineDocument", leaf("ram:LineID", strconv.Itoa(lineID))),
+		el("ram:SpecifiedTradeProduct", leaf("ram:Name", strings.TrimSpace(item.Description))),
+		el("ram:SpecifiedLineTradeAgreement",
+			el("ram:NetPriceProductTradePrice", leaf("ram:ChargeAmount", item.UnitPrice.Amount())),
+		),
+		el("ram:SpecifiedLineTradeDelivery",
+			leaf("ram:BilledQuantity", item.Quantity.String(), "unitCode", unitCodeOne),
+		),
+		el("ram:SpecifiedLineTradeSettlement",
+			el("ram:ApplicableTradeTax",
+				leaf("ram:TypeCode", "VAT"),
+				leaf("ram:CategoryCode", category.code),
+				leaf("ram:RateApplicablePercent", category.percent(item.TaxRatePercent)),
+			),
+			el("ram:SpecifiedTradeSettlementLineMonetarySummation",
+				leaf("ram:LineTotalAmount", item.LineTotal.Amount()),
+			),
+		),
+	)
+}
+
+// ciiAddress writes a postal address. The minimum profile only carries the
+// country.
+func ciiAddress(postalCode, line1, line2, city, country string, withStreet bool) *node {
+	if !withStreet {
+		return el("ram:PostalTradeAddress", leaf("ram:CountryID", country))
+	}
+	return el("ram:PostalTradeAddress",
+		leaf("ram:PostcodeCode", strings.TrimSpace(postalCode)),
+		leaf("ram:LineOne", strings.TrimSpace(line1)),
+		leaf("ram:LineTwo", strings.TrimSpace(line2)),
+		leaf("ram:CityName", strings.TrimSpace(city)),
+		leaf("ram:CountryID", country),
+	)
+}
+
+func ciiSettlement(profile models.Profile, invoice models.Invoice, currency string, basic bool) *node {
+	settlement := el("ram:ApplicableHeaderTradeSettlement")
+	if basic {
+		settlement.add(leaf("ram:PaymentReference", invoice.Number))
+	}
+	settlement.add(leaf("ram:InvoiceCurrencyCode", currency))
+
+	if basic {
+		if iban := compactIBAN(profile.PaymentDetails.IBAN); iban != "" {
+			settlement.add(el("ram:SpecifiedTradeSettlementPaymentMeans",
+				leaf("ram:TypeCode", paymentMeansCredit),
+				el("ram:PayeePartyCreditorFinancialAccount", leaf("ram:IBANID", iban)),
+				optional(el("ram:PayeeSpecifiedCreditorFinancialInstitution", leaf("ram:BICID", strings.TrimSpace(profile.PaymentDetails.BIC)))),
+			))
+		}
+		for _, tax := range invoice.TaxBreakdown {
+			category := taxCategory(invoice, tax.RatePercent)
+			settlement.add(el("ram:ApplicableTradeTax",
+				leaf("ram:CalculatedAmount", tax.Tax.Amount()),
+				leaf("ram:TypeCode", "VAT"),
+				leaf("ram:ExemptionReason", category.reason),
+				leaf("ram:BasisAmount", tax.Net.Amount()),
+				leaf("ram:CategoryCode", category.code),
+				leaf("ram:ExemptionReasonCode", category.reasonCode),
+				leaf("ram:RateApplicablePercent", category.percent(tax.RatePercent)),
+			))
+		}
+		settlement.add(optional(el("ram:SpecifiedTradePaymentTerms",
+			leaf("ram:Description", paymentTermsNote(profile, invoice)),
+			when(!invoice.DueDate.IsZero(), el("ram:DueDateDateTime", ciiDate("udt:DateTimeString", invoice.DueDate))),
+		)))
+	}
+
+	summation := el("ram:SpecifiedTradeSettlementHeaderMonetarySummation")
+	if basic {
+		summation.add(leaf("ram:LineTotalAmount", invoice.Subtotal.Amount()))
+	}
+	summation.add(
+		leaf("ram:TaxBasisTotalAmount", invoice.Subtotal.Amount()),
+		leaf("ram:TaxTotalAmount", invoice.TaxAmount.Amount(), "currencyID", currency),
+		leaf("ram:GrandTotalAmount", invoice.Total.Amount()),
+		when(basic && !prepaid(invoice).IsZero(), leaf("ram:TotalPrepaidAmount", prepaid(invoice).Amount())),
+		leaf("ram:DuePayableAmount", duePayable(invoice).Amount()),
+	)
+	settlement.add(summation)
+	if basic && invoice.IsCorrection() {
+		settlement.add(el("ram:InvoiceReferencedDocument", leaf("ram:IssuerAssignedID", invoice.OriginalNumber)))
+	}
+	return settlement
+}
+
+// prepaid is the amount settled before this document was exported (BT-113):
+// the payments recorded and the amounts credited by credit notes or
+// cancellations, matching models.Invoice.Outstanding.
+func prepaid(invoice models.Invoice) money.Money {
+	return invoice.AmountPaid().Add(invoice.Credited)
+}
+
+// duePayable is the amount that is still to be paid (BT-115): the total less
+// the prepaid amount. An early-payment discount is not deducted; EN 16931
+// only knows it from the payment terms.
+func duePayable(invoice models.Invoice) money.Money {
+	return invoice.Total.Sub(prepaid(invoice))
+}
+
+func ciiDate(name string, t time.Time) *node {
+	return leaf(name, t.Format("20060102"), "format", "102")
+}
+
+func compactIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(iban), " ", ""))
+}
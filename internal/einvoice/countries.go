@@ -0,0 +1,292 @@
+package einvoice
+
+import "strings"
+
+// isoCountries holds the ISO 3166-1 alpha-2 codes.
+var isoCountries = func() map[string]bool {
+	codes := strings.Fields(
+		"AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE " +
+			"BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD " +
+			"CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM " +
+			"DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR GA GB GD GE GF " +
+			"GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU " +
+			"ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN " +
+			"KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME " +
+			"MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ NA " +
+			"NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM " +
+			"PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI " +
+			"SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK " +
+			"TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI " +
+			"VN VU WF WS YE YT ZA ZM ZW",
+	)
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set
+}()
+
+// isoCountryNames maps the lower case English short names of ISO 3166-1,
+// including the common names where they differ, to their codes.
+var isoCountryNames = map[string]string{
+	"afghanistan":                            "AF",
+	"albania":                                "AL",
+	"algeria":                                "DZ",
+	"american samoa":                         "AS",
+	"andorra":                                "AD",
+	"angola":                                 "AO",
+	"anguilla":                               "AI",
+	"antarctica":                             "AQ",
+	"antigua and barbuda":                    "AG",
+	"argentina":                              "AR",
+	"armenia":                                "AM",
+	"aruba":                                  "AW",
+	"australia":                              "AU",
+	"austria":                                "AT",
+	"azerbaijan":                             "AZ",
+	"bahamas":                                "BS",
+	"bahrain":                                "BH",
+	"bangladesh":                             "BD",
+	"barbados":                               "BB",
+	"belarus":                                "BY",
+	"belgium":                                "BE",
+	"belize":                                 "BZ",
+	"benin":                                  "BJ",
+	"bermuda":                                "BM",
+	"bhutan":                                 "BT",
+	"bolivia":                                "BO",
+	"bolivia, plurinational state of":        "BO",
+	"bonaire, sint eustatius and saba":       "BQ",
+	"bosnia and herzegovina":                 "BA",
+	"botswana":                               "BW",
+	"bouvet island":                          "BV",
+	"brazil":                                 "BR",
+	"british indian ocean territory":         "IO",
+	"brunei darussalam":                      "BN",
+	"bulgaria":                               "BG",
+	"burkina faso":                           "BF",
+	"burundi":                                "BI",
+	"cabo verde":                             "CV",
+	"cambodia":                               "KH",
+	"cameroon":                               "CM",
+	"canada":                                 "CA",
+	"cayman islands":                         "KY",
+	"central african republic":               "CF",
+	"chad":                                   "TD",
+	"chile":                                  "CL",
+	"china":                                  "CN",
+	"christmas island":                       "CX",
+	"cocos (keeling) islands":                "CC",
+	"colombia":                               "CO",
+	"comoros":                                "KM",
+	"congo":                                  "CG",
+	"congo, the democratic republic of the":  "CD",
+	"cook islands":                           "CK",
+	"costa rica":                             "CR",
+	"croatia":                                "HR",
+	"cuba":                                   "CU",
+	"curaçao":                                "CW",
+	"cyprus":                                 "CY",
+	"czechia":                                "CZ",
+	"côte d'ivoire":                          "CI",
+	"denmark":                                "DK",
+	"djibouti":                               "DJ",
+	"dominica":                               "DM",
+	"dominican republic":                     "DO",
+	"ecuador":                                "EC",
+	"egypt":                                  "EG",
+	"el salvador":                            "SV",
+	"equatorial guinea":                      "GQ",
+	"eritrea":                                "ER",
+	"estonia":                                "EE",
+	"eswatini":                               "SZ",
+	"ethiopia":                               "ET",
+	"falkland islands (malvinas)":            "FK",
+	"faroe islands":                          "FO",
+	"fiji":                                   "FJ",
+	"finland":                                "FI",
+	"france":                                 "FR",
+	"french guiana":                          "GF",
+	"french polynesia":                       "PF",
+	"french southern territories":            "TF",
+	"gabon":                                  "GA",
+	"gambia":                                 "GM",
+	"georgia":                                "GE",
+	"germany":                                "DE",
+	"ghana":                                  "GH",
+	"gibraltar":                              "GI",
+	"greece":                                 "GR",
+	"greenland":                              "GL",
+	"grenada":                                "GD",
+	"guadeloupe":                             "GP",
+	"guam":                                   "GU",
+	"guatemala":                              "GT",
+	"guernsey":                               "GG",
+	"guinea":                                 "GN",
+	"guinea-bissau":                          "GW",
+	"guyana":                                 "GY",
+	"haiti":                                  "HT",
+	"heard island and mcdonald islands":      "HM",
+	"holy see (vatican city state)":          "VA",
+	"honduras":                               "HN",
+	"hong kong":                              "HK",
+	"hungary":                                "HU",
+	"iceland":                                "IS",
+	"india":                                  "IN",
+	"indonesia":                              "ID",
+	"iran":                                   "IR",
+	"iran, islamic republic of":              "IR",
+	"iraq":                                   "IQ",
+	"ireland":                                "IE",
+	"isle of man":                            "IM",
+	"israel":                                 "IL",
+	"italy":                                  "IT",
+	"jamaica":                                "JM",
+	"japan":                                  "JP",
+	"jersey":                                 "JE",
+	"jordan":                                 "JO",
+	"kazakhstan":                             "KZ",
+	"kenya":                                  "KE",
+	"kiribati":                               "KI",
+	"korea, democratic people's republic of": "KP",
+	"korea, republic of":                     "KR",
+	"kuwait":                                 "KW",
+	"kyrgyzstan":                             "KG",
+	"lao people's democratic republic":       "LA",
+	"laos":                                   "LA",
+	"latvia":                                 "LV",
+	"lebanon":                                "LB",
+	"lesotho":                                "LS",
+	"liberia":                                "LR",
+	"libya":                                  "LY",
+	"liechtenstein":                          "LI",
+	"lithuania":                              "LT",
+	"luxembourg":                             "LU",
+	"macao":                                  "MO",
+	"madagascar":                             "MG",
+	"malawi":                                 "MW",
+	"malaysia":                               "MY",
+	"maldives":                               "MV",
+	"mali":                                   "ML",
+	"malta":                                  "MT",
+	"marshall islands":                       "MH",
+	"martinique":                             "MQ",
+	"mauritania":                             "MR",
+	"mauritius":                              "MU",
+	"mayotte":                                "YT",
+	"mexico":                                 "MX",
+	"micronesia, federated states of":        "FM",
+	"moldova":                                "MD",
+	"moldova, republic of":                   "MD",
+	"monaco":                                 "MC",
+	"mongolia":                               "MN",
+	"montenegro":                             "ME",
+	"montserrat":                             "MS",
+	"morocco":                                "MA",
+	"mozambique":                             "MZ",
+	"myanmar":                                "MM",
+	"namibia":                                "NA",
+	"nauru":                                  "NR",
+	"nepal":                                  "NP",
+	"netherlands":                            "NL",
+	"new caledonia":                          "NC",
+	"new zealand":                            "NZ",
+	"nicaragua":                              "NI",
+	"niger":                                  "NE",
+	"nigeria":                                "NG",
+	"niue":                                   "NU",
+	"norfolk island":                         "NF",
+	"north korea":                            "KP",
+	"north macedonia":                        "MK",
+	"northern mariana islands":               "MP",
+	"norway":                                 "NO",
+	"oman":                                   "OM",
+	"pakistan":                               "PK",
+	"palau":                                  "PW",
+	"palestine, state of":                    "PS",
+	"panama":                                 "PA",
+	"papua new guinea":                       "PG",
+	"paraguay":                               "PY",
+	"peru":                                   "PE",
+	"philippines":                            "PH",
+	"pitcairn":                               "PN",
+	"poland":                                 "PL",
+	"portugal":                               "PT",
+	"puerto rico":                            "PR",
+	"qatar":                                  "QA",
+	"romania":                                "RO",
+	"russian federation":                     "RU",
+	"rwanda":                                 "RW",
+	"réunion":                                "RE",
+	"saint barthélemy":                       "BL",
+	"saint helena, ascension and tristan da cunha": "SH",
+	"saint kitts and nevis":                        "KN",
+	"saint lucia":                                  "LC",
+	"saint martin (french part)":                   "MF",
+	"saint pierre and miquelon":                    "PM",
+	"saint vincent and the grenadines":             "VC",
+	"samoa":                                        "WS",
+	"san marino":                                   "SM",
+	"sao tome and principe":                        "ST",
+	"saudi arabia":                                 "SA",
+	"senegal":                                      "SN",
+	"serbia":                                       "RS",
+	"seychelles":                                   "SC",
+	"sierra leone":                                 "SL",
+	"singapore":                                    "SG",
+	"sint maarten (dutch part)":                    "SX",
+	"slovakia":                                     "SK",
+	"slovenia":                                     "SI",
+	"solomon islands":                              "SB",
+	"somalia":                                      "SO",
+	"south africa":                                 "ZA",
+	"south georgia and the south sandwich islands": "GS",
+	"south korea":                          "KR",
+	"south sudan":                          "SS",
+	"spain":                                "ES",
+	"sri lanka":                            "LK",
+	"sudan":                                "SD",
+	"suriname":                             "SR",
+	"svalbard and jan mayen":               "SJ",
+	"sweden":                               "SE",
+	"switzerland":                          "CH",
+	"syria":                                "SY",
+	"syrian arab republic":                 "SY",
+	"taiwan":                               "TW",
+	"taiwan, province of china":            "TW",
+	"tajikistan":                           "TJ",
+	"tanzania":                             "TZ",
+	"tanzania, united republic of":         "TZ",
+	"thailand":                             "TH",
+	"timor-leste":                          "TL",
+	"togo":                                 "TG",
+	"tokelau":                              "TK",
+	"tonga":                                "TO",
+	"trinidad and tobago":                  "TT",
+	"tunisia":                              "TN",
+	"turkmenistan":                         "TM",
+	"turks and caicos islands":             "TC",
+	"tuvalu":                               "TV",
+	"türkiye":                              "TR",
+	"uganda":                               "UG",
+	"ukraine":                              "UA",
+	"united arab emirates":                 "AE",
+	"united kingdom":                       "GB",
+	"united states":                        "US",
+	"united states minor outlying islands": "UM",
+	"uruguay":                              "UY",
+	"uzbekistan":                           "UZ",
+	"vanuatu":                              "VU",
+	"venezuela":                            "VE",
+	"venezuela, bolivarian republic of":    "VE",
+	"viet nam":                             "VN",
+	"vietnam":                              "VN",
+	"virgin islands, british":              "VG",
+	"virgin islands, u.s.":                 "VI",
+	"wallis and futuna":                    "WF",
+	"western sahara":                       "EH",
+	"yemen":                                "YE",
+	"zambia":                               "ZM",
+	"zimbabwe":                             "ZW",
+	"åland islands":                        "AX",
+}
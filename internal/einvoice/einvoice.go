@@ -0,0 +1,225 @@
+// Package einvoice renders invoices as structured electronic invoices
+// following EN 16931.
+package einvoice
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// Factur-X / ZUGFeRD profiles supported for hybrid PDF invoices.
+const (
+	LevelMinimum = "MINIMUM"
+	LevelBasic   = "BASIC"
+	LevelEN16931 = "EN16931"
+)
+
+// Levels lists the supported Factur-X profiles from smallest to largest.
+func Levels() []string {
+	return []string{LevelMinimum, LevelBasic, LevelEN16931}
+}
+
+// ValidLevel reports whether level names a supported Factur-X profile.
+func ValidLevel(level string) bool {
+	for _, l := range Levels() {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// guideline returns the specification identifier (BT-24) of a profile.
+func guideline(level string) string {
+	switch level {
+	case LevelMinimum:
+		return "urn:factur-x.eu:1p0:minimum"
+	case LevelBasic:
+		return "urn:cen.eu:en16931:2017#compliant#urn:factur-x.eu:1p0:basic"
+	default:
+		return "urn:cen.eu:en16931:2017"
+	}
+}
+
+// ConformanceLevel returns the value of the fx:ConformanceLevel XMP property.
+func ConformanceLevel(level string) string {
+	if level == LevelEN16931 {
+		return "EN 16931"
+	}
+	return level
+}
+
+// Invoice type codes from UNTDID 1001.
+const (
+	typeCommercialInvoice = "380"
+	typeCorrectedInvoice  = "384"
+)
+
+// typeCode returns the document type code. Credit notes and cancellations
+// carry negated amounts, so they are corrected invoices rather than credit
+// notes in the sense of code 381, which expects positive amounts.
+func typeCode(invoice models.Invoice) string {
+	if invoice.IsCorrection() {
+		return typeCorrectedInvoice
+	}
+	return typeCommercialInvoice
+}
+
+// categoryStandard is the UNCL 5305 code of taxed items. Items without VAT
+// take the category of the invoice's tax exemption.
+const categoryStandard = "S"
+
+const (
+	unitCodeOne        = "C62" // UN/ECE Rec 20 "one"
+	paymentMeansCredit = "58"  // UNCL 4461 SEPA credit transfer
+)
+
+// exemptionCodes maps the categories that have a single VATEX exemption
+// reason code (BT-121) to it. Exempt items have many legal bases, so their
+// reason is only given as text.
+var exemptionCodes = map[string]string{
+	models.TaxReverseCharge:  "VATEX-EU-AE",
+	models.TaxIntraCommunity: "VATEX-EU-IC",
+	models.TaxExport:         "VATEX-EU-G",
+	models.TaxNotSubject:     "VATEX-EU-O",
+}
+
+// vatCategory is the tax category of a rate together with the exemption
+// reason text (BT-120) and code (BT-121) of untaxed categories.
+type vatCategory struct {
+	code       string
+	reason     string
+	reasonCode string
+}
+
+// taxCategory returns the category of items taxed at rate. Zero rated items
+// carry no exemption reason (BR-Z-10).
+func taxCategory(invoice models.Invoice, rate money.Decimal) vatCategory {
+	if !rate.IsZero() {
+		return vatCategory{code: categoryStandard}
+	}
+	code := invoice.TaxExemption.CategoryCode()
+	if code == models.TaxZeroRated {
+		return vatCategory{code: code}
+	}
+	return vatCategory{
+		code:       code,
+		reason:     strings.TrimSpace(invoice.TaxExemption.Reason),
+		reasonCode: exemptionCodes[code],
+	}
+}
+
+// percent returns the rate to state for the category; items not subject to
+// VAT have none (BR-O-05).
+func (c vatCategory) percent(rate money.Decimal) string {
+	if c.code == models.TaxNotSubject {
+		return ""
+	}
+	return rate.String()
+}
+
+// sellerName prefers the legal company name over the display name.
+func sellerName(p models.Profile) string {
+	if name := strings.TrimSpace(p.CompanyName); name != "" {
+		return name
+	}
+	return strings.TrimSpace(p.DisplayName)
+}
+
+// paymentTermsNote is the payment terms text (BT-20): the free text terms of
+// the profile followed by one "#SKONTO#" line per early-payment discount, the
+// notation XRechnung defines for discounts.
+func paymentTermsNote(profile models.Profile, invoice models.Invoice) string {
+	note := strings.TrimSpace(profile.PaymentDetails.PaymentTerms)
+	if invoice.IsCorrection() {
+		return note
+	}
+	for _, tier := range invoice.Terms.Discounts {
+		if tier.Percent.Sign() <= 0 {
+			continue
+		}
+		if note != "" && !strings.HasSuffix(note, "\n") {
+			note += "\n"
+		}
+		note += fmt.Sprintf("#SKONTO#TAGE=%d#PROZENT=%s#\n", tier.Days, tier.Percent.StringFixed(2))
+	}
+	return note
+}
+
+// taxScheme tells VAT identification numbers, which start with a country
+// prefix, apart from national tax numbers.
+func taxScheme(id string) string {
+	id = strings.TrimSpace(id)
+	if len(id) > 2 && unicode.IsLetter(rune(id[0])) && unicode.IsLetter(rune(id[1])) {
+		return "VA"
+	}
+	return "FC"
+}
+
+var countryNames = map[string]string{
+	"germany": "DE", "deutschland": "DE",
+	"austria": "AT", "österreich": "AT", "oesterreich": "AT",
+	"switzerland": "CH", "schweiz": "CH", "suisse": "CH",
+	"france": "FR", "frankreich": "FR",
+	"netherlands": "NL", "niederlande": "NL",
+	"belgium": "BE", "belgien": "BE",
+	"luxembourg": "LU", "luxemburg": "LU",
+	"italy": "IT", "italien": "IT",
+	"spain": "ES", "spanien": "ES",
+	"poland": "PL", "polen": "PL",
+	"denmark": "DK", "dänemark": "DK",
+	"czech republic": "CZ", "czechia": "CZ", "tschechien": "CZ",
+	"sweden": "SE", "schweden": "SE",
+	"united kingdom": "GB", "great britain": "GB", "uk": "GB", "großbritannien": "GB",
+	"united states": "US", "usa": "US", "vereinigte staaten": "US",
+	"ireland": "IE", "irland": "IE",
+	"portugal": "PT",
+	"finland":  "FI", "finnland": "FI",
+	"norway": "NO", "norwegen": "NO",
+	"liechtenstein": "LI", "fürstentum liechtenstein": "LI",
+	"greece": "GR", "griechenland": "GR", "el": "GR",
+	"hungary": "HU", "ungarn": "HU",
+	"slovakia": "SK", "slowakei": "SK",
+	"slovenia": "SI", "slowenien": "SI",
+	"croatia": "HR", "kroatien": "HR",
+	"romania": "RO", "rumänien": "RO",
+	"bulgaria": "BG", "bulgarien": "BG",
+	"estonia": "EE", "estland": "EE",
+	"latvia": "LV", "lettland": "LV",
+	"lithuania": "LT", "litauen": "LT",
+	"cyprus": "CY", "zypern": "CY",
+	"iceland": "IS", "island": "IS",
+	"turkey": "TR", "türkei": "TR",
+	"canada": "CA", "kanada": "CA",
+	"japan": "JP", "china": "CN",
+	"australia": "AU", "australien": "AU",
+}
+
+// CountryCode maps the free text country of an address to its ISO 3166-1
+// alpha-2 code. Codes are checked against the ISO list; names are looked up
+// among common English and German names, which also map the customary
+// prefixes UK and EL to GB and GR. Unknown input returns an empty string.
+func CountryCode(country string) string {
+	country = strings.TrimSpace(country)
+	if code := strings.ToUpper(country); isoCountries[code] {
+		return code
+	}
+	name := strings.ToLower(country)
+	if code, ok := countryNames[name]; ok {
+		return code
+	}
+	return isoCountryNames[name]
+}
+
+// countryOrError resolves a country for output formats that require it.
+func countryOrError(role, country string) (string, error) {
+	code := CountryCode(country)
+	if code == "" {
+		return "", fmt.Errorf("einvoice: %s country %q is not a known ISO 3166 country", role, country)
+	}
+	return code, nil
+}
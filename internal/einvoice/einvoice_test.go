@@ -0,0 +1,268 @@
+package einvoice
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func fixture(rate string, exemption models.TaxExemption) (models.Profile, models.Customer, models.Invoice) {
+	profile := models.Profile{
+		DisplayName:    "Erika Muster",
+		CompanyName:    "Seller GmbH",
+		Email:          "billing@seller.example",
+		Phone:          "+49 30 123456",
+		Country:        "DE",
+		TaxID:          "DE123456789",
+		PaymentDetails: models.PaymentDetails{IBAN: "DE89 3704 0044 0532 0130 00"},
+	}
+	customer := models.Customer{
+		DisplayName: "Buyer AB",
+		Email:       "ap@buyer.example",
+		Country:     "SE",
+		LeitwegID:   "991-12345-67",
+		VATID:       "SE123456789701",
+	}
+	invoice := models.Invoice{
+		Number:       "INV-1",
+		IssueDate:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Currency:     "EUR",
+		TaxExemption: exemption,
+		Items: []models.InvoiceItem{{
+			Description:    "Consulting",
+			Quantity:       money.DecimalFromInt(1),
+			UnitPrice:      money.New(10000, "EUR"),
+			TaxRatePercent: money.MustParseDecimal(rate),
+		}},
+	}
+	invoice.Recalculate()
+	return profile, customer, invoice
+}
+
+func TestTaxCategories(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      string
+		exemption models.TaxExemption
+		cii, ubl  []string
+		absent    []string
+	}{
+		{
+			name:   "standard rated",
+			rate:   "19",
+			cii:    []string{"<ram:CategoryCode>S</ram:CategoryCode>", "<ram:RateApplicablePercent>19</ram:RateApplicablePercent>"},
+			ubl:    []string{"<cbc:ID>S</cbc:ID>", "<cbc:Percent>19</cbc:Percent>"},
+			absent: []string{"ExemptionReason"},
+		},
+		{
+			name:      "zero rated ignores a reason",
+			rate:      "0",
+			exemption: models.TaxExemption{Reason: "Zero rated"},
+			cii:       []string{"<ram:CategoryCode>Z</ram:CategoryCode>"},
+			ubl:       []string{"<cbc:ID>Z</cbc:ID>"},
+			absent:    []string{"ExemptionReason"},
+		},
+		{
+			name:      "exempt",
+			rate:      "0",
+			exemption: models.TaxExemption{Category: models.TaxExempt, Reason: "Steuerfrei nach § 4 Nr. 21 UStG"},
+			cii:       []string{"<ram:ExemptionReason>Steuerfrei nach § 4 Nr. 21 UStG</ram:ExemptionReason>", "<ram:CategoryCode>E</ram:CategoryCode>"},
+			ubl:       []string{"<cbc:ID>E</cbc:ID>", "<cbc:TaxExemptionReason>Steuerfrei nach § 4 Nr. 21 UStG</cbc:TaxExemptionReason>"},
+			absent:    []string{"ExemptionReasonCode"},
+		},
+		{
+			name:      "reverse charge",
+			rate:      "0",
+			exemption: models.TaxExemption{Category: models.TaxReverseCharge, Reason: "Reverse charge"},
+			cii:       []string{"<ram:CategoryCode>AE</ram:CategoryCode>", "<ram:ExemptionReasonCode>VATEX-EU-AE</ram:ExemptionReasonCode>", `<ram:ID schemeID="VA">SE123456789701</ram:ID>`},
+			ubl:       []string{"<cbc:ID>AE</cbc:ID>", "<cbc:TaxExemptionReasonCode>VATEX-EU-AE</cbc:TaxExemptionReasonCode>", "<cbc:CompanyID>SE123456789701</cbc:CompanyID>"},
+		},
+		{
+			name:      "intra-community supply",
+			rate:      "0",
+			exemption: models.TaxExemption{Category: models.TaxIntraCommunity, Reason: "Intra-community supply"},
+			cii:       []string{"<ram:CategoryCode>K</ram:CategoryCode>", "<ram:ExemptionReasonCode>VATEX-EU-IC</ram:ExemptionReasonCode>"},
+			ubl:       []string{"<cbc:ID>K</cbc:ID>", "<cbc:TaxExemptionReasonCode>VATEX-EU-IC</cbc:TaxExemptionReasonCode>"},
+		},
+		{
+			name:      "not subject to VAT has no rate",
+			rate:      "0",
+			exemption: models.TaxExemption{Category: models.TaxNotSubject, Reason: "Not subject to VAT"},
+			cii:       []string{"<ram:CategoryCode>O</ram:CategoryCode>", "<ram:ExemptionReasonCode>VATEX-EU-O</ram:ExemptionReasonCode>"},
+			ubl:       []string{"<cbc:ID>O</cbc:ID>"},
+			absent:    []string{"RateApplicablePercent", "<cbc:Percent>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture(tt.rate, tt.exemption)
+			cii, err := CII(profile, customer, invoice, LevelEN16931)
+			if err != nil {
+				t.Fatalf("CII: %v", err)
+			}
+			ubl, err := XRechnung(profile, customer, invoice)
+			if err != nil {
+				t.Fatalf("XRechnung: %v", err)
+			}
+			for _, want := range tt.cii {
+				if !strings.Contains(string(cii), want) {
+					t.Errorf("CII lacks %s", want)
+				}
+			}
+			for _, want := range tt.ubl {
+				if !strings.Contains(string(ubl), want) {
+					t.Errorf("UBL lacks %s", want)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(string(cii), unwanted) || strings.Contains(string(ubl), unwanted) {
+					t.Errorf("output contains %s", unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestXRechnungEndpoints(t *testing.T) {
+	tests := []struct {
+		name          string
+		seller, buyer string
+		want          error
+	}{
+		{name: "both addresses", seller: "billing@seller.example", buyer: "ap@buyer.example"},
+		{name: "seller missing", buyer: "ap@buyer.example", want: ErrMissingEndpoint},
+		{name: "buyer missing", seller: "billing@seller.example", buyer: " ", want: ErrMissingEndpoint},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture("19", models.TaxExemption{})
+			profile.Email, customer.Email = tt.seller, tt.buyer
+			if _, err := XRechnung(profile, customer, invoice); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestXRechnungSellerDetails(t *testing.T) {
+	tests := []struct {
+		name           string
+		contact, phone string
+		iban           string
+		want           error
+		contains       []string
+	}{
+		{
+			name: "complete", contact: "Erika Muster", phone: "+49 30 123456", iban: "DE89 3704 0044 0532 0130 00",
+			contains: []string{
+				"<cbc:Name>Erika Muster</cbc:Name>",
+				"<cbc:Telephone>+49 30 123456</cbc:Telephone>",
+				"<cbc:ElectronicMail>billing@seller.example</cbc:ElectronicMail>",
+				"<cbc:PaymentMeansCode>58</cbc:PaymentMeansCode>",
+				"<cbc:ID>DE89370400440532013000</cbc:ID>",
+			},
+		},
+		{name: "contact name missing", phone: "+49 30 123456", iban: "DE89370400440532013000", want: ErrMissingContact},
+		{name: "phone missing", contact: "Erika Muster", phone: " ", iban: "DE89370400440532013000", want: ErrMissingContact},
+		{name: "IBAN missing", contact: "Erika Muster", phone: "+49 30 123456", want: ErrMissingPaymentMeans},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture("19", models.TaxExemption{})
+			profile.DisplayName, profile.Phone, profile.PaymentDetails.IBAN = tt.contact, tt.phone, tt.iban
+			data, err := XRechnung(profile, customer, invoice)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("output misses %s", want)
+				}
+			}
+		})
+	}
+}
+
+func TestPrepaidAmounts(t *testing.T) {
+	tests := []struct {
+		name             string
+		paid, credited   int64
+		prepaid, payable string
+	}{
+		{name: "open", payable: "119.00"},
+		{name: "partly paid", paid: 4000, prepaid: "40.00", payable: "79.00"},
+		{name: "partly credited", credited: 1900, prepaid: "19.00", payable: "100.00"},
+		{name: "paid and credited", paid: 4000, credited: 7900, prepaid: "119.00", payable: "0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture("19", models.TaxExemption{})
+			if tt.paid != 0 {
+				invoice.Payments = []models.Payment{{Date: invoice.IssueDate, Amount: money.New(tt.paid, "EUR")}}
+			}
+			invoice.Credited = money.New(tt.credited, "EUR")
+			cii, err := CII(profile, customer, invoice, LevelEN16931)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ubl, err := XRechnung(profile, customer, invoice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs := []struct {
+				data         []byte
+				prepaid, due string
+			}{
+				{data: cii, prepaid: "ram:TotalPrepaidAmount", due: "ram:DuePayableAmount"},
+				{data: ubl, prepaid: "cbc:PrepaidAmount", due: "cbc:PayableAmount"},
+			}
+			for _, out := range outputs {
+				if got := element(out.data, out.due); got != tt.payable {
+					t.Errorf("%s = %q, want %q", out.due, got, tt.payable)
+				}
+				if got := element(out.data, out.prepaid); got != tt.prepaid {
+					t.Errorf("%s = %q, want %q", out.prepaid, got, tt.prepaid)
+				}
+			}
+		})
+	}
+}
+
+// element returns the text of the first element called name, or "" if there
+// is none.
+func element(data []byte, name string) string {
+	_, rest, ok := strings.Cut(string(data), "<"+name)
+	if !ok {
+		return ""
+	}
+	_, rest, _ = strings.Cut(rest, ">")
+	text, _, _ := strings.Cut(rest, "</"+name+">")
+	return text
+}
+
+func TestCountryCode(t *testing.T) {
+	tests := []struct {
+		country, want string
+	}{
+		{country: "DE", want: "DE"},
+		{country: " de ", want: "DE"},
+		{country: "Deutschland", want: "DE"},
+		{country: "Greece", want: "GR"},
+		{country: "EL", want: "GR"},
+		{country: "UK", want: "GB"},
+		{country: "Hungary", want: "HU"},
+		{country: "Korea, Republic of", want: "KR"},
+		{country: "XX"},
+		{country: "Atlantis"},
+		{country: ""},
+	}
+	for _, tt := range tests {
+		if got := CountryCode(tt.country); got != tt.want {
+			t.Errorf("CountryCode(%q) = %q, want %q", tt.country, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package einvoice
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// XRechnung identifiers for version 3.0 of the German CIUS.
+const (
+	xrechnungCustomization = "urn:cen.eu:en16931:2017#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0"
+	xrechnungProfile       = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+)
+
+// ErrMissingLeitwegID is returned when an XRechnung is requested for a
+// customer without a Leitweg-ID; public authorities route invoices by it.
+var ErrMissingLeitwegID = errors.New("einvoice: customer has no Leitweg-ID")
+
+// ErrMissingEndpoint is returned when the seller or buyer has no email
+// address, which XRechnung requires as electronic address (BT-34, BT-49).
+var ErrMissingEndpoint = errors.New("einvoice: electronic address is missing")
+
+// ErrMissingContact is returned when the seller contact lacks a name, phone
+// number or email address (BR-DE-2, BR-DE-5 to BR-DE-7).
+var ErrMissingContact = errors.New("einvoice: seller contact is incomplete")
+
+// ErrMissingPaymentMeans is returned when the profile has no IBAN to pay to;
+// XRechnung requires payment instructions (BR-DE-1).
+var ErrMissingPaymentMeans = errors.New("einvoice: payment account is missing")
+
+// XRechnung renders the invoice as UBL 2.1 following the XRechnung CIUS.
+func XRechnung(profile models.Profile, customer models.Customer, invoice models.Invoice) ([]byte, error) {
+	leitwegID := strings.TrimSpace(customer.LeitwegID)
+	if leitwegID == "" {
+		return nil, ErrMissingLeitwegID
+	}
+	if strings.TrimSpace(profile.Email) == "" {
+		return nil, fmt.Errorf("%w: seller", ErrMissingEndpoint)
+	}
+	if strings.TrimSpace(customer.Email) == "" {
+		return nil, fmt.Errorf("%w: buyer", ErrMissingEndpoint)
+	}
+	switch {
+	case strings.TrimSpace(profile.DisplayName) == "":
+		return nil, fmt.Errorf("%w: name", ErrMissingContact)
+	case strings.TrimSpace(profile.Phone) == "":
+		return nil, fmt.Errorf("%w: phone", ErrMissingContact)
+	}
+	iban := compactIBAN(profile.PaymentDetails.IBAN)
+	if iban == "" {
+		return nil, ErrMissingPaymentMeans
+	}
+	sellerCountry, err := countryOrError("seller", profile.Country)
+	if err != nil {
+		return nil, err
+	}
+	buyerCountry, err := countryOrError("buyer", customer.Country)
+	if err != nil {
+		return nil, err
+	}
+	currency := invoice.Currency
+	amount := func(name, value string) *node {
+		return leaf(name, value, "currencyID", currency)
+	}
+
+	root := el("ubl:Invoice").
+		attr("xmlns:ubl", "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2").
+		attr("xmlns:cac", "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2").
+		attr("xmlns:cbc", "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2")
+
+	root.add(
+		leaf("cbc:CustomizationID", xrechnungCustomization),
+		leaf("cbc:ProfileID", xrechnungProfile),
+		leaf("cbc:ID", invoice.Number),
+		leaf("cbc:IssueDate", ublDate(invoice.IssueDate)),
+		when(!invoice.DueDate.IsZero(), leaf("cbc:DueDate", ublDate(invoice.DueDate))),
+		leaf("cbc:InvoiceTypeCode", typeCode(invoice)),
+		leaf("cbc:Note", strings.TrimSpace(invoice.Notes)),
+		leaf("cbc:DocumentCurrencyCode", currency),
+		leaf("cbc:BuyerReference", leitwegID),
+		when(invoice.IsCorrection(), el("cac:BillingReference",
+			el("cac:InvoiceDocumentReference", leaf("cbc:ID", invoice.OriginalNumber)),
+		)),
+	)
+
+	seller := el("cac:Party",
+		leaf("cbc:EndpointID", strings.TrimSpace(profile.Email), "schemeID", "EM"),
+		ublAddress(profile.AddressLine1, profile.AddressLine2, profile.City, profile.PostalCode, sellerCountry),
+	)
+	if taxID := strings.TrimSpace(profile.TaxID); taxID != "" {
+		scheme := "FC"
+		if taxScheme(taxID) == "VA" {
+			scheme = "VAT"
+		}
+		seller.add(el("cac:PartyTaxScheme",
+			leaf("cbc:CompanyID", taxID),
+			el("cac:TaxScheme", leaf("cbc:ID", scheme)),
+		))
+	}
+	seller.add(
+		el("cac:PartyLegalEntity", leaf("cbc:RegistrationName", sellerName(profile))),
+		el("cac:Contact",
+			leaf("cbc:Name", strings.TrimSpace(profile.DisplayName)),
+			leaf("cbc:Telephone", strings.TrimSpace(profile.Phone)),
+			leaf("cbc:ElectronicMail", strings.TrimSpace(profile.Email)),
+		),
+	)
+	root.add(el("cac:AccountingSupplierParty", seller))
+
+	buyer := el("cac:Party",
+		leaf("cbc:EndpointID", strings.TrimSpace(customer.Email), "schemeID", "EM"),
+		ublAddress(customer.AddressLine1, customer.AddressLine2, customer.City, customer.PostalCode, buyerCountry),
+	)
+	if vatID := strings.TrimSpace(customer.VATID); vatID != "" {
+		buyer.add(el("cac:PartyTaxScheme",
+			leaf("cbc:CompanyID", vatID),
+			el("cac:TaxScheme", leaf("cbc:ID", "VAT")),
+		))
+	}
+	buyer.add(
+		el("cac:PartyLegalEntity", leaf("cbc:RegistrationName", strings.TrimSpace(customer.DisplayName))),
+		optional(el("cac:Contact",
+			leaf("cbc:Name", strings.TrimSpace(customer.ContactName)),
+			leaf("cbc:Telephone", strings.TrimSpace(customer.Phone)),
+			leaf("cbc:ElectronicMail", strings.TrimSpace(customer.Email)),
+		)),
+	)
+	root.add(el("cac:AccountingCustomerParty", buyer))
+
+	root.add(el("cac:PaymentMeans",
+		leaf("cbc:PaymentMeansCode", paymentMeansCredit),
+		leaf("cbc:PaymentID", invoice.Number),
+		el("cac:PayeeFinancialAccount",
+			leaf("cbc:ID", iban),
+			leaf("cbc:Name", strings.TrimSpace(profile.PaymentDetails.BankName)),
+			optional(el("cac:FinancialInstitutionBranch", leaf("cbc:ID", strings.TrimSpace(profile.PaymentDetails.BIC)))),
+		),
+	))
+	root.add(optional(el("cac:PaymentTerms", leaf("cbc:Note", paymentTermsNote(profile, invoice)))))
+
+	taxTotal := el("cac:TaxTotal", amount("cbc:TaxAmount", invoice.TaxAmount.Amount()))
+	for _, tax := range invoice.TaxBreakdown {
+		taxTotal.add(el("cac:TaxSubtotal",
+			amount("cbc:TaxableAmount", tax.Net.Amount()),
+			amount("cbc:TaxAmount", tax.Tax.Amount()),
+			ublTaxCategory("cac:TaxCategory", tax.RatePercent, taxCategory(invoice, tax.RatePercent)),
+		))
+	}
+	root.add(taxTotal)
+
+	root.add(el("cac:LegalMonetaryTotal",
+		amount("cbc:LineExtensionAmount", invoice.Subtotal.Amount()),
+		amount("cbc:TaxExclusiveAmount", invoice.Subtotal.Amount()),
+		amount("cbc:TaxInclusiveAmount", invoice.Total.Amount()),
+		when(!prepaid(invoice).IsZero(), amount("cbc:PrepaidAmount", prepaid(invoice).Amount())),
+		amount("cbc:PayableAmount", duePayable(invoice).Amount()),
+	))
+
+	for i, item := range invoice.Items {
+		root.add(el("cac:InvoiceLine",
+			leaf("cbc:ID", strconv.Itoa(i+1)),
+			leaf("cbc:InvoicedQuantity", item.Quantity.String(), "unitCode", unitCodeOne),
+			amount("cbc:LineExtensionAmount", item.LineTotal.Amount()),
+			el("cac:Item",
+				leaf("cbc:Name", strings.TrimSpace(item.Description)),
+				ublTaxCategory("cac:ClassifiedTaxCategory", item.TaxRatePercent, lineCategory(taxCategory(invoice, item.TaxRatePercent))),
+			),
+			el("cac:Price", amount("cbc:PriceAmount", item.UnitPrice.Amount())),
+		))
+	}
+	return render(root), nil
+}
+
+func ublAddress(line1, line2, city, postalCode, country string) *node {
+	return el("cac:PostalAddress",
+		leaf("cbc:StreetName", strings.TrimSpace(line1)),
+		leaf("cbc:AdditionalStreetName", strings.TrimSpace(line2)),
+		leaf("cbc:CityName", strings.TrimSpace(city)),
+		leaf("cbc:PostalZone", strings.TrimSpace(postalCode)),
+		el("cac:Country", leaf("cbc:IdentificationCode", country)),
+	)
+}
+
+func ublTaxCategory(name string, rate money.Decimal, category vatCategory) *node {
+	return el(name,
+		leaf("cbc:ID", category.code),
+		leaf("cbc:Percent", category.percent(rate)),
+		leaf("cbc:TaxExemptionReasonCode", category.reasonCode),
+		leaf("cbc:TaxExemptionReason", category.reason),
+		el("cac:TaxScheme", leaf("cbc:ID", "VAT")),
+	)
+}
+
+// lineCategory drops the exemption reason, which UBL only allows in the
+// tax breakdown.
+func lineCategory(category vatCategory) vatCategory {
+	return vatCategory{code: category.code}
+}
+
+func ublDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,106 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// node is a minimal XML element tree. It keeps namespace prefixes exactly as
+// written, which encoding/xml's struct marshalling does not.
+type node struct {
+	name     string
+	attrs    [][2]string
+	text     string
+	children []*node
+}
+
+// el builds an element from its children. Nil children are skipped, so
+// optional parts can be passed inline.
+func el(name string, children ...*node) *node {
+	n := &node{name: name}
+	for _, child := range children {
+		if child != nil {
+			n.children = append(n.children, child)
+		}
+	}
+	return n
+}
+
+// leaf builds a text element. Empty text yields nil so the element is left out.
+func leaf(name, text string, attrs ...string) *node {
+	if text == "" {
+		return nil
+	}
+	n := &node{name: name, text: text}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		n.attrs = append(n.attrs, [2]string{attrs[i], attrs[i+1]})
+	}
+	return n
+}
+
+// optional drops containers that ended up without any children.
+func optional(n *node) *node {
+	if n == nil || len(n.children) == 0 {
+		return nil
+	}
+	return n
+}
+
+// when returns n if cond holds and nil otherwise.
+func when(cond bool, n *node) *node {
+	if !cond {
+		return nil
+	}
+	return n
+}
+
+func (n *node) attr(name, value string) *node {
+	n.attrs = append(n.attrs, [2]string{name, value})
+	return n
+}
+
+func (n *node) add(children ...*node) *node {
+	for _, child := range children {
+		if child != nil {
+			n.children = append(n.children, child)
+		}
+	}
+	return n
+}
+
+// render serialises the tree with an XML declaration and two space indentation.
+func render(root *node) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	root.write(&buf, 0)
+	return buf.Bytes()
+}
+
+func (n *node) write(buf *bytes.Buffer, depth int) {
+	for i := 0; i < depth; i++ {
+		buf.WriteString("  ")
+	}
+	buf.WriteString("<" + n.name)
+	for _, a := range n.attrs {
+		buf.WriteString(" " + a[0] + `="`)
+		xml.EscapeText(buf, []byte(a[1]))
+		buf.WriteString(`"`)
+	}
+	switch {
+	case len(n.children) > 0:
+		buf.WriteString(">\n")
+		for _, child := range n.children {
+			child.write(buf, depth+1)
+		}
+		for i := 0; i < depth; i++ {
+			buf.WriteString("  ")
+		}
+		buf.WriteString("</" + n.name + ">\n")
+	case n.text != "":
+		buf.WriteString(">")
+		xml.EscapeText(buf, []byte(n.text))
+		buf.WriteString("</" + n.name + ">\n")
+	default:
+		buf.WriteString("/>\n")
+	}
+}
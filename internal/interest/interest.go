@@ -0,0 +1,144 @@
+// Package interest computes the statutory late-payment interest of overdue
+// invoices from a base rate schedule.
+package interest
+
+import (
+	"sort"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// yearDays is the day count basis: a day's interest is the annual rate
+// divided by 365, also in leap years.
+const yearDays = 365
+
+// Period is a stretch of days with the same balance and rate.
+type Period struct {
+	From time.Time `json:"from"`
+	// To is the last day of the period, inclusive.
+	To          time.Time     `json:"to"`
+	Days        int           `json:"days"`
+	Balance     money.Money   `json:"balance"`
+	RatePercent money.Decimal `json:"rate_percent"`
+	Interest    money.Money   `json:"interest"`
+}
+
+// Result is the interest accrued on an invoice up to Until.
+type Result struct {
+	Until    time.Time   `json:"until"`
+	Periods  []Period    `json:"periods"`
+	Interest money.Money `json:"interest"`
+	FlatFee  money.Money `json:"flat_fee"`
+}
+
+// Total returns the interest plus the flat fee.
+func (r Result) Total() money.Money {
+	return r.Interest.Add(r.FlatFee)
+}
+
+// Calculate computes the interest on the outstanding balance of invoice from
+// the day after its due date up to and including until. A payment reduces the
+// balance from its date on. Periods where the rate is not positive accrue
+// nothing. Drafts and corrections accrue no interest.
+func Calculate(invoice models.Invoice, settings models.InterestSettings, until time.Time) Result {
+	settings = settings.Effective()
+	result := Result{Until: day(until), Interest: money.Zero(invoice.Currency), FlatFee: money.Zero(invoice.Currency)}
+	if invoice.IsDraft() || invoice.IsCorrection() || invoice.DueDate.IsZero() {
+		return result
+	}
+	start, end := day(invoice.DueDate).AddDate(0, 0, 1), result.Until.AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return result
+	}
+
+	// The balance or rate can only change where a rate period starts or a
+	// payment is made.
+	periods := append([]models.InterestPeriod(nil), settings.Periods...)
+	sort.Slice(periods, func(i, j int) bool { return periods[i].From.Before(periods[j].From) })
+	bounds := []time.Time{start, end}
+	for _, p := range periods {
+		if from := day(p.From); from.After(start) && from.Before(end) {
+			bounds = append(bounds, from)
+		}
+	}
+	for _, p := range invoice.Payments {
+		if date := day(p.Date); date.After(start) && date.Before(end) {
+			bounds = append(bounds, date)
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	for i := 0; i+1 < len(bounds); i++ {
+		from, next := bounds[i], bounds[i+1]
+		if !from.Before(next) {
+			continue
+		}
+		balance := balanceOn(invoice, from)
+		if balance.Sign() <= 0 {
+			continue
+		}
+		rate := baseRate(periods, from).Add(settings.MarginPoints)
+		days := int(next.Sub(from).Hours() / 24)
+		period := Period{From: from, To: next.AddDate(0, 0, -1), Days: days, Balance: balance, RatePercent: rate, Interest: accrue(balance, rate, days)}
+		// Consecutive days with the same balance and rate form one period.
+		if n := len(result.Periods); n > 0 {
+			last := &result.Periods[n-1]
+			if last.To.AddDate(0, 0, 1).Equal(from) && last.Balance.Cmp(balance) == 0 && last.RatePercent.Cmp(rate) == 0 {
+				last.To, last.Days = period.To, last.Days+days
+				last.Interest = accrue(balance, rate, last.Days)
+				continue
+			}
+		}
+		result.Periods = append(result.Periods, period)
+	}
+	for _, p := range result.Periods {
+		result.Interest = result.Interest.Add(p.Interest)
+	}
+	if len(result.Periods) > 0 && settings.FlatFee.Sign() > 0 {
+		result.FlatFee = money.FromDecimal(settings.FlatFee, invoice.Currency)
+	}
+	return result
+}
+
+// balanceOn returns the balance after the payments made up to date. An
+// earned early-payment discount is never owed and credits correct the claim
+// itself, so both are left out throughout.
+func balanceOn(invoice models.Invoice, date time.Time) money.Money {
+	balance := invoice.Total.Sub(invoice.Credited).Sub(invoice.DiscountGranted())
+	for _, p := range invoice.Payments {
+		if !day(p.Date).After(date) {
+			balance = balance.Sub(p.Amount)
+		}
+	}
+	return balance
+}
+
+// baseRate returns the rate of the latest period starting on or before date.
+// The periods are sorted by start; dates before the schedule use its first
+// rate.
+func baseRate(periods []models.InterestPeriod, date time.Time) money.Decimal {
+	var rate money.Decimal
+	for i, p := range periods {
+		if i > 0 && day(p.From).After(date) {
+			break
+		}
+		rate = p.RatePercent
+	}
+	return rate
+}
+
+// accrue returns the interest on balance for days, or zero if the rate is not
+// positive.
+func accrue(balance money.Money, rate money.Decimal, days int) money.Money {
+	if rate.Sign() <= 0 {
+		return money.Zero(balance.Currency)
+	}
+	return balance.Interest(rate, days, yearDays)
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
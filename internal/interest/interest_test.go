@@ -0,0 +1,163 @@
+package interest
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func date(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// fixture returns a finalised, untaxed invoice over total minor units of EUR
+// due on due.
+func fixture(total int64, due time.Time) models.Invoice {
+	invoice := models.Invoice{
+		IssueDate:   due.AddDate(0, 0, -14),
+		DueDate:     due,
+		Currency:    "EUR",
+		FinalizedAt: due.AddDate(0, 0, -14),
+		Items: []models.InvoiceItem{{
+			Description: "Consulting",
+			Quantity:    money.DecimalFromInt(1),
+			UnitPrice:   money.New(total, "EUR"),
+		}},
+	}
+	invoice.Recalculate()
+	return invoice
+}
+
+// periods formats the periods of a result for comparison.
+func periods(result Result) []string {
+	out := make([]string, len(result.Periods))
+	for i, p := range result.Periods {
+		out[i] = fmt.Sprintf("%s..%s %d %s %s%% %s", p.From.Format("2006-01-02"), p.To.Format("2006-01-02"), p.Days, p.Balance, p.RatePercent, p.Interest)
+	}
+	return out
+}
+
+func TestCalculate(t *testing.T) {
+	custom := models.InterestSettings{
+		Periods: []models.InterestPeriod{
+			{From: date(2025, time.February, 1), RatePercent: money.MustParseDecimal("-1")},
+			{From: date(2025, time.January, 1), RatePercent: money.MustParseDecimal("2")},
+		},
+		MarginPoints: money.DecimalFromInt(5),
+	}
+	tests := []struct {
+		name     string
+		invoice  models.Invoice
+		settings models.InterestSettings
+		until    time.Time
+		periods  []string
+		interest string
+		flatFee  string
+	}{
+		{
+			name:     "not yet overdue",
+			invoice:  fixture(1000000, date(2024, time.June, 20)),
+			until:    date(2024, time.June, 20),
+			interest: "0.00 EUR",
+			flatFee:  "0.00 EUR",
+		},
+		{
+			name:    "default rates across a base rate change",
+			invoice: fixture(1000000, date(2024, time.June, 20)),
+			until:   date(2024, time.July, 10),
+			periods: []string{
+				"2024-06-21..2024-06-30 10 10000.00 EUR 12.62% 34.58 EUR",
+				"2024-07-01..2024-07-10 10 10000.00 EUR 12.37% 33.89 EUR",
+			},
+			interest: "68.47 EUR",
+			flatFee:  "40.00 EUR",
+		},
+		{
+			name: "partial payment and a non-positive rate",
+			invoice: func() models.Invoice {
+				invoice := fixture(100000, date(2025, time.January, 10))
+				invoice.Payments = []models.Payment{{Date: date(2025, time.January, 21), Amount: money.New(40000, "EUR")}}
+				return invoice
+			}(),
+			settings: models.InterestSettings{
+				Periods:      custom.Periods,
+				MarginPoints: money.DecimalFromInt(1),
+			},
+			until: date(2025, time.February, 28),
+			periods: []string{
+				"2025-01-11..2025-01-20 10 1000.00 EUR 3% 0.82 EUR",
+				"2025-01-21..2025-01-31 11 600.00 EUR 3% 0.54 EUR",
+				"2025-02-01..2025-02-28 28 600.00 EUR 0% 0.00 EUR",
+			},
+			interest: "1.36 EUR",
+			flatFee:  "0.00 EUR",
+		},
+		{
+			name:     "unsorted custom schedule",
+			invoice:  fixture(100000, date(2025, time.January, 10)),
+			settings: custom,
+			until:    date(2025, time.February, 28),
+			periods: []string{
+				"2025-01-11..2025-01-31 21 1000.00 EUR 7% 4.03 EUR",
+				"2025-02-01..2025-02-28 28 1000.00 EUR 4% 3.07 EUR",
+			},
+			interest: "7.10 EUR",
+			flatFee:  "0.00 EUR",
+		},
+		{
+			name:     "due before the schedule uses its first rate",
+			invoice:  fixture(100000, date(2024, time.December, 21)),
+			settings: custom,
+			until:    date(2025, time.January, 10),
+			periods: []string{
+				"2024-12-22..2025-01-10 20 1000.00 EUR 7% 3.84 EUR",
+			},
+			interest: "3.84 EUR",
+			flatFee:  "0.00 EUR",
+		},
+		{
+			name: "paid in full stops accruing",
+			invoice: func() models.Invoice {
+				invoice := fixture(100000, date(2025, time.January, 10))
+				invoice.Payments = []models.Payment{{Date: date(2025, time.January, 15), Amount: money.New(100000, "EUR")}}
+				return invoice
+			}(),
+			settings: custom,
+			until:    date(2025, time.February, 28),
+			periods: []string{
+				"2025-01-11..2025-01-14 4 1000.00 EUR 7% 0.77 EUR",
+			},
+			interest: "0.77 EUR",
+			flatFee:  "0.00 EUR",
+		},
+		{
+			name: "drafts accrue nothing",
+			invoice: func() models.Invoice {
+				invoice := fixture(100000, date(2025, time.January, 10))
+				invoice.FinalizedAt = time.Time{}
+				return invoice
+			}(),
+			until:    date(2025, time.February, 28),
+			interest: "0.00 EUR",
+			flatFee:  "0.00 EUR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Calculate(tt.invoice, tt.settings, tt.until)
+			if got := periods(result); !slices.Equal(got, tt.periods) {
+				t.Errorf("periods = %q, want %q", got, tt.periods)
+			}
+			if got := result.Interest.String(); got != tt.interest {
+				t.Errorf("interest = %s, want %s", got, tt.interest)
+			}
+			if got := result.FlatFee.String(); got != tt.flatFee {
+				t.Errorf("flat fee = %s, want %s", got, tt.flatFee)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package invoicing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+	"github.com/janmarkuslanger/invoiceio/internal/validation"
+)
+
+// DefaultPaymentDays is the payment period used when neither a due date nor
+// payment terms with net days are given.
+const DefaultPaymentDays = 14
+
+// Definition describes an invoice to create. Profile and customer are
+// referenced by ID or display name; dates use the YYYY-MM-DD format.
+type Definition struct {
+	Source         string           `json:"-"`
+	Profile        string           `json:"profile"`
+	Customer       string           `json:"customer"`
+	IssueDate      string           `json:"issue_date"`
+	DueDate        string           `json:"due_date"`
+	Currency       string           `json:"currency"`
+	Notes          string           `json:"notes"`
+	TaxRatePercent money.Decimal    `json:"tax_rate_percent"`
+	Items          []ItemDefinition `json:"items"`
+	// Terms override the default payment terms of the customer or profile.
+	Terms *models.PaymentTerms `json:"terms"`
+	// TaxExemption overrides the tax exemption of the customer for items
+	// without VAT.
+	TaxExemption models.TaxExemption `json:"tax_exemption"`
+}
+
+// ItemDefinition is a line item of a Definition. Items without their own tax
+// rate use the rate of the definition.
+type ItemDefinition struct {
+	Description    string         `json:"description"`
+	Quantity       money.Decimal  `json:"quantity"`
+	UnitPrice      money.Decimal  `json:"unit_price"`
+	TaxRatePercent *money.Decimal `json:"tax_rate_percent"`
+}
+
+// LoadDefinitions reads the definitions in a JSON or YAML file. A file holds
+// either a single definition or a list of them.
+func LoadDefinitions(path string) ([]Definition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("invoicing: open %s: %w", path, err)
+	}
+	defer f.Close()
+	return ParseDefinitions(path, f)
+}
+
+// ParseDefinitions decodes definitions from r. The format is chosen by the
+// extension of name: .yaml and .yml are read as YAML, anything else as JSON.
+func ParseDefinitions(name string, r io.Reader) ([]Definition, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("invoicing: read %s: %w", name, err)
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		// YAML is converted to JSON so both formats share one set of decoders.
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invoicing: parse %s: %w", name, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("invoicing: parse %s: %w", name, err)
+		}
+	}
+
+	var defs []Definition
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &defs)
+	} else {
+		var def Definition
+		err = json.Unmarshal(trimmed, &def)
+		defs = []Definition{def}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invoicing: parse %s: %w", name, err)
+	}
+	base := filepath.Base(name)
+	for i := range defs {
+		defs[i].Source = base
+		if len(defs) > 1 {
+			defs[i].Source = fmt.Sprintf("%s #%d", base, i+1)
+		}
+	}
+	return defs, nil
+}
+
+// Result reports the outcome for one definition. Err is set when the invoice
+// was not (or, in a dry run, would not be) created.
+type Result struct {
+	Source   string
+	Profile  models.Profile
+	Customer models.Customer
+	Invoice  models.Invoice
+	Findings validation.Findings
+	Err      error
+}
+
+// ErrIncomplete is reported for definitions that fail blocking validation
+// rules; the findings are part of the result.
+var ErrIncomplete = errors.New("invoicing: invoice is not complete")
+
+// Import creates an invoice for every definition. Definitions are handled
+// independently, so one broken entry does not stop the rest. With dryRun
+// nothing is stored or rendered and the invoices carry no number.
+func Import(store *storage.Storage, defs []Definition, dryRun bool) []Result {
+	results := make([]Result, 0, len(defs))
+	for _, def := range defs {
+		res := Result{Source: def.Source}
+		res.Profile, res.Customer, res.Invoice, res.Err = Build(store, def)
+		if res.Err == nil {
+			res.Findings = CheckNew(res.Profile, res.Customer, res.Invoice)
+			if res.Findings.HasErrors() {
+				res.Err = ErrIncomplete
+			}
+		}
+		if res.Err == nil && !dryRun {
+			res.Invoice, res.Err = Create(store, res.Profile, res.Customer, res.Invoice)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// Build resolves the profile and customer of a definition and returns the
+// calculated, not yet stored invoice.
+func Build(store *storage.Storage, def Definition) (models.Profile, models.Customer, models.Invoice, error) {
+	profile, err := store.FindProfile(strings.TrimSpace(def.Profile))
+	if err != nil {
+		return profile, models.Customer{}, models.Invoice{}, err
+	}
+	customer, err := store.FindCustomer(strings.TrimSpace(def.Customer))
+	if err != nil {
+		return profile, customer, models.Invoice{}, err
+	}
+
+	issue := time.Now()
+	if def.IssueDate != "" {
+		if issue, err = parseDate(def.IssueDate); err != nil {
+			return profile, customer, models.Invoice{}, fmt.Errorf("invoicing: issue date: %w", err)
+		}
+	}
+	terms := DefaultTerms(profile, customer)
+	if def.Terms != nil {
+		terms = *def.Terms
+	}
+	due := DueDate(issue, terms)
+	if def.DueDate != "" {
+		if due, err = parseDate(def.DueDate); err != nil {
+			return profile, customer, models.Invoice{}, fmt.Errorf("invoicing: due date: %w", err)
+		}
+	}
+	currency := strings.ToUpper(strings.TrimSpace(def.Currency))
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	items := make([]models.InvoiceItem, 0, len(def.Items))
+	for _, item := range def.Items {
+		rate := def.TaxRatePercent
+		if item.TaxRatePercent != nil {
+			rate = *item.TaxRatePercent
+		}
+		items = append(items, models.InvoiceItem{
+			Description:    strings.TrimSpace(item.Description),
+			Quantity:       item.Quantity,
+			UnitPrice:      money.FromDecimal(item.UnitPrice, currency),
+			TaxRatePercent: rate,
+		})
+	}
+
+	now := time.Now()
+	invoice := models.Invoice{
+		ID:           id.New(),
+		ProfileID:    profile.ID,
+		CustomerID:   customer.ID,
+		IssueDate:    issue,
+		DueDate:      due,
+		Currency:     currency,
+		Items:        items,
+		Notes:        strings.TrimSpace(def.Notes),
+		Terms:        terms,
+		TaxExemption: def.TaxExemption,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	invoice.Recalculate()
+	return profile, customer, invoice, nil
+}
+
+// parseDate accepts plain dates and the timestamps YAML turns them into.
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,227 @@
+package invoicing
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/sepa"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+// ErrNotCollectable is returned for invoices that can not be collected by
+// direct debit: drafts, corrections, settled invoices, invoices in another
+// currency than EUR, invoices whose customer has no valid mandate and
+// invoices that are already pending collection.
+var ErrNotCollectable = errors.New("invoicing: invoice can not be collected by direct debit")
+
+// mandateValidity is how long a mandate stays valid without a collection.
+const mandateValidity = 36
+
+// Collectable reports whether invoice can be exported for direct debit from
+// the customer's account.
+func Collectable(customer models.Customer, invoice models.Invoice) bool {
+	return !invoice.IsDraft() && !invoice.IsCorrection() && !invoice.IsSettled() &&
+		strings.EqualFold(invoice.Currency, "EUR") && invoice.Collection.IsZero() &&
+		!customer.Mandate.IsZero() && invoice.CustomerID == customer.ID
+}
+
+// DirectDebitCandidates lists the invoices of a profile that can be exported
+// for direct debit, ordered by due date.
+func DirectDebitCandidates(store *storage.Storage, profileID string) ([]models.Invoice, error) {
+	invoices, err := store.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	customers := map[string]models.Customer{}
+	var out []models.Invoice
+	for _, inv := range invoices {
+		if inv.ProfileID != profileID {
+			continue
+		}
+		customer, ok := customers[inv.CustomerID]
+		if !ok {
+			if customer, err = store.GetCustomer(inv.CustomerID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+			customers[inv.CustomerID] = customer
+		}
+		if Collectable(customer, inv) {
+			out = append(out, inv)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DueDate.Before(out[j].DueDate) })
+	return out, nil
+}
+
+// ExportDirectDebit writes the invoices into one pain.008 file and marks
+// them as pending collection. Each invoice is collected on its due date, but
+// not before the lead time after now allows; the amount is what is
+// outstanding. The invoices must share a profile. A first collection turns
+// the customer's mandate into a recurring one. It returns the updated
+// invoices and the path of the file.
+func ExportDirectDebit(store *storage.Storage, invoices []models.Invoice, now time.Time) ([]models.Invoice, string, error) {
+	if len(invoices) == 0 {
+		return nil, "", fmt.Errorf("%w: no invoices", ErrNotCollectable)
+	}
+	profile, err := store.GetProfile(invoices[0].ProfileID)
+	if err != nil {
+		return nil, "", err
+	}
+	creditor := sepa.Creditor{
+		Name: profile.CompanyName,
+		IBAN: profile.PaymentDetails.IBAN,
+		BIC:  profile.PaymentDetails.BIC,
+		ID:   profile.PaymentDetails.CreditorID,
+	}
+	if strings.TrimSpace(creditor.Name) == "" {
+		creditor.Name = profile.DisplayName
+	}
+
+	messageID := "DD-" + now.Format("20060102150405") + "-" + id.Short()
+	customers := map[string]models.Customer{}
+	counts := map[string]int{}
+	updated := make([]models.Invoice, len(invoices))
+	debits := make([]sepa.Debit, len(invoices))
+	for i, inv := range invoices {
+		if inv.ProfileID != profile.ID {
+			return nil, "", fmt.Errorf("%w: %s belongs to another profile than %s", ErrNotCollectable, inv.Number, invoices[0].Number)
+		}
+		customer, ok := customers[inv.CustomerID]
+		if !ok {
+			if customer, err = store.GetCustomer(inv.CustomerID); err != nil {
+				return nil, "", err
+			}
+			customers[inv.CustomerID] = customer
+		}
+		if !Collectable(customer, inv) {
+			return nil, "", fmt.Errorf("%w: %s", ErrNotCollectable, inv.Number)
+		}
+		mandate := customer.Mandate
+		if err := checkMandate(mandate, now); err != nil {
+			return nil, "", fmt.Errorf("%w: %s: %v", ErrNotCollectable, inv.Number, err)
+		}
+		if counts[customer.ID]++; counts[customer.ID] > 1 && (mandate.SequenceType == models.SequenceOneOff || mandate.SequenceType == models.SequenceFinal) {
+			return nil, "", fmt.Errorf("%w: %s: mandate %s allows only one more collection", ErrNotCollectable, inv.Number, mandate.ID)
+		}
+		debits[i] = sepa.Debit{
+			EndToEndID:     sepa.Text(inv.Number, 35),
+			Amount:         inv.Outstanding(),
+			MandateID:      mandate.ID,
+			SignatureDate:  mandate.SignatureDate,
+			SequenceType:   mandate.SequenceType,
+			CollectionDate: sepa.CollectionDate(inv.DueDate, now),
+			DebtorName:     customer.DisplayName,
+			IBAN:           mandate.IBAN,
+			BIC:            mandate.BIC,
+			Remittance:     inv.Number,
+		}
+		inv.Collection = models.Collection{
+			MessageID:    messageID,
+			Date:         debits[i].CollectionDate,
+			Amount:       debits[i].Amount,
+			SequenceType: mandate.SequenceType,
+			MandateID:    mandate.ID,
+			ExportedAt:   now,
+		}
+		updated[i] = inv
+	}
+
+	data, err := sepa.Pain008(messageID, now, creditor, debits)
+	if err != nil {
+		return nil, "", err
+	}
+	path := store.DirectDebitPath(messageID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, "", fmt.Errorf("invoicing: create direct debit directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, "", fmt.Errorf("invoicing: write direct debit file: %w", err)
+	}
+	for _, inv := range updated {
+		if err := store.SaveInvoice(inv); err != nil {
+			return nil, "", err
+		}
+		customer := customers[inv.CustomerID]
+		if inv.Collection.Date.After(customer.Mandate.LastCollection) {
+			customer.Mandate.LastCollection = inv.Collection.Date
+		}
+		if customer.Mandate.SequenceType == models.SequenceFirst {
+			customer.Mandate.SequenceType = models.SequenceRecurring
+		}
+		customers[inv.CustomerID] = customer
+	}
+	for _, customer := range customers {
+		if err := store.SaveCustomer(customer); err != nil {
+			return nil, "", err
+		}
+	}
+	return updated, path, nil
+}
+
+// checkMandate reports why a mandate can not be used for a collection at now.
+func checkMandate(mandate models.Mandate, now time.Time) error {
+	if err := sepa.CheckMandate(mandate); err != nil {
+		return err
+	}
+	switch {
+	case mandate.SignatureDate.After(now):
+		return fmt.Errorf("mandate %s is signed in the future", mandate.ID)
+	case !mandate.LastCollection.IsZero() && (mandate.SequenceType == models.SequenceOneOff || mandate.SequenceType == models.SequenceFinal):
+		return fmt.Errorf("mandate %s was already used for its last collection", mandate.ID)
+	}
+	last := mandate.LastCollection
+	if last.IsZero() {
+		last = mandate.SignatureDate
+	}
+	if now.After(last.AddDate(0, mandateValidity, 0)) {
+		return fmt.Errorf("mandate %s expired %d months after its last use", mandate.ID, mandateValidity)
+	}
+	return nil
+}
+
+// CancelCollection removes the pending direct debit collection from an
+// invoice, e.g. because the file was never submitted or the debit was
+// returned. The mandate's last use is reset to the remaining collections;
+// a mandate whose only collection was the first one becomes first again.
+func CancelCollection(store *storage.Storage, invoice models.Invoice) (models.Invoice, error) {
+	if !invoice.CollectionPending() {
+		return invoice, fmt.Errorf("invoicing: %s has no pending direct debit collection", invoice.Number)
+	}
+	cancelled := invoice.Collection
+	invoice.Collection = models.Collection{}
+	if err := store.SaveInvoice(invoice); err != nil {
+		return invoice, err
+	}
+	customer, err := store.GetCustomer(invoice.CustomerID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return invoice, nil
+	}
+	if err != nil {
+		return invoice, err
+	}
+	if customer.Mandate.ID != cancelled.MandateID {
+		return invoice, nil
+	}
+	invoices, err := store.ListInvoices()
+	if err != nil {
+		return invoice, err
+	}
+	var last time.Time
+	for _, inv := range invoices {
+		if inv.ID != invoice.ID && inv.Collection.MandateID == cancelled.MandateID && inv.Collection.Date.After(last) {
+			last = inv.Collection.Date
+		}
+	}
+	customer.Mandate.LastCollection = last
+	if last.IsZero() && cancelled.SequenceType == models.SequenceFirst {
+		customer.Mandate.SequenceType = models.SequenceFirst
+	}
+	return invoice, store.SaveCustomer(customer)
+}
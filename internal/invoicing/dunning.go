@@ -0,0 +1,195 @@
+package invoicing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/interest"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/pdf"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+// ErrNotRemindable is returned for invoices that can not be reminded: drafts,
+// corrections, settled invoices, invoices that are not overdue yet, invoices
+// pending direct debit collection and invoices that already reached the last
+// dunning level.
+var ErrNotRemindable = errors.New("invoicing: invoice can not be reminded")
+
+// defaultReminderDays is the payment deadline of levels without PaymentDays.
+const defaultReminderDays = 7
+
+// DunningLevels returns the dunning levels of a profile with the default
+// titles and texts filled in.
+func DunningLevels(profile models.Profile) []models.DunningLevel {
+	levels := append([]models.DunningLevel(nil), profile.Dunning.EffectiveLevels()...)
+	for i := range levels {
+		// The first level is the friendly reminder, the others are numbered
+		// dunning notices.
+		name, text := i18n.T("dunning.reminder.title"), i18n.T("dunning.reminder.text")
+		if i > 0 {
+			name, text = i18n.T("dunning.notice.title", i), i18n.T("dunning.notice.text")
+		}
+		if strings.TrimSpace(levels[i].Name) == "" {
+			levels[i].Name = name
+		}
+		if strings.TrimSpace(levels[i].Text) == "" {
+			levels[i].Text = text
+		}
+	}
+	return levels
+}
+
+// NextReminderLevel returns the level of the next reminder for invoice,
+// counting from 1, and whether the level's threshold has passed at now. It
+// returns 0 if the invoice can not be reminded.
+func NextReminderLevel(levels []models.DunningLevel, invoice models.Invoice, now time.Time) (int, bool) {
+	if invoice.IsDraft() || invoice.IsCorrection() || invoice.IsSettled() || invoice.CollectionPending() || !invoice.DueDate.Before(now) {
+		return 0, false
+	}
+	next := invoice.DunningLevel() + 1
+	if next > len(levels) {
+		return 0, false
+	}
+	return next, !now.Before(invoice.DueDate.AddDate(0, 0, levels[next-1].DaysAfterDue))
+}
+
+// DueForReminder lists the invoices whose next dunning level is reached.
+func DueForReminder(store *storage.Storage, now time.Time) ([]models.Invoice, error) {
+	invoices, err := store.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	levels := make(map[string][]models.DunningLevel)
+	var due []models.Invoice
+	for _, inv := range invoices {
+		if _, ok := levels[inv.ProfileID]; !ok {
+			profile, err := store.GetProfile(inv.ProfileID)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+			levels[inv.ProfileID] = profile.Dunning.EffectiveLevels()
+		}
+		if _, reached := NextReminderLevel(levels[inv.ProfileID], inv, now); reached {
+			due = append(due, inv)
+		}
+	}
+	return due, nil
+}
+
+// ReminderCandidates returns invoice followed by the other overdue invoices
+// of the same customer, profile and currency that can be reminded, so they
+// can be listed in one letter.
+func ReminderCandidates(store *storage.Storage, invoice models.Invoice, now time.Time) ([]models.Invoice, error) {
+	profile, err := store.GetProfile(invoice.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	levels := profile.Dunning.EffectiveLevels()
+	if level, _ := NextReminderLevel(levels, invoice, now); level == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotRemindable, invoice.Number)
+	}
+	invoices, err := store.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	out := []models.Invoice{invoice}
+	for _, inv := range invoices {
+		if inv.ID == invoice.ID || inv.CustomerID != invoice.CustomerID || inv.ProfileID != invoice.ProfileID || inv.Currency != invoice.Currency {
+			continue
+		}
+		if level, _ := NextReminderLevel(levels, inv, now); level > 0 {
+			out = append(out, inv)
+		}
+	}
+	return out, nil
+}
+
+// SendReminder records a reminder on each invoice and renders one letter
+// listing them all. Each invoice advances to its own next level and is
+// charged that level's fee and, if the level says so, the interest accrued up
+// to now; the letter takes the title, text and deadline of the highest level.
+// The invoices must share customer, profile and currency. It returns the
+// updated invoices and the path of the letter.
+func SendReminder(store *storage.Storage, invoices []models.Invoice, now time.Time) ([]models.Invoice, string, error) {
+	if len(invoices) == 0 {
+		return nil, "", fmt.Errorf("%w: no invoices", ErrNotRemindable)
+	}
+	first := invoices[0]
+	profile, err := store.GetProfile(first.ProfileID)
+	if err != nil {
+		return nil, "", err
+	}
+	customer, err := store.GetCustomer(first.CustomerID)
+	if err != nil {
+		return nil, "", err
+	}
+	levels := DunningLevels(profile)
+
+	top := 0
+	next := make([]int, len(invoices))
+	for i, inv := range invoices {
+		if inv.CustomerID != first.CustomerID || inv.ProfileID != first.ProfileID || inv.Currency != first.Currency {
+			return nil, "", fmt.Errorf("%w: %s belongs to another customer, profile or currency than %s", ErrNotRemindable, inv.Number, first.Number)
+		}
+		if next[i], _ = NextReminderLevel(levels, inv, now); next[i] == 0 {
+			return nil, "", fmt.Errorf("%w: %s", ErrNotRemindable, inv.Number)
+		}
+		top = max(top, next[i])
+	}
+
+	level := levels[top-1]
+	days := level.PaymentDays
+	if days <= 0 {
+		days = defaultReminderDays
+	}
+	deadline := now.AddDate(0, 0, days)
+	path := store.ReminderPDFPath(first.Number, top)
+
+	updated := make([]models.Invoice, len(invoices))
+	numbers := make([]string, len(invoices))
+	due := money.Zero(first.Currency)
+	for i, inv := range invoices {
+		reminder := models.Reminder{
+			ID:       id.New(),
+			Level:    next[i],
+			Date:     now,
+			Fee:      money.FromDecimal(levels[next[i]-1].Fee, inv.Currency),
+			Deadline: deadline,
+			PDFPath:  path,
+		}
+		if levels[next[i]-1].Interest {
+			accrued := interest.Calculate(inv, profile.Interest, now)
+			reminder.Interest = accrued.Interest
+			if inv.ClaimedFlatFee().IsZero() {
+				reminder.FlatFee = accrued.FlatFee
+			}
+		}
+		inv.Reminders = append(append([]models.Reminder(nil), inv.Reminders...), reminder)
+		updated[i] = inv
+		numbers[i] = inv.Number
+		due = due.Add(inv.Outstanding()).Add(inv.DunningClaims())
+	}
+
+	text := strings.NewReplacer(
+		"{CUSTOMER}", customer.DisplayName,
+		"{INVOICES}", strings.Join(numbers, ", "),
+		"{AMOUNT}", due.String(),
+		"{DEADLINE}", deadline.Format("2006-01-02"),
+	).Replace(level.Text)
+	letter := pdf.ReminderLetter{Title: level.Name, Text: text, Date: now, Deadline: deadline, Invoices: updated}
+	if err := pdf.CreateReminderPDF(path, profile, customer, letter, pdf.ProfileOptions(profile, store.BaseDir())...); err != nil {
+		return nil, "", err
+	}
+	for _, inv := range updated {
+		if err := store.SaveInvoice(inv); err != nil {
+			return nil, "", err
+		}
+	}
+	return updated, path, nil
+}
@@ -0,0 +1,238 @@
+// Package invoicing holds the invoice workflows shared by the GUI, the command
+// line and batch imports, so every entry point calculates, validates, numbers
+// and renders invoices the same way.
+package invoicing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/pdf"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+	"github.com/janmarkuslanger/invoiceio/internal/validation"
+)
+
+// CheckNew validates an invoice that has not been stored yet. The number is
+// assigned on creation, so its absence is not reported.
+func CheckNew(profile models.Profile, customer models.Customer, invoice models.Invoice) validation.Findings {
+	invoice = withCustomerDefaults(customer, invoice)
+	return validation.Check(profile, customer, invoice).Without(validation.RuleInvoiceNumber)
+}
+
+// Create recalculates the invoice, assigns its number, stores it finalised and
+// renders the PDF. An invoice without tax exemption takes the customer's.
+func Create(store *storage.Storage, profile models.Profile, customer models.Customer, invoice models.Invoice) (models.Invoice, error) {
+	invoice = withCustomerDefaults(customer, invoice)
+	invoice.Recalculate()
+	invoice, err := store.CreateInvoice(invoice, profile, customer)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, Render(store, profile, customer, invoice)
+}
+
+// withCustomerDefaults fills in the tax exemption of the customer when the
+// invoice has none.
+func withCustomerDefaults(customer models.Customer, invoice models.Invoice) models.Invoice {
+	if invoice.TaxExemption.IsZero() {
+		invoice.TaxExemption = customer.TaxExemption
+	}
+	return invoice
+}
+
+// Render writes the PDF of a stored invoice to its PDF path using the
+// profile's document settings.
+func Render(store *storage.Storage, profile models.Profile, customer models.Customer, invoice models.Invoice) error {
+	return RenderTo(invoice.PDFPath, store, profile, customer, invoice)
+}
+
+// ErrDraft is returned when a draft would be rendered. Drafts have no number
+// yet, so they are never sent out.
+var ErrDraft = errors.New("invoicing: invoice is a draft")
+
+// RenderTo writes the PDF of a finalised invoice to path.
+func RenderTo(path string, store *storage.Storage, profile models.Profile, customer models.Customer, invoice models.Invoice) error {
+	if invoice.IsDraft() {
+		return ErrDraft
+	}
+	return pdf.CreateInvoicePDF(path, profile, customer, invoice, pdf.ProfileOptions(profile, store.BaseDir())...)
+}
+
+// Finalize validates a draft and, if no blocking rule fails, numbers it,
+// locks it against edits and renders the PDF. Blocking findings are returned
+// together with ErrIncomplete.
+func Finalize(store *storage.Storage, draft models.Invoice) (models.Invoice, validation.Findings, error) {
+	if !draft.IsDraft() {
+		return draft, nil, fmt.Errorf("invoicing: %w: %s", storage.ErrFinalized, draft.Number)
+	}
+	profile, err := store.GetProfile(draft.ProfileID)
+	if err != nil {
+		return draft, nil, err
+	}
+	customer, err := store.GetCustomer(draft.CustomerID)
+	if err != nil {
+		return draft, nil, err
+	}
+	draft.Recalculate()
+	findings := CheckNew(profile, customer, draft)
+	if findings.HasErrors() {
+		return draft, findings, ErrIncomplete
+	}
+	inv, err := Create(store, profile, customer, draft)
+	return inv, findings, err
+}
+
+// Payment states reported by Status.
+const (
+	StatusDraft   = "draft"
+	StatusOpen    = "open"
+	StatusPaid    = "paid"
+	StatusOverdue = "overdue"
+	// StatusCancelled marks invoices whose total is offset by corrections.
+	StatusCancelled = "cancelled"
+	// StatusCollectionPending marks invoices exported for direct debit whose
+	// payment has not been recorded yet.
+	StatusCollectionPending = "collection_pending"
+)
+
+// Status returns the state of an invoice at the given time. Invoices are
+// paid once their payments and credits cover the total; partly paid invoices
+// stay open or overdue, invoices fully offset by corrections are cancelled.
+// Credit notes and cancellations stay open until they are settled but are
+// never overdue. Invoices exported for direct debit are pending collection
+// until the payment is recorded.
+func Status(invoice models.Invoice, now time.Time) string {
+	switch {
+	case invoice.IsDraft():
+		return StatusDraft
+	case invoice.IsCancelled():
+		return StatusCancelled
+	case invoice.IsSettled():
+		return StatusPaid
+	case invoice.IsCorrection():
+		return StatusOpen
+	case invoice.CollectionPending():
+		return StatusCollectionPending
+	case invoice.DueDate.Before(now):
+		return StatusOverdue
+	default:
+		return StatusOpen
+	}
+}
+
+// ErrNotCorrectable is returned when a credit note or cancellation would
+// correct a draft or another correction.
+var ErrNotCorrectable = errors.New("invoicing: only finalised invoices can be corrected")
+
+// ErrAlreadyCorrected is returned when an invoice is cancelled already or its
+// corrections cover its total.
+var ErrAlreadyCorrected = errors.New("invoicing: invoice is corrected already")
+
+// Correct issues a credit note or cancellation for original: a copy with
+// negated quantities that references the original, numbered from the credit
+// sequence and rendered like any other document. The reason is printed as the
+// document's notes. The correction is offset against the open balance of the
+// original; only what the customer paid already is left to refund.
+func Correct(store *storage.Storage, original models.Invoice, docType, reason string, issueDate time.Time) (models.Invoice, error) {
+	if original.IsCorrection() || original.IsDraft() {
+		return models.Invoice{}, ErrNotCorrectable
+	}
+	switch docType {
+	case models.DocumentCreditNote, models.DocumentCancellation:
+	default:
+		return models.Invoice{}, fmt.Errorf("invoicing: unknown document type %q", docType)
+	}
+	// The stored original carries the credits of earlier corrections.
+	original, err := store.GetInvoice(original.ID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	previous, err := Corrections(store, original)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	corrected := money.Zero(original.Currency)
+	for _, c := range previous {
+		if c.Type() == models.DocumentCancellation {
+			return models.Invoice{}, fmt.Errorf("%w: %s is cancelled by %s", ErrAlreadyCorrected, original.Number, c.Number)
+		}
+		corrected = corrected.Sub(c.Total)
+	}
+	if corrected.Cmp(original.Total) >= 0 {
+		return models.Invoice{}, fmt.Errorf("%w: the corrections of %s cover its total", ErrAlreadyCorrected, original.Number)
+	}
+	profile, err := store.GetProfile(original.ProfileID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	customer, err := store.GetCustomer(original.CustomerID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	now := time.Now()
+	correction := models.Invoice{
+		ID:             id.New(),
+		DocumentType:   docType,
+		OriginalID:     original.ID,
+		OriginalNumber: original.Number,
+		ProfileID:      original.ProfileID,
+		CustomerID:     original.CustomerID,
+		IssueDate:      issueDate,
+		DueDate:        issueDate,
+		Currency:       original.Currency,
+		TaxExemption:   original.TaxExemption,
+		Notes:          reason,
+		Items:          make([]models.InvoiceItem, len(original.Items)),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	for i, item := range original.Items {
+		item.Quantity = item.Quantity.Neg()
+		correction.Items[i] = item
+	}
+	correction.Recalculate()
+	credit := correction.Total.Neg()
+	if open := original.Outstanding(); credit.Cmp(open) > 0 {
+		credit = open
+	}
+	if credit.Sign() < 0 {
+		credit = money.Zero(original.Currency)
+	}
+	correction.Credited = credit.Neg()
+
+	correction, err = Create(store, profile, customer, correction)
+	if err != nil {
+		// A correction that was stored but not rendered still offsets the
+		// original.
+		if _, getErr := store.GetInvoice(correction.ID); getErr != nil {
+			return correction, err
+		}
+	}
+	if !credit.IsZero() {
+		original.Credited = original.Credited.Add(credit)
+		if saveErr := store.SaveInvoice(original); saveErr != nil {
+			return correction, saveErr
+		}
+	}
+	return correction, err
+}
+
+// Corrections returns the credit notes and cancellations issued for an invoice.
+func Corrections(store *storage.Storage, original models.Invoice) ([]models.Invoice, error) {
+	invoices, err := store.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	var out []models.Invoice
+	for _, inv := range invoices {
+		if inv.OriginalID == original.ID {
+			out = append(out, inv)
+		}
+	}
+	return out, nil
+}
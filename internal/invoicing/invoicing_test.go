@@ -0,0 +1,166 @@
+package invoicing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+// newStore returns a store with one profile and customer and a finalised
+// invoice over 119.00 EUR due on 2025-03-15.
+func newStore(t *testing.T) (*storage.Storage, models.Invoice) {
+	t.Helper()
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := models.Profile{ID: "p", DisplayName: "Seller", AddressLine1: "Hauptstraße 1", PostalCode: "10115", City: "Berlin", Country: "DE", TaxID: "DE123456789"}
+	customer := models.Customer{ID: "c", DisplayName: "Buyer", AddressLine1: "Ring 2", PostalCode: "1010", City: "Wien", Country: "DE"}
+	if err := store.SaveProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveCustomer(customer); err != nil {
+		t.Fatal(err)
+	}
+	invoice, err := Create(store, profile, customer, models.Invoice{
+		ID:         "inv",
+		ProfileID:  "p",
+		CustomerID: "c",
+		IssueDate:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:    time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		Currency:   "EUR",
+		Items: []models.InvoiceItem{{
+			Description:    "Consulting",
+			Quantity:       money.DecimalFromInt(1),
+			UnitPrice:      money.New(10000, "EUR"),
+			TaxRatePercent: money.DecimalFromInt(19),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, invoice
+}
+
+func TestCorrectOffsetsBalance(t *testing.T) {
+	late := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                   string
+		paid                   int64
+		docType                string
+		status, correction     string
+		outstanding, remaining string
+	}{
+		{name: "unpaid cancellation", docType: models.DocumentCancellation, status: StatusCancelled, correction: StatusPaid, outstanding: "0.00 EUR", remaining: "0.00 EUR"},
+		{name: "unpaid credit note", docType: models.DocumentCreditNote, status: StatusCancelled, correction: StatusPaid, outstanding: "0.00 EUR", remaining: "0.00 EUR"},
+		{name: "partly paid", paid: 4000, docType: models.DocumentCancellation, status: StatusPaid, correction: StatusOpen, outstanding: "0.00 EUR", remaining: "-40.00 EUR"},
+		{name: "paid in full", paid: 11900, docType: models.DocumentCancellation, status: StatusPaid, correction: StatusOpen, outstanding: "0.00 EUR", remaining: "-119.00 EUR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, invoice := newStore(t)
+			if tt.paid > 0 {
+				var err error
+				invoice, err = RecordPayment(store, invoice, models.Payment{Date: invoice.IssueDate, Amount: money.New(tt.paid, "EUR")})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			correction, err := Correct(store, invoice, tt.docType, "wrong address", invoice.IssueDate)
+			if err != nil {
+				t.Fatalf("Correct: %v", err)
+			}
+			invoice, err = store.GetInvoice(invoice.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Status(invoice, late); got != tt.status {
+				t.Errorf("invoice status = %s, want %s", got, tt.status)
+			}
+			if got := invoice.Outstanding().String(); got != tt.outstanding {
+				t.Errorf("invoice outstanding = %s, want %s", got, tt.outstanding)
+			}
+			if got := Status(correction, late); got != tt.correction {
+				t.Errorf("correction status = %s, want %s", got, tt.correction)
+			}
+			if got := correction.Outstanding().String(); got != tt.remaining {
+				t.Errorf("correction outstanding = %s, want %s", got, tt.remaining)
+			}
+			if _, due := NextReminderLevel([]models.DunningLevel{{}}, invoice, late); due {
+				t.Error("corrected invoice is due for a reminder")
+			}
+			if _, err := Correct(store, invoice, models.DocumentCreditNote, "again", invoice.IssueDate); !errors.Is(err, ErrAlreadyCorrected) {
+				t.Errorf("second correction: err = %v, want ErrAlreadyCorrected", err)
+			}
+		})
+	}
+}
+
+func TestRunSubscriptions(t *testing.T) {
+	now := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+	exemption := models.TaxExemption{Category: models.TaxReverseCharge, Reason: "Reverse charge"}
+	tests := []struct {
+		name   string
+		drafts bool
+	}{
+		{name: "finalised"},
+		{name: "drafts", drafts: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newStore(t)
+			customer, err := store.GetCustomer("c")
+			if err != nil {
+				t.Fatal(err)
+			}
+			customer.Country, customer.VATID, customer.TaxExemption = "AT", "ATU12345678", exemption
+			if err := store.SaveCustomer(customer); err != nil {
+				t.Fatal(err)
+			}
+			sub := models.Subscription{
+				ID:         "s",
+				Name:       "Retainer",
+				ProfileID:  "p",
+				CustomerID: "c",
+				Currency:   "EUR",
+				Interval:   models.IntervalMonthly,
+				StartDate:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+				Drafts:     tt.drafts,
+				Items: []models.InvoiceItem{{
+					Description: "Retainer {PERIOD}",
+					Quantity:    money.DecimalFromInt(1),
+					UnitPrice:   money.New(100000, "EUR"),
+				}},
+			}
+			if err := store.SaveSubscription(sub); err != nil {
+				t.Fatal(err)
+			}
+			generated, err := RunSubscriptions(store, now)
+			if err != nil {
+				t.Fatalf("RunSubscriptions: %v", err)
+			}
+			if len(generated) != 4 {
+				t.Fatalf("generated %d invoices, want 4", len(generated))
+			}
+			today := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+			for i, g := range generated {
+				if !g.Invoice.IssueDate.Equal(today) {
+					t.Errorf("run %s issued on %s, want today", g.Date.Format(time.DateOnly), g.Invoice.IssueDate.Format(time.DateOnly))
+				}
+				if g.Invoice.TaxExemption != exemption {
+					t.Errorf("run %s tax exemption = %+v, want %+v", g.Date.Format(time.DateOnly), g.Invoice.TaxExemption, exemption)
+				}
+				if g.Invoice.IsDraft() != tt.drafts {
+					t.Errorf("run %s draft = %v, want %v", g.Date.Format(time.DateOnly), g.Invoice.IsDraft(), tt.drafts)
+				}
+				if want := "Retainer " + []string{"March", "April", "May", "June"}[i] + " 2025"; g.Invoice.Items[0].Description != want {
+					t.Errorf("description = %q, want %q", g.Invoice.Items[0].Description, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package invoicing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+// ErrInvalidPayment is returned for payments without date or amount, or in
+// another currency than the invoice.
+var ErrInvalidPayment = errors.New("invoicing: invalid payment")
+
+// RecordPayment books a payment against a finalised invoice and stores it.
+// The payment gets an ID if it has none; a payment without currency is taken
+// to be in the invoice currency.
+func RecordPayment(store *storage.Storage, invoice models.Invoice, payment models.Payment) (models.Invoice, error) {
+	if invoice.IsDraft() {
+		return invoice, ErrDraft
+	}
+	if payment.Amount.Currency == "" {
+		payment.Amount = payment.Amount.WithCurrency(invoice.Currency)
+	}
+	switch {
+	case payment.Date.IsZero():
+		return invoice, fmt.Errorf("%w: date is missing", ErrInvalidPayment)
+	case payment.Amount.IsZero():
+		return invoice, fmt.Errorf("%w: amount is zero", ErrInvalidPayment)
+	case !strings.EqualFold(payment.Amount.Currency, invoice.Currency):
+		return invoice, fmt.Errorf("%w: %s payment on a %s invoice", ErrInvalidPayment, payment.Amount.Currency, invoice.Currency)
+	}
+	if payment.ID == "" {
+		payment.ID = id.New()
+	}
+	payment.Method = strings.TrimSpace(payment.Method)
+	payment.Reference = strings.TrimSpace(payment.Reference)
+	payment.Note = strings.TrimSpace(payment.Note)
+	invoice.Payments = append(append([]models.Payment(nil), invoice.Payments...), payment)
+	return invoice, store.SaveInvoice(invoice)
+}
+
+// RemovePayment deletes a recorded payment from an invoice and stores it.
+func RemovePayment(store *storage.Storage, invoice models.Invoice, paymentID string) (models.Invoice, error) {
+	payments := make([]models.Payment, 0, len(invoice.Payments))
+	for _, p := range invoice.Payments {
+		if p.ID != paymentID {
+			payments = append(payments, p)
+		}
+	}
+	if len(payments) == len(invoice.Payments) {
+		return invoice, fmt.Errorf("invoicing: payment %q: %w", paymentID, storage.ErrNotFound)
+	}
+	invoice.Payments = payments
+	return invoice, store.SaveInvoice(invoice)
+}
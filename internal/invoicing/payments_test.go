@@ -0,0 +1,87 @@
+package invoicing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+func TestRecordPayment(t *testing.T) {
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		draft       bool
+		payment     models.Payment
+		err         error
+		outstanding string
+	}{
+		{name: "full payment", payment: models.Payment{Date: date, Amount: money.New(11900, "EUR")}, outstanding: "0.00 EUR"},
+		{name: "partial payment", payment: models.Payment{Date: date, Amount: money.New(4000, "EUR")}, outstanding: "79.00 EUR"},
+		{name: "overpayment", payment: models.Payment{Date: date, Amount: money.New(12000, "EUR")}, outstanding: "-1.00 EUR"},
+		{name: "currency taken from the invoice", payment: models.Payment{Date: date, Amount: money.New(4000, "")}, outstanding: "79.00 EUR"},
+		{name: "no date", payment: models.Payment{Amount: money.New(4000, "EUR")}, err: ErrInvalidPayment},
+		{name: "zero amount", payment: models.Payment{Date: date, Amount: money.Zero("EUR")}, err: ErrInvalidPayment},
+		{name: "other currency", payment: models.Payment{Date: date, Amount: money.New(4000, "CHF")}, err: ErrInvalidPayment},
+		{name: "draft", draft: true, payment: models.Payment{Date: date, Amount: money.New(4000, "EUR")}, err: ErrDraft},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, invoice := newStore(t)
+			if tt.draft {
+				invoice = models.Invoice{ID: "draft", Currency: "EUR"}
+				if err := store.SaveInvoice(invoice); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := RecordPayment(store, invoice, tt.payment)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			stored, serr := store.GetInvoice(invoice.ID)
+			if serr != nil {
+				t.Fatal(serr)
+			}
+			if err != nil {
+				if len(stored.Payments) != 0 {
+					t.Errorf("stored %d payments for a rejected payment", len(stored.Payments))
+				}
+				return
+			}
+			if len(stored.Payments) != 1 || stored.Payments[0].ID == "" || stored.Payments[0].ID != got.Payments[0].ID {
+				t.Fatalf("stored payments = %+v, want the recorded payment with an ID", stored.Payments)
+			}
+			if s := stored.Outstanding().String(); s != tt.outstanding {
+				t.Errorf("outstanding = %s, want %s", s, tt.outstanding)
+			}
+		})
+	}
+}
+
+func TestRemovePayment(t *testing.T) {
+	store, invoice := newStore(t)
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	for _, amount := range []int64{4000, 7900} {
+		var err error
+		if invoice, err = RecordPayment(store, invoice, models.Payment{Date: date, Amount: money.New(amount, "EUR")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := RemovePayment(store, invoice, "nope"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("unknown payment: err = %v, want ErrNotFound", err)
+	}
+	invoice, err := RemovePayment(store, invoice, invoice.Payments[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.GetInvoice(invoice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Payments) != 1 || stored.Payments[0].Amount.String() != "79.00 EUR" {
+		t.Errorf("payments = %+v, want the 79.00 EUR payment", stored.Payments)
+	}
+}
@@ -0,0 +1,91 @@
+package invoicing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/pdf"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+	"github.com/janmarkuslanger/invoiceio/internal/validation"
+)
+
+// DefaultQuoteDays is how long a new quote stays valid.
+const DefaultQuoteDays = 30
+
+// ErrQuoteConverted is returned when a quote already became an invoice.
+var ErrQuoteConverted = errors.New("invoicing: quote was already converted into an invoice")
+
+// ErrQuoteDeclined is returned when converting a declined quote.
+var ErrQuoteDeclined = errors.New("invoicing: quote was declined")
+
+// CreateQuote recalculates the quote, assigns its number, stores it and
+// renders the PDF.
+func CreateQuote(store *storage.Storage, profile models.Profile, customer models.Customer, quote models.Quote) (models.Quote, error) {
+	quote.Recalculate()
+	if quote.Status == "" {
+		quote.Status = models.QuoteDraft
+	}
+	quote, err := store.CreateQuote(quote, profile, customer)
+	if err != nil {
+		return quote, err
+	}
+	return quote, RenderQuote(store, profile, customer, quote)
+}
+
+// RenderQuote writes the PDF of a stored quote to its PDF path.
+func RenderQuote(store *storage.Storage, profile models.Profile, customer models.Customer, quote models.Quote) error {
+	return pdf.CreateQuotePDF(quote.PDFPath, profile, customer, quote, pdf.ProfileOptions(profile, store.BaseDir())...)
+}
+
+// ConvertQuote creates an invoice with the profile, customer, currency, notes
+// and items of the quote, issued on issueDate under the default payment terms
+// of the customer or profile. The quote is marked accepted and linked to the invoice.
+// If the invoice fails blocking validation rules nothing is stored and
+// ErrIncomplete is returned together with the findings.
+func ConvertQuote(store *storage.Storage, quote models.Quote, issueDate time.Time) (models.Invoice, validation.Findings, error) {
+	switch {
+	case quote.InvoiceID != "":
+		return models.Invoice{}, nil, ErrQuoteConverted
+	case quote.Status == models.QuoteDeclined:
+		return models.Invoice{}, nil, ErrQuoteDeclined
+	}
+	profile, err := store.GetProfile(quote.ProfileID)
+	if err != nil {
+		return models.Invoice{}, nil, err
+	}
+	customer, err := store.GetCustomer(quote.CustomerID)
+	if err != nil {
+		return models.Invoice{}, nil, err
+	}
+
+	now := time.Now()
+	terms := DefaultTerms(profile, customer)
+	invoice := models.Invoice{
+		ID:         id.New(),
+		ProfileID:  quote.ProfileID,
+		CustomerID: quote.CustomerID,
+		IssueDate:  issueDate,
+		DueDate:    DueDate(issueDate, terms),
+		Currency:   quote.Currency,
+		Items:      append([]models.InvoiceItem(nil), quote.Items...),
+		Notes:      quote.Notes,
+		Terms:      terms,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	invoice.Recalculate()
+	findings := CheckNew(profile, customer, invoice)
+	if findings.HasErrors() {
+		return invoice, findings, ErrIncomplete
+	}
+	invoice, err = Create(store, profile, customer, invoice)
+	if err != nil {
+		return invoice, findings, err
+	}
+
+	quote.Status = models.QuoteAccepted
+	quote.InvoiceID = invoice.ID
+	return invoice, findings, store.SaveQuote(quote)
+}
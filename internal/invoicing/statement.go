@@ -0,0 +1,45 @@
+package invoicing
+
+import (
+	"fmt"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/statement"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+// ProposeMatches matches the credit transactions of a bank statement to the
+// stored invoices.
+func ProposeMatches(store *storage.Storage, txs []statement.Transaction) ([]statement.Match, error) {
+	invoices, err := store.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	customers, err := store.ListCustomers()
+	if err != nil {
+		return nil, err
+	}
+	return statement.Propose(txs, invoices, customers), nil
+}
+
+// RecordTransaction books a bank transaction as bank transfer on the invoice.
+// The transaction ID becomes the payment reference, so a transaction is
+// recorded at most once per invoice.
+func RecordTransaction(store *storage.Storage, invoiceID string, tx statement.Transaction) (models.Invoice, error) {
+	invoice, err := store.GetInvoice(invoiceID)
+	if err != nil {
+		return invoice, err
+	}
+	for _, p := range invoice.Payments {
+		if p.Reference == tx.ID {
+			return invoice, fmt.Errorf("%w: transaction %s is already recorded on %s", ErrInvalidPayment, tx.ID, invoice.Number)
+		}
+	}
+	return RecordPayment(store, invoice, models.Payment{
+		Date:      tx.Date,
+		Amount:    tx.Amount,
+		Method:    models.MethodBankTransfer,
+		Reference: tx.ID,
+		Note:      tx.Remittance,
+	})
+}
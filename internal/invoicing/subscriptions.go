@@ -0,0 +1,214 @@
+package invoicing
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+	"github.com/janmarkuslanger/invoiceio/internal/validation"
+)
+
+// ErrInvalidSubscription is returned for subscriptions that can not be
+// scheduled.
+var ErrInvalidSubscription = errors.New("invoicing: invalid subscription")
+
+// CheckSubscription reports the first reason why a subscription can not be
+// scheduled.
+func CheckSubscription(sub models.Subscription) error {
+	switch {
+	case strings.TrimSpace(sub.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidSubscription)
+	case sub.ProfileID == "" || sub.CustomerID == "":
+		return fmt.Errorf("%w: profile and customer are required", ErrInvalidSubscription)
+	case len(sub.Items) == 0:
+		return fmt.Errorf("%w: at least one item is required", ErrInvalidSubscription)
+	case sub.StartDate.IsZero():
+		return fmt.Errorf("%w: start date is required", ErrInvalidSubscription)
+	case !sub.EndDate.IsZero() && sub.EndDate.Before(sub.StartDate):
+		return fmt.Errorf("%w: end date is before the start date", ErrInvalidSubscription)
+	case sub.DayOfMonth < 0 || sub.DayOfMonth > 31:
+		return fmt.Errorf("%w: day of month must be between 1 and 31, or 0 for the day of the start date", ErrInvalidSubscription)
+	}
+	for _, interval := range models.Intervals {
+		if sub.Interval == interval {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown interval %q", ErrInvalidSubscription, sub.Interval)
+}
+
+// DueRuns returns the run dates of a subscription that are due at now but
+// have not generated an invoice yet, oldest first. Runs missed while the
+// application was not started are included; paused subscriptions have none.
+func DueRuns(sub models.Subscription, now time.Time) []time.Time {
+	if sub.Paused {
+		return nil
+	}
+	var dates []time.Time
+	for {
+		date, ok := sub.NextRun()
+		if !ok || date.After(endOfDay(now, date.Location())) {
+			return dates
+		}
+		dates = append(dates, date)
+		sub.Runs = append(sub.Runs, models.SubscriptionRun{Date: date})
+	}
+}
+
+func endOfDay(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, loc)
+}
+
+// Placeholders lists the placeholders replaced in item descriptions and
+// notes of generated invoices.
+var Placeholders = []string{"{MONTH}", "{MM}", "{YEAR}", "{QUARTER}", "{WEEK}", "{PERIOD}"}
+
+// ExpandPlaceholders fills the placeholders in text for the run on date:
+// {MONTH} is the month name, {MM} its number, {YEAR} the year, {QUARTER}
+// the quarter as Q1 to Q4, {WEEK} the ISO week and {PERIOD} the billed
+// period in the subscription's interval, e.g. "March 2025" or "Q1 2025".
+func ExpandPlaceholders(text string, date time.Time, interval string) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+	month := i18n.T("month." + strconv.Itoa(int(date.Month())))
+	quarter := fmt.Sprintf("Q%d", (int(date.Month())-1)/3+1)
+	year, week := date.ISOWeek()
+	period := month + " " + strconv.Itoa(date.Year())
+	switch interval {
+	case models.IntervalWeekly:
+		period = fmt.Sprintf("%d-W%02d", year, week)
+	case models.IntervalQuarterly:
+		period = quarter + " " + strconv.Itoa(date.Year())
+	case models.IntervalYearly:
+		period = strconv.Itoa(date.Year())
+	}
+	return strings.NewReplacer(
+		"{MONTH}", month,
+		"{MM}", fmt.Sprintf("%02d", int(date.Month())),
+		"{YEAR}", strconv.Itoa(date.Year()),
+		"{QUARTER}", quarter,
+		"{WEEK}", strconv.Itoa(week),
+		"{PERIOD}", period,
+	).Replace(text)
+}
+
+// SubscriptionInvoice builds the invoice for the run of a subscription on
+// date, issued on issued under the default payment terms and tax exemption
+// of the customer or profile. Placeholders describe the run date. Nothing is
+// stored.
+func SubscriptionInvoice(profile models.Profile, customer models.Customer, sub models.Subscription, date, issued time.Time) models.Invoice {
+	now := time.Now()
+	terms := DefaultTerms(profile, customer)
+	items := make([]models.InvoiceItem, len(sub.Items))
+	for i, item := range sub.Items {
+		item.Description = ExpandPlaceholders(item.Description, date, sub.Interval)
+		items[i] = item
+	}
+	invoice := models.Invoice{
+		ID:         id.New(),
+		ProfileID:  sub.ProfileID,
+		CustomerID: sub.CustomerID,
+		IssueDate:  issued,
+		DueDate:    DueDate(issued, terms),
+		Currency:   sub.Currency,
+		Items:      items,
+		Notes:      ExpandPlaceholders(sub.Notes, date, sub.Interval),
+		Terms:      terms,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	invoice = withCustomerDefaults(customer, invoice)
+	invoice.Recalculate()
+	return invoice
+}
+
+// GeneratedInvoice is an invoice created by RunSubscriptions.
+type GeneratedInvoice struct {
+	Subscription models.Subscription
+	Date         time.Time
+	Invoice      models.Invoice
+	// Findings are the validation results; an invoice with blocking findings
+	// is kept as a draft.
+	Findings validation.Findings
+}
+
+// RunSubscriptions generates the invoices of all due subscription runs at
+// now and records them on their subscriptions. All of them are issued on the
+// day of now; their placeholders describe the runs. Invoices are finalised
+// unless the subscription asks for drafts or they fail blocking validation
+// rules, in which case they are stored as drafts for review. A failing
+// subscription does not stop the others; their errors are joined.
+func RunSubscriptions(store *storage.Storage, now time.Time) ([]GeneratedInvoice, error) {
+	subs, err := store.ListSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	var generated []GeneratedInvoice
+	var errs []error
+	for _, sub := range subs {
+		if len(DueRuns(sub, now)) == 0 {
+			continue
+		}
+		out, err := runSubscription(store, sub, now)
+		generated = append(generated, out...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invoicing: subscription %s: %w", sub.Name, err))
+		}
+	}
+	return generated, errors.Join(errs...)
+}
+
+func runSubscription(store *storage.Storage, sub models.Subscription, now time.Time) ([]GeneratedInvoice, error) {
+	if err := CheckSubscription(sub); err != nil {
+		return nil, err
+	}
+	profile, err := store.GetProfile(sub.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := store.GetCustomer(sub.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	// Every due run is issued today, including runs missed in the past, so
+	// that issue dates follow the order of the invoice numbers.
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, sub.StartDate.Location())
+	var out []GeneratedInvoice
+	for _, date := range DueRuns(sub, now) {
+		invoice := SubscriptionInvoice(profile, customer, sub, date, today)
+		findings := CheckNew(profile, customer, invoice)
+		if sub.Drafts || findings.HasErrors() {
+			err = store.SaveInvoice(invoice)
+		} else {
+			invoice, err = Create(store, profile, customer, invoice)
+		}
+		if err != nil {
+			// An invoice that was stored but not rendered still counts as
+			// generated, so the run is not repeated.
+			if _, getErr := store.GetInvoice(invoice.ID); getErr != nil {
+				return out, err
+			}
+		}
+		sub.Runs = append(sub.Runs, models.SubscriptionRun{
+			Date:      date,
+			InvoiceID: invoice.ID,
+			Number:    invoice.Number,
+			CreatedAt: now,
+		})
+		if saveErr := store.SaveSubscription(sub); saveErr != nil {
+			return out, saveErr
+		}
+		out = append(out, GeneratedInvoice{Subscription: sub, Date: date, Invoice: invoice, Findings: findings})
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
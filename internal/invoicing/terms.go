@@ -0,0 +1,23 @@
+package invoicing
+
+import (
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// DefaultTerms returns the payment terms of a new invoice: the customer's, or
+// the profile's if the customer has none.
+func DefaultTerms(profile models.Profile, customer models.Customer) models.PaymentTerms {
+	return customer.Terms.Or(profile.Terms)
+}
+
+// DueDate returns the due date under terms: the issue date plus the net days,
+// or plus DefaultPaymentDays if the terms give none.
+func DueDate(issue time.Time, terms models.PaymentTerms) time.Time {
+	days := terms.NetDays
+	if days <= 0 {
+		days = DefaultPaymentDays
+	}
+	return issue.AddDate(0, 0, days)
+}
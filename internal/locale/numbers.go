@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
 )
 
 // NumberParser abstracts locale aware floating point parsing so that different
@@ -13,6 +15,12 @@ type NumberParser interface {
 	ParseFloat(input string) (float64, error)
 }
 
+// DecimalParser is implemented by number parsers that parse exact
+// fixed-point decimals. Parsers without it fall back to ParseFloat.
+type DecimalParser interface {
+	ParseDecimal(input string) (money.Decimal, error)
+}
+
 var activeNumberParser NumberParser = EuroNumberParser{}
 
 // SetNumberParser allows callers to swap the active parser implementation.
@@ -30,6 +38,30 @@ func ParseFloat(input string) (float64, error) {
 	return activeNumberParser.ParseFloat(input)
 }
 
+// ParseDecimal delegates exact fixed-point parsing to the active parser. A
+// parser that is not a DecimalParser is used through ParseFloat; the shortest
+// representation of the float keeps amounts with few decimals exact.
+func ParseDecimal(input string) (money.Decimal, error) {
+	if p, ok := activeNumberParser.(DecimalParser); ok {
+		return p.ParseDecimal(input)
+	}
+	f, err := activeNumberParser.ParseFloat(input)
+	if err != nil {
+		return money.Decimal{}, err
+	}
+	return money.ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// ParseMoney parses an amount in major units (e.g. "1.234,56") into money of
+// the given currency.
+func ParseMoney(input, currency string) (money.Money, error) {
+	d, err := ParseDecimal(input)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return money.FromDecimal(d, currency), nil
+}
+
 // EuroNumberParser accepts common continental European number formats, e.g.:
 //
 //	1.234,56   -> 1234.56
@@ -46,6 +78,14 @@ func (EuroNumberParser) ParseFloat(input string) (float64, error) {
 	return strconv.ParseFloat(normalized, 64)
 }
 
+func (EuroNumberParser) ParseDecimal(input string) (money.Decimal, error) {
+	normalized, err := normalizeEuropeanNumber(input)
+	if err != nil {
+		return money.Decimal{}, err
+	}
+	return money.ParseDecimal(normalized)
+}
+
 func normalizeEuropeanNumber(in string) (string, error) {
 	value := strings.TrimSpace(in)
 	if value == "" {
@@ -0,0 +1,52 @@
+package locale
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1.234,56", "1234.56"},
+		{"1 234,56", "1234.56"},
+		{"1234,56", "1234.56"},
+		{"1234.56", "1234.56"},
+		{"-0,5", "-0.5"},
+		{"+3", "3"},
+		{"", "0"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.input)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+	for _, input := range []string{"1,2,3", "12a"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded, want error", input)
+		}
+	}
+}
+
+// floatParser implements only NumberParser.
+type floatParser struct{}
+
+func (floatParser) ParseFloat(input string) (float64, error) {
+	return EuroNumberParser{}.ParseFloat(strings.ReplaceAll(input, "_", ""))
+}
+
+func TestParseDecimalFloatParser(t *testing.T) {
+	SetNumberParser(floatParser{})
+	defer SetNumberParser(nil)
+	got, err := ParseDecimal("1_000,10")
+	if err != nil || got.String() != "1000.1" {
+		t.Errorf("ParseDecimal with float parser = %s, %v, want 1000.1", got, err)
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"sort"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// Recalculate derives line totals, the per-rate tax breakdown, subtotal, tax
+// and total from the items. All amounts are computed in exact minor units;
+// line totals are rounded per line and tax is rounded once per rate.
+func (inv *Invoice) Recalculate() {
+	if inv.Currency == "" {
+		inv.Currency = money.DefaultCurrency
+	}
+	inv.Subtotal, inv.TaxBreakdown, inv.TaxAmount, inv.Total = calculate(inv.Items, inv.Currency)
+}
+
+// Recalculate derives the quote totals the same way as for invoices.
+func (q *Quote) Recalculate() {
+	if q.Currency == "" {
+		q.Currency = money.DefaultCurrency
+	}
+	q.Subtotal, q.TaxBreakdown, q.TaxAmount, q.Total = calculate(q.Items, q.Currency)
+}
+
+// calculate updates the line totals of items in place and returns the
+// subtotal, tax breakdown, tax and total.
+func calculate(items []InvoiceItem, currency string) (subtotal money.Money, breakdown []TaxLine, tax, total money.Money) {
+	subtotal = money.Zero(currency)
+	for i := range items {
+		item := &items[i]
+		item.UnitPrice = item.UnitPrice.WithCurrency(currency)
+		item.LineTotal = item.UnitPrice.Mul(item.Quantity)
+		subtotal = subtotal.Add(item.LineTotal)
+	}
+	breakdown = TaxBreakdown(items, currency)
+
+	tax = money.Zero(currency)
+	for _, line := range breakdown {
+		tax = tax.Add(line.Tax)
+	}
+	return subtotal, breakdown, tax, subtotal.Add(tax)
+}
+
+// TaxBreakdown groups the line totals by tax rate, highest rate first.
+func TaxBreakdown(items []InvoiceItem, currency string) []TaxLine {
+	byRate := make(map[money.Decimal]money.Money)
+	rates := make([]money.Decimal, 0)
+	for _, item := range items {
+		net, ok := byRate[item.TaxRatePercent]
+		if !ok {
+			net = money.Zero(currency)
+			rates = append(rates, item.TaxRatePercent)
+		}
+		byRate[item.TaxRatePercent] = net.Add(item.LineTotal)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		return rates[i].Cmp(rates[j]) > 0
+	})
+
+	lines := make([]TaxLine, 0, len(rates))
+	for _, rate := range rates {
+		net := byRate[rate]
+		lines = append(lines, TaxLine{
+			RatePercent: rate,
+			Net:         net,
+			Tax:         net.Percent(rate),
+		})
+	}
+	return lines
+}
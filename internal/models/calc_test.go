@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func item(quantity string, priceMinor int64, rate string) InvoiceItem {
+	return InvoiceItem{
+		Quantity:       money.MustParseDecimal(quantity),
+		UnitPrice:      money.New(priceMinor, ""),
+		TaxRatePercent: money.MustParseDecimal(rate),
+	}
+}
+
+func TestRecalculate(t *testing.T) {
+	tests := []struct {
+		name                 string
+		items                []InvoiceItem
+		subtotal, tax, total string
+		breakdown            []string
+	}{
+		{
+			name:      "single rate",
+			items:     []InvoiceItem{item("2", 5000, "19")},
+			subtotal:  "100.00 EUR",
+			tax:       "19.00 EUR",
+			total:     "119.00 EUR",
+			breakdown: []string{"19: 100.00 EUR / 19.00 EUR"},
+		},
+		{
+			name:      "mixed rates highest first",
+			items:     []InvoiceItem{item("1", 1000, "7"), item("3", 333, "19"), item("1", 500, "0")},
+			subtotal:  "24.99 EUR",
+			tax:       "2.60 EUR",
+			total:     "27.59 EUR",
+			breakdown: []string{"19: 9.99 EUR / 1.90 EUR", "7: 10.00 EUR / 0.70 EUR", "0: 5.00 EUR / 0.00 EUR"},
+		},
+		{
+			name:      "tax rounded once per rate",
+			items:     []InvoiceItem{item("1", 5, "19"), item("1", 5, "19"), item("1", 5, "19")},
+			subtotal:  "0.15 EUR",
+			tax:       "0.03 EUR",
+			total:     "0.18 EUR",
+			breakdown: []string{"19: 0.15 EUR / 0.03 EUR"},
+		},
+		{
+			name:      "fractional quantity",
+			items:     []InvoiceItem{item("1.5", 9999, "19")},
+			subtotal:  "149.99 EUR",
+			tax:       "28.50 EUR",
+			total:     "178.49 EUR",
+			breakdown: []string{"19: 149.99 EUR / 28.50 EUR"},
+		},
+	}
+	for _, tt := range tests {
+		inv := Invoice{Items: tt.items}
+		inv.Recalculate()
+		if inv.Subtotal.String() != tt.subtotal || inv.TaxAmount.String() != tt.tax || inv.Total.String() != tt.total {
+			t.Errorf("%s: subtotal %s, tax %s, total %s; want %s, %s, %s", tt.name, inv.Subtotal, inv.TaxAmount, inv.Total, tt.subtotal, tt.tax, tt.total)
+		}
+		if len(inv.TaxBreakdown) != len(tt.breakdown) {
+			t.Errorf("%s: %d tax lines, want %d", tt.name, len(inv.TaxBreakdown), len(tt.breakdown))
+			continue
+		}
+		for i, line := range inv.TaxBreakdown {
+			if got := line.RatePercent.String() + ": " + line.Net.String() + " / " + line.Tax.String(); got != tt.breakdown[i] {
+				t.Errorf("%s: tax line %d = %s, want %s", tt.name, i, got, tt.breakdown[i])
+			}
+		}
+	}
+}
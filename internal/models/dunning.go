@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// DunningLevel is one step of the reminder escalation, from the friendly
+// reminder to the last dunning notice.
+type DunningLevel struct {
+	// Name titles the reminder; empty uses the default title of the level.
+	Name string `json:"name"`
+	// DaysAfterDue is how many days after the due date the level is reached.
+	DaysAfterDue int `json:"days_after_due"`
+	// Fee is charged per reminded invoice in the invoice currency.
+	Fee money.Decimal `json:"fee"`
+	// PaymentDays sets the new deadline, counted from the reminder date.
+	PaymentDays int `json:"payment_days"`
+	// Interest adds the late-payment interest and the flat fee to the letter.
+	Interest bool `json:"interest"`
+	// Text is the body of the letter; empty uses the default text of the
+	// level. It may contain the placeholders {CUSTOMER}, {INVOICES},
+	// {AMOUNT} and {DEADLINE}.
+	Text string `json:"text"`
+}
+
+// DunningSettings configures the reminders of a profile.
+type DunningSettings struct {
+	Levels []DunningLevel `json:"levels"`
+}
+
+// EffectiveLevels returns the configured levels, or DefaultDunningLevels if
+// none are configured.
+func (s DunningSettings) EffectiveLevels() []DunningLevel {
+	if len(s.Levels) == 0 {
+		return DefaultDunningLevels()
+	}
+	return s.Levels
+}
+
+// DefaultDunningLevels returns a friendly reminder without fee and two
+// dunning notices, the last one claiming interest.
+func DefaultDunningLevels() []DunningLevel {
+	return []DunningLevel{
+		{DaysAfterDue: 7, PaymentDays: 7},
+		{DaysAfterDue: 21, Fee: money.MustParseDecimal("5"), PaymentDays: 7},
+		{DaysAfterDue: 35, Fee: money.MustParseDecimal("10"), PaymentDays: 7, Interest: true},
+	}
+}
+
+// Reminder records a reminder sent for an invoice. Invoices reminded in the
+// same letter share its PDFPath.
+type Reminder struct {
+	ID string `json:"id"`
+	// Level counts from 1 for the first level of the profile.
+	Level int         `json:"level"`
+	Date  time.Time   `json:"date"`
+	Fee   money.Money `json:"fee"`
+	// Interest is the late-payment interest claimed up to Date; zero if the
+	// level does not claim interest.
+	Interest money.Money `json:"interest"`
+	// FlatFee is the flat late-payment fee, claimed at most once per invoice.
+	FlatFee  money.Money `json:"flat_fee"`
+	Deadline time.Time   `json:"deadline"`
+	PDFPath  string      `json:"pdf_path"`
+}
+
+// DunningLevel returns the highest level reminded so far, or 0.
+func (inv Invoice) DunningLevel() int {
+	level := 0
+	for _, r := range inv.Reminders {
+		if r.Level > level {
+			level = r.Level
+		}
+	}
+	return level
+}
+
+// LastReminder returns the most recent reminder.
+func (inv Invoice) LastReminder() (Reminder, bool) {
+	if len(inv.Reminders) == 0 {
+		return Reminder{}, false
+	}
+	return inv.Reminders[len(inv.Reminders)-1], true
+}
+
+// DunningFees sums the fees of all reminders. They are owed in addition to
+// the outstanding balance and do not change the payment state.
+func (inv Invoice) DunningFees() money.Money {
+	fees := money.Zero(inv.Currency)
+	for _, r := range inv.Reminders {
+		fees = fees.Add(r.Fee)
+	}
+	return fees
+}
+
+// ClaimedInterest returns the interest claimed by the latest reminder that
+// claimed any. Interest accrues, so later claims replace earlier ones.
+func (inv Invoice) ClaimedInterest() money.Money {
+	for i := len(inv.Reminders) - 1; i >= 0; i-- {
+		if !inv.Reminders[i].Interest.IsZero() {
+			return inv.Reminders[i].Interest
+		}
+	}
+	return money.Zero(inv.Currency)
+}
+
+// ClaimedFlatFee returns the flat late-payment fee claimed by the reminders.
+func (inv Invoice) ClaimedFlatFee() money.Money {
+	fee := money.Zero(inv.Currency)
+	for _, r := range inv.Reminders {
+		fee = fee.Add(r.FlatFee)
+	}
+	return fee
+}
+
+// DunningClaims returns everything owed on top of the outstanding balance:
+// dunning fees, claimed interest and the flat fee.
+func (inv Invoice) DunningClaims() money.Money {
+	return inv.DunningFees().Add(inv.ClaimedInterest()).Add(inv.ClaimedFlatFee())
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// InterestPeriod sets the base rate from From until the next period starts.
+type InterestPeriod struct {
+	From        time.Time     `json:"from"`
+	RatePercent money.Decimal `json:"rate_percent"`
+}
+
+// InterestSettings configures the late-payment interest of a profile. The
+// annual rate is the base rate of the period plus MarginPoints.
+type InterestSettings struct {
+	// Periods is the base rate schedule; empty uses DefaultBaseRates.
+	Periods []InterestPeriod `json:"periods"`
+	// MarginPoints are the percentage points added to the base rate.
+	MarginPoints money.Decimal `json:"margin_points"`
+	// FlatFee is claimed once per overdue invoice in the invoice currency.
+	FlatFee money.Decimal `json:"flat_fee"`
+}
+
+// IsZero reports whether nothing is configured.
+func (s InterestSettings) IsZero() bool {
+	return len(s.Periods) == 0 && s.MarginPoints.IsZero() && s.FlatFee.IsZero()
+}
+
+// Effective returns the settings with defaults filled in: the German rules
+// for business customers if nothing is configured, and DefaultBaseRates if
+// only the schedule is missing.
+func (s InterestSettings) Effective() InterestSettings {
+	if s.IsZero() {
+		s.MarginPoints = money.DecimalFromInt(9)
+		s.FlatFee = money.DecimalFromInt(40)
+	}
+	if len(s.Periods) == 0 {
+		s.Periods = DefaultBaseRates()
+	}
+	return s
+}
+
+// DefaultBaseRates returns the base rate of the Deutsche Bundesbank (§ 247
+// BGB) since mid 2016. Later changes must be added in the profile.
+func DefaultBaseRates() []InterestPeriod {
+	period := func(year int, month time.Month, rate string) InterestPeriod {
+		return InterestPeriod{From: time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), RatePercent: money.MustParseDecimal(rate)}
+	}
+	return []InterestPeriod{
+		period(2016, time.July, "-0.88"),
+		period(2023, time.January, "1.62"),
+		period(2023, time.July, "3.12"),
+		period(2024, time.January, "3.62"),
+		period(2024, time.July, "3.37"),
+		period(2025, time.January, "2.27"),
+		period(2025, time.July, "1.27"),
+	}
+}
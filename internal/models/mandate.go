@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// Sequence types of a SEPA direct debit.
+const (
+	SequenceFirst     = "FRST"
+	SequenceRecurring = "RCUR"
+	SequenceOneOff    = "OOFF"
+	SequenceFinal     = "FNAL"
+)
+
+// SequenceTypes lists the sequence types in display order.
+var SequenceTypes = []string{SequenceFirst, SequenceRecurring, SequenceOneOff, SequenceFinal}
+
+// Mandate is the SEPA direct debit mandate a customer signed. SequenceType is
+// the type of the next collection; a first collection switches it to
+// recurring.
+type Mandate struct {
+	ID            string    `json:"id"`
+	SignatureDate time.Time `json:"signature_date"`
+	SequenceType  string    `json:"sequence_type"`
+	IBAN          string    `json:"iban"`
+	BIC           string    `json:"bic"`
+	// LastCollection is the collection date of the latest export. Mandates
+	// expire 36 months after their last collection.
+	LastCollection time.Time `json:"last_collection"`
+}
+
+// IsZero reports whether the customer has no mandate.
+func (m Mandate) IsZero() bool {
+	return m.ID == ""
+}
+
+// Collection records that an invoice was exported for collection by SEPA
+// direct debit. The invoice stays pending until the payment is recorded.
+type Collection struct {
+	MessageID    string      `json:"message_id"`
+	Date         time.Time   `json:"date"`
+	Amount       money.Money `json:"amount"`
+	SequenceType string      `json:"sequence_type"`
+	MandateID    string      `json:"mandate_id"`
+	ExportedAt   time.Time   `json:"exported_at"`
+}
+
+// IsZero reports whether no collection was exported.
+func (c Collection) IsZero() bool {
+	return c.MessageID == ""
+}
+
+// CollectionPending reports whether the invoice was exported for direct debit
+// and is not settled yet.
+func (inv Invoice) CollectionPending() bool {
+	return !inv.Collection.IsZero() && !inv.IsSettled()
+}
@@ -1,74 +1,293 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
 
 // PaymentDetails capture how the business that issues the invoice expects to be paid.
+// QRBill appends the Swiss QR-bill payment part to CHF and EUR invoices when
+// the IBAN is Swiss or from Liechtenstein. CreditorID is the SEPA creditor
+// identifier required for direct debits.
 type PaymentDetails struct {
 	BankName     string `json:"bank_name"`
 	IBAN         string `json:"iban"`
 	BIC          string `json:"bic"`
 	PaymentTerms string `json:"payment_terms"`
+	QRBill       bool   `json:"qr_bill"`
+	CreditorID   string `json:"creditor_id"`
+}
+
+// NumberingScheme describes how document numbers are generated for a profile.
+// Pattern supports the tokens {YYYY}, {YY}, {MM}, {DD}, {CUSTOMER} and
+// {SEQ} / {SEQ:n}, where n is the zero padded width of the counter.
+type NumberingScheme struct {
+	Pattern     string `json:"pattern"`
+	ResetYearly bool   `json:"reset_yearly"`
+}
+
+// FontPair selects the TrueType files used for generated PDFs. Relative paths
+// are resolved inside the data directory; empty values use the bundled font.
+type FontPair struct {
+	Regular string `json:"regular"`
+	Bold    string `json:"bold"`
+}
+
+// Image positions on a PDF page.
+const (
+	PositionTopLeft     = "top-left"
+	PositionTopRight    = "top-right"
+	PositionBottomLeft  = "bottom-left"
+	PositionBottomRight = "bottom-right"
+)
+
+// ImagePlacement references a PNG or JPEG file inside the data directory and
+// where it is drawn on every page. Width is given in points; zero uses a
+// default size.
+type ImagePlacement struct {
+	Path     string `json:"path"`
+	Position string `json:"position"`
+	Width    int    `json:"width"`
+}
+
+// Branding holds the images printed on generated documents.
+type Branding struct {
+	Logo      ImagePlacement `json:"logo"`
+	Signature ImagePlacement `json:"signature"`
+}
+
+// EInvoiceSettings selects the structured invoice formats produced for a
+// profile. An empty FacturXProfile creates plain PDFs.
+type EInvoiceSettings struct {
+	FacturXProfile string `json:"facturx_profile"`
 }
 
 // Profile holds the issuer specific information (who is sending the invoice).
 type Profile struct {
-	ID             string         `json:"id"`
-	DisplayName    string         `json:"display_name"`
-	CompanyName    string         `json:"company_name"`
-	AddressLine1   string         `json:"address_line_1"`
-	AddressLine2   string         `json:"address_line_2"`
-	City           string         `json:"city"`
-	PostalCode     string         `json:"postal_code"`
-	Country        string         `json:"country"`
-	Email          string         `json:"email"`
-	Phone          string         `json:"phone"`
-	TaxID          string         `json:"tax_id"`
-	PaymentDetails PaymentDetails `json:"payment_details"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-}
-
-// Customer captures the invoice recipient information.
+	ID               string           `json:"id"`
+	DisplayName      string           `json:"display_name"`
+	CompanyName      string           `json:"company_name"`
+	AddressLine1     string           `json:"address_line_1"`
+	AddressLine2     string           `json:"address_line_2"`
+	City             string           `json:"city"`
+	PostalCode       string           `json:"postal_code"`
+	Country          string           `json:"country"`
+	Email            string           `json:"email"`
+	Phone            string           `json:"phone"`
+	TaxID            string           `json:"tax_id"`
+	PaymentDetails   PaymentDetails   `json:"payment_details"`
+	Terms            PaymentTerms     `json:"terms"`
+	InvoiceNumbering NumberingScheme  `json:"invoice_numbering"`
+	CreditNumbering  NumberingScheme  `json:"credit_numbering"`
+	QuoteNumbering   NumberingScheme  `json:"quote_numbering"`
+	Fonts            FontPair         `json:"fonts"`
+	Branding         Branding         `json:"branding"`
+	EInvoice         EInvoiceSettings `json:"e_invoice"`
+	Dunning          DunningSettings  `json:"dunning"`
+	Interest         InterestSettings `json:"interest"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+// Customer captures the invoice recipient information. LeitwegID is the
+// routing identifier German public authorities require on XRechnung invoices.
+// TaxExemption is copied onto new invoices that do not state their own, e.g.
+// for customers abroad that are invoiced under the reverse charge.
 type Customer struct {
-	ID           string    `json:"id"`
-	DisplayName  string    `json:"display_name"`
-	ContactName  string    `json:"contact_name"`
-	Email        string    `json:"email"`
-	Phone        string    `json:"phone"`
-	AddressLine1 string    `json:"address_line_1"`
-	AddressLine2 string    `json:"address_line_2"`
-	City         string    `json:"city"`
-	PostalCode   string    `json:"postal_code"`
-	Country      string    `json:"country"`
-	Notes        string    `json:"notes"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string       `json:"id"`
+	DisplayName  string       `json:"display_name"`
+	ContactName  string       `json:"contact_name"`
+	Email        string       `json:"email"`
+	Phone        string       `json:"phone"`
+	AddressLine1 string       `json:"address_line_1"`
+	AddressLine2 string       `json:"address_line_2"`
+	City         string       `json:"city"`
+	PostalCode   string       `json:"postal_code"`
+	Country      string       `json:"country"`
+	Notes        string       `json:"notes"`
+	LeitwegID    string       `json:"leitweg_id"`
+	VATID        string       `json:"vat_id"`
+	TaxExemption TaxExemption `json:"tax_exemption"`
+	Terms        PaymentTerms `json:"terms"`
+	Mandate      Mandate      `json:"mandate"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
 }
 
 // InvoiceItem describes an individual line item on an invoice.
 type InvoiceItem struct {
-	Description string  `json:"description"`
-	Quantity    float64 `json:"quantity"`
-	UnitPrice   float64 `json:"unit_price"`
-	LineTotal   float64 `json:"line_total"`
+	Description    string        `json:"description"`
+	Quantity       money.Decimal `json:"quantity"`
+	UnitPrice      money.Money   `json:"unit_price"`
+	TaxRatePercent money.Decimal `json:"tax_rate_percent"`
+	LineTotal      money.Money   `json:"line_total"`
+}
+
+// TaxLine groups the net amount and tax of all items sharing one tax rate.
+type TaxLine struct {
+	RatePercent money.Decimal `json:"rate_percent"`
+	Net         money.Money   `json:"net"`
+	Tax         money.Money   `json:"tax"`
+}
+
+// Tax categories from UNCL 5305 for line items without VAT. Taxed items are
+// always standard rated.
+const (
+	TaxZeroRated      = "Z"
+	TaxExempt         = "E"
+	TaxReverseCharge  = "AE"
+	TaxIntraCommunity = "K"
+	TaxExport         = "G"
+	TaxNotSubject     = "O"
+)
+
+// TaxCategories lists the categories for items without VAT, zero rated first.
+var TaxCategories = []string{TaxZeroRated, TaxExempt, TaxReverseCharge, TaxIntraCommunity, TaxExport, TaxNotSubject}
+
+// TaxExemption states why the items of a document without VAT are not
+// taxed. Reason is the exemption text printed on the document; all
+// categories except zero rated require one.
+type TaxExemption struct {
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
 }
 
-// Invoice represents an invoice issued to a customer.
+// IsZero reports whether no exemption is set.
+func (e TaxExemption) IsZero() bool {
+	return e.Category == "" && e.Reason == ""
+}
+
+// CategoryCode returns the category of items without VAT, zero rated if
+// none is set.
+func (e TaxExemption) CategoryCode() string {
+	if e.Category == "" {
+		return TaxZeroRated
+	}
+	return e.Category
+}
+
+// Document types of an Invoice. Records without a type are invoices.
+const (
+	DocumentInvoice      = "invoice"
+	DocumentCreditNote   = "credit_note"
+	DocumentCancellation = "cancellation"
+)
+
+// Invoice represents an invoice issued to a customer. TaxRatePercent and
+// PaidAt are only set on records written before tax rates moved onto the
+// individual items and before payments were recorded one by one.
+// Credit notes and cancellations reference the invoice they correct through
+// OriginalID and OriginalNumber and carry negated quantities. An invoice is a
+// draft until FinalizedAt is set; finalised invoices have a number and only
+// their payments, reminders and credits may change. Credited is the amount
+// a correction offsets against the open balance of its original: positive on
+// the original and negated on the correction, so neither is owed twice.
 type Invoice struct {
 	ID             string        `json:"id"`
 	Number         string        `json:"number"`
+	DocumentType   string        `json:"document_type"`
+	OriginalID     string        `json:"original_id"`
+	OriginalNumber string        `json:"original_number"`
 	ProfileID      string        `json:"profile_id"`
 	CustomerID     string        `json:"customer_id"`
 	IssueDate      time.Time     `json:"issue_date"`
 	DueDate        time.Time     `json:"due_date"`
+	Currency       string        `json:"currency"`
 	Items          []InvoiceItem `json:"items"`
 	Notes          string        `json:"notes"`
-	TaxRatePercent float64       `json:"tax_rate_percent"`
-	Subtotal       float64       `json:"subtotal"`
-	TaxAmount      float64       `json:"tax_amount"`
-	Total          float64       `json:"total"`
+	Terms          PaymentTerms  `json:"terms"`
+	TaxExemption   TaxExemption  `json:"tax_exemption"`
+	TaxRatePercent money.Decimal `json:"tax_rate_percent"`
+	TaxBreakdown   []TaxLine     `json:"tax_breakdown"`
+	Subtotal       money.Money   `json:"subtotal"`
+	TaxAmount      money.Money   `json:"tax_amount"`
+	Total          money.Money   `json:"total"`
 	PDFPath        string        `json:"pdf_path"`
+	Payments       []Payment     `json:"payments"`
+	Credited       money.Money   `json:"credited"`
+	Reminders      []Reminder    `json:"reminders"`
+	Collection     Collection    `json:"collection"`
 	PaidAt         time.Time     `json:"paid_at"`
+	FinalizedAt    time.Time     `json:"finalized_at"`
 	CreatedAt      time.Time     `json:"created_at"`
 	UpdatedAt      time.Time     `json:"updated_at"`
 }
+
+// Quote states. Draft and sent quotes whose validity ended report
+// QuoteExpired.
+const (
+	QuoteDraft    = "draft"
+	QuoteSent     = "sent"
+	QuoteAccepted = "accepted"
+	QuoteDeclined = "declined"
+	QuoteExpired  = "expired"
+)
+
+// Quote is an offer to a customer. Once accepted it can be converted into an
+// invoice; InvoiceID then references that invoice.
+type Quote struct {
+	ID           string        `json:"id"`
+	Number       string        `json:"number"`
+	ProfileID    string        `json:"profile_id"`
+	CustomerID   string        `json:"customer_id"`
+	IssueDate    time.Time     `json:"issue_date"`
+	ValidUntil   time.Time     `json:"valid_until"`
+	Status       string        `json:"status"`
+	Currency     string        `json:"currency"`
+	Items        []InvoiceItem `json:"items"`
+	Notes        string        `json:"notes"`
+	TaxBreakdown []TaxLine     `json:"tax_breakdown"`
+	Subtotal     money.Money   `json:"subtotal"`
+	TaxAmount    money.Money   `json:"tax_amount"`
+	Total        money.Money   `json:"total"`
+	PDFPath      string        `json:"pdf_path"`
+	InvoiceID    string        `json:"invoice_id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// CurrentStatus returns the stored status, or QuoteExpired for an unanswered
+// quote whose validity ended before now.
+func (q Quote) CurrentStatus(now time.Time) string {
+	status := q.Status
+	if status == "" {
+		status = QuoteDraft
+	}
+	if (status == QuoteDraft || status == QuoteSent) && !q.ValidUntil.IsZero() && q.ValidUntil.AddDate(0, 0, 1).Before(now) {
+		return QuoteExpired
+	}
+	return status
+}
+
+// SequenceCounter remembers the last number handed out for a numbering sequence.
+type SequenceCounter struct {
+	Key       string    `json:"key"`
+	Year      int       `json:"year"`
+	Last      int       `json:"last"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Type returns the document type, treating records without one as invoices.
+func (inv Invoice) Type() string {
+	if inv.DocumentType == "" {
+		return DocumentInvoice
+	}
+	return inv.DocumentType
+}
+
+// IsDraft reports whether the invoice can still be edited.
+func (inv Invoice) IsDraft() bool {
+	return inv.FinalizedAt.IsZero()
+}
+
+// IsCorrection reports whether the document is a credit note or cancellation.
+func (inv Invoice) IsCorrection() bool {
+	return inv.Type() != DocumentInvoice
+}
+
+// IsCancelled reports whether corrections offset the whole total of an
+// invoice.
+func (inv Invoice) IsCancelled() bool {
+	return !inv.IsCorrection() && !inv.Total.IsZero() && inv.Credited.Cmp(inv.Total) == 0
+}
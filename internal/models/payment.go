@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// Payment states derived from the payments recorded on an invoice.
+const (
+	PaymentUnpaid        = "unpaid"
+	PaymentPartiallyPaid = "partially_paid"
+	PaymentPaid          = "paid"
+	PaymentOverpaid      = "overpaid"
+)
+
+// Payment methods offered when recording a payment.
+const (
+	MethodBankTransfer = "bank_transfer"
+	MethodDirectDebit  = "direct_debit"
+	MethodCard         = "card"
+	MethodCash         = "cash"
+	MethodOther        = "other"
+)
+
+// PaymentMethods lists the payment methods in display order.
+var PaymentMethods = []string{MethodBankTransfer, MethodDirectDebit, MethodCard, MethodCash, MethodOther}
+
+// Payment is a receipt booked against an invoice. Refunds on credit notes and
+// cancellations are recorded with a negative amount.
+type Payment struct {
+	ID        string      `json:"id"`
+	Date      time.Time   `json:"date"`
+	Amount    money.Money `json:"amount"`
+	Method    string      `json:"method"`
+	Reference string      `json:"reference"`
+	Note      string      `json:"note"`
+}
+
+// AmountPaid sums the recorded payments.
+func (inv Invoice) AmountPaid() money.Money {
+	paid := money.Zero(inv.Currency)
+	for _, p := range inv.Payments {
+		paid = paid.Add(p.Amount)
+	}
+	return paid
+}
+
+// Outstanding returns the balance still to be paid after payments, credits
+// and an earned early-payment discount. It is negative once the invoice is
+// overpaid.
+func (inv Invoice) Outstanding() money.Money {
+	return inv.Total.Sub(inv.Credited).Sub(inv.AmountPaid()).Sub(inv.DiscountGranted())
+}
+
+// PaymentStatus derives the payment state from the recorded payments. Credits
+// reduce the amount owed, and an earned early-payment discount counts as
+// paid, so a fully cancelled invoice is paid. For documents with a negative
+// total, refunds count the same way.
+func (inv Invoice) PaymentStatus() string {
+	total, paid := inv.Total.Sub(inv.Credited), inv.AmountPaid().Add(inv.DiscountGranted())
+	if total.Sign() < 0 {
+		total, paid = total.Neg(), paid.Neg()
+	}
+	switch {
+	case paid.Cmp(total) == 0:
+		return PaymentPaid
+	case paid.Sign() <= 0:
+		return PaymentUnpaid
+	case paid.Cmp(total) < 0:
+		return PaymentPartiallyPaid
+	default:
+		return PaymentOverpaid
+	}
+}
+
+// IsSettled reports whether the invoice is paid in full or overpaid.
+func (inv Invoice) IsSettled() bool {
+	switch inv.PaymentStatus() {
+	case PaymentPaid, PaymentOverpaid:
+		return true
+	default:
+		return false
+	}
+}
+
+// LastPaymentDate returns the date of the latest payment, or the zero time.
+func (inv Invoice) LastPaymentDate() time.Time {
+	var last time.Time
+	for _, p := range inv.Payments {
+		if p.Date.After(last) {
+			last = p.Date
+		}
+	}
+	return last
+}
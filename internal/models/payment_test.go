@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func TestOutstanding(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int64
+		credited      int64
+		payments      []int64
+		outstanding   string
+		paymentStatus string
+	}{
+		{name: "unpaid", total: 11900, outstanding: "119.00 EUR", paymentStatus: PaymentUnpaid},
+		{name: "partly paid", total: 11900, payments: []int64{4000}, outstanding: "79.00 EUR", paymentStatus: PaymentPartiallyPaid},
+		{name: "partly credited", total: 11900, credited: 1900, outstanding: "100.00 EUR", paymentStatus: PaymentUnpaid},
+		{name: "paid after a credit", total: 11900, credited: 1900, payments: []int64{10000}, outstanding: "0.00 EUR", paymentStatus: PaymentPaid},
+		{name: "fully cancelled", total: 11900, credited: 11900, outstanding: "0.00 EUR", paymentStatus: PaymentPaid},
+		{name: "overpaid after a credit", total: 11900, credited: 1900, payments: []int64{11900}, outstanding: "-19.00 EUR", paymentStatus: PaymentOverpaid},
+		{name: "refunded credit note", total: -11900, credited: -11900, outstanding: "0.00 EUR", paymentStatus: PaymentPaid},
+		{name: "credit note with refund", total: -4000, payments: []int64{-4000}, outstanding: "0.00 EUR", paymentStatus: PaymentPaid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := Invoice{Currency: "EUR", Total: money.New(tt.total, "EUR"), Credited: money.New(tt.credited, "EUR")}
+			for _, amount := range tt.payments {
+				inv.Payments = append(inv.Payments, Payment{Amount: money.New(amount, "EUR")})
+			}
+			if got := inv.Outstanding().String(); got != tt.outstanding {
+				t.Errorf("outstanding = %s, want %s", got, tt.outstanding)
+			}
+			if got := inv.PaymentStatus(); got != tt.paymentStatus {
+				t.Errorf("payment status = %s, want %s", got, tt.paymentStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Revision actions.
+const (
+	RevisionCreated   = "created"
+	RevisionUpdated   = "updated"
+	RevisionFinalized = "finalized"
+	RevisionDeleted   = "deleted"
+)
+
+// FieldChange is one changed field of a revision. Field uses the JSON name,
+// with items addressed as items[n].field, payments as payments[id].field,
+// reminders as reminders[id].field and the direct debit as collection.field.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// InvoiceRevision records a change of an invoice. Revisions are only ever
+// appended, never rewritten.
+type InvoiceRevision struct {
+	InvoiceID  string        `json:"invoice_id"`
+	Number     string        `json:"number"`
+	Action     string        `json:"action"`
+	Changes    []FieldChange `json:"changes"`
+	RecordedAt time.Time     `json:"recorded_at"`
+}
+
+// DiffInvoices lists the fields that differ between two versions of an
+// invoice. Derived values such as the tax breakdown and the update time are
+// left out; they follow from the listed fields.
+func DiffInvoices(before, after Invoice) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, o, n any) {
+		a, b := formatField(o), formatField(n)
+		if a != b {
+			changes = append(changes, FieldChange{Field: field, Old: a, New: b})
+		}
+	}
+	add("number", before.Number, after.Number)
+	add("document_type", before.DocumentType, after.DocumentType)
+	add("original_number", before.OriginalNumber, after.OriginalNumber)
+	add("profile_id", before.ProfileID, after.ProfileID)
+	add("customer_id", before.CustomerID, after.CustomerID)
+	add("issue_date", before.IssueDate, after.IssueDate)
+	add("due_date", before.DueDate, after.DueDate)
+	add("currency", before.Currency, after.Currency)
+	add("notes", before.Notes, after.Notes)
+	add("terms", before.Terms, after.Terms)
+	add("tax_exemption.category", before.TaxExemption.Category, after.TaxExemption.Category)
+	add("tax_exemption.reason", before.TaxExemption.Reason, after.TaxExemption.Reason)
+	for i := 0; i < len(before.Items) || i < len(after.Items); i++ {
+		var o, n InvoiceItem
+		if i < len(before.Items) {
+			o = before.Items[i]
+		}
+		if i < len(after.Items) {
+			n = after.Items[i]
+		}
+		prefix := fmt.Sprintf("items[%d].", i)
+		add(prefix+"description", o.Description, n.Description)
+		add(prefix+"quantity", o.Quantity, n.Quantity)
+		add(prefix+"unit_price", o.UnitPrice, n.UnitPrice)
+		add(prefix+"tax_rate_percent", o.TaxRatePercent, n.TaxRatePercent)
+	}
+	add("subtotal", before.Subtotal, after.Subtotal)
+	add("tax_amount", before.TaxAmount, after.TaxAmount)
+	add("total", before.Total, after.Total)
+	add("pdf_path", before.PDFPath, after.PDFPath)
+	add("credited", before.Credited, after.Credited)
+	// Payments are matched by ID so that removing one does not shift the rest.
+	addPayment := func(o, n Payment) {
+		key := n.ID
+		if key == "" {
+			key = o.ID
+		}
+		prefix := fmt.Sprintf("payments[%s].", key)
+		add(prefix+"date", o.Date, n.Date)
+		add(prefix+"amount", o.Amount, n.Amount)
+		add(prefix+"method", o.Method, n.Method)
+		add(prefix+"reference", o.Reference, n.Reference)
+		add(prefix+"note", o.Note, n.Note)
+	}
+	previous := make(map[string]Payment, len(before.Payments))
+	for _, p := range before.Payments {
+		previous[p.ID] = p
+	}
+	for _, p := range after.Payments {
+		addPayment(previous[p.ID], p)
+		delete(previous, p.ID)
+	}
+	for _, p := range before.Payments {
+		if _, removed := previous[p.ID]; removed {
+			addPayment(p, Payment{})
+		}
+	}
+	// Reminders are only ever appended.
+	sent := make(map[string]bool, len(before.Reminders))
+	for _, r := range before.Reminders {
+		sent[r.ID] = true
+	}
+	for _, r := range after.Reminders {
+		if !sent[r.ID] {
+			prefix := fmt.Sprintf("reminders[%s].", r.ID)
+			add(prefix+"level", "", r.Level)
+			add(prefix+"date", time.Time{}, r.Date)
+			add(prefix+"fee", "", r.Fee)
+			if !r.Interest.IsZero() || !r.FlatFee.IsZero() {
+				add(prefix+"interest", "", r.Interest)
+				add(prefix+"flat_fee", "", r.FlatFee)
+			}
+			add(prefix+"deadline", time.Time{}, r.Deadline)
+		}
+	}
+	add("collection.message_id", before.Collection.MessageID, after.Collection.MessageID)
+	add("collection.date", before.Collection.Date, after.Collection.Date)
+	add("collection.amount", before.Collection.Amount, after.Collection.Amount)
+	add("collection.sequence_type", before.Collection.SequenceType, after.Collection.SequenceType)
+	add("collection.mandate_id", before.Collection.MandateID, after.Collection.MandateID)
+	add("finalized_at", before.FinalizedAt, after.FinalizedAt)
+	return changes
+}
+
+func formatField(v any) string {
+	switch v := v.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+// Intervals of a recurring invoice.
+const (
+	IntervalWeekly    = "weekly"
+	IntervalMonthly   = "monthly"
+	IntervalQuarterly = "quarterly"
+	IntervalYearly    = "yearly"
+)
+
+// Intervals lists the intervals in display order.
+var Intervals = []string{IntervalWeekly, IntervalMonthly, IntervalQuarterly, IntervalYearly}
+
+// Subscription is a template for invoices issued at a fixed interval, e.g. a
+// monthly retainer. Item descriptions and notes may contain placeholders
+// that are filled in for each run.
+type Subscription struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	ProfileID  string        `json:"profile_id"`
+	CustomerID string        `json:"customer_id"`
+	Currency   string        `json:"currency"`
+	Items      []InvoiceItem `json:"items"`
+	Notes      string        `json:"notes"`
+	Interval   string        `json:"interval"`
+	// DayOfMonth moves monthly, quarterly and yearly runs to that day,
+	// clamped to the length of the month. Zero keeps the day of StartDate.
+	DayOfMonth int       `json:"day_of_month"`
+	StartDate  time.Time `json:"start_date"`
+	// EndDate is the last day a run may fall on; zero runs indefinitely.
+	EndDate time.Time `json:"end_date"`
+	// Drafts keeps generated invoices as drafts for review instead of
+	// finalising them.
+	Drafts    bool              `json:"drafts"`
+	Paused    bool              `json:"paused"`
+	Runs      []SubscriptionRun `json:"runs"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// SubscriptionRun records an invoice generated from a subscription.
+type SubscriptionRun struct {
+	Date      time.Time `json:"date"`
+	InvoiceID string    `json:"invoice_id"`
+	Number    string    `json:"number"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RunDate returns the date of the n-th run, counting from 0, without regard
+// to the end date.
+func (s Subscription) RunDate(n int) time.Time {
+	start := s.StartDate
+	if s.Interval == IntervalWeekly {
+		return start.AddDate(0, 0, 7*n)
+	}
+	months := 1
+	switch s.Interval {
+	case IntervalQuarterly:
+		months = 3
+	case IntervalYearly:
+		months = 12
+	}
+	day := s.DayOfMonth
+	if day <= 0 {
+		day = start.Day()
+	}
+	// A day before the start day moves the first run into the next month.
+	offset := 0
+	if day < start.Day() {
+		offset = 1
+	}
+	first := time.Date(start.Year(), start.Month()+time.Month(offset+n*months), 1, 0, 0, 0, 0, start.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
+
+// NextRun returns the date of the first run after the last recorded one and
+// whether there is one before the end date.
+func (s Subscription) NextRun() (time.Time, bool) {
+	var last time.Time
+	for _, r := range s.Runs {
+		if r.Date.After(last) {
+			last = r.Date
+		}
+	}
+	for n := 0; ; n++ {
+		date := s.RunDate(n)
+		if !s.EndDate.IsZero() && date.After(s.EndDate) {
+			return time.Time{}, false
+		}
+		if last.IsZero() || date.After(last) {
+			return date, true
+		}
+	}
+}
+
+// IsActive reports whether the subscription will generate further invoices.
+func (s Subscription) IsActive() bool {
+	_, ok := s.NextRun()
+	return ok && !s.Paused
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionRunDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval string
+		day      int
+		start    string
+		want     []string
+	}{
+		{name: "monthly from the 31st", interval: IntervalMonthly, start: "2025-01-31", want: []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"}},
+		{name: "monthly in a leap year", interval: IntervalMonthly, start: "2024-01-30", want: []string{"2024-01-30", "2024-02-29", "2024-03-30"}},
+		{name: "day after the start day", interval: IntervalMonthly, day: 31, start: "2025-01-15", want: []string{"2025-01-31", "2025-02-28", "2025-03-31"}},
+		{name: "day before the start day", interval: IntervalMonthly, day: 1, start: "2025-01-15", want: []string{"2025-02-01", "2025-03-01", "2025-04-01"}},
+		{name: "day on the start day", interval: IntervalMonthly, day: 15, start: "2025-01-15", want: []string{"2025-01-15", "2025-02-15"}},
+		{name: "clamped in the start month", interval: IntervalMonthly, day: 31, start: "2025-11-30", want: []string{"2025-11-30", "2025-12-31", "2026-01-31", "2026-02-28"}},
+		{name: "quarterly from a month end", interval: IntervalQuarterly, start: "2024-11-30", want: []string{"2024-11-30", "2025-02-28", "2025-05-30", "2025-08-30"}},
+		{name: "quarterly on the last day", interval: IntervalQuarterly, day: 31, start: "2025-03-31", want: []string{"2025-03-31", "2025-06-30", "2025-09-30", "2025-12-31"}},
+		{name: "yearly from a leap day", interval: IntervalYearly, start: "2024-02-29", want: []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"}},
+		{name: "weekly ignores the day", interval: IntervalWeekly, day: 1, start: "2025-01-30", want: []string{"2025-01-30", "2025-02-06", "2025-02-13"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, err := time.Parse(time.DateOnly, tt.start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sub := Subscription{Interval: tt.interval, DayOfMonth: tt.day, StartDate: start}
+			got := make([]string, len(tt.want))
+			for n := range got {
+				got[n] = sub.RunDate(n).Format(time.DateOnly)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("runs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionNextRun(t *testing.T) {
+	// date parses s, leaving the zero time for "".
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	tests := []struct {
+		name string
+		runs []string
+		end  string
+		want string
+	}{
+		{name: "first run", want: "2025-01-31"},
+		{name: "after the last run", runs: []string{"2025-01-31", "2025-02-28"}, want: "2025-03-31"},
+		{name: "runs out of order", runs: []string{"2025-02-28", "2025-01-31"}, want: "2025-03-31"},
+		{name: "last run on the end date", runs: []string{"2025-01-31", "2025-02-28"}, end: "2025-03-31", want: "2025-03-31"},
+		{name: "ended", runs: []string{"2025-01-31", "2025-02-28"}, end: "2025-03-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := Subscription{Interval: IntervalMonthly, StartDate: date("2025-01-31"), EndDate: date(tt.end)}
+			for _, r := range tt.runs {
+				sub.Runs = append(sub.Runs, SubscriptionRun{Date: date(r)})
+			}
+			next, ok := sub.NextRun()
+			if tt.want == "" {
+				if ok {
+					t.Errorf("next run = %s, want none", next.Format(time.DateOnly))
+				}
+				return
+			}
+			if got := next.Format(time.DateOnly); !ok || got != tt.want {
+				t.Errorf("next run = %s, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+}
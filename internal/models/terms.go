@@ -0,0 +1,137 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// DiscountTier grants Percent off the total for payment within Days of the
+// issue date.
+type DiscountTier struct {
+	Percent money.Decimal `json:"percent"`
+	Days    int           `json:"days"`
+}
+
+// PaymentTerms are the structured payment terms of an invoice, e.g. 2 %
+// discount within 10 days, net 30 days. Profiles and customers carry them as
+// defaults for new invoices; the customer's terms win.
+type PaymentTerms struct {
+	// NetDays is the payment period; the due date is the issue date plus
+	// NetDays.
+	NetDays   int            `json:"net_days"`
+	Discounts []DiscountTier `json:"discounts"`
+}
+
+// IsZero reports whether no terms are set.
+func (t PaymentTerms) IsZero() bool {
+	return t.NetDays == 0 && len(t.Discounts) == 0
+}
+
+// String formats the terms compactly, e.g. "2% 10d, net 30d", for the
+// revision history.
+func (t PaymentTerms) String() string {
+	var parts []string
+	for _, tier := range t.Discounts {
+		parts = append(parts, fmt.Sprintf("%s%% %dd", tier.Percent, tier.Days))
+	}
+	if t.NetDays > 0 {
+		parts = append(parts, fmt.Sprintf("net %dd", t.NetDays))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Or returns t, or fallback if t is not set.
+func (t PaymentTerms) Or(fallback PaymentTerms) PaymentTerms {
+	if t.IsZero() {
+		return fallback
+	}
+	return t
+}
+
+// DiscountOffer is a discount tier applied to an invoice: paying Amount by
+// Deadline settles the invoice, Discount is what the customer saves.
+type DiscountOffer struct {
+	Percent  money.Decimal `json:"percent"`
+	Deadline time.Time     `json:"deadline"`
+	Discount money.Money   `json:"discount"`
+	Amount   money.Money   `json:"amount"`
+}
+
+// DiscountOffers returns the discount tiers of the invoice terms with their
+// deadlines and amounts, earliest deadline first. Corrections and invoices
+// without a positive total get no discount.
+func (inv Invoice) DiscountOffers() []DiscountOffer {
+	if inv.IsCorrection() || inv.Total.Sign() <= 0 {
+		return nil
+	}
+	var offers []DiscountOffer
+	for _, tier := range inv.Terms.Discounts {
+		if tier.Percent.Sign() <= 0 {
+			continue
+		}
+		discount := inv.Total.Percent(tier.Percent)
+		offers = append(offers, DiscountOffer{
+			Percent:  tier.Percent,
+			Deadline: inv.IssueDate.AddDate(0, 0, tier.Days),
+			Discount: discount,
+			Amount:   inv.Total.Sub(discount),
+		})
+	}
+	sort.SliceStable(offers, func(i, j int) bool { return offers[i].Deadline.Before(offers[j].Deadline) })
+	return offers
+}
+
+// DiscountGranted returns the discount earned by the payments: once the
+// payments made up to a tier's deadline reach its discounted amount, the rest
+// of the balance, at most the tier's discount, is waived. Of several earned
+// tiers the earliest counts.
+func (inv Invoice) DiscountGranted() money.Money {
+	for _, offer := range inv.DiscountOffers() {
+		if inv.paidBy(offer.Deadline).Add(inv.Credited).Cmp(offer.Amount) < 0 {
+			continue
+		}
+		rest := inv.Total.Sub(inv.Credited).Sub(inv.AmountPaid())
+		if rest.Sign() <= 0 {
+			break
+		}
+		if rest.Cmp(offer.Discount) > 0 {
+			rest = offer.Discount
+		}
+		return rest
+	}
+	return money.Zero(inv.Currency)
+}
+
+// OpenDiscount returns the discount that can still be earned by paying on
+// date: the first tier whose deadline has not passed, unless the invoice is
+// settled already.
+func (inv Invoice) OpenDiscount(date time.Time) (DiscountOffer, bool) {
+	if inv.IsSettled() {
+		return DiscountOffer{}, false
+	}
+	y, m, d := date.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
+	for _, offer := range inv.DiscountOffers() {
+		if !offer.Deadline.Before(day) {
+			return offer, true
+		}
+	}
+	return DiscountOffer{}, false
+}
+
+// paidBy sums the payments made up to and including the day of deadline.
+func (inv Invoice) paidBy(deadline time.Time) money.Money {
+	y, m, d := deadline.Date()
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, deadline.Location())
+	paid := money.Zero(inv.Currency)
+	for _, p := range inv.Payments {
+		if p.Date.Before(end) {
+			paid = paid.Add(p.Amount)
+		}
+	}
+	return paid
+}
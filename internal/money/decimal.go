@@ -0,0 +1,228 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// decimalPlaces is the number of fractional digits a Decimal keeps.
+const decimalPlaces = 4
+
+const decimalScale = 10000
+
+// maxParsed bounds the magnitude of parsed prices, quantities and rates so
+// that their products stay far below the int64 range.
+const maxParsed = 1_000_000_000
+
+// ErrOutOfRange is returned for numbers beyond the magnitude the package
+// calculates with.
+var ErrOutOfRange = errors.New("money: number out of range")
+
+// Decimal is a fixed-point number with four fractional digits. It is used for
+// quantities and percentages where float64 rounding errors are not acceptable.
+type Decimal struct {
+	units int64
+}
+
+// DecimalFromInt returns the decimal representation of a whole number.
+func DecimalFromInt(n int64) Decimal {
+	return Decimal{units: n * decimalScale}
+}
+
+// ParseDecimal parses a plain decimal string such as "-12.5" or "3". Digits
+// beyond the fourth fractional place are rounded half away from zero.
+// Magnitudes above one billion are rejected with ErrOutOfRange.
+func ParseDecimal(input string) (Decimal, error) {
+	units, err := parseFixed(input, decimalPlaces)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{units: units}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on invalid input. It is
+// intended for constants in code.
+func MustParseDecimal(input string) Decimal {
+	d, err := ParseDecimal(input)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	switch {
+	case d.units < 0:
+		return -1
+	case d.units > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Cmp compares d and o and returns -1, 0 or +1.
+func (d Decimal) Cmp(o Decimal) int {
+	return d.Sub(o).Sign()
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{units: d.units + o.units}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return Decimal{units: d.units - o.units}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: -d.units}
+}
+
+// Mul multiplies two decimals, rounding the result half away from zero.
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{units: mulDivRound(d.units, o.units, decimalScale)}
+}
+
+// Float64 returns the nearest float64 value. Only use it for display or
+// interoperability, never for further arithmetic.
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / decimalScale
+}
+
+// String formats the decimal without trailing fractional zeros, e.g. "1.5".
+func (d Decimal) String() string {
+	out := formatFixed(d.units, decimalPlaces)
+	if strings.Contains(out, ".") {
+		out = strings.TrimRight(out, "0")
+		out = strings.TrimSuffix(out, ".")
+	}
+	return out
+}
+
+// StringFixed formats the decimal with exactly the given number of fractional
+// digits, rounding half away from zero when digits are dropped.
+func (d Decimal) StringFixed(places int) string {
+	if places < 0 {
+		places = 0
+	}
+	if places >= decimalPlaces {
+		return formatFixed(d.units, decimalPlaces) + strings.Repeat("0", places-decimalPlaces)
+	}
+	divisor := int64(1)
+	for i := places; i < decimalPlaces; i++ {
+		divisor *= 10
+	}
+	return formatFixed(mulDivRound(d.units, 1, divisor), places)
+}
+
+// MarshalJSON encodes the decimal as a plain JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers and numeric strings. Numbers are parsed
+// from their textual representation so legacy float values decode exactly.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+		return nil
+	}
+	text := string(data)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseDecimal(text)
+	if err != nil {
+		return fmt.Errorf("money: decode decimal: %w", err)
+	}
+	*d = parsed
+	return nil
+}
+
+// parseFixed converts a decimal string into an integer scaled by 10^places.
+func parseFixed(input string, places int) (int64, error) {
+	value := strings.TrimSpace(input)
+	if value == "" {
+		return 0, fmt.Errorf("money: empty number")
+	}
+	if strings.Contains(value, "/") {
+		return 0, fmt.Errorf("money: invalid number %q", input)
+	}
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return 0, fmt.Errorf("money: invalid number %q", input)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	rat.Mul(rat, new(big.Rat).SetInt(scale))
+	rounded := roundRat(rat)
+	if new(big.Int).Abs(rounded).Cmp(new(big.Int).Mul(big.NewInt(maxParsed), scale)) > 0 {
+		return 0, fmt.Errorf("%w: %q", ErrOutOfRange, input)
+	}
+	return rounded.Int64(), nil
+}
+
+// formatFixed renders an integer scaled by 10^places as a decimal string.
+func formatFixed(units int64, places int) string {
+	sign := ""
+	magnitude := new(big.Int).SetInt64(units)
+	if units < 0 {
+		sign = "-"
+		magnitude.Neg(magnitude)
+	}
+	digits := magnitude.String()
+	if places == 0 {
+		return sign + digits
+	}
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	cut := len(digits) - places
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+// mulDivRound computes a*b/div rounded half away from zero without overflowing
+// intermediate results. Results beyond the int64 range are clamped to it;
+// Money.InRange reports amounts that large so callers can reject them.
+func mulDivRound(a, b, div int64) int64 {
+	num := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	rat := new(big.Rat).SetFrac(num, big.NewInt(div))
+	rounded := roundRat(rat)
+	switch {
+	case rounded.IsInt64():
+		return rounded.Int64()
+	case rounded.Sign() < 0:
+		return math.MinInt64
+	default:
+		return math.MaxInt64
+	}
+}
+
+func roundRat(r *big.Rat) *big.Int {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	negative := num.Sign() < 0
+	if negative {
+		num.Neg(num)
+	}
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if negative {
+		quo.Neg(quo)
+	}
+	return quo
+}
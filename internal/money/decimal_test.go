@@ -0,0 +1,104 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"3", "3"},
+		{"-12.5", "-12.5"},
+		{"0.0001", "0.0001"},
+		{"1.23455", "1.2346"},
+		{"-1.23455", "-1.2346"},
+		{"1.23454", "1.2345"},
+		{"  7.10  ", "7.1"},
+		{"1e2", "100"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.input)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimalInvalid(t *testing.T) {
+	for _, input := range []string{"", "abc", "1/3", "1,5", "99999999999999999999"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestDecimalStringFixed(t *testing.T) {
+	tests := []struct {
+		value  string
+		places int
+		want   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"-1.005", 2, "-1.01"},
+		{"1.0049", 2, "1.00"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"0.5", 6, "0.500000"},
+		{"19", 2, "19.00"},
+	}
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.value).StringFixed(tt.places); got != tt.want {
+			t.Errorf("%s.StringFixed(%d) = %s, want %s", tt.value, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalMul(t *testing.T) {
+	tests := []struct {
+		a, b, want string
+	}{
+		{"1.5", "2", "3"},
+		{"0.3333", "3", "0.9999"},
+		{"0.0001", "0.5", "0.0001"},
+		{"-0.0001", "0.5", "-0.0001"},
+		{"0.0001", "0.4", "0"},
+	}
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.a).Mul(MustParseDecimal(tt.b)); got.String() != tt.want {
+			t.Errorf("%s * %s = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`1.5`, "1.5"},
+		{`"19"`, "19"},
+		{`0.1`, "0.1"},
+		{`null`, "0"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.input), &d); err != nil {
+			t.Errorf("unmarshal %s: %v", tt.input, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("unmarshal %s = %s, want %s", tt.input, d, tt.want)
+		}
+		out, err := json.Marshal(d)
+		if err != nil || string(out) != tt.want {
+			t.Errorf("marshal %s = %s, %v", d, out, err)
+		}
+	}
+}
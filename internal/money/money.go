@@ -0,0 +1,192 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is used whenever a document does not specify a currency.
+const DefaultCurrency = "EUR"
+
+// minorDigits is the number of fractional digits stored in minor units. All
+// supported currencies use cents.
+const minorDigits = 2
+
+const minorPerMajor = 100
+
+// maxMinor is the largest amount InRange accepts: ten trillion in major
+// units, leaving room to add up thousands of such amounts in int64.
+const maxMinor = 1_000_000_000_000_000
+
+// Money is an exact monetary amount stored in minor units (cents) together
+// with its ISO 4217 currency code.
+type Money struct {
+	Minor    int64  `json:"minor"`
+	Currency string `json:"currency"`
+}
+
+// New returns an amount of the given minor units.
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: normalizeCurrency(currency)}
+}
+
+// Zero returns a zero amount in the given currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// FromDecimal converts a decimal major-unit value into money, rounding half
+// away from zero to whole cents.
+func FromDecimal(d Decimal, currency string) Money {
+	return New(mulDivRound(d.units, minorPerMajor, decimalScale), currency)
+}
+
+// Parse parses a plain decimal string such as "1234.56" into money.
+// Magnitudes above one billion are rejected with ErrOutOfRange.
+func Parse(input, currency string) (Money, error) {
+	minor, err := parseFixed(input, minorDigits)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(minor, currency), nil
+}
+
+// Sum adds up all amounts, starting from zero in the given currency.
+func Sum(currency string, amounts ...Money) Money {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		total = total.Add(amount)
+	}
+	return total
+}
+
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of m.
+func (m Money) Sign() int {
+	switch {
+	case m.Minor < 0:
+		return -1
+	case m.Minor > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// InRange reports whether the amount is small enough to be added up and
+// multiplied safely. Amounts beyond it only result from extreme prices,
+// quantities or rates, including products clamped by Mul and Percent.
+func (m Money) InRange() bool {
+	return m.Minor >= -maxMinor && m.Minor <= maxMinor
+}
+
+// Cmp compares m and o and returns -1, 0 or +1.
+func (m Money) Cmp(o Money) int {
+	return m.Sub(o).Sign()
+}
+
+// WithCurrency returns the same amount tagged with another currency.
+func (m Money) WithCurrency(currency string) Money {
+	return New(m.Minor, currency)
+}
+
+// Add returns m+o. An amount without currency adopts the other currency;
+// adding two different currencies is a programming error and panics.
+func (m Money) Add(o Money) Money {
+	return Money{Minor: m.Minor + o.Minor, Currency: mergeCurrency(m, o)}
+}
+
+// Sub returns m-o with the same currency rules as Add.
+func (m Money) Sub(o Money) Money {
+	return Money{Minor: m.Minor - o.Minor, Currency: mergeCurrency(m, o)}
+}
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Mul multiplies the amount by a decimal factor such as a quantity, rounding
+// half away from zero to whole cents.
+func (m Money) Mul(factor Decimal) Money {
+	return Money{Minor: mulDivRound(m.Minor, factor.units, decimalScale), Currency: m.Currency}
+}
+
+// Percent returns rate percent of the amount, rounded to whole cents.
+func (m Money) Percent(rate Decimal) Money {
+	return Money{Minor: mulDivRound(m.Minor, rate.units, decimalScale*100), Currency: m.Currency}
+}
+
+// Interest returns the simple interest at rate percent per year for days out
+// of a year of yearDays days, rounded to whole cents.
+func (m Money) Interest(rate Decimal, days, yearDays int) Money {
+	return Money{Minor: mulDivRound(m.Minor, rate.units*int64(days), decimalScale*100*int64(yearDays)), Currency: m.Currency}
+}
+
+// Decimal returns the amount in major units.
+func (m Money) Decimal() Decimal {
+	return Decimal{units: m.Minor * (decimalScale / minorPerMajor)}
+}
+
+// Amount formats the amount without currency, e.g. "1234.56".
+func (m Money) Amount() string {
+	return formatFixed(m.Minor, minorDigits)
+}
+
+// String formats the amount followed by its currency, e.g. "1234.56 EUR".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount()
+	}
+	return m.Amount() + " " + m.Currency
+}
+
+// UnmarshalJSON accepts the structured representation as well as plain JSON
+// numbers, which older data files used to store float amounts.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '{' {
+		type plain Money
+		var out plain
+		if err := json.Unmarshal(data, &out); err != nil {
+			return err
+		}
+		*m = New(out.Minor, out.Currency)
+		return nil
+	}
+	text := string(data)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(text, "")
+	if err != nil {
+		return fmt.Errorf("money: decode amount: %w", err)
+	}
+	*m = parsed
+	return nil
+}
+
+func mergeCurrency(a, b Money) string {
+	switch {
+	case a.Currency == "":
+		return b.Currency
+	case b.Currency == "" || a.Currency == b.Currency:
+		return a.Currency
+	default:
+		panic(fmt.Sprintf("money: currency mismatch %s/%s", a.Currency, b.Currency))
+	}
+}
+
+func normalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
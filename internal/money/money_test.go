@@ -0,0 +1,128 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoneyParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"1234.56", 123456},
+		{"0.005", 1},
+		{"-0.005", -1},
+		{"0.004", 0},
+		{"10", 1000},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input, "eur")
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if got.Minor != tt.want || got.Currency != "EUR" {
+			t.Errorf("Parse(%q) = %+v, want %d EUR", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want string
+	}{
+		{"mul quantity", New(1999, "EUR").Mul(MustParseDecimal("3")), "59.97 EUR"},
+		{"mul rounds half up", New(1, "EUR").Mul(MustParseDecimal("0.5")), "0.01 EUR"},
+		{"mul rounds negative away from zero", New(-1, "EUR").Mul(MustParseDecimal("0.5")), "-0.01 EUR"},
+		{"percent", New(10000, "EUR").Percent(MustParseDecimal("19")), "19.00 EUR"},
+		{"percent rounding", New(333, "EUR").Percent(MustParseDecimal("7")), "0.23 EUR"},
+		{"interest", New(100000, "EUR").Interest(MustParseDecimal("9.12"), 30, 365), "7.50 EUR"},
+		{"from decimal", FromDecimal(MustParseDecimal("2.345"), "EUR"), "2.35 EUR"},
+		{"sum", Sum("EUR", New(1, "EUR"), New(2, ""), New(-4, "EUR")), "-0.01 EUR"},
+		{"small negative", New(-5, "CHF"), "-0.05 CHF"},
+	}
+	for _, tt := range tests {
+		if got := tt.got.String(); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{input: "1000000000"},
+		{input: "-1000000000"},
+		{input: "1000000000.00001"},
+		{input: "1000000000.0001", err: ErrOutOfRange},
+		{input: "-1e10", err: ErrOutOfRange},
+		{input: "99999999999999999999", err: ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		if _, err := ParseDecimal(tt.input); !errors.Is(err, tt.err) {
+			t.Errorf("ParseDecimal(%q): err = %v, want %v", tt.input, err, tt.err)
+		}
+	}
+	if _, err := Parse("1000000000.01", "EUR"); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Parse: err = %v, want ErrOutOfRange", err)
+	}
+}
+
+func TestMoneyOverflow(t *testing.T) {
+	huge := New(math.MaxInt64/2, "EUR")
+	tests := []struct {
+		name    string
+		got     Money
+		want    int64
+		inRange bool
+	}{
+		{name: "largest parsed product", got: New(100000000000, "EUR").Mul(MustParseDecimal("1000000000")), want: math.MaxInt64},
+		{name: "clamped up", got: huge.Mul(MustParseDecimal("3")), want: math.MaxInt64},
+		{name: "clamped down", got: huge.Mul(MustParseDecimal("-3")), want: math.MinInt64},
+		{name: "percent", got: huge.Percent(MustParseDecimal("1000")), want: math.MaxInt64},
+		{name: "in range", got: New(100000000000, "EUR").Mul(MustParseDecimal("10000")), want: 1000000000000000, inRange: true},
+	}
+	for _, tt := range tests {
+		if tt.got.Minor != tt.want || tt.got.InRange() != tt.inRange {
+			t.Errorf("%s = %d, in range %v, want %d, %v", tt.name, tt.got.Minor, tt.got.InRange(), tt.want, tt.inRange)
+		}
+	}
+}
+
+func TestMoneyCurrencyMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("adding EUR and CHF did not panic")
+		}
+	}()
+	New(1, "EUR").Add(New(1, "CHF"))
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"minor":1050,"currency":"eur"}`, "10.50 EUR"},
+		{`10.5`, "10.50"},
+		{`"0.07"`, "0.07"},
+		{`null`, "0.00"},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.input), &m); err != nil {
+			t.Errorf("unmarshal %s: %v", tt.input, err)
+			continue
+		}
+		if m.String() != tt.want {
+			t.Errorf("unmarshal %s = %s, want %s", tt.input, m, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package numbering
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultPattern is used for profiles that do not configure their own scheme.
+const DefaultPattern = "INV-{YYYY}-{SEQ:4}"
+
+// DefaultCreditPattern numbers credit notes and cancellations of profiles
+// without their own correction scheme.
+const DefaultCreditPattern = "CN-{YYYY}-{SEQ:4}"
+
+// DefaultQuotePattern numbers quotes of profiles without a quote scheme.
+const DefaultQuotePattern = "QUO-{YYYY}-{SEQ:4}"
+
+// maxCustomerTokenLength keeps {CUSTOMER} from dominating the number.
+const maxCustomerTokenLength = 10
+
+var tokenPattern = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// ErrMissingSequence is returned for patterns without a {SEQ} token; such
+// patterns can not produce consecutive, collision free numbers.
+var ErrMissingSequence = errors.New("numbering: pattern must contain {SEQ} or {SEQ:n}")
+
+// Context carries the values substituted into a pattern.
+type Context struct {
+	Date     time.Time
+	Sequence int
+	Customer string
+}
+
+// Validate checks that a pattern only uses known tokens and contains a sequence.
+func Validate(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return nil
+	}
+	hasSequence := false
+	for _, match := range tokenPattern.FindAllStringSubmatch(pattern, -1) {
+		switch match[1] {
+		case "SEQ":
+			hasSequence = true
+		case "YYYY", "YY", "MM", "DD", "CUSTOMER":
+			if match[2] != "" {
+				return fmt.Errorf("numbering: token {%s} does not take a width", match[1])
+			}
+		default:
+			return fmt.Errorf("numbering: unknown token {%s}", match[1])
+		}
+	}
+	if !hasSequence {
+		return ErrMissingSequence
+	}
+	return nil
+}
+
+// Format renders pattern for the given context. An empty pattern falls back to
+// DefaultPattern.
+func Format(pattern string, ctx Context) (string, error) {
+	if strings.TrimSpace(pattern) == "" {
+		pattern = DefaultPattern
+	}
+	if err := Validate(pattern); err != nil {
+		return "", err
+	}
+	out := tokenPattern.ReplaceAllStringFunc(pattern, func(token string) string {
+		match := tokenPattern.FindStringSubmatch(token)
+		switch match[1] {
+		case "YYYY":
+			return ctx.Date.Format("2006")
+		case "YY":
+			return ctx.Date.Format("06")
+		case "MM":
+			return ctx.Date.Format("01")
+		case "DD":
+			return ctx.Date.Format("02")
+		case "CUSTOMER":
+			return customerToken(ctx.Customer)
+		case "SEQ":
+			width, _ := strconv.Atoi(match[2])
+			return fmt.Sprintf("%0*d", width, ctx.Sequence)
+		}
+		return token
+	})
+	return out, nil
+}
+
+// customerToken reduces a customer name to upper-case letters and digits.
+func customerToken(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			continue
+		}
+		b.WriteRune(r)
+		if b.Len() >= maxCustomerTokenLength {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "X"
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package numbering
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2025, time.March, 7, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		pattern  string
+		sequence int
+		customer string
+		want     string
+	}{
+		{"", 1, "", "INV-2025-0001"},
+		{DefaultCreditPattern, 42, "", "CN-2025-0042"},
+		{"{YY}{MM}{DD}-{SEQ}", 7, "", "250307-7"},
+		{"{SEQ:3}", 12345, "", "12345"},
+		{"{CUSTOMER}-{SEQ:2}", 3, "Müller & Söhne GmbH", "MLLERSHNEG-03"},
+		{"{CUSTOMER}/{SEQ}", 1, "ÄÖÜ", "X/1"},
+		{"R {YYYY}/{SEQ:5}", 9, "", "R 2025/00009"},
+	}
+	for _, tt := range tests {
+		got, err := Format(tt.pattern, Context{Date: date, Sequence: tt.sequence, Customer: tt.customer})
+		if err != nil {
+			t.Errorf("Format(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Format(%q, %d) = %q, want %q", tt.pattern, tt.sequence, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"", false},
+		{"INV-{SEQ}", false},
+		{"{YYYY}-{MM}-{SEQ:6}", false},
+		{"INV-{YYYY}", true},
+		{"{YYYY:4}-{SEQ}", true},
+		{"{FOO}-{SEQ}", true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.pattern); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) = %v, want error %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+	if err := Validate("INV-{YYYY}"); !errors.Is(err, ErrMissingSequence) {
+		t.Errorf("Validate without sequence = %v, want ErrMissingSequence", err)
+	}
+}
@@ -0,0 +1,252 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	_ "embed"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+//go:embed fonts/DejaVuSans.ttf
+var bundledRegularData []byte
+
+//go:embed fonts/DejaVuSans-Bold.ttf
+var bundledBoldData []byte
+
+var (
+	bundledOnce    sync.Once
+	bundledRegular *trueTypeFont
+	bundledBold    *trueTypeFont
+	bundledErr     error
+)
+
+func bundledFonts() (*trueTypeFont, *trueTypeFont, error) {
+	bundledOnce.Do(func() {
+		bundledRegular, bundledErr = parseTrueType(bundledRegularData)
+		if bundledErr != nil {
+			return
+		}
+		bundledBold, bundledErr = parseTrueType(bundledBoldData)
+	})
+	return bundledRegular, bundledBold, bundledErr
+}
+
+// fontSet is the regular/bold pair used to render one document.
+type fontSet struct {
+	regular *embeddedFont
+	bold    *embeddedFont
+}
+
+func (s *fontSet) all() []*embeddedFont {
+	return []*embeddedFont{s.regular, s.bold}
+}
+
+// embeddedFont records which glyphs of a font a document uses so only those
+// outlines end up in the file.
+type embeddedFont struct {
+	ref  string
+	font *trueTypeFont
+	used map[uint16]rune
+}
+
+// loadFontSet resolves the profile's font pair. Relative paths are looked up
+// inside assetDir; empty entries use the bundled DejaVu Sans fonts.
+func loadFontSet(pair models.FontPair, assetDir string) (*fontSet, error) {
+	defaultRegular, defaultBold, err := bundledFonts()
+	if err != nil {
+		return nil, fmt.Errorf("pdf: load bundled font: %w", err)
+	}
+	regular, err := loadFontFile(pair.Regular, assetDir, defaultRegular)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := loadFontFile(pair.Bold, assetDir, defaultBold)
+	if err != nil {
+		return nil, err
+	}
+	return &fontSet{
+		regular: &embeddedFont{ref: "/F1", font: regular, used: make(map[uint16]rune)},
+		bold:    &embeddedFont{ref: "/F2", font: bold, used: make(map[uint16]rune)},
+	}, nil
+}
+
+func loadFontFile(path, assetDir string, fallback *trueTypeFont) (*trueTypeFont, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return fallback, nil
+	}
+	path, err := resolveAsset(path, assetDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: read font: %w", err)
+	}
+	font, err := parseTrueType(data)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: font %s: %w", filepath.Base(path), err)
+	}
+	return font, nil
+}
+
+// ErrAssetPath is returned for font and image paths that are absolute or
+// lead out of the data directory.
+var ErrAssetPath = errors.New("pdf: asset path must stay inside the data directory")
+
+// CheckFont reports whether path points to a TrueType font that can be
+// embedded. Relative paths are resolved inside assetDir.
+func CheckFont(path, assetDir string) error {
+	_, err := loadFontFile(path, assetDir, nil)
+	return err
+}
+
+// resolveAsset returns the file of an asset path stored on a profile. Only
+// paths relative to assetDir are accepted, including after following
+// symbolic links, so profiles can not read arbitrary files into a PDF.
+func resolveAsset(path, assetDir string) (string, error) {
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("%w: %s", ErrAssetPath, path)
+	}
+	if assetDir == "" {
+		return path, nil
+	}
+	full := filepath.Join(assetDir, path)
+	real, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		// Missing files are reported when they are read.
+		return full, nil
+	}
+	base, err := filepath.EvalSymlinks(assetDir)
+	if err != nil {
+		return "", fmt.Errorf("pdf: resolve asset directory: %w", err)
+	}
+	if rel, err := filepath.Rel(base, real); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%w: %s", ErrAssetPath, path)
+	}
+	return full, nil
+}
+
+// encode converts text into a hex string of glyph ids for the Identity-H
+// encoding and remembers the glyphs for subsetting.
+func (e *embeddedFont) encode(text string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range text {
+		r, gid := e.font.printable(r)
+		e.used[gid] = r
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+func (e *embeddedFont) width(text string, size float64) float64 {
+	return e.font.textWidth(text, size)
+}
+
+// writeObjects adds the Type0 font with its descendant CID font, descriptor,
+// subset font program and ToUnicode map. It returns the Type0 object number.
+func (e *embeddedFont) writeObjects(w *objectWriter) (int, error) {
+	gids := make([]int, 0, len(e.used))
+	for gid := range e.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+	baseFont := subsetTag(gids) + "+" + e.font.postScriptName
+
+	program := e.font.subset(e.used)
+	compressed, err := deflate(program)
+	if err != nil {
+		return 0, err
+	}
+	fontFile := w.addStream(fmt.Sprintf(" /Filter /FlateDecode /Length1 %d", len(program)), compressed)
+
+	flags := 4
+	if e.font.fixedPitch {
+		flags |= 1
+	}
+	bbox := e.font.bbox
+	descriptor := w.add([]byte(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] /ItalicAngle %0.2f /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>\n",
+		baseFont, flags, bbox[0], bbox[1], bbox[2], bbox[3], e.font.italicAngle, e.font.ascent, e.font.descent, e.font.capHeight, fontFile)))
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, e.font.advance(uint16(gid)))
+	}
+	cidFont := w.add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW %d /W [%s] /CIDToGIDMap /Identity >>\n",
+		baseFont, descriptor, e.font.advance(0), strings.TrimSpace(widths.String()))))
+
+	toUnicode, err := deflate(e.toUnicodeCMap(gids))
+	if err != nil {
+		return 0, err
+	}
+	cmap := w.addStream(" /Filter /FlateDecode", toUnicode)
+
+	return w.add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>\n",
+		baseFont, cidFont, cmap))), nil
+}
+
+// toUnicodeCMap maps every used glyph back to its Unicode character so text
+// can be searched and copied from the PDF.
+func (e *embeddedFont) toUnicodeCMap(gids []int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	buf.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	buf.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	buf.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		end := start + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&buf, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			var unicode strings.Builder
+			for _, unit := range utf16.Encode([]rune{e.used[uint16(gid)]}) {
+				fmt.Fprintf(&unicode, "%04X", unit)
+			}
+			fmt.Fprintf(&buf, "<%04X> <%s>\n", gid, unicode.String())
+		}
+		buf.WriteString("endbfchar\n")
+	}
+	buf.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return buf.Bytes()
+}
+
+// subsetTag derives the six letter prefix that marks a font as a subset.
+func subsetTag(gids []int) string {
+	h := fnv.New32a()
+	for _, gid := range gids {
+		fmt.Fprintf(h, "%d,", gid)
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = byte('A' + sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("pdf: compress stream: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("pdf: compress stream: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,45 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveAsset(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "images"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "images", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.png"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, path string
+		want       string
+		err        error
+	}{
+		{name: "relative", path: "images/logo.png", want: filepath.Join(dir, "images", "logo.png")},
+		{name: "cleaned", path: "images/../logo.png", want: filepath.Join(dir, "logo.png")},
+		{name: "absolute", path: filepath.Join(outside, "secret.png"), err: ErrAssetPath},
+		{name: "parent", path: "../secret.png", err: ErrAssetPath},
+		{name: "escaping", path: "images/../../secret.png", err: ErrAssetPath},
+		{name: "symbolic link", path: "images/link/secret.png", err: ErrAssetPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAsset(tt.path, dir)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/qr"
+)
+
+const (
+	// giroCodeSize is the printed width of the code without its quiet zone,
+	// about 3 cm.
+	giroCodeSize = 85.0
+	// quietZone is the light margin around a QR code in modules.
+	quietZone = 4
+	// EPC069-12 limits the name to 70 and the remittance text to 140
+	// characters and the code to version 13.
+	giroCodeNameLength       = 70
+	giroCodeRemittanceLength = 140
+	giroCodeMaxVersion       = 13
+)
+
+// giroCodePayload returns the EPC069-12 "GiroCode" payload of a SEPA credit
+// transfer of the outstanding amount to the profile's account, with the
+// invoice number as remittance text. ok is false if the invoice can not be
+// paid by SEPA transfer: no IBAN or company name, another currency than EUR,
+// a correction, or nothing outstanding.
+func giroCodePayload(profile models.Profile, invoice models.Invoice) (payload string, ok bool) {
+	iban := compactAccount(profile.PaymentDetails.IBAN)
+	name := truncateRunes(strings.TrimSpace(profile.CompanyName), giroCodeNameLength)
+	if iban == "" || name == "" || invoice.Currency != "EUR" || invoice.IsCorrection() {
+		return "", false
+	}
+	amount := invoice.Outstanding()
+	if amount.Sign() <= 0 {
+		return "", false
+	}
+	lines := []string{
+		"BCD",
+		"002", // version 002 makes the BIC optional within the EEA
+		"1",   // UTF-8
+		"SCT",
+		compactAccount(profile.PaymentDetails.BIC),
+		name,
+		iban,
+		fmt.Sprintf("EUR%d.%02d", amount.Minor/100, amount.Minor%100),
+		"", // purpose code
+		"", // structured creditor reference
+		truncateRunes(invoice.Number, giroCodeRemittanceLength),
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// layoutGiroCode places the GiroCode of the invoice below the payment
+// details, if it has one.
+func layoutGiroCode(d *document, profile models.Profile, invoice models.Invoice) {
+	payload, ok := giroCodePayload(profile, invoice)
+	if !ok {
+		return
+	}
+	code, err := qr.EncodeVersion([]byte(payload), qr.M, 1, giroCodeMaxVersion)
+	if err != nil {
+		return
+	}
+	module := giroCodeSize / float64(code.Size)
+	caption := d.wrap(i18n.T("pdf.text.giroCode", invoice.Outstanding()), contentWidth-giroCodeSize-2*sectionSpace, styleSmall)
+	d.space(sectionSpace)
+	d.ensure(styleBold.lineHeight() + 2*quietZone*module + giroCodeSize)
+	d.line(leftMargin, i18n.T("pdf.section.giroCode"), styleBold, alignLeft)
+
+	top := d.y + styleBold.size - styleBold.lineHeight() - quietZone*module
+	d.qrCode(code, leftMargin, top, module)
+	y := top - styleSmall.size
+	for _, line := range caption {
+		d.textAt(leftMargin+giroCodeSize+2*sectionSpace, y, line, styleSmall, alignLeft)
+		y -= styleSmall.lineHeight()
+	}
+	d.y = top - giroCodeSize - quietZone*module
+}
+
+// qrCode draws code with its upper left corner at x, top. Each run of dark
+// modules in a row becomes one rectangle.
+func (d *document) qrCode(code *qr.Code, x, top, module float64) {
+	for row := 0; row < code.Size; row++ {
+		y := top - float64(row+1)*module
+		for col := 0; col < code.Size; {
+			if !code.Dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < code.Size && code.Dark(col, row) {
+				col++
+			}
+			d.box(x+float64(start)*module, y, float64(col-start)*module, module, 0)
+		}
+	}
+}
+
+// compactAccount removes the spaces of a formatted IBAN or BIC.
+func compactAccount(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
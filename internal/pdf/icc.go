@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+var (
+	srgbOnce    sync.Once
+	srgbProfile []byte
+)
+
+// sRGBProfile returns a compact ICC v2 display profile for sRGB, used as the
+// PDF/A output intent.
+func sRGBProfile() []byte {
+	srgbOnce.Do(func() {
+		srgbProfile = buildSRGBProfile()
+	})
+	return srgbProfile
+}
+
+type iccTag struct {
+	sig  string
+	data []byte
+}
+
+func buildSRGBProfile() []byte {
+	// Primaries and white point adapted to the D50 profile connection space.
+	trc := iccCurve()
+	tags := []iccTag{
+		{"desc", iccDescription("sRGB IEC61966-2.1")},
+		{"cprt", iccText("No copyright, use freely")},
+		{"wtpt", iccXYZ(0.9642, 1.0, 0.8249)},
+		{"rXYZ", iccXYZ(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", iccXYZ(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", iccXYZ(0.1431, 0.0606, 0.7141)},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	const headerSize = 128
+	offset := headerSize + 4 + 12*len(tags)
+	table := make([]byte, 4, 4+12*len(tags))
+	binary.BigEndian.PutUint32(table, uint32(len(tags)))
+	var data []byte
+	for _, tag := range tags {
+		entry := make([]byte, 12)
+		copy(entry, tag.sig)
+		binary.BigEndian.PutUint32(entry[4:], uint32(offset+len(data)))
+		binary.BigEndian.PutUint32(entry[8:], uint32(len(tag.data)))
+		table = append(table, entry...)
+		data = append(data, tag.data...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+
+	header := make([]byte, headerSize)
+	size := headerSize + len(table) + len(data)
+	binary.BigEndian.PutUint32(header[0:], uint32(size))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000) // version 2.1
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	for i, v := range []uint16{2024, 1, 1, 0, 0, 0} {
+		binary.BigEndian.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	copy(header[68:], iccXYZ(0.9642, 1.0, 0.8249)[8:])
+
+	out := append(header, table...)
+	return append(out, data...)
+}
+
+func s15Fixed16(v float64) uint32 {
+	return uint32(int32(math.Round(v * 65536)))
+}
+
+func iccXYZ(x, y, z float64) []byte {
+	b := make([]byte, 20)
+	copy(b, "XYZ ")
+	binary.BigEndian.PutUint32(b[8:], s15Fixed16(x))
+	binary.BigEndian.PutUint32(b[12:], s15Fixed16(y))
+	binary.BigEndian.PutUint32(b[16:], s15Fixed16(z))
+	return b
+}
+
+func iccText(text string) []byte {
+	b := make([]byte, 8, 8+len(text)+1)
+	copy(b, "text")
+	b = append(b, text...)
+	return append(b, 0)
+}
+
+func iccDescription(text string) []byte {
+	b := make([]byte, 12, 12+len(text)+1+12+67)
+	copy(b, "desc")
+	binary.BigEndian.PutUint32(b[8:], uint32(len(text)+1))
+	b = append(b, text...)
+	b = append(b, 0)
+	// Empty Unicode and ScriptCode descriptions.
+	b = append(b, make([]byte, 8)...)
+	b = append(b, make([]byte, 2+1+67)...)
+	return b
+}
+
+// iccCurve samples the sRGB transfer function.
+func iccCurve() []byte {
+	const points = 1024
+	b := make([]byte, 12+2*points)
+	copy(b, "curv")
+	binary.BigEndian.PutUint32(b[8:], points)
+	for i := 0; i < points; i++ {
+		v := float64(i) / (points - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		binary.BigEndian.PutUint16(b[12+2*i:], uint16(math.Round(v*65535)))
+	}
+	return b
+}
@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+const (
+	imageEdgeMargin       = 36.0 // distance of top images from the page edge
+	maxImageHeight        = 90.0
+	defaultLogoWidth      = 120.0
+	defaultSignatureWidth = 140.0
+)
+
+// pdfImage is a decoded PNG or JPEG ready to be written as an image XObject.
+type pdfImage struct {
+	width, height int
+	colorSpace    string
+	filter        string
+	data          []byte
+	alpha         []byte // 8 bit soft mask, nil for opaque images
+}
+
+// CheckImage reports whether path points to an image that can be embedded.
+// Relative paths are resolved inside assetDir.
+func CheckImage(path, assetDir string) error {
+	_, err := loadImage(path, assetDir)
+	return err
+}
+
+func loadImage(path, assetDir string) (*pdfImage, error) {
+	path, err := resolveAsset(path, assetDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: read image: %w", err)
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: image %s: %w", filepath.Base(path), err)
+	}
+	switch format {
+	case "jpeg":
+		return jpegImage(data)
+	case "png":
+		return pngImage(data)
+	}
+	return nil, fmt.Errorf("pdf: image %s: unsupported format %q", filepath.Base(path), format)
+}
+
+// jpegImage embeds RGB and grayscale JPEG data unchanged using the DCT
+// filter. CMYK JPEGs are converted to RGB, since PDF/A output declares an sRGB
+// output intent and device CMYK would not match it.
+func jpegImage(data []byte) (*pdfImage, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: decode jpeg: %w", err)
+	}
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		return &pdfImage{width: cfg.Width, height: cfg.Height, colorSpace: "/DeviceGray", filter: "/DCTDecode", data: data}, nil
+	case color.CMYKModel:
+		src, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("pdf: decode jpeg: %w", err)
+		}
+		return rgbImage(src)
+	}
+	return &pdfImage{width: cfg.Width, height: cfg.Height, colorSpace: "/DeviceRGB", filter: "/DCTDecode", data: data}, nil
+}
+
+// pngImage re-encodes the pixels as deflated RGB with the alpha channel as a
+// separate soft mask.
+func pngImage(data []byte) (*pdfImage, error) {
+	src, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: decode png: %w", err)
+	}
+	return rgbImage(src)
+}
+
+// rgbImage encodes the pixels of src as deflated RGB, adding a soft mask
+// unless the image is opaque.
+func rgbImage(src image.Image) (*pdfImage, error) {
+	bounds := src.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xff {
+				opaque = false
+			}
+		}
+	}
+	compressed, err := deflate(rgb)
+	if err != nil {
+		return nil, err
+	}
+	img := &pdfImage{width: bounds.Dx(), height: bounds.Dy(), colorSpace: "/DeviceRGB", filter: "/FlateDecode", data: compressed}
+	if !opaque {
+		if img.alpha, err = deflate(alpha); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// writeObjects adds the image XObject, preceded by its soft mask, and returns
+// the image object number.
+func (img *pdfImage) writeObjects(w *objectWriter) int {
+	smask := ""
+	if img.alpha != nil {
+		mask := w.addStream(fmt.Sprintf(" /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+			img.width, img.height), img.alpha)
+		smask = fmt.Sprintf(" /SMask %d 0 R", mask)
+	}
+	return w.addStream(fmt.Sprintf(" /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s%s",
+		img.width, img.height, img.colorSpace, img.filter, smask), img.data)
+}
+
+// placedImage is an image together with its position and size on the page.
+type placedImage struct {
+	ref           string
+	image         *pdfImage
+	position      string
+	width, height float64
+}
+
+// loadPlacement loads the image of a profile placement. It returns nil when
+// no image is configured.
+func loadPlacement(p models.ImagePlacement, assetDir, ref string, defaultWidth float64, defaultPosition string) (*placedImage, error) {
+	if strings.TrimSpace(p.Path) == "" {
+		return nil, nil
+	}
+	img, err := loadImage(p.Path, assetDir)
+	if err != nil {
+		return nil, err
+	}
+	width := defaultWidth
+	if p.Width > 0 {
+		width = float64(p.Width)
+	}
+	if width > contentWidth {
+		width = contentWidth
+	}
+	height := width * float64(img.height) / float64(img.width)
+	if height > maxImageHeight {
+		width *= maxImageHeight / height
+		height = maxImageHeight
+	}
+	position := p.Position
+	if position == "" {
+		position = defaultPosition
+	}
+	return &placedImage{ref: ref, image: img, position: position, width: width, height: height}, nil
+}
+
+func (p *placedImage) atTop() bool {
+	return p.position == models.PositionTopLeft || p.position == models.PositionTopRight
+}
+
+// origin returns the lower left corner of the image on the page.
+func (p *placedImage) origin() (float64, float64) {
+	x := leftMargin
+	if p.position == models.PositionTopRight || p.position == models.PositionBottomRight {
+		x = rightMargin - p.width
+	}
+	if p.atTop() {
+		return x, pageHeight - imageEdgeMargin - p.height
+	}
+	return x, footerY + 20
+}
+
+// loadBranding loads the logo and signature configured on the profile.
+func loadBranding(branding models.Branding, assetDir string) ([]*placedImage, error) {
+	var images []*placedImage
+	logo, err := loadPlacement(branding.Logo, assetDir, "/Im1", defaultLogoWidth, models.PositionTopRight)
+	if err != nil {
+		return nil, err
+	}
+	if logo != nil {
+		images = append(images, logo)
+	}
+	signature, err := loadPlacement(branding.Signature, assetDir, "/Im2", defaultSignatureWidth, models.PositionBottomLeft)
+	if err != nil {
+		return nil, err
+	}
+	if signature != nil {
+		images = append(images, signature)
+	}
+	return images, nil
+}
@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+func TestJPEGColorSpace(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	rgb := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	tests := []struct {
+		name       string
+		src        image.Image
+		colorSpace string
+	}{
+		{name: "gray", src: gray, colorSpace: "/DeviceGray"},
+		{name: "rgb", src: rgb, colorSpace: "/DeviceRGB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, tt.src, nil); err != nil {
+				t.Fatal(err)
+			}
+			img, err := jpegImage(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.colorSpace != tt.colorSpace || img.filter != "/DCTDecode" {
+				t.Errorf("image = %s %s, want %s /DCTDecode", img.colorSpace, img.filter, tt.colorSpace)
+			}
+			if !bytes.Equal(img.data, buf.Bytes()) {
+				t.Error("JPEG data was not embedded unchanged")
+			}
+		})
+	}
+}
+
+func TestRGBImageFromCMYK(t *testing.T) {
+	src := image.NewCMYK(image.Rect(0, 0, 2, 1))
+	src.SetCMYK(0, 0, color.CMYK{C: 0xff})
+	src.SetCMYK(1, 0, color.CMYK{K: 0xff})
+	img, err := rgbImage(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.colorSpace != "/DeviceRGB" || img.filter != "/FlateDecode" || img.alpha != nil {
+		t.Fatalf("image = %s %s with alpha %v, want opaque /DeviceRGB /FlateDecode", img.colorSpace, img.filter, img.alpha != nil)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(img.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pixels, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0, 0xff, 0xff, 0, 0, 0}; !bytes.Equal(pixels, want) {
+		t.Errorf("pixels = % X, want % X", pixels, want)
+	}
+}
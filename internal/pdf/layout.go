@@ -0,0 +1,396 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Coordinates are PDF points with the origin in the lower left corner of the
+// page. The document cursor is the baseline of the next line.
+
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignRight
+)
+
+type textStyle struct {
+	bold bool
+	size float64
+}
+
+func (s textStyle) lineHeight() float64 {
+	return s.size * 1.4
+}
+
+var (
+	styleBody  = textStyle{size: 9.5}
+	styleBold  = textStyle{bold: true, size: 9.5}
+	styleTitle = textStyle{bold: true, size: 16}
+	styleSmall = textStyle{size: 8}
+)
+
+const (
+	cellPadding  = 6.0
+	ruleGray     = 0.6
+	ruleWidth    = 0.5
+	strongRule   = 1.0
+	sectionSpace = 12.0
+)
+
+type textOp struct {
+	x, y  float64
+	style textStyle
+	text  string
+}
+
+type ruleOp struct {
+	x1, x2, y, width float64
+}
+
+// boxOp is a filled rectangle with its lower left corner at x, y; gray runs
+// from 0 (black) to 1 (white).
+type boxOp struct {
+	x, y, width, height, gray float64
+}
+
+// page collects the drawing operations of one page. The bottom of a page with
+// a paymentSlip belongs to the Swiss QR-bill: bottom images and the footer
+// rule are left out.
+type page struct {
+	texts       []textOp
+	rules       []ruleOp
+	boxes       []boxOp
+	paymentSlip bool
+}
+
+// document places content top to bottom and starts a new page whenever the
+// next element does not fit above the bottom margin. Images are drawn on
+// every page; the margins grow so that text never runs into them.
+type document struct {
+	fonts  *fontSet
+	images []*placedImage
+	pages  []*page
+	top    float64
+	bottom float64
+	y      float64
+}
+
+func newDocument(fonts *fontSet, images []*placedImage) *document {
+	d := &document{fonts: fonts, images: images, top: topMargin, bottom: bottomMargin}
+	for _, img := range images {
+		_, y := img.origin()
+		if img.atTop() {
+			d.top = min(d.top, y-sectionSpace-styleTitle.size)
+		} else {
+			d.bottom = max(d.bottom, y+img.height+sectionSpace)
+		}
+	}
+	d.newPage()
+	return d
+}
+
+func (d *document) newPage() {
+	d.pages = append(d.pages, &page{})
+	d.y = d.top
+}
+
+func (d *document) current() *page {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *document) font(style textStyle) *embeddedFont {
+	if style.bold {
+		return d.fonts.bold
+	}
+	return d.fonts.regular
+}
+
+func (d *document) fits(height float64) bool {
+	return d.y-height >= d.bottom
+}
+
+func (d *document) ensure(height float64) {
+	if !d.fits(height) {
+		d.newPage()
+	}
+}
+
+func (d *document) space(height float64) {
+	d.y -= height
+}
+
+// textAt places a single line. For alignRight, x is the right edge.
+func (d *document) textAt(x, y float64, text string, style textStyle, align alignment) {
+	text = sanitizeLine(text)
+	if text == "" {
+		return
+	}
+	if align == alignRight {
+		x -= d.font(style).width(text, style.size)
+	}
+	d.current().texts = append(d.current().texts, textOp{x: x, y: y, style: style, text: text})
+}
+
+func (d *document) rule(x1, x2, y, width float64) {
+	d.current().rules = append(d.current().rules, ruleOp{x1: x1, x2: x2, y: y, width: width})
+}
+
+func (d *document) box(x, y, width, height, gray float64) {
+	d.current().boxes = append(d.current().boxes, boxOp{x: x, y: y, width: width, height: height, gray: gray})
+}
+
+// line writes one line at the cursor and moves the cursor down.
+func (d *document) line(x float64, text string, style textStyle, align alignment) {
+	d.ensure(style.lineHeight())
+	d.textAt(x, d.y, text, style, align)
+	d.y -= style.lineHeight()
+}
+
+// paragraph writes text word-wrapped to width, breaking pages as needed.
+func (d *document) paragraph(x, width float64, text string, style textStyle) {
+	for _, line := range d.wrap(text, width, style) {
+		d.line(x, line, style, alignLeft)
+	}
+}
+
+// wrap splits text into lines no wider than width. Explicit line breaks are
+// kept and words that are wider than a whole line are broken up.
+func (d *document) wrap(text string, width float64, style textStyle) []string {
+	font := d.font(style)
+	var out []string
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(sanitizeLine(para))
+		if len(words) == 0 {
+			out = append(out, "")
+			continue
+		}
+		line := ""
+		for _, word := range words {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if font.width(candidate, style.size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				out = append(out, line)
+			}
+			for font.width(word, style.size) > width {
+				cut := fitPrefix(font, word, width, style.size)
+				out = append(out, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// fitPrefix returns the byte length of the longest prefix of word that fits
+// into width, but at least one rune.
+func fitPrefix(font *embeddedFont, word string, width, size float64) int {
+	cut := 0
+	for i, r := range word {
+		if i > 0 && font.width(word[:i+len(string(r))], size) > width {
+			break
+		}
+		cut = i + len(string(r))
+	}
+	return cut
+}
+
+// styledLine is one entry of a textBlock.
+type styledLine struct {
+	text  string
+	style textStyle
+}
+
+// textBlock is a column of lines that is placed next to other blocks.
+type textBlock struct {
+	x, width float64
+	align    alignment
+	lines    []styledLine
+}
+
+func (b *textBlock) add(text string, style textStyle) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	b.lines = append(b.lines, styledLine{text: text, style: style})
+}
+
+// columns draws the blocks side by side starting at the cursor and moves the
+// cursor below the tallest block.
+func (d *document) columns(blocks ...textBlock) {
+	wrapped := make([][]styledLine, len(blocks))
+	tallest := 0.0
+	for i, block := range blocks {
+		height := 0.0
+		for _, line := range block.lines {
+			for _, text := range d.wrap(line.text, block.width, line.style) {
+				wrapped[i] = append(wrapped[i], styledLine{text: text, style: line.style})
+				height += line.style.lineHeight()
+			}
+		}
+		if height > tallest {
+			tallest = height
+		}
+	}
+	d.ensure(tallest)
+	for i, block := range blocks {
+		x := block.x
+		if block.align == alignRight {
+			x += block.width
+		}
+		y := d.y
+		for _, line := range wrapped[i] {
+			d.textAt(x, y, line.text, line.style, block.align)
+			y -= line.style.lineHeight()
+		}
+	}
+	d.y -= tallest
+}
+
+type tableColumn struct {
+	title string
+	width float64
+	align alignment
+}
+
+// table lays out rows whose cells wrap within their column.
+type table struct {
+	x       float64
+	columns []tableColumn
+}
+
+func (t table) width() float64 {
+	total := 0.0
+	for _, col := range t.columns {
+		total += col.width
+	}
+	return total
+}
+
+func (t table) wrapRow(d *document, cells []string, style textStyle) ([][]string, float64) {
+	wrapped := make([][]string, len(t.columns))
+	lines := 1
+	for i, col := range t.columns {
+		if i >= len(cells) {
+			break
+		}
+		wrapped[i] = d.wrap(cells[i], col.width-cellPadding, style)
+		if len(wrapped[i]) > lines {
+			lines = len(wrapped[i])
+		}
+	}
+	return wrapped, float64(lines) * style.lineHeight()
+}
+
+func (t table) rowHeight(d *document, cells []string, style textStyle) float64 {
+	_, height := t.wrapRow(d, cells, style)
+	return height
+}
+
+// row draws one row at the cursor without breaking the page.
+func (t table) row(d *document, cells []string, style textStyle) {
+	wrapped, height := t.wrapRow(d, cells, style)
+	x := t.x
+	for i, col := range t.columns {
+		cellX := x
+		if col.align == alignRight {
+			cellX = x + col.width
+		}
+		if i > 0 && col.align == alignLeft {
+			cellX += cellPadding
+		}
+		y := d.y
+		for _, line := range wrapped[i] {
+			d.textAt(cellX, y, line, style, col.align)
+			y -= style.lineHeight()
+		}
+		x += col.width
+	}
+	d.y -= height
+}
+
+func (t table) headerHeight(d *document) float64 {
+	return t.rowHeight(d, t.titles(), styleBold) + 4
+}
+
+// header draws the column titles followed by a rule.
+func (t table) header(d *document) {
+	t.row(d, t.titles(), styleBold)
+	d.rule(t.x, t.x+t.width(), d.y+styleBold.lineHeight()-3, ruleWidth)
+	d.space(4)
+}
+
+func (t table) titles() []string {
+	titles := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		titles[i] = col.title
+	}
+	return titles
+}
+
+// sanitizeLine trims the text and drops control characters that the fonts
+// can not render.
+func sanitizeLine(line string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(line))
+}
+
+// contentStream renders the drawing operations of one page.
+func (d *document) contentStream(p *page) []byte {
+	var buf bytes.Buffer
+	for _, img := range d.images {
+		if p.paymentSlip && !img.atTop() {
+			continue
+		}
+		x, y := img.origin()
+		buf.WriteString(fmt.Sprintf("q %0.2f 0 0 %0.2f %0.2f %0.2f cm %s Do Q\n", img.width, img.height, x, y, img.ref))
+	}
+	if len(p.rules) > 0 {
+		buf.WriteString(fmt.Sprintf("%0.2f G\n", ruleGray))
+		for _, r := range p.rules {
+			buf.WriteString(fmt.Sprintf("%0.2f w %0.2f %0.2f m %0.2f %0.2f l S\n", r.width, r.x1, r.y, r.x2, r.y))
+		}
+		buf.WriteString("0 G\n")
+	}
+	// Consecutive boxes of the same gray are filled as one path.
+	for i, b := range p.boxes {
+		if i == 0 || b.gray != p.boxes[i-1].gray {
+			buf.WriteString(fmt.Sprintf("%0.2f g\n", b.gray))
+		}
+		buf.WriteString(fmt.Sprintf("%0.3f %0.3f %0.3f %0.3f re\n", b.x, b.y, b.width, b.height))
+		if i == len(p.boxes)-1 || p.boxes[i+1].gray != b.gray {
+			buf.WriteString("f\n")
+		}
+	}
+	if len(p.boxes) > 0 {
+		buf.WriteString("0 g\n")
+	}
+	buf.WriteString("BT\n")
+	var current *embeddedFont
+	var currentSize float64
+	for _, t := range p.texts {
+		font := d.font(t.style)
+		if font != current || t.style.size != currentSize {
+			buf.WriteString(fmt.Sprintf("%s %0.2f Tf\n", font.ref, t.style.size))
+			current, currentSize = font, t.style.size
+		}
+		buf.WriteString(fmt.Sprintf("1 0 0 1 %0.2f %0.2f Tm\n%s Tj\n", t.x, t.y, font.encode(t.text)))
+	}
+	buf.WriteString("ET\n")
+	return buf.Bytes()
+}
@@ -1,36 +1,135 @@
 package pdf
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/janmarkuslanger/invoiceio/internal/einvoice"
 	"github.com/janmarkuslanger/invoiceio/internal/i18n"
 	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
 )
 
 const (
-	pageWidth      = 595.28 // A4 in points
-	pageHeight     = 841.89
-	leftMargin     = 56.0
-	topMargin      = 780.0
-	lineHeight     = 16.0
-	defaultFont    = "Courier"
-	defaultFontRef = "/F1"
+	pageWidth    = 595.28 // A4 in points
+	pageHeight   = 841.89
+	leftMargin   = 56.0
+	rightMargin  = pageWidth - leftMargin
+	contentWidth = rightMargin - leftMargin
+	topMargin    = 780.0
+	bottomMargin = 76.0
+	footerY      = 40.0
 )
 
-// CreateInvoicePDF renders a very small single page PDF invoice document.
-func CreateInvoicePDF(outputPath string, profile models.Profile, customer models.Customer, invoice models.Invoice) error {
+// Option adjusts how a document is rendered.
+type Option func(*options)
+
+type options struct {
+	assetDir string
+	facturX  string
+}
+
+// WithAssetDir sets the directory relative asset paths, such as profile
+// fonts, are resolved against.
+func WithAssetDir(dir string) Option {
+	return func(o *options) {
+		o.assetDir = dir
+	}
+}
+
+// WithFacturX produces a PDF/A-3b hybrid invoice that embeds the invoice as
+// Factur-X / ZUGFeRD XML in the given profile (see einvoice.Levels). An empty
+// level keeps the plain PDF output.
+func WithFacturX(level string) Option {
+	return func(o *options) {
+		o.facturX = level
+	}
+}
+
+// ProfileOptions returns the options configured on a profile, with assets
+// resolved inside assetDir. Every caller rendering stored invoices uses them so
+// the GUI and the command line produce identical documents.
+func ProfileOptions(profile models.Profile, assetDir string) []Option {
+	return []Option{
+		WithAssetDir(assetDir),
+		WithFacturX(profile.EInvoice.FacturXProfile),
+	}
+}
+
+// CheckAssets reports whether the fonts and images configured on a profile
+// lie inside assetDir and can be embedded.
+func CheckAssets(profile models.Profile, assetDir string) error {
+	if _, err := loadFontSet(profile.Fonts, assetDir); err != nil {
+		return err
+	}
+	_, err := loadBranding(profile.Branding, assetDir)
+	return err
+}
+
+// CreateInvoicePDF renders the invoice as a paginated PDF document.
+func CreateInvoicePDF(outputPath string, profile models.Profile, customer models.Customer, invoice models.Invoice, opts ...Option) error {
+	cfg := collectOptions(opts)
+	var archive *pdfArchive
+	if cfg.facturX != "" {
+		data, err := einvoice.CII(profile, customer, invoice, cfg.facturX)
+		if err != nil {
+			return err
+		}
+		archive = &pdfArchive{
+			title:   fmt.Sprintf("%s %s", documentTitle(invoice), invoice.Number),
+			author:  profile.CompanyName,
+			created: time.Now(),
+			level:   cfg.facturX,
+			xml:     data,
+		}
+	}
+	return render(outputPath, profile, cfg, archive, func(d *document) {
+		layoutInvoice(d, profile, customer, invoice)
+	})
+}
+
+// CreateQuotePDF renders a quote in the invoice layout. Quotes are not
+// e-invoices, so a Factur-X option is ignored.
+func CreateQuotePDF(outputPath string, profile models.Profile, customer models.Customer, quote models.Quote, opts ...Option) error {
+	return render(outputPath, profile, collectOptions(opts), nil, func(d *document) {
+		layoutQuote(d, profile, customer, quote)
+	})
+}
+
+func collectOptions(opts []Option) options {
+	var cfg options
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// render lays out a document with the profile's fonts and branding and writes
+// it to outputPath.
+func render(outputPath string, profile models.Profile, cfg options, archive *pdfArchive, layout func(*document)) error {
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
 		return fmt.Errorf("pdf: ensure directory: %w", err)
 	}
+	fonts, err := loadFontSet(profile.Fonts, cfg.assetDir)
+	if err != nil {
+		return err
+	}
+	images, err := loadBranding(profile.Branding, cfg.assetDir)
+	if err != nil {
+		return err
+	}
 
-	lines := buildInvoiceLines(profile, customer, invoice)
-	contentStream := buildContentStream(lines)
-	doc, err := assembleSinglePagePDF(contentStream)
+	d := newDocument(fonts, images)
+	layout(d)
+	contents := make([][]byte, len(d.pages))
+	for i, p := range d.pages {
+		d.pageFooter(p, i+1, len(d.pages))
+		contents[i] = d.contentStream(p)
+	}
+	doc, err := assemblePDF(fonts, images, contents, archive)
 	if err != nil {
 		return err
 	}
@@ -40,149 +139,355 @@ func CreateInvoicePDF(outputPath string, profile models.Profile, customer models
 	return nil
 }
 
-func buildInvoiceLines(profile models.Profile, customer models.Customer, invoice models.Invoice) []string {
-	now := time.Now().Format("2006-01-02 15:04")
-
-	lines := []string{
-		fmt.Sprintf("%s", strings.TrimSpace(profile.DisplayName)),
-		strings.TrimSpace(profile.CompanyName),
-		strings.TrimSpace(profile.AddressLine1),
-		strings.TrimSpace(profile.AddressLine2),
-		fmt.Sprintf("%s %s", strings.TrimSpace(profile.PostalCode), strings.TrimSpace(profile.City)),
-		strings.TrimSpace(profile.Country),
-		i18n.T("pdf.label.email", strings.TrimSpace(profile.Email)),
-		i18n.T("pdf.label.phone", strings.TrimSpace(profile.Phone)),
-		i18n.T("pdf.label.taxID", strings.TrimSpace(profile.TaxID)),
-		"",
-		i18n.T("pdf.label.invoiceNumber", invoice.Number),
-		i18n.T("pdf.label.issuedOn", invoice.IssueDate.Format("2006-01-02")),
-		i18n.T("pdf.label.dueDate", invoice.DueDate.Format("2006-01-02")),
-		i18n.T("pdf.label.generatedOn", now),
-	}
-	if !invoice.PaidAt.IsZero() {
-		lines = append(lines, i18n.T("pdf.label.paidOn", invoice.PaidAt.Format("2006-01-02")))
-	}
-	lines = append(lines,
-		"",
-		i18n.T("pdf.section.billTo"),
-		strings.TrimSpace(customer.DisplayName),
-		strings.TrimSpace(customer.ContactName),
-		strings.TrimSpace(customer.AddressLine1),
-		strings.TrimSpace(customer.AddressLine2),
-		fmt.Sprintf("%s %s", strings.TrimSpace(customer.PostalCode), strings.TrimSpace(customer.City)),
-		strings.TrimSpace(customer.Country),
-		i18n.T("pdf.label.email", strings.TrimSpace(customer.Email)),
-		i18n.T("pdf.label.phone", strings.TrimSpace(customer.Phone)),
-		"",
-		i18n.T("pdf.section.items"),
-		fmt.Sprintf("%-40s %6s %10s %12s",
-			i18n.T("pdf.items.column.description"),
-			i18n.T("pdf.items.column.quantity"),
-			i18n.T("pdf.items.column.unit"),
-			i18n.T("pdf.items.column.lineTotal"),
-		),
-		strings.Repeat("-", 70),
-	)
-
-	for _, item := range invoice.Items {
-		lines = append(lines, fmt.Sprintf("%-40s %6.2f %10.2f %12.2f", item.Description, item.Quantity, item.UnitPrice, item.LineTotal))
-	}
-
-	lines = append(lines,
-		strings.Repeat("-", 70),
-		fmt.Sprintf("%-40s %28.2f", i18n.T("pdf.label.subtotal"), invoice.Subtotal),
-		fmt.Sprintf("%-40s %27.2f (%0.2f%%)", i18n.T("pdf.label.tax"), invoice.TaxAmount, invoice.TaxRatePercent),
-		fmt.Sprintf("%-40s %28.2f", i18n.T("pdf.label.total"), invoice.Total),
-	)
-
-	if strings.TrimSpace(invoice.Notes) != "" {
-		lines = append(lines, "", i18n.T("pdf.section.notes"))
-		for _, line := range strings.Split(invoice.Notes, "\n") {
-			lines = append(lines, line)
-		}
+// layoutInvoice places the invoice: sender and recipient side by side, the
+// invoice data, the item table, totals, notes, payment details and the
+// GiroCode or QR-bill.
+func layoutInvoice(d *document, profile models.Profile, customer models.Customer, invoice models.Invoice) {
+	meta := metaBlock()
+	meta.add(documentTitle(invoice), styleTitle)
+	if invoice.IsCorrection() {
+		meta.add(i18n.T("pdf.label.documentNumber", invoice.Number), styleBold)
+		meta.add(i18n.T("pdf.label.corrects", invoice.OriginalNumber), styleBody)
+		meta.add(i18n.T("pdf.label.issuedOn", invoice.IssueDate.Format("2006-01-02")), styleBody)
+	} else {
+		meta.add(i18n.T("pdf.label.invoiceNumber", invoice.Number), styleBold)
+		meta.add(i18n.T("pdf.label.issuedOn", invoice.IssueDate.Format("2006-01-02")), styleBody)
+		meta.add(i18n.T("pdf.label.dueDate", invoice.DueDate.Format("2006-01-02")), styleBody)
+	}
+	if invoice.IsSettled() && len(invoice.Payments) > 0 {
+		meta.add(i18n.T("pdf.label.paidOn", invoice.LastPaymentDate().Format("2006-01-02")), styleBody)
 	}
+	meta.add(i18n.T("pdf.label.generatedOn", time.Now().Format("2006-01-02 15:04")), styleSmall)
+	layoutParties(d, profile, customer, meta, i18n.T("pdf.section.billTo"))
 
-	lines = append(lines, "", i18n.T("pdf.section.paymentDetails"))
+	switch invoice.Type() {
+	case models.DocumentCancellation:
+		d.paragraph(leftMargin, contentWidth, i18n.T("pdf.text.cancellation", invoice.OriginalNumber), styleBody)
+		d.space(sectionSpace)
+	case models.DocumentCreditNote:
+		d.paragraph(leftMargin, contentWidth, i18n.T("pdf.text.creditNote", invoice.OriginalNumber), styleBody)
+		d.space(sectionSpace)
+	}
+	layoutItems(d, invoice.Items, invoice.Currency)
+	layoutTotals(d, invoice.Subtotal, invoice.TaxBreakdown, invoice.Total)
+	if invoice.PaymentStatus() == models.PaymentPartiallyPaid {
+		layoutBalance(d, invoice.AmountPaid(), invoice.Outstanding())
+	}
+	layoutExemption(d, invoice)
+	layoutNotes(d, invoice.Notes)
+	layoutPaymentDetails(d, profile, paymentTerms(profile, invoice))
+	// The QR-bill replaces the GiroCode for Swiss accounts.
+	if bill, ok := newQRBill(profile, customer, invoice); ok {
+		layoutQRBill(d, bill)
+	} else {
+		layoutGiroCode(d, profile, invoice)
+	}
+}
+
+// paymentTerms describes the payment terms of an invoice: the free text of
+// the profile, the early-payment discounts and the net period. Corrections
+// have none.
+func paymentTerms(profile models.Profile, invoice models.Invoice) []string {
+	if invoice.IsCorrection() {
+		return nil
+	}
+	var lines []string
+	if profile.PaymentDetails.PaymentTerms != "" {
+		lines = append(lines, i18n.T("pdf.label.terms", profile.PaymentDetails.PaymentTerms))
+	}
+	for _, offer := range invoice.DiscountOffers() {
+		lines = append(lines, i18n.T("pdf.label.discount", offer.Percent, offer.Deadline.Format("2006-01-02"), offer.Discount, offer.Amount))
+	}
+	if invoice.Terms.NetDays > 0 {
+		lines = append(lines, i18n.T("pdf.label.netTerms", invoice.Terms.NetDays, invoice.DueDate.Format("2006-01-02")))
+	}
+	return lines
+}
+
+// layoutPaymentDetails lists the bank account of the profile followed by the
+// given payment terms.
+func layoutPaymentDetails(d *document, profile models.Profile, terms []string) {
+	payment := []string{}
 	if profile.PaymentDetails.BankName != "" {
-		lines = append(lines, i18n.T("pdf.label.bank", profile.PaymentDetails.BankName))
+		payment = append(payment, i18n.T("pdf.label.bank", profile.PaymentDetails.BankName))
 	}
 	if profile.PaymentDetails.IBAN != "" {
-		lines = append(lines, i18n.T("pdf.label.iban", profile.PaymentDetails.IBAN))
+		payment = append(payment, i18n.T("pdf.label.iban", profile.PaymentDetails.IBAN))
 	}
 	if profile.PaymentDetails.BIC != "" {
-		lines = append(lines, i18n.T("pdf.label.bic", profile.PaymentDetails.BIC))
+		payment = append(payment, i18n.T("pdf.label.bic", profile.PaymentDetails.BIC))
 	}
-	if profile.PaymentDetails.PaymentTerms != "" {
-		lines = append(lines, i18n.T("pdf.label.terms", profile.PaymentDetails.PaymentTerms))
+	payment = append(payment, terms...)
+	if len(payment) > 0 {
+		d.space(sectionSpace)
+		d.ensure(styleBold.lineHeight() + styleBody.lineHeight())
+		d.line(leftMargin, i18n.T("pdf.section.paymentDetails"), styleBold, alignLeft)
+		for _, line := range payment {
+			d.paragraph(leftMargin, contentWidth, line, styleBody)
+		}
 	}
+}
 
-	return sanitizeLines(lines)
+// documentTitle names the document type in the heading.
+func documentTitle(invoice models.Invoice) string {
+	switch invoice.Type() {
+	case models.DocumentCreditNote:
+		return i18n.T("pdf.title.creditNote")
+	case models.DocumentCancellation:
+		return i18n.T("pdf.title.cancellation")
+	default:
+		return i18n.T("pdf.title")
+	}
 }
 
-func sanitizeLines(lines []string) []string {
-	out := make([]string, len(lines))
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			out[i] = " "
-			continue
+// layoutQuote places a quote like an invoice, with its validity instead of
+// payment details.
+func layoutQuote(d *document, profile models.Profile, customer models.Customer, quote models.Quote) {
+	meta := metaBlock()
+	meta.add(i18n.T("pdf.title.quote"), styleTitle)
+	meta.add(i18n.T("pdf.label.quoteNumber", quote.Number), styleBold)
+	meta.add(i18n.T("pdf.label.quoteDate", quote.IssueDate.Format("2006-01-02")), styleBody)
+	if !quote.ValidUntil.IsZero() {
+		meta.add(i18n.T("pdf.label.validUntil", quote.ValidUntil.Format("2006-01-02")), styleBody)
+	}
+	meta.add(i18n.T("pdf.label.generatedOn", time.Now().Format("2006-01-02 15:04")), styleSmall)
+	layoutParties(d, profile, customer, meta, i18n.T("pdf.section.quoteFor"))
+
+	d.paragraph(leftMargin, contentWidth, i18n.T("pdf.text.quote"), styleBody)
+	d.space(sectionSpace)
+	layoutItems(d, quote.Items, quote.Currency)
+	layoutTotals(d, quote.Subtotal, quote.TaxBreakdown, quote.Total)
+	layoutNotes(d, quote.Notes)
+
+	if !quote.ValidUntil.IsZero() {
+		d.space(sectionSpace)
+		d.paragraph(leftMargin, contentWidth, i18n.T("pdf.text.quoteValidity", quote.ValidUntil.Format("2006-01-02")), styleBody)
+	}
+	if terms := profile.PaymentDetails.PaymentTerms; terms != "" {
+		d.paragraph(leftMargin, contentWidth, i18n.T("pdf.label.terms", terms), styleBody)
+	}
+}
+
+// metaBlock returns the right-hand column next to the sender for the document
+// data.
+func metaBlock() textBlock {
+	return textBlock{x: leftMargin + contentWidth/2, width: contentWidth / 2, align: alignRight}
+}
+
+// layoutParties places the sender next to meta, followed by the recipient
+// under the given heading and its contact details.
+func layoutParties(d *document, profile models.Profile, customer models.Customer, meta textBlock, recipientTitle string) {
+	half := contentWidth / 2
+
+	sender := textBlock{x: leftMargin, width: half - sectionSpace}
+	sender.add(profile.DisplayName, styleTitle)
+	sender.add(profile.CompanyName, styleBody)
+	sender.add(profile.AddressLine1, styleBody)
+	sender.add(profile.AddressLine2, styleBody)
+	sender.add(strings.TrimSpace(profile.PostalCode+" "+profile.City), styleBody)
+	sender.add(profile.Country, styleBody)
+	if profile.Email != "" {
+		sender.add(i18n.T("pdf.label.email", profile.Email), styleSmall)
+	}
+	if profile.Phone != "" {
+		sender.add(i18n.T("pdf.label.phone", profile.Phone), styleSmall)
+	}
+	if profile.TaxID != "" {
+		sender.add(i18n.T("pdf.label.taxID", profile.TaxID), styleSmall)
+	}
+	d.columns(sender, meta)
+
+	d.space(2 * sectionSpace)
+	billTo := textBlock{x: leftMargin, width: half - sectionSpace}
+	billTo.add(recipientTitle, styleBold)
+	billTo.add(customer.DisplayName, styleBody)
+	billTo.add(customer.ContactName, styleBody)
+	billTo.add(customer.AddressLine1, styleBody)
+	billTo.add(customer.AddressLine2, styleBody)
+	billTo.add(strings.TrimSpace(customer.PostalCode+" "+customer.City), styleBody)
+	billTo.add(customer.Country, styleBody)
+	contact := textBlock{x: leftMargin + half, width: half, align: alignRight}
+	if customer.Email != "" {
+		contact.add(i18n.T("pdf.label.email", customer.Email), styleSmall)
+	}
+	if customer.Phone != "" {
+		contact.add(i18n.T("pdf.label.phone", customer.Phone), styleSmall)
+	}
+	if customer.VATID != "" {
+		contact.add(i18n.T("pdf.label.vatID", customer.VATID), styleSmall)
+	}
+	d.columns(billTo, contact)
+	d.space(2 * sectionSpace)
+}
+
+// layoutExemption states below the totals why the items without VAT are not
+// taxed.
+func layoutExemption(d *document, invoice models.Invoice) {
+	reason := strings.TrimSpace(invoice.TaxExemption.Reason)
+	if reason == "" || !hasUntaxedItems(invoice.Items) {
+		return
+	}
+	d.space(sectionSpace)
+	d.paragraph(leftMargin, contentWidth, reason, styleBody)
+}
+
+func hasUntaxedItems(items []models.InvoiceItem) bool {
+	for _, item := range items {
+		if item.TaxRatePercent.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+func layoutNotes(d *document, notes string) {
+	if strings.TrimSpace(notes) == "" {
+		return
+	}
+	d.space(sectionSpace)
+	d.line(leftMargin, i18n.T("pdf.section.notes"), styleBold, alignLeft)
+	d.paragraph(leftMargin, contentWidth, notes, styleBody)
+}
+
+// layoutItems draws the item table. When it breaks across pages the running
+// subtotal is carried forward and the column titles are repeated.
+func layoutItems(d *document, items []models.InvoiceItem, currency string) {
+	t := table{x: leftMargin, columns: []tableColumn{
+		{title: i18n.T("pdf.items.column.description"), width: contentWidth - 255},
+		{title: i18n.T("pdf.items.column.quantity"), width: 50, align: alignRight},
+		{title: i18n.T("pdf.items.column.taxRate"), width: 45, align: alignRight},
+		{title: i18n.T("pdf.items.column.unit"), width: 75, align: alignRight},
+		{title: i18n.T("pdf.items.column.lineTotal"), width: 85, align: alignRight},
+	}}
+	carry := func(label string, amount money.Money) []string {
+		return []string{label, "", "", "", amount.String()}
+	}
+
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		rows[i] = []string{
+			item.Description,
+			item.Quantity.StringFixed(2),
+			item.TaxRatePercent.String() + "%",
+			item.UnitPrice.Amount(),
+			item.LineTotal.Amount(),
 		}
-		out[i] = line
-	}
-	return out
-}
-
-func buildContentStream(lines []string) []byte {
-	var buf bytes.Buffer
-	buf.WriteString("BT\n")
-	buf.WriteString(fmt.Sprintf("%s %0.2f Tf\n", defaultFontRef, 12.0))
-	buf.WriteString(fmt.Sprintf("%0.2f TL\n", lineHeight))
-	buf.WriteString(fmt.Sprintf("1 0 0 1 %0.2f %0.2f Tm\n", leftMargin, topMargin))
-	for _, line := range lines {
-		buf.WriteString(fmt.Sprintf("(%s) Tj\nT*\n", escapePDFString(line)))
-	}
-	buf.WriteString("ET\n")
-	return buf.Bytes()
-}
-
-func assembleSinglePagePDF(content []byte) ([]byte, error) {
-	var doc bytes.Buffer
-	doc.WriteString("%PDF-1.4\n")
-
-	objects := make([][]byte, 0, 5)
-	objects = append(objects, []byte("<< /Type /Catalog /Pages 2 0 R >>\n"))
-	objects = append(objects, []byte("<< /Type /Pages /Kids [3 0 R] /Count 1 >>\n"))
-	pageObj := fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %0.2f %0.2f] /Contents 4 0 R /Resources << /Font << %s 5 0 R >> >> >>\n", pageWidth, pageHeight, defaultFontRef)
-	objects = append(objects, []byte(pageObj))
-	stream := fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream\n", len(content), content)
-	objects = append(objects, []byte(stream))
-	fontObj := fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s >>\n", defaultFont)
-	objects = append(objects, []byte(fontObj))
-
-	offsets := make([]int, len(objects)+1)
-	for i, obj := range objects {
-		offsets[i+1] = doc.Len()
-		doc.WriteString(fmt.Sprintf("%d 0 obj\n", i+1))
-		doc.Write(obj)
-		doc.WriteString("endobj\n")
-	}
-
-	xrefOffset := doc.Len()
-	doc.WriteString("xref\n")
-	doc.WriteString(fmt.Sprintf("0 %d\n", len(objects)+1))
-	doc.WriteString("0000000000 65535 f \n")
-	for i := 1; i <= len(objects); i++ {
-		doc.WriteString(fmt.Sprintf("%010d 00000 n \n", offsets[i]))
-	}
-	doc.WriteString("trailer\n")
-	doc.WriteString(fmt.Sprintf("<< /Size %d /Root 1 0 R >>\n", len(objects)+1))
-	doc.WriteString("startxref\n")
-	doc.WriteString(fmt.Sprintf("%d\n", xrefOffset))
-	doc.WriteString("%%EOF\n")
-	return doc.Bytes(), nil
-}
-
-func escapePDFString(in string) string {
-	replacer := strings.NewReplacer("(", "\\(", ")", "\\)", "\\", "\\\\")
-	return replacer.Replace(in)
+	}
+
+	// Keep the column titles together with the first row and a carry row.
+	carryHeight := styleBody.lineHeight()
+	first := carryHeight
+	if len(rows) > 0 {
+		first = t.rowHeight(d, rows[0], styleBody)
+	}
+	d.ensure(t.headerHeight(d) + first + carryHeight)
+	t.header(d)
+
+	running := money.Zero(currency)
+	for i, cells := range rows {
+		if i > 0 && !d.fits(t.rowHeight(d, cells, styleBody)+carryHeight) {
+			t.row(d, carry(i18n.T("pdf.label.carriedForward"), running), styleBold)
+			d.newPage()
+			t.header(d)
+			t.row(d, carry(i18n.T("pdf.label.broughtForward"), running), styleBold)
+		}
+		t.row(d, cells, styleBody)
+		running = running.Add(items[i].LineTotal)
+	}
+	d.rule(leftMargin, rightMargin, d.y+styleBody.lineHeight()-3, ruleWidth)
+	d.space(4)
+}
+
+// layoutTotals right-aligns subtotal, one line per tax rate and the total.
+func layoutTotals(d *document, subtotal money.Money, breakdown []models.TaxLine, grandTotal money.Money) {
+	lines := 2 + len(breakdown)
+	d.ensure(float64(lines)*styleBody.lineHeight() + 4)
+	totalLine(d, i18n.T("pdf.label.subtotal"), subtotal, styleBody)
+	for _, tax := range breakdown {
+		totalLine(d, i18n.T("pdf.label.taxRate", tax.RatePercent.String(), tax.Net.Amount()), tax.Tax, styleBody)
+	}
+	d.rule(totalsLabelX, rightMargin, d.y+styleBody.lineHeight()-3, strongRule)
+	d.space(4)
+	totalLine(d, i18n.T("pdf.label.total"), grandTotal, styleBold)
+}
+
+// layoutBalance follows the totals of a partly paid invoice with the amount
+// already received and the balance still due.
+func layoutBalance(d *document, paid, outstanding money.Money) {
+	d.ensure(2 * styleBody.lineHeight())
+	totalLine(d, i18n.T("pdf.label.amountPaid"), paid, styleBody)
+	totalLine(d, i18n.T("pdf.label.amountDue"), outstanding, styleBold)
+}
+
+// totalsLabelX is where the labels of the totals block start.
+const totalsLabelX = rightMargin - 85 - 190
+
+func totalLine(d *document, label string, amount money.Money, style textStyle) {
+	d.ensure(style.lineHeight())
+	d.textAt(totalsLabelX, d.y, label, style, alignLeft)
+	d.textAt(rightMargin, d.y, amount.String(), style, alignRight)
+	d.space(style.lineHeight())
+}
+
+// pageFooter adds the page number to the bottom right of a page, or above
+// the separator line of a QR-bill payment part.
+func (d *document) pageFooter(p *page, number, count int) {
+	text := i18n.T("pdf.label.page", number, count)
+	width := d.fonts.regular.width(text, styleSmall.size)
+	if p.paymentSlip {
+		p.texts = append(p.texts, textOp{x: rightMargin - width, y: slipHeight + slipCaptionGap, style: styleSmall, text: text})
+		return
+	}
+	p.rules = append(p.rules, ruleOp{x1: leftMargin, x2: rightMargin, y: footerY + 12, width: ruleWidth})
+	p.texts = append(p.texts, textOp{x: rightMargin - width, y: footerY, style: styleSmall, text: text})
+}
+
+// assemblePDF writes one page per content stream. The fonts are embedded
+// after all pages were encoded so their subsets cover every used glyph. A
+// non-nil archive turns the output into PDF/A-3b.
+func assemblePDF(fonts *fontSet, images []*placedImage, contents [][]byte, archive *pdfArchive) ([]byte, error) {
+	if len(contents) == 0 {
+		return nil, fmt.Errorf("pdf: document has no pages")
+	}
+	w := &objectWriter{}
+	catalog := w.reserve()
+	pagesNum := w.reserve()
+
+	var fontRefs strings.Builder
+	for _, font := range fonts.all() {
+		num, err := font.writeObjects(w)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&fontRefs, " %s %d 0 R", font.ref, num)
+	}
+	resources := fmt.Sprintf("/Font <<%s >>", fontRefs.String())
+	if len(images) > 0 {
+		var imageRefs strings.Builder
+		for _, img := range images {
+			fmt.Fprintf(&imageRefs, " %s %d 0 R", img.ref, img.image.writeObjects(w))
+		}
+		resources += fmt.Sprintf(" /XObject <<%s >>", imageRefs.String())
+	}
+
+	kids := make([]string, 0, len(contents))
+	for _, content := range contents {
+		stream := w.addStream("", content)
+		page := w.add([]byte(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %0.2f %0.2f] /Contents %d 0 R /Resources << %s >> >>\n",
+			pagesNum, pageWidth, pageHeight, stream, resources)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	catalogExtra, trailer := "", ""
+	if archive != nil {
+		entries, err := archive.catalogEntries(w)
+		if err != nil {
+			return nil, err
+		}
+		catalogExtra, trailer = entries, archive.trailerID()
+	}
+
+	w.set(catalog, []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R%s >>\n", pagesNum, catalogExtra)))
+	w.set(pagesNum, []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(kids))))
+	return w.bytes(catalog, trailer), nil
 }
@@ -0,0 +1,123 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/einvoice"
+)
+
+// pdfArchive carries what is needed to write PDF/A-3b: XMP metadata, an
+// sRGB output intent and, for hybrid invoices, the embedded Factur-X XML.
+type pdfArchive struct {
+	title   string
+	author  string
+	created time.Time
+	level   string // Factur-X profile, empty for plain PDF/A
+	xml     []byte
+}
+
+// catalogEntries writes the archive objects and returns the entries that have
+// to be added to the document catalog.
+func (a *pdfArchive) catalogEntries(w *objectWriter) (string, error) {
+	metadata := w.addStream(" /Type /Metadata /Subtype /XML", a.xmp())
+
+	icc, err := deflate(sRGBProfile())
+	if err != nil {
+		return "", err
+	}
+	profile := w.addStream(" /N 3 /Filter /FlateDecode", icc)
+	entries := fmt.Sprintf(" /Metadata %d 0 R /OutputIntents [<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier (sRGB IEC61966-2.1) /Info (sRGB IEC61966-2.1) /DestOutputProfile %d 0 R >>]",
+		metadata, profile)
+
+	if a.xml == nil {
+		return entries, nil
+	}
+	compressed, err := deflate(a.xml)
+	if err != nil {
+		return "", err
+	}
+	file := w.addStream(fmt.Sprintf(" /Type /EmbeddedFile /Subtype /text#2Fxml /Filter /FlateDecode /Params << /ModDate (%s) /Size %d >>",
+		pdfDate(a.created), len(a.xml)), compressed)
+	name := einvoice.FacturXFileName
+	spec := w.add([]byte(fmt.Sprintf("<< /Type /Filespec /F (%s) /UF (%s) /Desc (Factur-X invoice) /AFRelationship /%s /EF << /F %d 0 R /UF %d 0 R >> >>\n",
+		name, name, a.relationship(), file, file)))
+	entries += fmt.Sprintf(" /Names << /EmbeddedFiles << /Names [(%s) %d 0 R] >> >> /AF [%d 0 R]", name, spec, spec)
+	return entries, nil
+}
+
+// relationship follows the Factur-X specification: the XML of the smaller
+// profiles only supplements the PDF, from BASIC on it is an equivalent
+// representation.
+func (a *pdfArchive) relationship() string {
+	if a.level == einvoice.LevelMinimum {
+		return "Data"
+	}
+	return "Alternative"
+}
+
+// trailerID returns the file identifier required by PDF/A.
+func (a *pdfArchive) trailerID() string {
+	sum := md5.Sum([]byte(a.title + a.created.String()))
+	return fmt.Sprintf(" /ID [<%x> <%x>]", sum, sum)
+}
+
+func (a *pdfArchive) xmp() []byte {
+	var buf bytes.Buffer
+	esc := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	created := a.created.Format(time.RFC3339)
+
+	buf.WriteString("<?xpacket begin=\"\xef\xbb\xbf\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	buf.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\">\n")
+	buf.WriteString("<pdfaid:part>3</pdfaid:part>\n<pdfaid:conformance>B</pdfaid:conformance>\n</rdf:Description>\n")
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:dc=\"http://purl.org/dc/elements/1.1/\">\n")
+	fmt.Fprintf(&buf, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", esc(a.title))
+	fmt.Fprintf(&buf, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n</rdf:Description>\n", esc(a.author))
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\">\n")
+	fmt.Fprintf(&buf, "<xmp:CreatorTool>invoiceio</xmp:CreatorTool>\n<xmp:CreateDate>%s</xmp:CreateDate>\n<xmp:ModifyDate>%s</xmp:ModifyDate>\n</rdf:Description>\n", created, created)
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\">\n<pdf:Producer>invoiceio</pdf:Producer>\n</rdf:Description>\n")
+	if a.xml != nil {
+		buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:fx=\"urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#\">\n")
+		fmt.Fprintf(&buf, "<fx:DocumentType>INVOICE</fx:DocumentType>\n<fx:DocumentFileName>%s</fx:DocumentFileName>\n", einvoice.FacturXFileName)
+		fmt.Fprintf(&buf, "<fx:Version>1.0</fx:Version>\n<fx:ConformanceLevel>%s</fx:ConformanceLevel>\n</rdf:Description>\n", einvoice.ConformanceLevel(a.level))
+		buf.WriteString(facturXExtensionSchema)
+	}
+	buf.WriteString("</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return buf.Bytes()
+}
+
+// facturXExtensionSchema declares the fx properties as PDF/A requires for
+// custom XMP schemas.
+const facturXExtensionSchema = `<rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/" xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#" xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">
+<pdfaExtension:schemas><rdf:Bag><rdf:li rdf:parseType="Resource">
+<pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>
+<pdfaSchema:namespaceURI>urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#</pdfaSchema:namespaceURI>
+<pdfaSchema:prefix>fx</pdfaSchema:prefix>
+<pdfaSchema:property><rdf:Seq>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>DocumentFileName</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>The name of the embedded XML document</pdfaProperty:description></rdf:li>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>DocumentType</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>The type of the hybrid document in capital letters, e.g. INVOICE or ORDER</pdfaProperty:description></rdf:li>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>Version</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>The actual version of the standard applying to the embedded XML document</pdfaProperty:description></rdf:li>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>ConformanceLevel</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>The conformance level of the embedded XML document</pdfaProperty:description></rdf:li>
+</rdf:Seq></pdfaSchema:property>
+</rdf:li></rdf:Bag></pdfaExtension:schemas>
+</rdf:Description>
+`
+
+// pdfDate formats t as a PDF date string.
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("D:%s%c%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
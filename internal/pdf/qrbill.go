@@ -0,0 +1,389 @@
+package pdf
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/janmarkuslanger/invoiceio/internal/einvoice"
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/qr"
+)
+
+// Dimensions of the QR-bill payment part (Swiss Implementation Guidelines
+// QR-bill, section 3.4) in points: an A6 landscape strip at the bottom of the
+// page with the receipt on the left and the payment part on the right.
+const (
+	mm             = 72 / 25.4
+	slipHeight     = 105 * mm
+	slipMargin     = 5 * mm
+	receiptWidth   = 62 * mm
+	slipQRSize     = 46 * mm
+	slipCrossSize  = 7 * mm
+	slipInfoX      = 118 * mm
+	slipCaptionGap = 4.0
+	// The payment part may use QR code versions up to 25.
+	qrBillMaxVersion = 25
+)
+
+var (
+	slipTitle          = textStyle{bold: true, size: 11}
+	receiptHeading     = textStyle{bold: true, size: 6}
+	receiptValue       = textStyle{size: 8}
+	paymentPartHeading = textStyle{bold: true, size: 8}
+	paymentPartValue   = textStyle{size: 10}
+)
+
+// qrBillAddress is a structured address ("S") of the QR-bill.
+type qrBillAddress struct {
+	name, street, postalCode, town, country string
+}
+
+func (a qrBillAddress) isZero() bool {
+	return a.name == ""
+}
+
+// fields returns the seven payload lines of the address; an unknown address
+// leaves them empty.
+func (a qrBillAddress) fields() []string {
+	if a.isZero() {
+		return make([]string, 7)
+	}
+	return []string{"S", truncateRunes(a.name, 70), truncateRunes(a.street, 70), "", truncateRunes(a.postalCode, 16), truncateRunes(a.town, 35), a.country}
+}
+
+// lines returns the address as printed on the payment part.
+func (a qrBillAddress) lines() []string {
+	lines := []string{a.name}
+	if a.street != "" {
+		lines = append(lines, a.street)
+	}
+	town := strings.TrimSpace(a.postalCode + " " + a.town)
+	if a.country != "CH" && a.country != "LI" {
+		town = a.country + "-" + town
+	}
+	return append(lines, town)
+}
+
+// newQRBillAddress returns the address if it is complete enough for the
+// QR-bill: a name, postal code, town and a known country.
+func newQRBillAddress(name, street, postalCode, town, country string) (qrBillAddress, bool) {
+	a := qrBillAddress{
+		name:       slipText(name),
+		street:     slipText(street),
+		postalCode: slipText(postalCode),
+		town:       slipText(town),
+		country:    einvoice.CountryCode(country),
+	}
+	if a.name == "" || a.postalCode == "" || a.town == "" || a.country == "" {
+		return qrBillAddress{}, false
+	}
+	return a, true
+}
+
+// Reference types of the QR-bill.
+const (
+	qrReferenceQRR  = "QRR"  // 27 digit QR reference, only with a QR-IBAN
+	qrReferenceSCOR = "SCOR" // ISO 11649 creditor reference
+	qrReferenceNone = "NON"
+)
+
+// qrBill is the content of a QR-bill payment part.
+type qrBill struct {
+	iban          string
+	creditor      qrBillAddress
+	amount        money.Money
+	debtor        qrBillAddress
+	referenceType string
+	reference     string
+	message       string
+}
+
+// newQRBill returns the QR-bill of the outstanding amount of an invoice. ok is
+// false if the profile does not enable it, the IBAN is not Swiss or from
+// Liechtenstein, the currency is neither CHF nor EUR, the profile address is
+// incomplete, the invoice is a correction, nothing is outstanding or no QR
+// reference can be derived for a QR-IBAN. The debtor is left blank if the customer address is incomplete.
+func newQRBill(profile models.Profile, customer models.Customer, invoice models.Invoice) (bill qrBill, ok bool) {
+	iban := compactAccount(profile.PaymentDetails.IBAN)
+	if !profile.PaymentDetails.QRBill || len(iban) != 21 || !strings.HasPrefix(iban, "CH") && !strings.HasPrefix(iban, "LI") {
+		return bill, false
+	}
+	if invoice.Currency != "CHF" && invoice.Currency != "EUR" || invoice.IsCorrection() {
+		return bill, false
+	}
+	creditor, ok := newQRBillAddress(profile.CompanyName, profile.AddressLine1, profile.PostalCode, profile.City, profile.Country)
+	if !ok {
+		return bill, false
+	}
+	amount := invoice.Outstanding()
+	if amount.Sign() <= 0 {
+		return bill, false
+	}
+	bill = qrBill{iban: iban, creditor: creditor, amount: amount, message: slipText(i18n.T("pdf.qrbill.message", invoice.Number))}
+	bill.debtor, _ = newQRBillAddress(customer.DisplayName, customer.AddressLine1, customer.PostalCode, customer.City, customer.Country)
+	if isQRIBAN(iban) {
+		// A QR-IBAN requires a QR reference.
+		if bill.reference = qrReference(invoice.Number, invoice.ID); bill.reference == "" {
+			return qrBill{}, false
+		}
+		bill.referenceType = qrReferenceQRR
+	} else if ref := creditorReference(invoice.Number); ref != "" {
+		bill.referenceType, bill.reference = qrReferenceSCOR, ref
+	} else {
+		bill.referenceType = qrReferenceNone
+	}
+	return bill, true
+}
+
+// payload returns the content of the Swiss QR code, version 2.0 of the
+// standard with UTF-8 encoding.
+func (b qrBill) payload() string {
+	lines := []string{"SPC", "0200", "1", b.iban}
+	lines = append(lines, b.creditor.fields()...)
+	lines = append(lines, make([]string, 7)...) // ultimate creditor, reserved
+	lines = append(lines, b.amount.Amount(), b.amount.Currency)
+	lines = append(lines, b.debtor.fields()...)
+	lines = append(lines, b.referenceType, b.reference, truncateRunes(b.message, 140), "EPD")
+	return strings.Join(lines, "\n")
+}
+
+// isQRIBAN reports whether the institution identification of a Swiss IBAN is
+// in the QR-IID range 30000 to 31999.
+func isQRIBAN(iban string) bool {
+	iid, err := strconv.Atoi(iban[4:9])
+	return err == nil && iid >= 30000 && iid <= 31999
+}
+
+// qrReference derives the 27 digit QR reference from the digits of the
+// invoice number: zero padded to 26 digits plus the modulo 10 recursive check
+// digit. A number without a non-zero digit would give the same reference for
+// every invoice, so the hexadecimal invoice ID is used instead, in decimal. It
+// returns "" if neither yields a reference.
+func qrReference(number, invoiceID string) string {
+	var digits strings.Builder
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	ref := strings.TrimLeft(digits.String(), "0")
+	if ref == "" {
+		if n, ok := new(big.Int).SetString(invoiceID, 16); ok && n.Sign() > 0 {
+			ref = n.String()
+		}
+	}
+	if ref == "" {
+		return ""
+	}
+	if len(ref) > 26 {
+		ref = ref[len(ref)-26:]
+	}
+	ref = strings.Repeat("0", 26-len(ref)) + ref
+	return ref + strconv.Itoa(mod10Recursive(ref))
+}
+
+func mod10Recursive(digits string) int {
+	table := [10]int{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+	carry := 0
+	for _, r := range digits {
+		carry = table[(carry+int(r-'0'))%10]
+	}
+	return (10 - carry) % 10
+}
+
+// creditorReference derives the ISO 11649 creditor reference "RF.." from the
+// letters and digits of the invoice number, or returns "" if it has none.
+func creditorReference(number string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(number) {
+		if r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+		}
+	}
+	ref := b.String()
+	if ref == "" {
+		return ""
+	}
+	if len(ref) > 21 {
+		ref = ref[len(ref)-21:]
+	}
+	// ISO 7064 MOD 97-10 over the reference followed by "RF00", letters
+	// counting as 10 to 35.
+	remainder := 0
+	for _, r := range ref + "RF00" {
+		value := int(r - '0')
+		if r >= 'A' {
+			value = int(r-'A') + 10
+		}
+		for _, digit := range strconv.Itoa(value) {
+			remainder = (remainder*10 + int(digit-'0')) % 97
+		}
+	}
+	return fmt.Sprintf("RF%02d%s", 98-remainder, ref)
+}
+
+// formattedReference groups the reference for reading: QR references in
+// blocks of five from the right, creditor references in blocks of four.
+func (b qrBill) formattedReference() string {
+	if b.referenceType == qrReferenceQRR {
+		head := len(b.reference) % 5
+		groups := []string{b.reference[:head]}
+		for i := head; i < len(b.reference); i += 5 {
+			groups = append(groups, b.reference[i:i+5])
+		}
+		return strings.TrimSpace(strings.Join(groups, " "))
+	}
+	return groupsOf(b.reference, 4)
+}
+
+func groupsOf(s string, n int) string {
+	var groups []string
+	for len(s) > n {
+		groups = append(groups, s[:n])
+		s = s[n:]
+	}
+	return strings.Join(append(groups, s), " ")
+}
+
+// formattedAmount prints the amount with spaces as thousands separators.
+func formattedAmount(amount money.Money) string {
+	whole, cents, _ := strings.Cut(amount.Amount(), ".")
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + " " + whole[i:]
+	}
+	return whole + "." + cents
+}
+
+// slipText flattens text to a single payload line.
+func slipText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// layoutQRBill adds the QR-bill payment part at the bottom of the last page,
+// or of a new page if the content reaches too far down.
+func layoutQRBill(d *document, bill qrBill) {
+	code, err := qr.EncodeVersion([]byte(bill.payload()), qr.M, 1, qrBillMaxVersion)
+	if err != nil {
+		return
+	}
+	if d.y < slipHeight+2*sectionSpace {
+		d.newPage()
+	}
+	d.current().paymentSlip = true
+
+	// Separator lines between the invoice, the receipt and the payment part.
+	separate := i18n.T("pdf.qrbill.separate")
+	d.textAt((pageWidth-d.fonts.regular.width(separate, styleSmall.size))/2, slipHeight+slipCaptionGap, separate, styleSmall, alignLeft)
+	d.box(0, slipHeight-0.25, pageWidth, 0.5, 0)
+	d.box(receiptWidth-0.25, 0, 0.5, slipHeight, 0)
+
+	top := slipHeight - slipMargin - slipTitle.size
+	d.layoutReceipt(bill, top)
+	d.layoutPaymentPart(bill, code, top)
+}
+
+func (d *document) layoutReceipt(bill qrBill, top float64) {
+	x, width := slipMargin, receiptWidth-2*slipMargin
+	d.textAt(x, top, i18n.T("pdf.qrbill.receipt"), slipTitle, alignLeft)
+
+	info := slipColumn{d: d, x: x, y: top - 7*mm, width: width, heading: receiptHeading, value: receiptValue, leading: 9}
+	info.section(i18n.T("pdf.qrbill.account"), append([]string{groupsOf(bill.iban, 4)}, bill.creditor.lines()...)...)
+	if bill.referenceType != qrReferenceNone {
+		info.section(i18n.T("pdf.qrbill.reference"), bill.formattedReference())
+	}
+	info.payableBy(bill.debtor, 52*mm, 20*mm)
+
+	amountTop := 37*mm - receiptHeading.size
+	d.textAt(x, amountTop, i18n.T("pdf.qrbill.currency"), receiptHeading, alignLeft)
+	d.textAt(x+12*mm, amountTop, i18n.T("pdf.qrbill.amount"), receiptHeading, alignLeft)
+	d.textAt(x, amountTop-9, bill.amount.Currency, receiptValue, alignLeft)
+	d.textAt(x+12*mm, amountTop-9, formattedAmount(bill.amount), receiptValue, alignLeft)
+
+	d.textAt(receiptWidth-slipMargin, 23*mm-receiptHeading.size, i18n.T("pdf.qrbill.acceptancePoint"), receiptHeading, alignRight)
+}
+
+func (d *document) layoutPaymentPart(bill qrBill, code *qr.Code, top float64) {
+	x := receiptWidth + slipMargin
+	d.textAt(x, top, i18n.T("pdf.qrbill.paymentPart"), slipTitle, alignLeft)
+
+	qrTop := slipHeight - 17*mm
+	d.qrCode(code, x, qrTop, slipQRSize/float64(code.Size))
+	d.swissCross(x+slipQRSize/2, qrTop-slipQRSize/2)
+
+	amountTop := 37*mm - paymentPartHeading.size
+	d.textAt(x, amountTop, i18n.T("pdf.qrbill.currency"), paymentPartHeading, alignLeft)
+	d.textAt(x+14*mm, amountTop, i18n.T("pdf.qrbill.amount"), paymentPartHeading, alignLeft)
+	d.textAt(x, amountTop-11, bill.amount.Currency, paymentPartValue, alignLeft)
+	d.textAt(x+14*mm, amountTop-11, formattedAmount(bill.amount), paymentPartValue, alignLeft)
+
+	info := slipColumn{d: d, x: slipInfoX, y: top, width: pageWidth - slipMargin - slipInfoX, heading: paymentPartHeading, value: paymentPartValue, leading: 11}
+	info.section(i18n.T("pdf.qrbill.account"), append([]string{groupsOf(bill.iban, 4)}, bill.creditor.lines()...)...)
+	if bill.referenceType != qrReferenceNone {
+		info.section(i18n.T("pdf.qrbill.reference"), bill.formattedReference())
+	}
+	info.section(i18n.T("pdf.qrbill.additionalInformation"), bill.message)
+	info.payableBy(bill.debtor, 65*mm, 25*mm)
+}
+
+// swissCross draws the Swiss cross centered at x, y over the QR code.
+func (d *document) swissCross(x, y float64) {
+	square := slipCrossSize - 1*mm
+	arm, length := square*0.19, square*0.62
+	d.box(x-slipCrossSize/2, y-slipCrossSize/2, slipCrossSize, slipCrossSize, 1)
+	d.box(x-square/2, y-square/2, square, square, 0)
+	d.box(x-arm/2, y-length/2, arm, length, 1)
+	d.box(x-length/2, y-arm/2, length, arm, 1)
+}
+
+// slipColumn writes headed sections top down in one column of the payment
+// part.
+type slipColumn struct {
+	d              *document
+	x, y, width    float64
+	heading, value textStyle
+	leading        float64
+}
+
+func (c *slipColumn) section(title string, lines ...string) {
+	c.d.textAt(c.x, c.y, title, c.heading, alignLeft)
+	c.y -= c.leading
+	for _, line := range lines {
+		for _, wrapped := range c.d.wrap(line, c.width, c.value) {
+			c.d.textAt(c.x, c.y, wrapped, c.value, alignLeft)
+			c.y -= c.leading
+		}
+	}
+	c.y -= c.leading / 2
+}
+
+// payableBy writes the debtor, or a field of width × height with corner marks
+// for writing it in by hand.
+func (c *slipColumn) payableBy(debtor qrBillAddress, width, height float64) {
+	if !debtor.isZero() {
+		c.section(i18n.T("pdf.qrbill.payableBy"), debtor.lines()...)
+		return
+	}
+	c.section(i18n.T("pdf.qrbill.payableByBlank"))
+	c.d.cornerMarks(c.x, c.y+c.leading/2-height, width, height)
+}
+
+// cornerMarks draws the corners of a field to be filled in by hand.
+func (d *document) cornerMarks(x, y, width, height float64) {
+	const line, mark = 0.75, 3 * mm
+	for _, corner := range [][2]float64{{x, y}, {x + width, y}, {x, y + height}, {x + width, y + height}} {
+		cx, cy := corner[0], corner[1]
+		hx, vy := cx, cy
+		if cx > x {
+			hx = cx - mark
+		}
+		if cy > y {
+			vy = cy - mark
+		}
+		d.box(hx, cy-line/2, mark, line, 0)
+		d.box(cx-line/2, vy, line, mark, 0)
+	}
+}
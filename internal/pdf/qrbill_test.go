@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func TestQRReference(t *testing.T) {
+	tests := []struct {
+		name, number, id, want string
+	}{
+		{name: "example of the implementation guidelines", number: "21000000000313947143000901", want: "210000000003139471430009017"},
+		{name: "digits of the number", number: "RE-2025-0042", id: "F", want: "000000000000000000202500423"},
+		{name: "leading digits are cut", number: "1234567890123456789012345678", want: "345678901234567890123456782"},
+		{name: "number without digits uses the ID", number: "RE-ABC", id: "0000000000000000000000000000000F", want: "000000000000000000000000152"},
+		{name: "number without non-zero digits uses the ID", number: "RE-000", id: "F", want: "000000000000000000000000152"},
+		{name: "no reference", number: "RE-ABC", id: "not hex"},
+		{name: "no number and no ID", number: "", id: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qrReference(tt.number, tt.id); got != tt.want {
+				t.Errorf("qrReference(%q, %q) = %q, want %q", tt.number, tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreditorReference(t *testing.T) {
+	tests := []struct {
+		number, want string
+	}{
+		{number: "539007547034", want: "RF18539007547034"},
+		{number: "re-2025/0042", want: "RF49RE20250042"},
+		{number: "---", want: ""},
+	}
+	for _, tt := range tests {
+		if got := creditorReference(tt.number); got != tt.want {
+			t.Errorf("creditorReference(%q) = %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestNewQRBillReference(t *testing.T) {
+	tests := []struct {
+		name, iban, number, id string
+		ok                     bool
+		referenceType          string
+		reference              string
+	}{
+		{name: "QR-IBAN", iban: "CH44 3199 9123 0008 8901 2", number: "RE-0042", ok: true, referenceType: qrReferenceQRR, reference: "000000000000000000000000420"},
+		{name: "QR-IBAN with the invoice ID", iban: "CH4431999123000889012", number: "RE-ABC", id: "F", ok: true, referenceType: qrReferenceQRR, reference: "000000000000000000000000152"},
+		{name: "QR-IBAN without a reference", iban: "CH4431999123000889012", number: "RE-ABC"},
+		{name: "IBAN with creditor reference", iban: "CH9300762011623852957", number: "539007547034", ok: true, referenceType: qrReferenceSCOR, reference: "RF18539007547034"},
+		{name: "IBAN without reference", iban: "CH9300762011623852957", number: "---", ok: true, referenceType: qrReferenceNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := models.Profile{
+				CompanyName:    "Muster AG",
+				AddressLine1:   "Bahnhofstrasse 1",
+				PostalCode:     "8001",
+				City:           "Zürich",
+				Country:        "CH",
+				PaymentDetails: models.PaymentDetails{IBAN: tt.iban, QRBill: true},
+			}
+			invoice := models.Invoice{
+				ID:          tt.id,
+				Number:      tt.number,
+				IssueDate:   time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+				Currency:    "CHF",
+				FinalizedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+				Items: []models.InvoiceItem{{
+					Description: "Consulting",
+					Quantity:    money.DecimalFromInt(1),
+					UnitPrice:   money.New(10000, "CHF"),
+				}},
+			}
+			invoice.Recalculate()
+			bill, ok := newQRBill(profile, models.Customer{}, invoice)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if bill.referenceType != tt.referenceType || bill.reference != tt.reference {
+				t.Errorf("reference = %s %q, want %s %q", bill.referenceType, bill.reference, tt.referenceType, tt.reference)
+			}
+		})
+	}
+}
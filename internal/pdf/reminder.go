@@ -0,0 +1,100 @@
+package pdf
+
+import (
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// ReminderLetter is the content of a payment reminder. The invoices already
+// carry the reminder being sent, so their fees include the new one.
+type ReminderLetter struct {
+	Title    string
+	Text     string
+	Date     time.Time
+	Deadline time.Time
+	Invoices []models.Invoice
+}
+
+// CreateReminderPDF renders a payment reminder that lists the overdue
+// invoices with their outstanding balance, dunning fees and claimed interest.
+func CreateReminderPDF(outputPath string, profile models.Profile, customer models.Customer, letter ReminderLetter, opts ...Option) error {
+	return render(outputPath, profile, collectOptions(opts), nil, func(d *document) {
+		layoutReminder(d, profile, customer, letter)
+	})
+}
+
+func layoutReminder(d *document, profile models.Profile, customer models.Customer, letter ReminderLetter) {
+	meta := metaBlock()
+	meta.add(letter.Title, styleTitle)
+	meta.add(i18n.T("pdf.label.reminderDate", letter.Date.Format("2006-01-02")), styleBody)
+	if !letter.Deadline.IsZero() {
+		meta.add(i18n.T("pdf.label.payBy", letter.Deadline.Format("2006-01-02")), styleBold)
+	}
+	meta.add(i18n.T("pdf.label.generatedOn", time.Now().Format("2006-01-02 15:04")), styleSmall)
+	layoutParties(d, profile, customer, meta, i18n.T("pdf.section.billTo"))
+
+	d.paragraph(leftMargin, contentWidth, letter.Text, styleBody)
+	d.space(sectionSpace)
+
+	currency := money.DefaultCurrency
+	if len(letter.Invoices) > 0 {
+		currency = letter.Invoices[0].Currency
+	}
+	outstanding, fees, interest, flatFees := money.Zero(currency), money.Zero(currency), money.Zero(currency), money.Zero(currency)
+	for _, inv := range letter.Invoices {
+		outstanding = outstanding.Add(inv.Outstanding())
+		fees = fees.Add(inv.DunningFees())
+		interest = interest.Add(inv.ClaimedInterest())
+		flatFees = flatFees.Add(inv.ClaimedFlatFee())
+	}
+	// The interest column is only shown once interest has been claimed.
+	claimsInterest := !interest.IsZero() || !flatFees.IsZero()
+
+	columns := []tableColumn{
+		{title: i18n.T("pdf.reminder.column.invoice"), width: contentWidth - 305},
+		{title: i18n.T("pdf.reminder.column.issued"), width: 75},
+		{title: i18n.T("pdf.reminder.column.due"), width: 75},
+		{title: i18n.T("pdf.reminder.column.outstanding"), width: 85, align: alignRight},
+		{title: i18n.T("pdf.reminder.column.fees"), width: 70, align: alignRight},
+	}
+	if claimsInterest {
+		columns[0].width -= 75
+		columns = append(columns, tableColumn{title: i18n.T("pdf.reminder.column.interest"), width: 75, align: alignRight})
+	}
+	t := table{x: leftMargin, columns: columns}
+	d.ensure(t.headerHeight(d) + styleBody.lineHeight())
+	t.header(d)
+	for _, inv := range letter.Invoices {
+		cells := []string{
+			inv.Number,
+			inv.IssueDate.Format("2006-01-02"),
+			inv.DueDate.Format("2006-01-02"),
+			inv.Outstanding().Amount(),
+			inv.DunningFees().Amount(),
+		}
+		if claimsInterest {
+			cells = append(cells, inv.ClaimedInterest().Add(inv.ClaimedFlatFee()).Amount())
+		}
+		t.row(d, cells, styleBody)
+	}
+	d.rule(leftMargin, rightMargin, d.y+styleBody.lineHeight()-3, ruleWidth)
+	d.space(4)
+
+	d.ensure(5*styleBody.lineHeight() + 4)
+	totalLine(d, i18n.T("pdf.label.outstanding"), outstanding, styleBody)
+	totalLine(d, i18n.T("pdf.label.dunningFees"), fees, styleBody)
+	if !interest.IsZero() {
+		totalLine(d, i18n.T("pdf.label.lateInterest", letter.Date.Format("2006-01-02")), interest, styleBody)
+	}
+	if !flatFees.IsZero() {
+		totalLine(d, i18n.T("pdf.label.flatFee"), flatFees, styleBody)
+	}
+	d.rule(totalsLabelX, rightMargin, d.y+styleBody.lineHeight()-3, strongRule)
+	d.space(4)
+	totalLine(d, i18n.T("pdf.label.amountDue"), outstanding.Add(fees).Add(interest).Add(flatFees), styleBold)
+
+	layoutPaymentDetails(d, profile, nil)
+}
@@ -0,0 +1,479 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+)
+
+// trueTypeFont is a parsed TrueType (glyf based) font program with the metrics
+// needed to lay out text and to embed a glyph subset into a PDF.
+type trueTypeFont struct {
+	postScriptName string
+	tables         map[string][]byte
+
+	unitsPerEm  int
+	ascent      int
+	descent     int
+	capHeight   int
+	bbox        [4]int
+	italicAngle float64
+	fixedPitch  bool
+
+	numGlyphs int
+	advances  []int
+	cmap      map[rune]uint16
+	locaLong  bool
+}
+
+var errNotTrueType = errors.New("pdf: not a TrueType font with glyf outlines")
+
+// parseTrueType reads the tables of a TrueType font file.
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errNotTrueType
+	}
+	version := binary.BigEndian.Uint32(data)
+	if version != 0x00010000 && version != 0x74727565 {
+		return nil, errNotTrueType
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errNotTrueType
+	}
+
+	f := &trueTypeFont{tables: make(map[string][]byte, numTables)}
+	for i := 0; i < numTables; i++ {
+		rec := data[12+16*i:]
+		tag := string(rec[:4])
+		offset := int(binary.BigEndian.Uint32(rec[8:]))
+		length := int(binary.BigEndian.Uint32(rec[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("pdf: font table %q out of bounds", tag)
+		}
+		f.tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := f.tables[tag]; !ok {
+			if tag == "glyf" || tag == "loca" {
+				return nil, errNotTrueType
+			}
+			return nil, fmt.Errorf("pdf: font is missing the %q table", tag)
+		}
+	}
+
+	if err := f.parseMetrics(); err != nil {
+		return nil, err
+	}
+	cmap, err := parseCmap(f.tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.cmap = cmap
+	f.postScriptName = parsePostScriptName(f.tables["name"])
+	if f.postScriptName == "" {
+		f.postScriptName = "EmbeddedFont"
+	}
+	return f, nil
+}
+
+func (f *trueTypeFont) parseMetrics() error {
+	head := f.tables["head"]
+	hhea := f.tables["hhea"]
+	maxp := f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return fmt.Errorf("pdf: font metric tables are truncated")
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		f.unitsPerEm = 1000
+	}
+	for i := 0; i < 4; i++ {
+		f.bbox[i] = f.scale(int(int16(binary.BigEndian.Uint16(head[36+2*i:]))))
+	}
+	f.locaLong = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = f.scale(int(int16(binary.BigEndian.Uint16(hhea[4:]))))
+	f.descent = f.scale(int(int16(binary.BigEndian.Uint16(hhea[6:]))))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = f.scale(int(int16(binary.BigEndian.Uint16(os2[88:]))))
+	}
+	if post := f.tables["post"]; len(post) >= 16 {
+		f.italicAngle = float64(int32(binary.BigEndian.Uint32(post[4:]))) / 65536
+		f.fixedPitch = binary.BigEndian.Uint32(post[12:]) != 0
+	}
+
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numHMetrics == 0 || len(hmtx) < 4*numHMetrics {
+		return fmt.Errorf("pdf: font hmtx table is truncated")
+	}
+	f.advances = make([]int, f.numGlyphs)
+	last := 0
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		if gid < numHMetrics {
+			last = int(binary.BigEndian.Uint16(hmtx[4*gid:]))
+		}
+		f.advances[gid] = last
+	}
+	return nil
+}
+
+// scale converts font units into the 1/1000 em units used by PDF.
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// glyph returns the glyph index for r, or 0 (.notdef) if the font lacks it.
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// advance returns the advance width of a glyph in 1/1000 em.
+func (f *trueTypeFont) advance(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return f.scale(f.advances[gid])
+}
+
+// textWidth measures text in points at the given font size.
+// printable returns the glyph used to print r. Characters the font lacks are
+// replaced by a question mark rather than the .notdef glyph.
+func (f *trueTypeFont) printable(r rune) (rune, uint16) {
+	if gid := f.glyph(r); gid != 0 {
+		return r, gid
+	}
+	return '?', f.glyph('?')
+}
+
+func (f *trueTypeFont) textWidth(text string, size float64) float64 {
+	total := 0
+	for _, r := range text {
+		_, gid := f.printable(r)
+		total += f.advance(gid)
+	}
+	return float64(total) * size / 1000
+}
+
+func parseCmap(data []byte) (map[rune]uint16, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("pdf: font cmap table is truncated")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[2:]))
+	var best []byte
+	bestScore := 0
+	for i := 0; i < numTables; i++ {
+		rec := data[4+8*i:]
+		if len(rec) < 8 {
+			break
+		}
+		platform := binary.BigEndian.Uint16(rec)
+		encoding := binary.BigEndian.Uint16(rec[2:])
+		offset := int(binary.BigEndian.Uint32(rec[4:]))
+		if offset >= len(data) {
+			continue
+		}
+		score := 0
+		switch {
+		case platform == 3 && encoding == 10:
+			score = 4
+		case platform == 0 && (encoding == 4 || encoding == 6):
+			score = 3
+		case platform == 3 && encoding == 1:
+			score = 2
+		case platform == 0:
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = data[offset:], score
+		}
+	}
+	if best == nil || len(best) < 2 {
+		return nil, fmt.Errorf("pdf: font has no Unicode cmap")
+	}
+
+	out := make(map[rune]uint16)
+	switch binary.BigEndian.Uint16(best) {
+	case 4:
+		if len(best) < 14 {
+			return nil, fmt.Errorf("pdf: font cmap format 4 is truncated")
+		}
+		segCount := int(binary.BigEndian.Uint16(best[6:])) / 2
+		if len(best) < 16+8*segCount {
+			return nil, fmt.Errorf("pdf: font cmap format 4 is truncated")
+		}
+		ends := best[14:]
+		starts := ends[2*segCount+2:]
+		deltas := starts[2*segCount:]
+		rangeOffsets := deltas[2*segCount:]
+		for seg := 0; seg < segCount; seg++ {
+			end := int(binary.BigEndian.Uint16(ends[2*seg:]))
+			start := int(binary.BigEndian.Uint16(starts[2*seg:]))
+			delta := int(binary.BigEndian.Uint16(deltas[2*seg:]))
+			rangeOffset := int(binary.BigEndian.Uint16(rangeOffsets[2*seg:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				var gid int
+				if rangeOffset == 0 {
+					gid = (c + delta) & 0xFFFF
+				} else {
+					idx := 2*seg + rangeOffset + 2*(c-start)
+					if idx+2 > len(rangeOffsets) {
+						continue
+					}
+					gid = int(binary.BigEndian.Uint16(rangeOffsets[idx:]))
+					if gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				if gid != 0 {
+					out[rune(c)] = uint16(gid)
+				}
+			}
+		}
+	case 12:
+		if len(best) < 16 {
+			return nil, fmt.Errorf("pdf: font cmap format 12 is truncated")
+		}
+		groups := int(binary.BigEndian.Uint32(best[12:]))
+		for g := 0; g < groups; g++ {
+			rec := best[16+12*g:]
+			if len(rec) < 12 {
+				break
+			}
+			start := binary.BigEndian.Uint32(rec)
+			end := binary.BigEndian.Uint32(rec[4:])
+			gid := binary.BigEndian.Uint32(rec[8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				out[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("pdf: unsupported cmap format %d", binary.BigEndian.Uint16(best))
+	}
+	return out, nil
+}
+
+func parsePostScriptName(data []byte) string {
+	if len(data) < 6 {
+		return ""
+	}
+	count := int(binary.BigEndian.Uint16(data[2:]))
+	storage := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		rec := data[6+12*i:]
+		if len(rec) < 12 {
+			break
+		}
+		platform := binary.BigEndian.Uint16(rec)
+		nameID := binary.BigEndian.Uint16(rec[6:])
+		length := int(binary.BigEndian.Uint16(rec[8:]))
+		offset := storage + int(binary.BigEndian.Uint16(rec[10:]))
+		if nameID != 6 || offset+length > len(data) {
+			continue
+		}
+		raw := data[offset : offset+length]
+		if platform == 3 || platform == 0 {
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			return sanitizeFontName(string(utf16.Decode(units)))
+		}
+		return sanitizeFontName(string(raw))
+	}
+	return ""
+}
+
+// sanitizeFontName keeps only characters that are valid in a PDF name.
+func sanitizeFontName(name string) string {
+	out := make([]rune, 0, len(name))
+	for _, r := range name {
+		if r > 32 && r < 127 && r != '/' && r != '(' && r != ')' && r != '[' && r != ']' && r != '<' && r != '>' && r != '{' && r != '}' && r != '%' && r != '#' {
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// glyphRange returns the glyf bytes of a glyph.
+func (f *trueTypeFont) glyphRange(gid int) []byte {
+	loca := f.tables["loca"]
+	glyf := f.tables["glyf"]
+	var start, end int
+	if f.locaLong {
+		if 4*gid+8 > len(loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint32(loca[4*gid:]))
+		end = int(binary.BigEndian.Uint32(loca[4*gid+4:]))
+	} else {
+		if 2*gid+4 > len(loca) {
+			return nil
+		}
+		start = 2 * int(binary.BigEndian.Uint16(loca[2*gid:]))
+		end = 2 * int(binary.BigEndian.Uint16(loca[2*gid+2:]))
+	}
+	if start > end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// compositeComponents lists the glyphs referenced by a composite glyph.
+func compositeComponents(glyph []byte) []uint16 {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+	const (
+		argsAreWords    = 0x0001
+		haveScale       = 0x0008
+		moreComponents  = 0x0020
+		haveXYScale     = 0x0040
+		haveTwoByTwo    = 0x0080
+		componentHeader = 4
+	)
+	var out []uint16
+	pos := 10
+	for pos+componentHeader <= len(glyph) {
+		flags := binary.BigEndian.Uint16(glyph[pos:])
+		out = append(out, binary.BigEndian.Uint16(glyph[pos+2:]))
+		pos += componentHeader
+		if flags&argsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return out
+}
+
+// subset builds a reduced font program that only keeps the outlines of the
+// used glyphs. Glyph indices are preserved so the PDF can map CIDs to glyphs
+// with the identity mapping; unused glyphs are simply left empty.
+func (f *trueTypeFont) subset(used map[uint16]rune) []byte {
+	keep := make(map[uint16]bool, len(used)+1)
+	queue := []uint16{0}
+	for gid := range used {
+		queue = append(queue, gid)
+	}
+	for len(queue) > 0 {
+		gid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if keep[gid] || int(gid) >= f.numGlyphs {
+			continue
+		}
+		keep[gid] = true
+		queue = append(queue, compositeComponents(f.glyphRange(int(gid)))...)
+	}
+
+	var glyf []byte
+	loca := make([]byte, 4*(f.numGlyphs+1))
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(loca[4*gid:], uint32(len(glyf)))
+		if keep[uint16(gid)] {
+			glyf = append(glyf, f.glyphRange(gid)...)
+			for len(glyf)%4 != 0 {
+				glyf = append(glyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*f.numGlyphs:], uint32(len(glyf)))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"maxp": f.tables["maxp"],
+		"hmtx": f.tables["hmtx"],
+		"loca": loca,
+		"glyf": glyf,
+	}
+	for _, tag := range []string{"cvt ", "fpgm", "prep", "OS/2", "post", "name"} {
+		if data, ok := f.tables[tag]; ok {
+			tables[tag] = data
+		}
+	}
+	font := writeFontFile(tables)
+
+	adjustment := 0xB1B0AFBA - tableChecksum(font)
+	headOffset := findTableOffset(font, "head")
+	binary.BigEndian.PutUint32(font[headOffset+8:], adjustment)
+	return font
+}
+
+func writeFontFile(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= numTables {
+		searchRange *= 2
+		entrySelector++
+	}
+	searchRange *= 16
+
+	header := make([]byte, 12+16*numTables)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(numTables*16-searchRange))
+
+	out := header
+	for i, tag := range tags {
+		data := tables[tag]
+		rec := out[12+16*i:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], tableChecksum(data))
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(data)))
+		out = append(out, data...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
+
+func findTableOffset(font []byte, tag string) int {
+	numTables := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := font[12+16*i:]
+		if string(rec[:4]) == tag {
+			return int(binary.BigEndian.Uint32(rec[8:]))
+		}
+	}
+	return 0
+}
+
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
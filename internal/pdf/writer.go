@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// objectWriter collects numbered PDF objects and serialises them together with
+// the cross reference table. Object numbers start at 1.
+type objectWriter struct {
+	objects [][]byte
+}
+
+// reserve allocates an object number whose body is provided later via set.
+func (w *objectWriter) reserve() int {
+	w.objects = append(w.objects, nil)
+	return len(w.objects)
+}
+
+// add appends a new object and returns its number.
+func (w *objectWriter) add(body []byte) int {
+	w.objects = append(w.objects, body)
+	return len(w.objects)
+}
+
+func (w *objectWriter) set(num int, body []byte) {
+	w.objects[num-1] = body
+}
+
+// addStream appends a stream object with the given extra dictionary entries.
+func (w *objectWriter) addStream(dict string, data []byte) int {
+	return w.add(streamObject(dict, data))
+}
+
+func streamObject(dict string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("<< /Length %d%s >>\nstream\n", len(data), dict))
+	buf.Write(data)
+	buf.WriteString("\nendstream\n")
+	return buf.Bytes()
+}
+
+// bytes renders the complete file. trailer holds extra trailer entries such as
+// /Info or /ID.
+func (w *objectWriter) bytes(root int, trailer string) []byte {
+	var doc bytes.Buffer
+	// The binary comment marks the file as binary for transfer tools and is
+	// required by PDF/A.
+	doc.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(w.objects)+1)
+	for i, obj := range w.objects {
+		offsets[i+1] = doc.Len()
+		doc.WriteString(fmt.Sprintf("%d 0 obj\n", i+1))
+		doc.Write(obj)
+		doc.WriteString("endobj\n")
+	}
+
+	xrefOffset := doc.Len()
+	doc.WriteString("xref\n")
+	doc.WriteString(fmt.Sprintf("0 %d\n", len(w.objects)+1))
+	doc.WriteString("0000000000 65535 f \n")
+	for i := 1; i <= len(w.objects); i++ {
+		doc.WriteString(fmt.Sprintf("%010d 00000 n \n", offsets[i]))
+	}
+	doc.WriteString("trailer\n")
+	doc.WriteString(fmt.Sprintf("<< /Size %d /Root %d 0 R%s >>\n", len(w.objects)+1, root, trailer))
+	doc.WriteString("startxref\n")
+	doc.WriteString(fmt.Sprintf("%d\n", xrefOffset))
+	doc.WriteString("%%EOF\n")
+	return doc.Bytes()
+}
@@ -0,0 +1,289 @@
+package qr
+
+// grid is a code under construction. Function modules (finder, timing and
+// alignment patterns, format and version information) are never masked.
+type grid struct {
+	Code
+	function []bool
+}
+
+func newGrid(version int, level Level) *grid {
+	size := 4*version + 17
+	return &grid{
+		Code:     Code{Version: version, Level: level, Size: size, modules: make([]bool, size*size)},
+		function: make([]bool, size*size),
+	}
+}
+
+func (g *grid) set(x, y int, dark bool) {
+	g.modules[y*g.Size+x] = dark
+}
+
+func (g *grid) setFunction(x, y int, dark bool) {
+	g.set(x, y, dark)
+	g.function[y*g.Size+x] = true
+}
+
+func (g *grid) drawFunctionPatterns() {
+	for i := 0; i < g.Size; i++ {
+		g.setFunction(6, i, i%2 == 0)
+		g.setFunction(i, 6, i%2 == 0)
+	}
+	g.drawFinder(3, 3)
+	g.drawFinder(g.Size-4, 3)
+	g.drawFinder(3, g.Size-4)
+
+	positions := alignmentPositions(g.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// The corners with finder patterns have no alignment pattern.
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			g.drawAlignment(x, y)
+		}
+	}
+	g.drawFormatBits(0) // reserves the area until the mask is chosen
+	g.drawVersion()
+}
+
+// drawFinder draws a finder pattern with its separator around the center x, y.
+func (g *grid) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= g.Size || yy < 0 || yy >= g.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			g.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (g *grid) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			g.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPositions returns the row and column centers of the alignment
+// patterns, ascending.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, 4*version+10; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// drawFormatBits draws both copies of the level and mask, BCH protected.
+func (g *grid) drawFormatBits(mask int) {
+	data := g.Level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		g.setFunction(8, i, bit(i))
+	}
+	g.setFunction(8, 7, bit(6))
+	g.setFunction(8, 8, bit(7))
+	g.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		g.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		g.setFunction(g.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		g.setFunction(8, g.Size-15+i, bit(i))
+	}
+	g.setFunction(8, g.Size-8, true) // the dark module
+}
+
+// drawVersion draws both copies of the version information of versions 7 and
+// up.
+func (g *grid) drawVersion() {
+	if g.Version < 7 {
+		return
+	}
+	rem := g.Version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := g.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := g.Size-11+i%3, i/3
+		g.setFunction(a, b, dark)
+		g.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the zigzag order of two module wide
+// columns, right to left, skipping the vertical timing pattern. Remainder
+// modules stay light.
+func (g *grid) drawCodewords(data []byte) {
+	i := 0
+	for right := g.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < g.Size; vert++ {
+			y := vert
+			if upward {
+				y = g.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if g.function[y*g.Size+x] || i >= len(data)*8 {
+					continue
+				}
+				g.set(x, y, data[i>>3]>>(7-i&7)&1 == 1)
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the non-function modules selected by mask.
+func (g *grid) applyMask(mask int) {
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !g.function[y*g.Size+x] {
+				g.modules[y*g.Size+x] = !g.modules[y*g.Size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol by the four rules of ISO/IEC 18004 section 7.8.3;
+// the mask with the lowest score is used.
+func (g *grid) penalty() int {
+	score := 0
+	line := make([]bool, g.Size)
+	for horizontal := 0; horizontal < 2; horizontal++ {
+		for a := 0; a < g.Size; a++ {
+			for b := 0; b < g.Size; b++ {
+				if horizontal == 0 {
+					line[b] = g.Dark(b, a)
+				} else {
+					line[b] = g.Dark(a, b)
+				}
+			}
+			score += linePenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
+			if g.Dark(x, y) {
+				dark++
+			}
+			if x+1 < g.Size && y+1 < g.Size {
+				c := g.Dark(x, y)
+				if c == g.Dark(x+1, y) && c == g.Dark(x, y+1) && c == g.Dark(x+1, y+1) {
+					score += 3
+				}
+			}
+		}
+	}
+	total := g.Size * g.Size
+	score += abs(dark*100/total-50) / 5 * 10
+	return score
+}
+
+// finderLike is the 1:1:3:1:1 pattern penalised with four light modules on
+// either side.
+var finderLike = []bool{true, false, true, true, true, false, true}
+
+// linePenalty scores runs of five or more modules of the same colour and
+// finder-like patterns in one row or column.
+func linePenalty(line []bool) int {
+	score := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			score += run - 2
+		}
+		run = 1
+	}
+	for i := 0; i+len(finderLike) <= len(line); i++ {
+		if !matches(line[i:], finderLike) {
+			continue
+		}
+		end := i + len(finderLike)
+		if light(line, i-4, i) || light(line, end, end+4) {
+			score += 40
+		}
+	}
+	return score
+}
+
+func matches(line, pattern []bool) bool {
+	for i, p := range pattern {
+		if line[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// light reports whether the modules from..to exist and are all light.
+func light(line []bool, from, to int) bool {
+	if from < 0 || to > len(line) {
+		return false
+	}
+	for _, dark := range line[from:to] {
+		if dark {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,198 @@
+// Package qr encodes data as QR codes (ISO/IEC 18004) in byte mode, for the
+// payment codes printed on documents.
+package qr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Level is the error correction level of a code.
+type Level int
+
+const (
+	L Level = iota // recovers about 7 % of the codewords
+	M              // about 15 %
+	Q              // about 25 %
+	H              // about 30 %
+)
+
+// formatBits are the level bits of the format information.
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// ErrTooLong is returned if the data does not fit into a version 40 code.
+var ErrTooLong = errors.New("qr: data too long")
+
+// Code is an encoded QR code: a square of Size × Size modules without the
+// quiet zone.
+type Code struct {
+	Version int
+	Level   Level
+	Size    int
+	modules []bool
+}
+
+// Dark reports whether the module in column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y*c.Size+x]
+}
+
+// Encode encodes data in the smallest version that fits at the given level.
+func Encode(data []byte, level Level) (*Code, error) {
+	return EncodeVersion(data, level, 1, 40)
+}
+
+// EncodeVersion encodes data in the smallest version between minVersion and
+// maxVersion that fits. Payment standards limit the version, e.g. EPC069-12
+// allows at most version 13.
+func EncodeVersion(data []byte, level Level, minVersion, maxVersion int) (*Code, error) {
+	if level < L || level > H {
+		return nil, fmt.Errorf("qr: invalid level %d", level)
+	}
+	minVersion, maxVersion = max(minVersion, 1), min(maxVersion, 40)
+	for version := minVersion; version <= maxVersion; version++ {
+		if dataBits(version, len(data)) <= dataCodewords(version, level)*8 {
+			return build(data, level, version), nil
+		}
+	}
+	return nil, ErrTooLong
+}
+
+// countBits is the width of the character count in byte mode.
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// dataBits is the length of the mode indicator, count and data in bits.
+func dataBits(version, n int) int {
+	return 4 + countBits(version) + 8*n
+}
+
+func build(data []byte, level Level, version int) *Code {
+	c := newGrid(version, level)
+	c.drawFunctionPatterns()
+	c.drawCodewords(interleave(codewords(data, level, version), level, version))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // masking twice restores the modules
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	return &c.Code
+}
+
+// codewords returns the data codewords: mode indicator, count, data,
+// terminator and the alternating pad bytes.
+func codewords(data []byte, level Level, version int) []byte {
+	capacity := dataCodewords(version, level) * 8
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+// append adds the n low bits of value, most significant first.
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+// interleave splits the data into blocks, appends the error correction
+// codewords to each and interleaves the blocks.
+func interleave(data []byte, level Level, version int) []byte {
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	raw := rawDataModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0) // placeholder, skipped below
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// rawDataModules is the number of modules available for codewords, including
+// remainder bits.
+func rawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// dataCodewords is the number of codewords available for data.
+func dataCodewords(version int, level Level) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// Error correction codewords per block and number of blocks, indexed by level
+// and version (ISO/IEC 18004 table 9).
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var eccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
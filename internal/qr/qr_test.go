@@ -0,0 +1,152 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// The expected values are the worked examples of ISO/IEC 18004 and the
+// capacity tables of the standard.
+
+func TestReedSolomon(t *testing.T) {
+	tests := []struct {
+		name      string
+		data, ecc []byte
+	}{
+		{
+			name: "01234567 at 1-M",
+			data: []byte{16, 32, 12, 86, 97, 128, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17},
+			ecc:  []byte{165, 36, 212, 193, 237, 54, 199, 135, 44, 85},
+		},
+		{
+			name: "HELLO WORLD at 1-M",
+			data: []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			ecc:  []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rsRemainder(tt.data, rsDivisor(len(tt.ecc))); !bytes.Equal(got, tt.ecc) {
+				t.Errorf("ecc = %v, want %v", got, tt.ecc)
+			}
+		})
+	}
+}
+
+func TestGeneratorPolynomial(t *testing.T) {
+	want := []byte{127, 122, 154, 164, 11, 68, 117}
+	if got := rsDivisor(7); !bytes.Equal(got, want) {
+		t.Errorf("divisor = %v, want %v", got, want)
+	}
+}
+
+func TestCodewords(t *testing.T) {
+	want := []byte{0x40, 0x56, 0x86, 0x56, 0xC6, 0xC6, 0xF0, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC}
+	if got := codewords([]byte("hello"), M, 1); !bytes.Equal(got, want) {
+		t.Errorf("codewords = % X, want % X", got, want)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	tests := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{level: L, mask: 0, want: 0b111011111000100},
+		{level: L, mask: 7, want: 0b110100101110110},
+		{level: M, mask: 0, want: 0b101010000010010},
+		{level: M, mask: 5, want: 0b100000011001110},
+		{level: Q, mask: 0, want: 0b011010101011111},
+		{level: H, mask: 0, want: 0b001011010001001},
+		{level: H, mask: 7, want: 0b000100000111011},
+	}
+	for _, tt := range tests {
+		g := newGrid(1, tt.level)
+		g.drawFormatBits(tt.mask)
+		var got int
+		for i := 0; i < 8; i++ {
+			if g.Dark(g.Size-1-i, 8) {
+				got |= 1 << i
+			}
+		}
+		for i := 8; i < 15; i++ {
+			if g.Dark(8, g.Size-15+i) {
+				got |= 1 << i
+			}
+		}
+		if got != tt.want {
+			t.Errorf("level %d mask %d: format = %015b, want %015b", tt.level, tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	tests := []struct {
+		version int
+		want    int
+	}{
+		{version: 7, want: 0x07C94},
+		{version: 13, want: 0x0D847},
+		{version: 40, want: 0x28C69},
+	}
+	for _, tt := range tests {
+		g := newGrid(tt.version, M)
+		g.drawVersion()
+		var got int
+		for i := 0; i < 18; i++ {
+			if g.Dark(g.Size-11+i%3, i/3) {
+				got |= 1 << i
+			}
+		}
+		if got != tt.want {
+			t.Errorf("version %d: bits = %05X, want %05X", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeCapacity(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		level   Level
+		version int
+		err     error
+	}{
+		{name: "1-L full", size: 17, level: L, version: 1},
+		{name: "1-L overflow", size: 18, level: L, version: 2},
+		{name: "1-M full", size: 14, level: M, version: 1},
+		{name: "1-Q full", size: 11, level: Q, version: 1},
+		{name: "1-H full", size: 7, level: H, version: 1},
+		{name: "10-M full", size: 213, level: M, version: 10},
+		{name: "13-M full", size: 331, level: M, version: 13},
+		{name: "40-L full", size: 2953, level: L, version: 40},
+		{name: "40-L overflow", size: 2954, level: L, err: ErrTooLong},
+		{name: "40-H full", size: 1273, level: H, version: 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(bytes.Repeat([]byte{'a'}, tt.size), tt.level)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && code.Version != tt.version {
+				t.Errorf("version = %d, want %d", code.Version, tt.version)
+			}
+			if err == nil && code.Size != 4*tt.version+17 {
+				t.Errorf("size = %d, want %d", code.Size, 4*tt.version+17)
+			}
+		})
+	}
+}
+
+func TestEncodeVersionLimit(t *testing.T) {
+	if _, err := EncodeVersion(bytes.Repeat([]byte{'a'}, 332), M, 1, 13); !errors.Is(err, ErrTooLong) {
+		t.Errorf("err = %v, want ErrTooLong", err)
+	}
+	code, err := EncodeVersion([]byte("a"), M, 3, 13)
+	if err != nil || code.Version != 3 {
+		t.Errorf("code = %+v, err = %v, want version 3", code, err)
+	}
+}
@@ -0,0 +1,77 @@
+package sepa
+
+import "time"
+
+// leadDays is the number of TARGET business days a CORE direct debit has to
+// be submitted before the collection date. Since November 2016 it is the same
+// for first and recurring collections.
+const leadDays = 1
+
+// IsBusinessDay reports whether the TARGET2 payment system settles on date:
+// every weekday except New Year's Day, Good Friday, Easter Monday, 1 May and
+// 25 and 26 December.
+func IsBusinessDay(date time.Time) bool {
+	switch date.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	_, month, day := date.Date()
+	switch {
+	case month == time.January && day == 1,
+		month == time.May && day == 1,
+		month == time.December && (day == 25 || day == 26):
+		return false
+	}
+	easter := easterSunday(date.Year())
+	d := dayOf(date)
+	return !d.Equal(easter.AddDate(0, 0, -2)) && !d.Equal(easter.AddDate(0, 0, 1))
+}
+
+// AddBusinessDays returns the date n business days after date.
+func AddBusinessDays(date time.Time, n int) time.Time {
+	d := dayOf(date)
+	for n > 0 {
+		d = d.AddDate(0, 0, 1)
+		if IsBusinessDay(d) {
+			n--
+		}
+	}
+	return d
+}
+
+// CollectionDate returns the requested collection date of an invoice due on
+// due when the file is submitted on submitted: the due date, but no earlier
+// than the lead time allows, moved to the next business day if needed.
+func CollectionDate(due, submitted time.Time) time.Time {
+	earliest := AddBusinessDays(submitted, leadDays)
+	d := dayOf(due)
+	if d.Before(earliest) {
+		return earliest
+	}
+	for !IsBusinessDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// easterSunday computes Easter Sunday of the Gregorian calendar (anonymous
+// Gregorian algorithm).
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func dayOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
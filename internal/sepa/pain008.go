@@ -0,0 +1,245 @@
+package sepa
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// Namespace is the XML namespace of the generated messages.
+const Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.008.001.08"
+
+// Creditor is the party collecting the direct debits.
+type Creditor struct {
+	Name string
+	IBAN string
+	BIC  string
+	// ID is the SEPA creditor identifier.
+	ID string
+}
+
+// Debit is one collection from a debtor's account under a mandate.
+type Debit struct {
+	EndToEndID     string
+	Amount         money.Money
+	MandateID      string
+	SignatureDate  time.Time
+	SequenceType   string
+	CollectionDate time.Time
+	DebtorName     string
+	IBAN           string
+	BIC            string
+	Remittance     string
+}
+
+// Pain008 validates the debits and returns them as a pain.008.001.08 CORE
+// direct debit initiation. Debits with the same sequence type and collection
+// date form one payment information block.
+func Pain008(messageID string, created time.Time, creditor Creditor, debits []Debit) ([]byte, error) {
+	if err := validate(messageID, creditor, debits); err != nil {
+		return nil, err
+	}
+	type batchKey struct {
+		sequence string
+		date     string
+	}
+	batches := map[batchKey][]Debit{}
+	var keys []batchKey
+	for _, d := range debits {
+		key := batchKey{sequence: d.SequenceType, date: d.CollectionDate.Format("2006-01-02")}
+		if _, ok := batches[key]; !ok {
+			keys = append(keys, key)
+		}
+		batches[key] = append(batches[key], d)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].date != keys[j].date {
+			return keys[i].date < keys[j].date
+		}
+		return keys[i].sequence < keys[j].sequence
+	})
+
+	doc := painDocument{
+		Namespace: Namespace,
+		Header: painGroupHeader{
+			MessageID:    messageID,
+			Created:      created.Format("2006-01-02T15:04:05"),
+			Transactions: len(debits),
+			ControlSum:   controlSum(debits),
+			Initiator:    Text(creditor.Name, 70),
+		},
+	}
+	for i, key := range keys {
+		batch := batches[key]
+		info := painPaymentInfo{
+			ID:             fmt.Sprintf("%s-%d", messageID, i+1),
+			Method:         "DD",
+			BatchBooking:   true,
+			Transactions:   len(batch),
+			ControlSum:     controlSum(batch),
+			ServiceLevel:   "SEPA",
+			LocalInstrumnt: "CORE",
+			SequenceType:   key.sequence,
+			CollectionDate: key.date,
+			CreditorName:   Text(creditor.Name, 70),
+			CreditorIBAN:   Compact(creditor.IBAN),
+			CreditorAgent:  agent(creditor.BIC),
+			ChargeBearer:   "SLEV",
+			SchemeID:       Compact(creditor.ID),
+			SchemeName:     "SEPA",
+		}
+		for _, d := range batch {
+			info.Debits = append(info.Debits, painDebit{
+				EndToEndID:    d.EndToEndID,
+				Amount:        painAmount{Currency: d.Amount.Currency, Value: d.Amount.Amount()},
+				MandateID:     d.MandateID,
+				SignatureDate: d.SignatureDate.Format("2006-01-02"),
+				DebtorAgent:   agent(d.BIC),
+				DebtorName:    Text(d.DebtorName, 70),
+				DebtorIBAN:    Compact(d.IBAN),
+				Remittance:    Text(d.Remittance, 140),
+			})
+		}
+		doc.Payments = append(doc.Payments, info)
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("sepa: encode pain.008: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// validate applies the rules of the SEPA CORE rulebook that the bank would
+// otherwise reject the file for.
+func validate(messageID string, creditor Creditor, debits []Debit) error {
+	switch {
+	case len(debits) == 0:
+		return fmt.Errorf("%w: no debits", ErrInvalid)
+	case messageID == "" || len(messageID) > 35:
+		return fmt.Errorf("%w: message ID %q", ErrInvalid, messageID)
+	case Text(creditor.Name, 70) == "":
+		return fmt.Errorf("%w: creditor name is missing", ErrInvalid)
+	case !ValidIBAN(creditor.IBAN):
+		return fmt.Errorf("%w: creditor IBAN %q", ErrInvalid, creditor.IBAN)
+	case creditor.BIC != "" && !ValidBIC(creditor.BIC):
+		return fmt.Errorf("%w: creditor BIC %q", ErrInvalid, creditor.BIC)
+	case !ValidCreditorID(creditor.ID):
+		return fmt.Errorf("%w: creditor identifier %q", ErrInvalid, creditor.ID)
+	}
+	seen := map[string]bool{}
+	for _, d := range debits {
+		switch {
+		case d.EndToEndID == "" || len(d.EndToEndID) > 35 || seen[d.EndToEndID]:
+			return fmt.Errorf("%w: end-to-end ID %q", ErrInvalid, d.EndToEndID)
+		case d.Amount.Currency != "EUR":
+			return fmt.Errorf("%w: %s: currency %s, only EUR can be collected", ErrInvalid, d.EndToEndID, d.Amount.Currency)
+		case d.Amount.Sign() <= 0 || d.Amount.Minor > 99999999999:
+			return fmt.Errorf("%w: %s: amount %s", ErrInvalid, d.EndToEndID, d.Amount)
+		case !ValidMandateID(d.MandateID):
+			return fmt.Errorf("%w: %s: mandate reference %q", ErrInvalid, d.EndToEndID, d.MandateID)
+		case d.SignatureDate.IsZero() || d.SignatureDate.After(d.CollectionDate):
+			return fmt.Errorf("%w: %s: mandate signature date", ErrInvalid, d.EndToEndID)
+		case !validSequence(d.SequenceType):
+			return fmt.Errorf("%w: %s: sequence type %q", ErrInvalid, d.EndToEndID, d.SequenceType)
+		case !IsBusinessDay(d.CollectionDate):
+			return fmt.Errorf("%w: %s: collection date %s is no TARGET business day", ErrInvalid, d.EndToEndID, d.CollectionDate.Format("2006-01-02"))
+		case Text(d.DebtorName, 70) == "":
+			return fmt.Errorf("%w: %s: debtor name is missing", ErrInvalid, d.EndToEndID)
+		case !ValidIBAN(d.IBAN):
+			return fmt.Errorf("%w: %s: debtor IBAN %q", ErrInvalid, d.EndToEndID, d.IBAN)
+		case d.BIC != "" && !ValidBIC(d.BIC):
+			return fmt.Errorf("%w: %s: debtor BIC %q", ErrInvalid, d.EndToEndID, d.BIC)
+		}
+		seen[d.EndToEndID] = true
+	}
+	return nil
+}
+
+func validSequence(sequence string) bool {
+	for _, s := range models.SequenceTypes {
+		if s == sequence {
+			return true
+		}
+	}
+	return false
+}
+
+func controlSum(debits []Debit) string {
+	sum := money.Zero("EUR")
+	for _, d := range debits {
+		sum = sum.Add(d.Amount)
+	}
+	return sum.Amount()
+}
+
+// agent identifies a bank by its BIC. Without a BIC, which SEPA allows within
+// the EEA, it is marked as not provided.
+func agent(bic string) painAgent {
+	if bic = Compact(bic); bic != "" {
+		return painAgent{BIC: bic}
+	}
+	return painAgent{Other: &painOther{ID: "NOTPROVIDED"}}
+}
+
+type painDocument struct {
+	XMLName   xml.Name          `xml:"Document"`
+	Namespace string            `xml:"xmlns,attr"`
+	Header    painGroupHeader   `xml:"CstmrDrctDbtInitn>GrpHdr"`
+	Payments  []painPaymentInfo `xml:"CstmrDrctDbtInitn>PmtInf"`
+}
+
+type painGroupHeader struct {
+	MessageID    string `xml:"MsgId"`
+	Created      string `xml:"CreDtTm"`
+	Transactions int    `xml:"NbOfTxs"`
+	ControlSum   string `xml:"CtrlSum"`
+	Initiator    string `xml:"InitgPty>Nm"`
+}
+
+type painPaymentInfo struct {
+	ID             string      `xml:"PmtInfId"`
+	Method         string      `xml:"PmtMtd"`
+	BatchBooking   bool        `xml:"BtchBookg"`
+	Transactions   int         `xml:"NbOfTxs"`
+	ControlSum     string      `xml:"CtrlSum"`
+	ServiceLevel   string      `xml:"PmtTpInf>SvcLvl>Cd"`
+	LocalInstrumnt string      `xml:"PmtTpInf>LclInstrm>Cd"`
+	SequenceType   string      `xml:"PmtTpInf>SeqTp"`
+	CollectionDate string      `xml:"ReqdColltnDt"`
+	CreditorName   string      `xml:"Cdtr>Nm"`
+	CreditorIBAN   string      `xml:"CdtrAcct>Id>IBAN"`
+	CreditorAgent  painAgent   `xml:"CdtrAgt"`
+	ChargeBearer   string      `xml:"ChrgBr"`
+	SchemeID       string      `xml:"CdtrSchmeId>Id>PrvtId>Othr>Id"`
+	SchemeName     string      `xml:"CdtrSchmeId>Id>PrvtId>Othr>SchmeNm>Prtry"`
+	Debits         []painDebit `xml:"DrctDbtTxInf"`
+}
+
+type painAgent struct {
+	BIC   string     `xml:"FinInstnId>BICFI,omitempty"`
+	Other *painOther `xml:"FinInstnId>Othr,omitempty"`
+}
+
+type painOther struct {
+	ID string `xml:"Id"`
+}
+
+type painDebit struct {
+	EndToEndID    string     `xml:"PmtId>EndToEndId"`
+	Amount        painAmount `xml:"InstdAmt"`
+	MandateID     string     `xml:"DrctDbtTx>MndtRltdInf>MndtId"`
+	SignatureDate string     `xml:"DrctDbtTx>MndtRltdInf>DtOfSgntr"`
+	DebtorAgent   painAgent  `xml:"DbtrAgt"`
+	DebtorName    string     `xml:"Dbtr>Nm"`
+	DebtorIBAN    string     `xml:"DbtrAcct>Id>IBAN"`
+	Remittance    string     `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+type painAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
@@ -0,0 +1,119 @@
+// Package sepa checks SEPA account data and writes direct debit collections
+// as ISO 20022 pain.008 messages.
+package sepa
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// ErrInvalid is wrapped by every validation error of a collection.
+var ErrInvalid = errors.New("sepa: invalid direct debit")
+
+// Compact removes spaces from a formatted IBAN, BIC or identifier and upper
+// cases it.
+func Compact(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+var (
+	ibanPattern       = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+	bicPattern        = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+	creditorIDPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{3}[A-Z0-9]{1,28}$`)
+	// mandatePattern is the restricted character set of mandate references;
+	// spaces are not allowed.
+	mandatePattern = regexp.MustCompile(`^[A-Za-z0-9+?/:().,'-]{1,35}$`)
+)
+
+// ValidIBAN reports whether iban is well-formed and its check digits match.
+func ValidIBAN(iban string) bool {
+	iban = Compact(iban)
+	return ibanPattern.MatchString(iban) && mod97(iban[4:]+iban[:4]) == 1
+}
+
+// ValidBIC reports whether bic is a well-formed 8 or 11 character BIC.
+func ValidBIC(bic string) bool {
+	return bicPattern.MatchString(Compact(bic))
+}
+
+// ValidCreditorID reports whether id is a well-formed SEPA creditor
+// identifier, e.g. DE98ZZZ09999999999. The check digits cover the national
+// identifier and the country code; the business code is left out.
+func ValidCreditorID(id string) bool {
+	id = Compact(id)
+	return creditorIDPattern.MatchString(id) && mod97(id[7:]+id[:4]) == 1
+}
+
+// ValidMandateID reports whether id can be used as a mandate reference.
+func ValidMandateID(id string) bool {
+	return mandatePattern.MatchString(id)
+}
+
+// mod97 returns the ISO 7064 MOD 97-10 remainder of s, letters counting as
+// 10 to 35.
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		value := int(r - '0')
+		if r >= 'A' && r <= 'Z' {
+			value = int(r-'A') + 10
+		}
+		for _, digit := range strconv.Itoa(value) {
+			remainder = (remainder*10 + int(digit-'0')) % 97
+		}
+	}
+	return remainder
+}
+
+// transliterations replace characters outside the SEPA character set that
+// are common in German names.
+var transliterations = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ß", "ss",
+	"&", "+", "é", "e", "è", "e", "à", "a", "ç", "c",
+)
+
+// Text converts s to the SEPA character set (Latin letters, digits and
+// / - ? : ( ) . , ' + and space) and cuts it to max characters. Other
+// characters become spaces.
+func Text(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-?:().,'+ ", r):
+			return r
+		default:
+			return ' '
+		}
+	}, transliterations.Replace(s))
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > max {
+		s = strings.TrimSpace(s[:max])
+	}
+	return s
+}
+
+// CheckMandate reports the first problem of a mandate that keeps it from
+// being used for collections. A zero mandate is valid.
+func CheckMandate(m models.Mandate) error {
+	switch {
+	case m.IsZero():
+		return nil
+	case !ValidMandateID(m.ID):
+		return fmt.Errorf("%w: mandate reference %q", ErrInvalid, m.ID)
+	case m.SignatureDate.IsZero():
+		return fmt.Errorf("%w: mandate %s: signature date is missing", ErrInvalid, m.ID)
+	case !validSequence(m.SequenceType):
+		return fmt.Errorf("%w: mandate %s: sequence type %q", ErrInvalid, m.ID, m.SequenceType)
+	case !ValidIBAN(m.IBAN):
+		return fmt.Errorf("%w: mandate %s: IBAN %q", ErrInvalid, m.ID, m.IBAN)
+	case m.BIC != "" && !ValidBIC(m.BIC):
+		return fmt.Errorf("%w: mandate %s: BIC %q", ErrInvalid, m.ID, m.BIC)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package sepa
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEasterSunday(t *testing.T) {
+	tests := []struct {
+		year int
+		want time.Time
+	}{
+		{year: 2019, want: date(2019, time.April, 21)},
+		{year: 2024, want: date(2024, time.March, 31)},
+		{year: 2025, want: date(2025, time.April, 20)},
+		{year: 2026, want: date(2026, time.April, 5)},
+		{year: 2038, want: date(2038, time.April, 25)},
+		{year: 2285, want: date(2285, time.March, 22)},
+	}
+	for _, tt := range tests {
+		if got := easterSunday(tt.year); !got.Equal(tt.want) {
+			t.Errorf("easterSunday(%d) = %s, want %s", tt.year, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	tests := []struct {
+		name string
+		date time.Time
+		want bool
+	}{
+		{name: "weekday", date: date(2025, time.March, 12), want: true},
+		{name: "saturday", date: date(2025, time.March, 15)},
+		{name: "sunday", date: date(2025, time.March, 16)},
+		{name: "new year", date: date(2026, time.January, 1)},
+		{name: "good friday", date: date(2025, time.April, 18)},
+		{name: "easter monday", date: date(2025, time.April, 21)},
+		{name: "good friday in march", date: date(2024, time.March, 29)},
+		{name: "easter monday in april", date: date(2024, time.April, 1)},
+		{name: "labour day", date: date(2025, time.May, 1)},
+		{name: "christmas eve", date: date(2025, time.December, 24), want: true},
+		{name: "christmas", date: date(2025, time.December, 25)},
+		{name: "boxing day", date: date(2025, time.December, 26)},
+		{name: "new year's eve", date: date(2025, time.December, 31), want: true},
+		{name: "national holiday", date: date(2025, time.October, 3), want: true},
+		{name: "time of day", date: time.Date(2025, time.April, 18, 23, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBusinessDay(tt.date); got != tt.want {
+				t.Errorf("IsBusinessDay(%s) = %v, want %v", tt.date.Format(time.DateOnly), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	tests := []struct {
+		name string
+		date time.Time
+		n    int
+		want time.Time
+	}{
+		{name: "none", date: date(2025, time.March, 15), want: date(2025, time.March, 15)},
+		{name: "next day", date: date(2025, time.March, 12), n: 1, want: date(2025, time.March, 13)},
+		{name: "over a weekend", date: date(2025, time.March, 14), n: 1, want: date(2025, time.March, 17)},
+		{name: "over easter", date: date(2025, time.April, 17), n: 1, want: date(2025, time.April, 22)},
+		{name: "over christmas", date: date(2025, time.December, 24), n: 1, want: date(2025, time.December, 29)},
+		{name: "over new year", date: date(2025, time.December, 24), n: 4, want: date(2026, time.January, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddBusinessDays(tt.date, tt.n); !got.Equal(tt.want) {
+				t.Errorf("AddBusinessDays = %s, want %s", got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestCollectionDate(t *testing.T) {
+	tests := []struct {
+		name           string
+		due, submitted time.Time
+		want           time.Time
+	}{
+		{name: "due date", due: date(2025, time.March, 20), submitted: date(2025, time.March, 10), want: date(2025, time.March, 20)},
+		{name: "due on a saturday", due: date(2025, time.March, 1), submitted: date(2025, time.February, 20), want: date(2025, time.March, 3)},
+		{name: "due on good friday", due: date(2025, time.April, 18), submitted: date(2025, time.April, 1), want: date(2025, time.April, 22)},
+		{name: "due today", due: date(2025, time.March, 10), submitted: date(2025, time.March, 10), want: date(2025, time.March, 11)},
+		{name: "overdue on a friday", due: date(2025, time.February, 1), submitted: date(2025, time.March, 14), want: date(2025, time.March, 17)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CollectionDate(tt.due, tt.submitted); !got.Equal(tt.want) {
+				t.Errorf("CollectionDate = %s, want %s", got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestValidIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want bool
+	}{
+		{iban: "DE89370400440532013000", want: true},
+		{iban: "de89 3704 0044 0532 0130 00", want: true},
+		{iban: "CH9300762011623852957", want: true},
+		{iban: "GB82WEST12345698765432", want: true},
+		{iban: "NL91ABNA0417164300", want: true},
+		{iban: "DE88370400440532013000"},
+		{iban: "DE89370400440532013001"},
+		{iban: "DE8937040044"},
+		{iban: "8937040044053201300000"},
+		{iban: ""},
+	}
+	for _, tt := range tests {
+		if got := ValidIBAN(tt.iban); got != tt.want {
+			t.Errorf("ValidIBAN(%q) = %v, want %v", tt.iban, got, tt.want)
+		}
+	}
+}
+
+func TestValidCreditorID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "DE98ZZZ09999999999", want: true},
+		{id: "de98 zzz 09999999999", want: true},
+		{id: "DE98ABC09999999999", want: true},
+		{id: "DE99ZZZ09999999999"},
+		{id: "DE98ZZZ09999999998"},
+		{id: "DE98ZZZ"},
+		{id: "DE89370400440532013000"},
+	}
+	for _, tt := range tests {
+		if got := ValidCreditorID(tt.id); got != tt.want {
+			t.Errorf("ValidCreditorID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestValidBIC(t *testing.T) {
+	tests := []struct {
+		bic  string
+		want bool
+	}{
+		{bic: "COBADEFFXXX", want: true},
+		{bic: "COBADEFF", want: true},
+		{bic: "cobadeff", want: true},
+		{bic: "COBADEF"},
+		{bic: "COBADEFFXX"},
+		{bic: "C0BADEFF"},
+	}
+	for _, tt := range tests {
+		if got := ValidBIC(tt.bic); got != tt.want {
+			t.Errorf("ValidBIC(%q) = %v, want %v", tt.bic, got, tt.want)
+		}
+	}
+}
+
+func TestValidMandateID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "MANDATE-2025/001", want: true},
+		{id: strings.Repeat("A", 35), want: true},
+		{id: strings.Repeat("A", 36)},
+		{id: "MANDATE 1"},
+		{id: "MANDAT-Ä"},
+		{id: ""},
+	}
+	for _, tt := range tests {
+		if got := ValidMandateID(tt.id); got != tt.want {
+			t.Errorf("ValidMandateID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
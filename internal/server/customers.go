@@ -0,0 +1,90 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/sepa"
+)
+
+func (s *Server) listCustomers(w http.ResponseWriter, _ *http.Request) {
+	customers, err := s.store.ListCustomers()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, customers)
+}
+
+func (s *Server) getCustomer(w http.ResponseWriter, r *http.Request) {
+	customer, err := s.store.GetCustomer(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, customer)
+}
+
+func (s *Server) createCustomer(w http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+	if !decode(w, r, &customer) {
+		return
+	}
+	now := time.Now()
+	customer.ID = id.New()
+	customer.CreatedAt = now
+	customer.UpdatedAt = now
+	s.saveCustomer(w, customer, http.StatusCreated)
+}
+
+func (s *Server) updateCustomer(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.store.GetCustomer(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var customer models.Customer
+	if !decode(w, r, &customer) {
+		return
+	}
+	customer.ID = existing.ID
+	customer.CreatedAt = existing.CreatedAt
+	customer.UpdatedAt = time.Now()
+	s.saveCustomer(w, customer, http.StatusOK)
+}
+
+func (s *Server) saveCustomer(w http.ResponseWriter, customer models.Customer, status int) {
+	if strings.TrimSpace(customer.DisplayName) == "" {
+		writeError(w, http.StatusUnprocessableEntity, errors.New("display_name is required"))
+		return
+	}
+	if err := sepa.CheckMandate(customer.Mandate); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err := s.store.SaveCustomer(customer); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, status, customer)
+}
+
+func (s *Server) deleteCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID := r.PathValue("id")
+	if _, err := s.store.GetCustomer(customerID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !s.unreferenced(w, func(inv models.Invoice) bool { return inv.CustomerID == customerID }) {
+		return
+	}
+	if err := s.store.DeleteCustomer(customerID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/sepa"
+)
+
+// directDebitCandidates lists the invoices of the profile query parameter
+// that can be collected by direct debit.
+func (s *Server) directDebitCandidates(w http.ResponseWriter, r *http.Request) {
+	profile, err := s.store.FindProfile(r.URL.Query().Get("profile"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	candidates, err := invoicing.DirectDebitCandidates(s.store, profile.ID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if candidates == nil {
+		candidates = []models.Invoice{}
+	}
+	writeJSON(w, http.StatusOK, candidates)
+}
+
+// exportDirectDebit writes a pain.008 file for the invoices of a body
+// {"invoices", "date"} and marks them as pending collection. The date is the
+// submission date and defaults to today.
+func (s *Server) exportDirectDebit(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Invoices []string `json:"invoices"`
+		Date     string   `json:"date"`
+	}
+	if !decode(w, r, &body) {
+		return
+	}
+	now := time.Now()
+	if body.Date != "" {
+		var err error
+		if now, err = time.Parse(dateLayout, body.Date); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date: %w", err))
+			return
+		}
+	}
+	invoices := make([]models.Invoice, 0, len(body.Invoices))
+	for _, ref := range body.Invoices {
+		inv, err := s.store.FindInvoice(ref)
+		if err != nil {
+			writeBuildError(w, err)
+			return
+		}
+		invoices = append(invoices, inv)
+	}
+	updated, path, err := invoicing.ExportDirectDebit(s.store, invoices, now)
+	if errors.Is(err, sepa.ErrInvalid) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, struct {
+		Invoices []models.Invoice `json:"invoices"`
+		Path     string           `json:"path"`
+	}{updated, path})
+}
+
+// cancelCollection removes the pending direct debit collection of an invoice.
+func (s *Server) cancelCollection(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !inv.CollectionPending() {
+		writeError(w, http.StatusConflict, fmt.Errorf("invoice %s has no pending direct debit collection", inv.Number))
+		return
+	}
+	inv, err = invoicing.CancelCollection(s.store, inv)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
@@ -0,0 +1,492 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/interest"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+const dateLayout = "2006-01-02"
+
+// listInvoices supports the filters status (draft, open, paid, overdue,
+// collection_pending), profile and customer IDs and an issue date range
+// from/to (inclusive, YYYY-MM-DD).
+func (s *Server) listInvoices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var from, to time.Time
+	for _, bound := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if v := query.Get(bound.name); v != "" {
+			t, err := time.Parse(dateLayout, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %w", bound.name, err))
+				return
+			}
+			*bound.value = t
+		}
+	}
+	status := query.Get("status")
+	switch status {
+	case "", invoicing.StatusDraft, invoicing.StatusOpen, invoicing.StatusPaid, invoicing.StatusOverdue, invoicing.StatusCollectionPending, invoicing.StatusCancelled:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown status %q", status))
+		return
+	}
+
+	invoices, err := s.store.ListInvoices()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	now := time.Now()
+	out := make([]models.Invoice, 0, len(invoices))
+	for _, inv := range invoices {
+		switch {
+		case status != "" && invoicing.Status(inv, now) != status:
+		case query.Get("profile") != "" && inv.ProfileID != query.Get("profile"):
+		case query.Get("customer") != "" && inv.CustomerID != query.Get("customer"):
+		case !from.IsZero() && inv.IssueDate.Before(from):
+		case !to.IsZero() && !inv.IssueDate.Before(to.AddDate(0, 0, 1)):
+		default:
+			out = append(out, inv)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+// createInvoice takes the same definition format as the batch import. With
+// ?draft=true the invoice is stored as an unvalidated draft without number.
+func (s *Server) createInvoice(w http.ResponseWriter, r *http.Request) {
+	var def invoicing.Definition
+	if !decode(w, r, &def) {
+		return
+	}
+	profile, customer, inv, err := invoicing.Build(s.store, def)
+	if err != nil {
+		writeBuildError(w, err)
+		return
+	}
+	if r.URL.Query().Get("draft") == "true" {
+		if err := s.store.SaveInvoice(inv); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, inv)
+		return
+	}
+	if findings := invoicing.CheckNew(profile, customer, inv); findings.HasErrors() {
+		writeFindings(w, findings)
+		return
+	}
+	inv, err = invoicing.Create(s.store, profile, customer, inv)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+// updateInvoice replaces the content of a draft with a definition. Finalised
+// invoices are answered with 409 Conflict.
+func (s *Server) updateInvoice(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !existing.IsDraft() {
+		writeError(w, http.StatusConflict, fmt.Errorf("%w: %s", storage.ErrFinalized, existing.Number))
+		return
+	}
+	var def invoicing.Definition
+	if !decode(w, r, &def) {
+		return
+	}
+	_, _, inv, err := invoicing.Build(s.store, def)
+	if err != nil {
+		writeBuildError(w, err)
+		return
+	}
+	inv.ID = existing.ID
+	inv.CreatedAt = existing.CreatedAt
+	if err := s.store.SaveInvoice(inv); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+// finalizeInvoice numbers a draft, locks it and renders its PDF.
+func (s *Server) finalizeInvoice(w http.ResponseWriter, r *http.Request) {
+	draft, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	inv, findings, err := invoicing.Finalize(s.store, draft)
+	if errors.Is(err, invoicing.ErrIncomplete) {
+		writeFindings(w, findings)
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+// invoiceRevisions returns the change history of an invoice, oldest first.
+func (s *Server) invoiceRevisions(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	revisions, err := s.store.InvoiceRevisions(inv.ID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if revisions == nil {
+		revisions = []models.InvoiceRevision{}
+	}
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+func (s *Server) deleteInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if err := s.store.DeleteInvoice(inv.ID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// invoicePDF returns the stored PDF, rendering it first if it does not exist.
+// Drafts have no PDF.
+func (s *Server) invoicePDF(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if inv.IsDraft() {
+		writeStoreError(w, invoicing.ErrDraft)
+		return
+	}
+	if inv.PDFPath == "" {
+		inv.PDFPath = s.store.InvoicePDFPath(inv.Number)
+		if err := s.store.SaveInvoice(inv); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+	}
+	if _, err := os.Stat(inv.PDFPath); errors.Is(err, os.ErrNotExist) {
+		profile, err := s.store.GetProfile(inv.ProfileID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		customer, err := s.store.GetCustomer(inv.CustomerID)
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		if err := invoicing.Render(s.store, profile, customer, inv); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(inv.PDFPath)))
+	http.ServeFile(w, r, inv.PDFPath)
+}
+
+// markPaid records the outstanding balance as a single payment unless the
+// invoice is settled already. It accepts an optional body
+// {"paid_at": "YYYY-MM-DD", "method": "..."}; without a date the payment is
+// booked today.
+func (s *Server) markPaid(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var body struct {
+		PaidAt string `json:"paid_at"`
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	payment := models.Payment{Date: time.Now(), Amount: inv.Outstanding(), Method: body.Method}
+	if body.PaidAt != "" {
+		if payment.Date, err = time.Parse(dateLayout, body.PaidAt); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid paid_at: %w", err))
+			return
+		}
+	}
+	if inv.IsSettled() && !inv.IsDraft() {
+		writeJSON(w, http.StatusOK, inv)
+		return
+	}
+	s.recordPayment(w, inv, payment, http.StatusOK)
+}
+
+// markUnpaid reverses the single payment booked by markPaid. Invoices with
+// several payments are rejected; their payments have to be removed one by one
+// through the payments endpoint.
+func (s *Server) markUnpaid(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	switch {
+	case inv.IsDraft():
+		writeStoreError(w, invoicing.ErrDraft)
+		return
+	case len(inv.Payments) == 0:
+		writeJSON(w, http.StatusOK, inv)
+		return
+	case len(inv.Payments) > 1:
+		writeError(w, http.StatusConflict, fmt.Errorf("invoice has %d payments; remove them through /api/invoices/%s/payments/{payment}", len(inv.Payments), inv.ID))
+		return
+	}
+	inv, err = invoicing.RemovePayment(s.store, inv, inv.Payments[0].ID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+func (s *Server) listPayments(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	payments := inv.Payments
+	if payments == nil {
+		payments = []models.Payment{}
+	}
+	writeJSON(w, http.StatusOK, payments)
+}
+
+// addPayment books a payment {"date", "amount", "method", "reference",
+// "note"} in the invoice currency. The date defaults to today.
+func (s *Server) addPayment(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var body struct {
+		Date      string        `json:"date"`
+		Amount    money.Decimal `json:"amount"`
+		Method    string        `json:"method"`
+		Reference string        `json:"reference"`
+		Note      string        `json:"note"`
+	}
+	if !decode(w, r, &body) {
+		return
+	}
+	payment := models.Payment{
+		Date:      time.Now(),
+		Amount:    money.FromDecimal(body.Amount, inv.Currency),
+		Method:    body.Method,
+		Reference: body.Reference,
+		Note:      body.Note,
+	}
+	if body.Date != "" {
+		if payment.Date, err = time.Parse(dateLayout, body.Date); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date: %w", err))
+			return
+		}
+	}
+	s.recordPayment(w, inv, payment, http.StatusCreated)
+}
+
+func (s *Server) recordPayment(w http.ResponseWriter, inv models.Invoice, payment models.Payment, status int) {
+	inv, err := invoicing.RecordPayment(s.store, inv, payment)
+	if errors.Is(err, invoicing.ErrInvalidPayment) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, status, inv)
+}
+
+func (s *Server) deletePayment(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	inv, err = invoicing.RemovePayment(s.store, inv, r.PathValue("payment"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+// sendReminder reminds the customer of an overdue invoice from an optional
+// body {"only", "date"}. Unless only is set, the customer's other overdue
+// invoices are listed in the same letter.
+func (s *Server) sendReminder(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var body struct {
+		Only bool   `json:"only"`
+		Date string `json:"date"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	now := time.Now()
+	if body.Date != "" {
+		if now, err = time.Parse(dateLayout, body.Date); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date: %w", err))
+			return
+		}
+	}
+	invoices := []models.Invoice{inv}
+	if !body.Only {
+		if invoices, err = invoicing.ReminderCandidates(s.store, inv, now); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+	}
+	updated, path, err := invoicing.SendReminder(s.store, invoices, now)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, struct {
+		Invoices []models.Invoice `json:"invoices"`
+		PDFPath  string           `json:"pdf_path"`
+	}{updated, path})
+}
+
+// dueReminders lists the invoices whose next reminder is due.
+func (s *Server) dueReminders(w http.ResponseWriter, _ *http.Request) {
+	due, err := invoicing.DueForReminder(s.store, time.Now())
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if due == nil {
+		due = []models.Invoice{}
+	}
+	writeJSON(w, http.StatusOK, due)
+}
+
+// invoiceInterest returns the late-payment interest accrued on an invoice up
+// to the date query parameter, or today.
+func (s *Server) invoiceInterest(w http.ResponseWriter, r *http.Request) {
+	inv, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	until := time.Now()
+	if date := r.URL.Query().Get("date"); date != "" {
+		if until, err = time.Parse(dateLayout, date); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date: %w", err))
+			return
+		}
+	}
+	profile, err := s.store.GetProfile(inv.ProfileID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	result := interest.Calculate(inv, profile.Interest, until)
+	if result.Periods == nil {
+		result.Periods = []interest.Period{}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// correctInvoice issues a credit note or cancellation for an invoice from a
+// body {"document_type", "reason", "issue_date"}.
+func (s *Server) correctInvoice(w http.ResponseWriter, r *http.Request) {
+	original, err := s.store.FindInvoice(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var body struct {
+		DocumentType string `json:"document_type"`
+		Reason       string `json:"reason"`
+		IssueDate    string `json:"issue_date"`
+	}
+	if !decode(w, r, &body) {
+		return
+	}
+	issue := time.Now()
+	if body.IssueDate != "" {
+		if issue, err = time.Parse(dateLayout, body.IssueDate); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid issue_date: %w", err))
+			return
+		}
+	}
+	switch body.DocumentType {
+	case models.DocumentCreditNote, models.DocumentCancellation:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown document_type %q", body.DocumentType))
+		return
+	}
+	correction, err := invoicing.Correct(s.store, original, body.DocumentType, body.Reason, issue)
+	if errors.Is(err, invoicing.ErrNotCorrectable) || errors.Is(err, invoicing.ErrAlreadyCorrected) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, correction)
+}
+
+// writeBuildError reports unknown profiles or customers as unprocessable
+// rather than as a missing resource.
+func writeBuildError(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusUnprocessableEntity, err)
+}
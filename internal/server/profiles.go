@@ -0,0 +1,126 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/einvoice"
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/numbering"
+	"github.com/janmarkuslanger/invoiceio/internal/pdf"
+	"github.com/janmarkuslanger/invoiceio/internal/sepa"
+)
+
+func (s *Server) listProfiles(w http.ResponseWriter, _ *http.Request) {
+	profiles, err := s.store.ListProfiles()
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := s.store.GetProfile(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (s *Server) createProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.Profile
+	if !decode(w, r, &profile) {
+		return
+	}
+	now := time.Now()
+	profile.ID = id.New()
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+	s.saveProfile(w, profile, http.StatusCreated)
+}
+
+func (s *Server) updateProfile(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.store.GetProfile(r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var profile models.Profile
+	if !decode(w, r, &profile) {
+		return
+	}
+	profile.ID = existing.ID
+	profile.CreatedAt = existing.CreatedAt
+	profile.UpdatedAt = time.Now()
+	s.saveProfile(w, profile, http.StatusOK)
+}
+
+// saveProfile applies the checks of the profile form before storing.
+func (s *Server) saveProfile(w http.ResponseWriter, profile models.Profile, status int) {
+	if strings.TrimSpace(profile.DisplayName) == "" {
+		writeError(w, http.StatusUnprocessableEntity, errors.New("display_name is required"))
+		return
+	}
+	for _, scheme := range []models.NumberingScheme{profile.InvoiceNumbering, profile.CreditNumbering, profile.QuoteNumbering} {
+		if err := numbering.Validate(scheme.Pattern); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+	if level := profile.EInvoice.FacturXProfile; level != "" && !einvoice.ValidLevel(level) {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("unknown Factur-X profile %q", level))
+		return
+	}
+	if id := profile.PaymentDetails.CreditorID; id != "" && !sepa.ValidCreditorID(id) {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("invalid creditor_id %q", id))
+		return
+	}
+	if err := pdf.CheckAssets(profile, s.store.BaseDir()); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err := s.store.SaveProfile(profile); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, status, profile)
+}
+
+func (s *Server) deleteProfile(w http.ResponseWriter, r *http.Request) {
+	profileID := r.PathValue("id")
+	if _, err := s.store.GetProfile(profileID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if !s.unreferenced(w, func(inv models.Invoice) bool { return inv.ProfileID == profileID }) {
+		return
+	}
+	if err := s.store.DeleteProfile(profileID); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unreferenced reports whether no invoice matches uses; otherwise it answers
+// with a conflict, since invoices must keep their issuer and recipient.
+func (s *Server) unreferenced(w http.ResponseWriter, uses func(models.Invoice) bool) bool {
+	invoices, err := s.store.ListInvoices()
+	if err != nil {
+		writeStoreError(w, err)
+		return false
+	}
+	for _, inv := range invoices {
+		if uses(inv) {
+			writeError(w, http.StatusConflict, fmt.Errorf("still used by invoice %s", inv.Number))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+// Package server exposes profiles, customers and invoices as a JSON HTTP API
+// on top of the storage layer.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+	"github.com/janmarkuslanger/invoiceio/internal/validation"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Defaults for running the API.
+const (
+	DefaultAddr = "127.0.0.1:8080"
+	TokenEnv    = "INVOICEIO_API_TOKEN"
+)
+
+// ErrMissingToken is returned when the server would run without
+// authentication.
+var ErrMissingToken = errors.New("server: an API token is required")
+
+// Server serves the API for one data directory.
+type Server struct {
+	store *storage.Storage
+	token string
+	mux   *http.ServeMux
+}
+
+// New returns the API handler. Every request except the OpenAPI
+// description has to send the token as "Authorization: Bearer <token>".
+func New(store *storage.Storage, token string) (*Server, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, ErrMissingToken
+	}
+	s := &Server{store: store, token: token, mux: http.NewServeMux()}
+	s.routes()
+	return s, nil
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/openapi.json", s.openAPI)
+
+	s.handle("GET /api/profiles", s.listProfiles)
+	s.handle("POST /api/profiles", s.createProfile)
+	s.handle("GET /api/profiles/{id}", s.getProfile)
+	s.handle("PUT /api/profiles/{id}", s.updateProfile)
+	s.handle("DELETE /api/profiles/{id}", s.deleteProfile)
+
+	s.handle("GET /api/customers", s.listCustomers)
+	s.handle("POST /api/customers", s.createCustomer)
+	s.handle("GET /api/customers/{id}", s.getCustomer)
+	s.handle("PUT /api/customers/{id}", s.updateCustomer)
+	s.handle("DELETE /api/customers/{id}", s.deleteCustomer)
+
+	s.handle("GET /api/invoices", s.listInvoices)
+	s.handle("POST /api/invoices", s.createInvoice)
+	s.handle("GET /api/invoices/{id}", s.getInvoice)
+	s.handle("PUT /api/invoices/{id}", s.updateInvoice)
+	s.handle("DELETE /api/invoices/{id}", s.deleteInvoice)
+	s.handle("GET /api/invoices/{id}/pdf", s.invoicePDF)
+	s.handle("POST /api/invoices/{id}/paid", s.markPaid)
+	s.handle("DELETE /api/invoices/{id}/paid", s.markUnpaid)
+	s.handle("GET /api/invoices/{id}/payments", s.listPayments)
+	s.handle("POST /api/invoices/{id}/payments", s.addPayment)
+	s.handle("DELETE /api/invoices/{id}/payments/{payment}", s.deletePayment)
+	s.handle("POST /api/invoices/{id}/corrections", s.correctInvoice)
+	s.handle("POST /api/invoices/{id}/finalize", s.finalizeInvoice)
+	s.handle("GET /api/invoices/{id}/revisions", s.invoiceRevisions)
+	s.handle("POST /api/invoices/{id}/reminders", s.sendReminder)
+	s.handle("GET /api/invoices/{id}/interest", s.invoiceInterest)
+	s.handle("GET /api/dunning", s.dueReminders)
+	s.handle("GET /api/direct-debits", s.directDebitCandidates)
+	s.handle("POST /api/direct-debits", s.exportDirectDebit)
+	s.handle("DELETE /api/invoices/{id}/collection", s.cancelCollection)
+}
+
+// handle registers an authenticated route.
+func (s *Server) handle(pattern string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "Bearer "
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, prefix) || subtle.ConstantTimeCompare([]byte(header[len(prefix):]), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="invoiceio"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		h(w, r)
+	})
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API on addr until ctx is done and then shuts the
+// listener down, giving running requests a few seconds to finish.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      2 * time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdown)
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// apiError is the body of every error response.
+type apiError struct {
+	Error    string        `json:"error"`
+	Findings []findingBody `json:"findings,omitempty"`
+}
+
+type findingBody struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func findingsBody(findings validation.Findings) []findingBody {
+	out := make([]findingBody, len(findings))
+	for i, f := range findings {
+		out[i] = findingBody{Rule: f.Rule, Severity: string(f.Severity), Message: f.Message()}
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+// writeStoreError maps storage errors onto status codes.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, storage.ErrFinalized), errors.Is(err, invoicing.ErrDraft), errors.Is(err, invoicing.ErrNotRemindable),
+		errors.Is(err, invoicing.ErrNotCollectable):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func writeFindings(w http.ResponseWriter, findings validation.Findings) {
+	writeJSON(w, http.StatusUnprocessableEntity, apiError{
+		Error:    "invoice is not complete",
+		Findings: findingsBody(findings),
+	})
+}
+
+// decode reads a JSON request body into v, rejecting unknown fields.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/storage"
+)
+
+const token = "secret"
+
+// newServer returns a server on a store with a finalised invoice "inv" over
+// 119.00 EUR and a draft "draft".
+func newServer(t *testing.T) (*Server, *storage.Storage) {
+	t.Helper()
+	store, err := storage.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := models.Profile{ID: "p", DisplayName: "Seller", AddressLine1: "Hauptstraße 1", PostalCode: "10115", City: "Berlin", Country: "DE", TaxID: "DE123456789"}
+	customer := models.Customer{ID: "c", DisplayName: "Buyer", AddressLine1: "Ring 2", PostalCode: "80331", City: "München", Country: "DE"}
+	if err := store.SaveProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveCustomer(customer); err != nil {
+		t.Fatal(err)
+	}
+	invoice := models.Invoice{
+		ID:         "inv",
+		ProfileID:  "p",
+		CustomerID: "c",
+		IssueDate:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:    time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		Currency:   "EUR",
+		Items: []models.InvoiceItem{{
+			Description:    "Consulting",
+			Quantity:       money.DecimalFromInt(1),
+			UnitPrice:      money.New(10000, "EUR"),
+			TaxRatePercent: money.DecimalFromInt(19),
+		}},
+	}
+	draft := invoice
+	draft.ID = "draft"
+	draft.Recalculate()
+	if err := store.SaveInvoice(draft); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invoicing.Create(store, profile, customer, invoice); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(store, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, store
+}
+
+// do sends an authenticated request unless auth is set explicitly.
+func do(srv *Server, method, path, auth, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNewRequiresToken(t *testing.T) {
+	if _, err := New(nil, " "); !errors.Is(err, ErrMissingToken) {
+		t.Errorf("err = %v, want ErrMissingToken", err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	srv, _ := newServer(t)
+	tests := []struct {
+		name, path, auth string
+		want             int
+	}{
+		{name: "valid token", path: "/api/invoices", auth: "Bearer secret", want: http.StatusOK},
+		{name: "no token", path: "/api/invoices", want: http.StatusUnauthorized},
+		{name: "wrong token", path: "/api/invoices", auth: "Bearer secrets", want: http.StatusUnauthorized},
+		{name: "other scheme", path: "/api/invoices", auth: "Basic secret", want: http.StatusUnauthorized},
+		{name: "openapi without token", path: "/api/openapi.json", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(srv, http.MethodGet, tt.path, tt.auth, "")
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header is missing")
+			}
+		})
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{name: "get invoice", method: http.MethodGet, path: "/api/invoices/inv", want: http.StatusOK},
+		{name: "unknown invoice", method: http.MethodGet, path: "/api/invoices/nope", want: http.StatusNotFound},
+		{name: "unknown customer", method: http.MethodGet, path: "/api/customers/nope", want: http.StatusNotFound},
+		{name: "update finalised invoice", method: http.MethodPut, path: "/api/invoices/inv", body: "{}", want: http.StatusConflict},
+		{name: "unknown field", method: http.MethodPost, path: "/api/customers", body: `{"nope": 1}`, want: http.StatusBadRequest},
+		{name: "invalid paid_at", method: http.MethodPost, path: "/api/invoices/inv/paid", body: `{"paid_at": "March"}`, want: http.StatusBadRequest},
+		{name: "mark paid", method: http.MethodPost, path: "/api/invoices/inv/paid", want: http.StatusOK},
+		{name: "mark draft paid", method: http.MethodPost, path: "/api/invoices/draft/paid", want: http.StatusConflict},
+		{name: "update profile", method: http.MethodPut, path: "/api/profiles/p", body: `{"display_name": "Seller"}`, want: http.StatusOK},
+		{name: "absolute logo path", method: http.MethodPut, path: "/api/profiles/p", body: `{"display_name": "Seller", "branding": {"logo": {"path": "/etc/passwd"}}}`, want: http.StatusUnprocessableEntity},
+		{name: "font outside the data directory", method: http.MethodPut, path: "/api/profiles/p", body: `{"display_name": "Seller", "fonts": {"regular": "../font.ttf"}}`, want: http.StatusUnprocessableEntity},
+		{name: "unknown payment", method: http.MethodDelete, path: "/api/invoices/inv/payments/nope", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newServer(t)
+			rec := do(srv, tt.method, tt.path, "Bearer "+token, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestMarkUnpaid(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		payments []int64
+		want     int
+		left     int
+	}{
+		{name: "no payments", id: "inv", want: http.StatusOK},
+		{name: "one payment", id: "inv", payments: []int64{11900}, want: http.StatusOK},
+		{name: "several payments", id: "inv", payments: []int64{4000, 7900}, want: http.StatusConflict, left: 2},
+		{name: "draft", id: "draft", want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, store := newServer(t)
+			invoice, err := store.GetInvoice(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, amount := range tt.payments {
+				invoice, err = invoicing.RecordPayment(store, invoice, models.Payment{Date: invoice.IssueDate, Amount: money.New(amount, "EUR")})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			rec := do(srv, http.MethodDelete, "/api/invoices/"+tt.id+"/paid", "Bearer "+token, "")
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if rec.Code == http.StatusOK {
+				var got models.Invoice
+				if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+					t.Fatal(err)
+				}
+				if len(got.Payments) != 0 {
+					t.Errorf("response has %d payments, want none", len(got.Payments))
+				}
+			}
+			stored, err := store.GetInvoice(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored.Payments) != tt.left {
+				t.Errorf("stored invoice has %d payments, want %d", len(stored.Payments), tt.left)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// camtDocument maps the parts of an ISO 20022 camt.053 statement that are
+// needed for payment matching. Element names are matched without namespace
+// so all camt.053 versions are accepted.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount      camtAmount      `xml:"Amt"`
+	Indicator   string          `xml:"CdtDbtInd"`
+	Status      camtStatus      `xml:"Sts"`
+	BookingDate camtDate        `xml:"BookgDt"`
+	ValueDate   camtDate        `xml:"ValDt"`
+	Reference   string          `xml:"AcctSvcrRef"`
+	Info        string          `xml:"AddtlNtryInf"`
+	Details     []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTxDetails struct {
+	Reference       string      `xml:"Refs>AcctSvcrRef"`
+	EndToEndID      string      `xml:"Refs>EndToEndId"`
+	Amount          *camtAmount `xml:"Amt"`
+	TxAmount        *camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	Indicator       string      `xml:"CdtDbtInd"`
+	Debtor          camtParty   `xml:"RltdPties>Dbtr"`
+	DebtorAccount   string      `xml:"RltdPties>DbtrAcct>Id>IBAN"`
+	Creditor        camtParty   `xml:"RltdPties>Cdtr"`
+	CreditorAccount string      `xml:"RltdPties>CdtrAcct>Id>IBAN"`
+	Unstructured    []string    `xml:"RmtInf>Ustrd"`
+	Structured      []string    `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	Info            string      `xml:"AddtlTxInf"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+// camtStatus holds the entry status, a plain code up to version 7 and a
+// nested Cd element from version 8 on.
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// camtParty holds the name of a party, directly below it up to version 7 and
+// below Pty from version 8 on.
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+// ParseCAMT reads the booked entries of a camt.053 statement. Batch entries
+// with several transaction details yield one transaction per detail.
+func ParseCAMT(data []byte) ([]Transaction, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("statement: camt.053: %w", err)
+	}
+	var out []Transaction
+	for _, stmt := range doc.Statements {
+		for _, entry := range stmt.Entries {
+			if status := strings.TrimSpace(entry.Status.Code + entry.Status.Text); status != "" && !strings.EqualFold(status, "BOOK") {
+				continue
+			}
+			date, err := entry.date()
+			if err != nil {
+				return nil, err
+			}
+			details := entry.Details
+			if len(details) == 0 {
+				details = []camtTxDetails{{}}
+			}
+			for _, d := range details {
+				tx, err := entry.transaction(d, len(entry.Details) > 1)
+				if err != nil {
+					return nil, err
+				}
+				tx.Date = date
+				out = append(out, tx)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (e camtEntry) date() (time.Time, error) {
+	for _, d := range []camtDate{e.BookingDate, e.ValueDate} {
+		if value := strings.TrimSpace(d.Date); value != "" {
+			return parseDate(value, "2006-01-02")
+		}
+		if value := strings.TrimSpace(d.DateTime); len(value) >= 10 {
+			return parseDate(value[:10], "2006-01-02")
+		}
+	}
+	return time.Time{}, fmt.Errorf("statement: camt.053: entry %s has no date", e.Reference)
+}
+
+// transaction combines an entry with one of its details. In batches the
+// detail amount is used; a lone detail inherits the entry amount.
+func (e camtEntry) transaction(d camtTxDetails, batch bool) (Transaction, error) {
+	amount := e.Amount
+	indicator := e.Indicator
+	if batch {
+		switch {
+		case d.TxAmount != nil:
+			amount = *d.TxAmount
+		case d.Amount != nil:
+			amount = *d.Amount
+		}
+		if d.Indicator != "" {
+			indicator = d.Indicator
+		}
+	}
+	value, err := money.Parse(strings.TrimSpace(amount.Value), amount.Currency)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("statement: camt.053: amount %q: %w", amount.Value, err)
+	}
+	tx := Transaction{Amount: value}
+	if strings.EqualFold(strings.TrimSpace(indicator), "DBIT") {
+		tx.Amount = tx.Amount.Neg()
+		tx.Counterparty, tx.IBAN = d.Creditor.name(), d.CreditorAccount
+	} else {
+		tx.Counterparty, tx.IBAN = d.Debtor.name(), d.DebtorAccount
+	}
+
+	remittance := append(append([]string(nil), d.Unstructured...), d.Structured...)
+	if len(remittance) == 0 {
+		remittance = append(remittance, d.Info, e.Info)
+	}
+	tx.Remittance = strings.TrimSpace(strings.Join(remittance, " "))
+
+	switch {
+	case batch && d.Reference != "":
+		tx.ID = d.Reference
+	case !batch && e.Reference != "":
+		tx.ID = e.Reference
+	case !batch && d.Reference != "":
+		tx.ID = d.Reference
+	}
+	tx.ID = strings.TrimSpace(tx.ID)
+	return tx, nil
+}
+
+func (p camtParty) name() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.PartyName
+}
+
+func parseDate(value, layout string) (time.Time, error) {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("statement: date %q: %w", value, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,254 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// CSVFormat describes the columns of a bank CSV export. Columns are named by
+// their header; empty names fall back to the headers common in German and
+// English online banking exports.
+type CSVFormat struct {
+	// Delimiter separates the fields; zero detects ';', ',' or tab.
+	Delimiter          rune   `json:"delimiter"`
+	DateColumn         string `json:"date_column"`
+	AmountColumn       string `json:"amount_column"`
+	CurrencyColumn     string `json:"currency_column"`
+	CounterpartyColumn string `json:"counterparty_column"`
+	IBANColumn         string `json:"iban_column"`
+	RemittanceColumn   string `json:"remittance_column"`
+	ReferenceColumn    string `json:"reference_column"`
+	// DateLayout is a Go time layout; empty tries DD.MM.YYYY, YYYY-MM-DD and
+	// MM/DD/YYYY.
+	DateLayout string `json:"date_layout"`
+	// Currency is used when there is no currency column.
+	Currency string `json:"currency"`
+	// DecimalSeparator separates the cents of amounts; zero means ','.
+	DecimalSeparator rune `json:"decimal_separator"`
+	// ThousandsSeparator groups the digits of amounts; zero means '.', or ','
+	// when the decimal separator is '.'.
+	ThousandsSeparator rune `json:"thousands_separator"`
+}
+
+var csvAliases = map[string][]string{
+	"date":         {"buchungstag", "buchungsdatum", "datum", "booking date", "date", "valutadatum", "wertstellung", "value date"},
+	"amount":       {"betrag", "betrag (eur)", "betrag (€)", "umsatz", "amount"},
+	"currency":     {"währung", "waehrung", "currency"},
+	"counterparty": {"name zahlungsbeteiligter", "beguenstigter/zahlungspflichtiger", "begünstigter/zahlungspflichtiger", "zahlungspflichtige*r", "auftraggeber/empfänger", "auftraggeber / begünstigter", "counterparty", "payer", "name"},
+	"iban":         {"iban zahlungsbeteiligter", "kontonummer/iban", "iban", "counterparty iban"},
+	"remittance":   {"verwendungszweck", "remittance information", "purpose", "reference text", "description"},
+	"reference":    {"bankreferenz", "bank reference", "transaction id"},
+}
+
+var csvDateLayouts = []string{"02.01.2006", "02.01.06", "2006-01-02", "01/02/2006"}
+
+// ParseCSV reads a bank CSV export. Lines before the header row, as written
+// by some banks, are skipped. Files that are not UTF-8 are read as Latin-1.
+func ParseCSV(data []byte, format CSVFormat) ([]Transaction, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		data = latin1ToUTF8(data)
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = format.Delimiter
+	if reader.Comma == 0 {
+		reader.Comma = detectDelimiter(data)
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("statement: csv: %w", err)
+	}
+
+	var cols map[string]int
+	for i, record := range records {
+		if cols = format.columns(record); cols != nil {
+			records = records[i+1:]
+			break
+		}
+	}
+	if cols == nil {
+		return nil, errors.New("statement: csv: no header row with date and amount columns")
+	}
+
+	var out []Transaction
+	for n, record := range records {
+		field := func(key string) string {
+			if i, ok := cols[key]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if field("date") == "" && field("amount") == "" {
+			continue
+		}
+		date, err := format.parseDate(field("date"))
+		if err != nil {
+			return nil, fmt.Errorf("statement: csv: row %d: %w", n+1, err)
+		}
+		currency := field("currency")
+		if currency == "" {
+			currency = format.Currency
+		}
+		if currency == "" {
+			currency = money.DefaultCurrency
+		}
+		raw := strings.TrimSpace(strings.NewReplacer("€", "", currency, "").Replace(field("amount")))
+		amount, err := format.parseAmount(raw, currency)
+		if err != nil {
+			return nil, fmt.Errorf("statement: csv: row %d: amount %q: %w", n+1, field("amount"), err)
+		}
+		out = append(out, Transaction{
+			ID:           field("reference"),
+			Date:         date,
+			Amount:       amount,
+			Counterparty: field("counterparty"),
+			IBAN:         field("iban"),
+			Remittance:   field("remittance"),
+		})
+	}
+	return out, nil
+}
+
+// columns maps the fields to their index in header, or returns nil if the
+// row does not name a date and an amount column.
+func (f CSVFormat) columns(header []string) map[string]int {
+	configured := map[string]string{
+		"date":         f.DateColumn,
+		"amount":       f.AmountColumn,
+		"currency":     f.CurrencyColumn,
+		"counterparty": f.CounterpartyColumn,
+		"iban":         f.IBANColumn,
+		"remittance":   f.RemittanceColumn,
+		"reference":    f.ReferenceColumn,
+	}
+	cols := make(map[string]int)
+	for key, name := range configured {
+		names := csvAliases[key]
+		if name = strings.TrimSpace(name); name != "" {
+			names = []string{name}
+		}
+		if i := headerIndex(header, names); i >= 0 {
+			cols[key] = i
+		}
+	}
+	_, hasDate := cols["date"]
+	_, hasAmount := cols["amount"]
+	if !hasDate || !hasAmount {
+		return nil
+	}
+	return cols
+}
+
+// headerIndex returns the first column whose header equals one of names,
+// trying the names in order of preference.
+func headerIndex(header, names []string) int {
+	for _, name := range names {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (f CSVFormat) parseDate(value string) (time.Time, error) {
+	layouts := csvDateLayouts
+	if f.DateLayout != "" {
+		layouts = []string{f.DateLayout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q does not match %s", value, strings.Join(layouts, ", "))
+}
+
+func (f CSVFormat) separators() (decimal, thousands rune) {
+	decimal, thousands = f.DecimalSeparator, f.ThousandsSeparator
+	if decimal == 0 {
+		decimal = ','
+	}
+	if thousands == 0 {
+		thousands = '.'
+		if decimal == '.' {
+			thousands = ','
+		}
+	}
+	return decimal, thousands
+}
+
+// parseAmount reads an amount written with the separators of the format,
+// signed by a leading '+' or '-' or a trailing '-'. Amounts with more than
+// two decimals or misplaced separators are rejected rather than rounded.
+func (f CSVFormat) parseAmount(value, currency string) (money.Money, error) {
+	decimal, thousands := f.separators()
+	invalid := fmt.Errorf("%q does not match the format 1%c234%c56", value, thousands, decimal)
+	sign := ""
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign, value = "-", value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	case strings.HasSuffix(value, "-"):
+		sign, value = "-", value[:len(value)-1]
+	}
+	whole, cents, _ := strings.Cut(strings.TrimSpace(value), string(decimal))
+	if len(cents) > 2 || !digits(cents) {
+		return money.Money{}, invalid
+	}
+	groups := strings.Split(whole, string(thousands))
+	for i, group := range groups {
+		if group == "" || !digits(group) || (i > 0 && len(group) != 3) || (i == 0 && len(groups) > 1 && len(group) > 3) {
+			return money.Money{}, invalid
+		}
+	}
+	d, err := money.ParseDecimal(sign + strings.Join(groups, "") + "." + cents + strings.Repeat("0", 2-len(cents)))
+	if err != nil {
+		return money.Money{}, invalid
+	}
+	return money.FromDecimal(d, currency), nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// detectDelimiter picks the separator that occurs most often in the first
+// lines.
+func detectDelimiter(data []byte) rune {
+	head := data
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	best, count := ';', 0
+	for _, r := range []rune{';', ',', '\t'} {
+		if n := bytes.Count(head, []byte(string(r))); n > count {
+			best, count = r, n
+		}
+	}
+	return best
+}
+
+func latin1ToUTF8(data []byte) []byte {
+	out := make([]rune, len(data))
+	for i, b := range data {
+		out[i] = rune(b)
+	}
+	return []byte(string(out))
+}
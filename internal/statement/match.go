@@ -0,0 +1,189 @@
+package statement
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// Reasons why a transaction was matched to an invoice.
+const (
+	ReasonNumber   = "number"
+	ReasonAmount   = "amount"
+	ReasonCustomer = "customer"
+	ReasonDiscount = "discount"
+)
+
+// Points per reason. The invoice number alone is enough for a proposal; an
+// amount needs the customer name as well.
+const (
+	scoreNumber   = 60
+	scoreAmount   = 30
+	scoreCustomer = 20
+
+	// MinScore is the least score for which a match is proposed.
+	MinScore = 50
+)
+
+// Match pairs a credit transaction with the invoice it most likely pays.
+// Invoice is nil when no open invoice scores at least MinScore. Recorded is
+// set when the transaction was booked on the invoice by an earlier import.
+type Match struct {
+	Transaction Transaction     `json:"transaction"`
+	Invoice     *models.Invoice `json:"invoice,omitempty"`
+	Score       int             `json:"score"`
+	Reasons     []string        `json:"reasons,omitempty"`
+	Recorded    bool            `json:"recorded"`
+}
+
+// Propose matches the credit transactions of a statement to invoices. Only
+// finalised invoices that are not settled and are in the transaction
+// currency are considered, and each invoice is proposed at most once, best
+// score first. Debits are left out of the result.
+func Propose(txs []Transaction, invoices []models.Invoice, customers []models.Customer) []Match {
+	names := make(map[string][]string, len(customers))
+	for _, c := range customers {
+		names[c.ID] = []string{c.DisplayName, c.ContactName}
+	}
+
+	recorded := make(map[string]models.Invoice)
+	for _, inv := range invoices {
+		for _, p := range inv.Payments {
+			if p.Reference != "" {
+				recorded[p.Reference] = inv
+			}
+		}
+	}
+
+	type candidate struct {
+		tx, inv int
+		score   int
+		reasons []string
+	}
+	var (
+		matches    []Match
+		candidates []candidate
+	)
+	for _, tx := range txs {
+		if !tx.IsCredit() {
+			continue
+		}
+		m := Match{Transaction: tx}
+		if inv, ok := recorded[tx.ID]; ok {
+			m.Invoice, m.Recorded = &inv, true
+		}
+		matches = append(matches, m)
+		if m.Recorded {
+			continue
+		}
+		for j, inv := range invoices {
+			if inv.IsDraft() || inv.IsSettled() || inv.Total.Sign() <= 0 || !strings.EqualFold(inv.Currency, tx.Amount.Currency) {
+				continue
+			}
+			score, reasons := scoreMatch(tx, inv, names[inv.CustomerID])
+			if score >= MinScore {
+				candidates = append(candidates, candidate{tx: len(matches) - 1, inv: j, score: score, reasons: reasons})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].score > candidates[b].score
+	})
+	used := make(map[int]bool)
+	for _, c := range candidates {
+		if matches[c.tx].Invoice != nil || used[c.inv] {
+			continue
+		}
+		inv := invoices[c.inv]
+		matches[c.tx].Invoice, matches[c.tx].Score, matches[c.tx].Reasons = &inv, c.score, c.reasons
+		used[c.inv] = true
+	}
+	return matches
+}
+
+func scoreMatch(tx Transaction, inv models.Invoice, customerNames []string) (int, []string) {
+	score, reasons := 0, []string(nil)
+	if containsNumber(normalizeRef(tx.Remittance), normalizeRef(inv.Number)) {
+		score += scoreNumber
+		reasons = append(reasons, ReasonNumber)
+	}
+	if tx.Amount.Cmp(inv.Outstanding()) == 0 {
+		score += scoreAmount
+		reasons = append(reasons, ReasonAmount)
+	} else if discountedAmount(tx, inv) {
+		score += scoreAmount
+		reasons = append(reasons, ReasonDiscount)
+	}
+	counterparty := normalizeName(tx.Counterparty)
+	for _, name := range customerNames {
+		if name = normalizeName(name); len(name) >= 3 && len(counterparty) >= 3 &&
+			(strings.Contains(counterparty, name) || strings.Contains(name, counterparty)) {
+			score += scoreCustomer
+			reasons = append(reasons, ReasonCustomer)
+			break
+		}
+	}
+	return score, reasons
+}
+
+// discountedAmount reports whether tx pays the unpaid invoice less an
+// early-payment discount whose deadline had not passed.
+func discountedAmount(tx Transaction, inv models.Invoice) bool {
+	if !inv.AmountPaid().IsZero() {
+		return false
+	}
+	for _, offer := range inv.DiscountOffers() {
+		if tx.Amount.Cmp(offer.Amount) == 0 && tx.Date.Before(offer.Deadline.AddDate(0, 0, 1)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNumber reports whether the invoice number occurs in the remittance
+// text without being part of a longer number, so INV-1 does not match
+// INV-10.
+func containsNumber(text, number string) bool {
+	if number == "" {
+		return false
+	}
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], number)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(number)
+		before := start > 0 && isDigit(text[start-1]) && isDigit(number[0])
+		after := end < len(text) && isDigit(text[end]) && isDigit(number[len(number)-1])
+		if !before && !after {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// normalizeRef keeps only letters and digits, upper-cased, because banks
+// drop or replace separators in remittance texts.
+func normalizeRef(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// normalizeName lower-cases a name and reduces it to single-spaced words.
+func normalizeName(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
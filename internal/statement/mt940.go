@@ -0,0 +1,139 @@
+package statement
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// mt940Line matches the statement line (:61:): value date, optional entry
+// date, debit/credit mark, optional funds code, amount, transaction type,
+// customer reference and optional bank reference.
+var mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d+,\d*)([A-Z0-9]{4})([^/]*)(?://(.*))?$`)
+
+// mt940Balance matches the opening balance (:60F: / :60M:) which carries the
+// account currency.
+var mt940Balance = regexp.MustCompile(`^[CD]\d{6}([A-Z]{3})`)
+
+type mt940Field struct {
+	tag   string
+	lines []string
+}
+
+// ParseMT940 reads the statement lines of a SWIFT MT940 file. Information
+// to the account owner (:86:) in the structured German format with ?nn
+// subfields is split into counterparty, IBAN and remittance; otherwise the
+// whole text is used as remittance.
+func ParseMT940(data []byte) ([]Transaction, error) {
+	var (
+		out      []Transaction
+		currency = money.DefaultCurrency
+		pending  *Transaction
+	)
+	flush := func() {
+		if pending != nil {
+			out = append(out, *pending)
+			pending = nil
+		}
+	}
+	for _, field := range mt940Fields(string(data)) {
+		switch field.tag {
+		case "60F", "60M":
+			if m := mt940Balance.FindStringSubmatch(field.lines[0]); m != nil {
+				currency = m[1]
+			}
+		case "61":
+			flush()
+			tx, err := parseMT940Line(field.lines[0], currency)
+			if err != nil {
+				return nil, err
+			}
+			pending = &tx
+		case "86":
+			if pending != nil {
+				applyMT940Info(pending, field.lines)
+				flush()
+			}
+		}
+	}
+	flush()
+	return out, nil
+}
+
+// mt940Fields splits the text into tagged fields with their continuation
+// lines. Block markers of the SWIFT envelope are ignored.
+func mt940Fields(text string) []mt940Field {
+	var fields []mt940Field
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, "\r ")
+		switch {
+		case line == "" || line == "-" || strings.HasPrefix(line, "-}") || strings.HasPrefix(line, "{"):
+			continue
+		case strings.HasPrefix(line, ":"):
+			end := strings.Index(line[1:], ":")
+			if end < 0 {
+				continue
+			}
+			fields = append(fields, mt940Field{tag: line[1 : end+1], lines: []string{line[end+2:]}})
+		case len(fields) > 0:
+			last := &fields[len(fields)-1]
+			last.lines = append(last.lines, line)
+		}
+	}
+	return fields
+}
+
+func parseMT940Line(line, currency string) (Transaction, error) {
+	m := mt940Line.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Transaction{}, fmt.Errorf("statement: mt940: malformed statement line %q", line)
+	}
+	date, err := parseDate(m[1], "060102")
+	if err != nil {
+		return Transaction{}, err
+	}
+	amount, err := money.Parse(strings.Replace(m[5], ",", ".", 1), currency)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("statement: mt940: amount %q: %w", m[5], err)
+	}
+	// D and RC (reversal of a credit) take money from the account.
+	if mark := m[3]; mark == "D" || mark == "RC" {
+		amount = amount.Neg()
+	}
+	tx := Transaction{Date: date, Amount: amount}
+	if ref := strings.TrimSpace(m[8]); ref != "" && !strings.EqualFold(ref, "NONREF") {
+		tx.ID = ref
+	}
+	return tx, nil
+}
+
+// applyMT940Info fills counterparty, IBAN and remittance from the :86:
+// field.
+func applyMT940Info(tx *Transaction, lines []string) {
+	text := strings.Join(lines, "")
+	if len(text) < 4 || text[3] != '?' {
+		tx.Remittance = strings.Join(lines, " ")
+		return
+	}
+	var remittance, name []string
+	for _, sub := range strings.Split(text[4:], "?") {
+		if len(sub) < 2 {
+			continue
+		}
+		// Subfields are cut at a fixed width, often within a word, so their
+		// values are concatenated as they are.
+		code, value := sub[:2], sub[2:]
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			remittance = append(remittance, value)
+		case code == "31":
+			tx.IBAN = strings.TrimSpace(value)
+		case code == "32" || code == "33":
+			name = append(name, value)
+		}
+	}
+	tx.Remittance = strings.Join(remittance, "")
+	tx.Counterparty = strings.Join(name, "")
+}
@@ -0,0 +1,116 @@
+// Package statement reads bank account statements (CAMT.053, MT940 and bank
+// CSV exports) and matches their credit transactions to open invoices.
+package statement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// Supported statement formats.
+const (
+	FormatCAMT  = "camt"
+	FormatMT940 = "mt940"
+	FormatCSV   = "csv"
+)
+
+// ErrUnknownFormat is returned for files that are neither CAMT.053, MT940
+// nor CSV.
+var ErrUnknownFormat = errors.New("statement: unknown file format")
+
+// Transaction is a single booking on the account. Credits have a positive
+// amount, debits a negative one.
+type Transaction struct {
+	ID           string      `json:"id"`
+	Date         time.Time   `json:"date"`
+	Amount       money.Money `json:"amount"`
+	Counterparty string      `json:"counterparty"`
+	IBAN         string      `json:"iban"`
+	Remittance   string      `json:"remittance"`
+}
+
+// IsCredit reports whether money was received.
+func (tx Transaction) IsCredit() bool {
+	return tx.Amount.Sign() > 0
+}
+
+// Parse reads a statement. The format is detected from the file name and
+// content; csv describes the columns of CSV exports.
+func Parse(name string, r io.Reader, csv CSVFormat) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("statement: read %s: %w", name, err)
+	}
+	var txs []Transaction
+	switch format := DetectFormat(name, data); format {
+	case FormatCAMT:
+		txs, err = ParseCAMT(data)
+	case FormatMT940:
+		txs, err = ParseMT940(data)
+	case FormatCSV:
+		txs, err = ParseCSV(data, csv)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range txs {
+		txs[i].Counterparty = strings.Join(strings.Fields(txs[i].Counterparty), " ")
+		txs[i].Remittance = strings.Join(strings.Fields(txs[i].Remittance), " ")
+		txs[i].IBAN = strings.ToUpper(strings.ReplaceAll(txs[i].IBAN, " ", ""))
+		if txs[i].ID == "" {
+			txs[i].ID = fingerprint(txs[i])
+		}
+	}
+	return txs, nil
+}
+
+// Load reads a statement file.
+func Load(path string, csv CSVFormat) ([]Transaction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("statement: open %s: %w", path, err)
+	}
+	defer f.Close()
+	return Parse(filepath.Base(path), f, csv)
+}
+
+// DetectFormat guesses the statement format from the file name and content.
+// It returns an empty string if the format is not recognised.
+func DetectFormat(name string, data []byte) string {
+	head := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	switch ext := strings.ToLower(filepath.Ext(name)); {
+	case ext == ".xml" || bytes.HasPrefix(head, []byte("<")):
+		if bytes.Contains(head, []byte("camt.053")) || bytes.Contains(head, []byte("BkToCstmrStmt")) {
+			return FormatCAMT
+		}
+	case ext == ".sta" || ext == ".mt940" || ext == ".940" || bytes.Contains(head, []byte(":20:")) && bytes.Contains(head, []byte(":25:")):
+		return FormatMT940
+	case ext == ".csv" || ext == ".txt":
+		return FormatCSV
+	}
+	return ""
+}
+
+// fingerprint derives a stable ID for transactions the bank did not give a
+// reference, so importing the same statement twice is recognised.
+func fingerprint(tx Transaction) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		tx.Date.Format("2006-01-02"), tx.Amount.String(), tx.IBAN, tx.Counterparty, tx.Remittance,
+	}, "\x00")))
+	return "tx-" + hex.EncodeToString(sum[:8])
+}
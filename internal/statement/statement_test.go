@@ -0,0 +1,186 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+)
+
+// summary formats a transaction for comparison.
+func summary(tx Transaction) string {
+	return strings.Join([]string{tx.Date.Format("2006-01-02"), tx.Amount.String(), tx.Counterparty, tx.IBAN, tx.Remittance, tx.ID}, "|")
+}
+
+func TestParseCSVAmounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		format CSVFormat
+		amount string
+		want   string
+	}{
+		{name: "german", amount: "1.234,56", want: "1234.56 EUR"},
+		{name: "german without grouping", amount: "1234,5", want: "1234.50 EUR"},
+		{name: "german negative", amount: "-12,00", want: "-12.00 EUR"},
+		{name: "trailing minus", amount: "12,00-", want: "-12.00 EUR"},
+		{name: "whole amount", amount: "25", want: "25.00 EUR"},
+		{name: "currency sign", amount: "1.000,00 €", want: "1000.00 EUR"},
+		{name: "english", format: CSVFormat{DecimalSeparator: '.'}, amount: "1,234.56", want: "1234.56 EUR"},
+		{name: "english millions", format: CSVFormat{DecimalSeparator: '.'}, amount: "+1,234,567.8", want: "1234567.80 EUR"},
+		{name: "swiss grouping", format: CSVFormat{DecimalSeparator: '.', ThousandsSeparator: '\''}, amount: "1'234.56", want: "1234.56 EUR"},
+		{name: "space grouping", format: CSVFormat{ThousandsSeparator: ' '}, amount: "1 234,56", want: "1234.56 EUR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "Buchungstag;Betrag;Verwendungszweck\n01.03.2025;\"" + tt.amount + "\";RE-1\n"
+			txs, err := ParseCSV([]byte(data), tt.format)
+			if err != nil {
+				t.Fatalf("ParseCSV: %v", err)
+			}
+			if len(txs) != 1 || txs[0].Amount.String() != tt.want {
+				t.Fatalf("got %v, want %s", txs, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVRejectsMismatchedAmounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		format CSVFormat
+		amount string
+	}{
+		{name: "english amount in german format", amount: "1,234.56"},
+		{name: "dot decimal in german format", amount: "12.50"},
+		{name: "german amount in english format", format: CSVFormat{DecimalSeparator: '.'}, amount: "1.234,56"},
+		{name: "three decimals", format: CSVFormat{DecimalSeparator: '.'}, amount: "1.234"},
+		{name: "short group", amount: "1.23,00"},
+		{name: "letters", amount: "12a,00"},
+		{name: "empty", amount: "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "Buchungstag;Betrag\n01.03.2025;\"" + tt.amount + "\"\n"
+			if txs, err := ParseCSV([]byte(data), tt.format); err == nil {
+				t.Fatalf("ParseCSV accepted %q as %v", tt.amount, txs)
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	data := "Kontoumsätze\n\n" +
+		"Buchungstag;Name Zahlungsbeteiligter;IBAN Zahlungsbeteiligter;Verwendungszweck;Betrag;Währung\n" +
+		"03.03.2025;Kunde GmbH;DE02120300000000202051;Rechnung RE-2025-0001;119,00;EUR\n" +
+		"04.03.2025;Vermieter;DE02500105170137075030;Miete;-800,00;EUR\n"
+	txs, err := ParseCSV([]byte(data), CSVFormat{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2025-03-03|119.00 EUR|Kunde GmbH|DE02120300000000202051|Rechnung RE-2025-0001|",
+		"2025-03-04|-800.00 EUR|Vermieter|DE02500105170137075030|Miete|",
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(txs), len(want))
+	}
+	for i, tx := range txs {
+		if got := summary(tx); got != want[i] {
+			t.Errorf("transaction %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestParseCAMT(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+<BkToCstmrStmt><Stmt>
+<Ntry>
+  <Amt Ccy="EUR">119.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+  <BookgDt><Dt>2025-03-03</Dt></BookgDt><AcctSvcrRef>REF1</AcctSvcrRef>
+  <NtryDtls><TxDtls>
+    <RltdPties><Dbtr><Pty><Nm>Kunde GmbH</Nm></Pty></Dbtr><DbtrAcct><Id><IBAN>DE02120300000000202051</IBAN></Id></DbtrAcct></RltdPties>
+    <RmtInf><Ustrd>RE-2025-0001</Ustrd></RmtInf>
+  </TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">50.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts>
+  <BookgDt><Dt>2025-03-04</Dt></BookgDt>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">300.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts>BOOK</Sts>
+  <ValDt><DtTm>2025-03-05T10:00:00</DtTm></ValDt>
+  <NtryDtls>
+    <TxDtls><Refs><AcctSvcrRef>B1</AcctSvcrRef></Refs><Amt Ccy="EUR">100.00</Amt><RltdPties><Dbtr><Nm>A</Nm></Dbtr></RltdPties><RmtInf><Ustrd>RE-2</Ustrd></RmtInf></TxDtls>
+    <TxDtls><Refs><AcctSvcrRef>B2</AcctSvcrRef></Refs><AmtDtls><TxAmt><Amt Ccy="EUR">200.00</Amt></TxAmt></AmtDtls><RltdPties><Dbtr><Nm>B</Nm></Dbtr></RltdPties><RmtInf><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf></TxDtls>
+  </NtryDtls>
+</Ntry>
+<Ntry>
+  <Amt Ccy="EUR">10.50</Amt><CdtDbtInd>DBIT</CdtDbtInd>
+  <BookgDt><Dt>2025-03-06</Dt></BookgDt><AddtlNtryInf>Kontoführung</AddtlNtryInf>
+</Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>`
+	txs, err := ParseCAMT([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2025-03-03|119.00 EUR|Kunde GmbH|DE02120300000000202051|RE-2025-0001|REF1",
+		"2025-03-05|100.00 EUR|A||RE-2|B1",
+		"2025-03-05|200.00 EUR|B||RF18539007547034|B2",
+		"2025-03-06|-10.50 EUR|||Kontoführung|",
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(txs), len(want))
+	}
+	for i, tx := range txs {
+		if got := summary(tx); got != want[i] {
+			t.Errorf("transaction %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestParseMT940(t *testing.T) {
+	data := ":20:STARTUMS\r\n" +
+		":25:12030000/0000202051\r\n" +
+		":28C:1/1\r\n" +
+		":60F:C250301CHF1000,00\r\n" +
+		":61:2503030303CR119,00NTRFNONREF//BANKREF1\r\n" +
+		":86:166?00GUTSCHRIFT?20RE-2025-?210001?31DE02120300000000202051?32Kunde GmbH\r\n" +
+		":61:250304DN800,NTRFNONREF\r\n" +
+		":86:Miete Maerz\r\n" +
+		":62F:C250304CHF319,00\r\n" +
+		"-\r\n"
+	txs, err := ParseMT940([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2025-03-03|119.00 CHF|Kunde GmbH|DE02120300000000202051|RE-2025-0001|BANKREF1",
+		"2025-03-04|-800.00 CHF|||Miete Maerz|",
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(txs), len(want))
+	}
+	for i, tx := range txs {
+		if got := summary(tx); got != want[i] {
+			t.Errorf("transaction %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name, file, data, want string
+	}{
+		{name: "camt by content", file: "statement.xml", data: `<Document><BkToCstmrStmt/></Document>`, want: FormatCAMT},
+		{name: "mt940 by content", file: "export.txt", data: ":20:STARTUMS\n:25:x\n:61:", want: FormatMT940},
+		{name: "csv by extension", file: "umsaetze.csv", data: "Buchungstag;Betrag", want: FormatCSV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat(tt.file, []byte(tt.data)); got != tt.want {
+				t.Errorf("DetectFormat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
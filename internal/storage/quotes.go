@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/numbering"
+)
+
+func (s *Storage) SaveQuote(q models.Quote) error {
+	q.UpdatedAt = time.Now()
+	return s.quoteStore.Set(q.ID, q)
+}
+
+func (s *Storage) GetQuote(id string) (models.Quote, error) {
+	return s.quoteStore.Get(id)
+}
+
+func (s *Storage) DeleteQuote(id string) error {
+	return s.quoteStore.Delete(id)
+}
+
+func (s *Storage) ListQuotes() ([]models.Quote, error) {
+	return listAll(s.quoteStore, func(q models.Quote) string {
+		return fmt.Sprintf("%s-%s", q.IssueDate.Format(time.RFC3339), q.Number)
+	})
+}
+
+// FindQuote resolves a quote by ID or, case-insensitively, by number.
+func (s *Storage) FindQuote(ref string) (models.Quote, error) {
+	if q, err := s.GetQuote(ref); !errors.Is(err, ErrNotFound) {
+		return q, err
+	}
+	quotes, err := s.ListQuotes()
+	if err != nil {
+		return models.Quote{}, err
+	}
+	for _, q := range quotes {
+		if strings.EqualFold(q.Number, ref) {
+			return q, nil
+		}
+	}
+	return models.Quote{}, fmt.Errorf("storage: quote %q: %w", ref, ErrNotFound)
+}
+
+// CreateQuote numbers the quote from the profile's quote numbering scheme and
+// stores it. Like invoices, the counter only advances after the save.
+func (s *Storage) CreateQuote(q models.Quote, profile models.Profile, customer models.Customer) (models.Quote, error) {
+	s.numberMu.Lock()
+	defer s.numberMu.Unlock()
+
+	scheme := profile.QuoteNumbering
+	if strings.TrimSpace(scheme.Pattern) == "" {
+		scheme.Pattern = numbering.DefaultQuotePattern
+	}
+	taken, err := s.quoteNumbers()
+	if err != nil {
+		return q, err
+	}
+	number, counter, err := s.nextNumber(quoteSequenceKey(profile.ID), scheme, customer, q.IssueDate, taken)
+	if err != nil {
+		return q, err
+	}
+	q.Number = number
+	if q.PDFPath == "" {
+		q.PDFPath = s.QuotePDFPath(number)
+	}
+	if err := s.SaveQuote(q); err != nil {
+		return q, err
+	}
+	if err := s.counterStore.Set(counter.Key, counter); err != nil {
+		return q, fmt.Errorf("storage: advance counter: %w", err)
+	}
+	return q, nil
+}
+
+// QuotePDFPath returns the default location of the PDF for a quote number.
+func (s *Storage) QuotePDFPath(number string) string {
+	return filepath.Join(s.baseDir, "pdf", "quotes", invoiceFileName(number)+".pdf")
+}
+
+func (s *Storage) quoteNumbers() (map[string]bool, error) {
+	quotes, err := s.ListQuotes()
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(quotes))
+	for _, q := range quotes {
+		taken[q.Number] = true
+	}
+	return taken, nil
+}
+
+func quoteSequenceKey(profileID string) string {
+	return profileID + ":quote"
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// ErrFinalized is returned when a finalised invoice would be changed beyond
+// its payments and reminders, or deleted.
+var ErrFinalized = errors.New("storage: invoice is finalised")
+
+// revisionFile keeps the invoice history as JSON lines. Unlike the json
+// stores it is opened in append mode only, so earlier entries are never
+// rewritten.
+const revisionFile = "revisions.jsonl"
+
+// checkFinalized reports ErrFinalized if next changes more of a finalised
+// invoice than its payments, reminders, direct debit collection, credits or
+// PDF location.
+func checkFinalized(prev, next models.Invoice) error {
+	if prev.IsDraft() {
+		return nil
+	}
+	for _, change := range models.DiffInvoices(prev, next) {
+		if change.Field != "pdf_path" && change.Field != "credited" && !strings.HasPrefix(change.Field, "payments[") && !strings.HasPrefix(change.Field, "reminders[") && !strings.HasPrefix(change.Field, "collection.") {
+			return fmt.Errorf("%w: %s can not change %s", ErrFinalized, prev.Number, change.Field)
+		}
+	}
+	return nil
+}
+
+// recordRevision appends the change from prev to next to the history. found
+// is false for new invoices.
+func (s *Storage) recordRevision(prev, next models.Invoice, found bool) error {
+	action := models.RevisionUpdated
+	switch {
+	case !found:
+		action = models.RevisionCreated
+	case prev.IsDraft() && !next.IsDraft():
+		action = models.RevisionFinalized
+	}
+	changes := models.DiffInvoices(prev, next)
+	if found && len(changes) == 0 {
+		return nil
+	}
+	return s.appendRevision(models.InvoiceRevision{
+		InvoiceID:  next.ID,
+		Number:     next.Number,
+		Action:     action,
+		Changes:    changes,
+		RecordedAt: time.Now(),
+	})
+}
+
+func (s *Storage) appendRevision(rev models.InvoiceRevision) error {
+	line, err := json.Marshal(rev)
+	if err != nil {
+		return fmt.Errorf("storage: encode revision: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.baseDir, revisionFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("storage: open revisions: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("storage: write revision: %w", err)
+	}
+	return f.Sync()
+}
+
+// InvoiceRevisions returns the history of an invoice, oldest first.
+func (s *Storage) InvoiceRevisions(invoiceID string) ([]models.InvoiceRevision, error) {
+	s.invoiceMu.Lock()
+	defer s.invoiceMu.Unlock()
+
+	f, err := os.Open(filepath.Join(s.baseDir, revisionFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: open revisions: %w", err)
+	}
+	defer f.Close()
+
+	var out []models.InvoiceRevision
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rev models.InvoiceRevision
+		if err := json.Unmarshal(scanner.Bytes(), &rev); err != nil {
+			return nil, fmt.Errorf("storage: read revisions: %w", err)
+		}
+		if rev.InvoiceID == invoiceID {
+			out = append(out, rev)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("storage: read revisions: %w", err)
+	}
+	return out, nil
+}
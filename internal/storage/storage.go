@@ -3,22 +3,35 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/janmarkuslanger/jsonstore"
 
 	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+	"github.com/janmarkuslanger/invoiceio/internal/numbering"
 )
 
 // Storage wires together the type-safe json stores that keep the application data.
 type Storage struct {
-	baseDir       string
-	profileStore  *jsonstore.Store[models.Profile]
-	customerStore *jsonstore.Store[models.Customer]
-	invoiceStore  *jsonstore.Store[models.Invoice]
+	baseDir           string
+	profileStore      *jsonstore.Store[models.Profile]
+	customerStore     *jsonstore.Store[models.Customer]
+	invoiceStore      *jsonstore.Store[models.Invoice]
+	quoteStore        *jsonstore.Store[models.Quote]
+	counterStore      *jsonstore.Store[models.SequenceCounter]
+	subscriptionStore *jsonstore.Store[models.Subscription]
+
+	// numberMu serialises number assignment so two saves never share a number.
+	numberMu sync.Mutex
+	// invoiceMu serialises invoice writes with their revision entries.
+	invoiceMu sync.Mutex
 }
 
 // ErrNotFound is returned when an entity can not be located in the underlying store.
@@ -45,12 +58,27 @@ func New(baseDir string) (*Storage, error) {
 	if err != nil {
 		return nil, fmt.Errorf("storage: open invoices store: %w", err)
 	}
+	quotes, err := jsonstore.NewStore[models.Quote](filepath.Join(baseDir, "quotes.json"))
+	if err != nil {
+		return nil, fmt.Errorf("storage: open quotes store: %w", err)
+	}
+	counters, err := jsonstore.NewStore[models.SequenceCounter](filepath.Join(baseDir, "counters.json"))
+	if err != nil {
+		return nil, fmt.Errorf("storage: open counters store: %w", err)
+	}
+	subscriptions, err := jsonstore.NewStore[models.Subscription](filepath.Join(baseDir, "subscriptions.json"))
+	if err != nil {
+		return nil, fmt.Errorf("storage: open subscriptions store: %w", err)
+	}
 
 	return &Storage{
-		baseDir:       baseDir,
-		profileStore:  profiles,
-		customerStore: customers,
-		invoiceStore:  invoices,
+		baseDir:           baseDir,
+		profileStore:      profiles,
+		customerStore:     customers,
+		invoiceStore:      invoices,
+		quoteStore:        quotes,
+		counterStore:      counters,
+		subscriptionStore: subscriptions,
 	}, nil
 }
 
@@ -88,23 +116,263 @@ func (s *Storage) ListCustomers() ([]models.Customer, error) {
 	return listAll(s.customerStore, func(c models.Customer) string { return c.DisplayName })
 }
 
+// SaveInvoice stores an invoice and appends the change to its revision
+// history. Finalised invoices only accept changes to their payment state.
 func (s *Storage) SaveInvoice(inv models.Invoice) error {
+	s.invoiceMu.Lock()
+	defer s.invoiceMu.Unlock()
+
+	prev, err := s.GetInvoice(inv.ID)
+	found := err == nil
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	// Stored invoices come back with the currency on every amount; a zero
+	// credit without one would otherwise show up as a change.
+	inv.Credited = inv.Credited.WithCurrency(inv.Currency)
+	if found {
+		if err := checkFinalized(prev, inv); err != nil {
+			return err
+		}
+	}
 	inv.UpdatedAt = time.Now()
-	return s.invoiceStore.Set(inv.ID, inv)
+	if err := s.invoiceStore.Set(inv.ID, inv); err != nil {
+		return err
+	}
+	return s.recordRevision(prev, inv, found)
 }
 
 func (s *Storage) GetInvoice(id string) (models.Invoice, error) {
-	return s.invoiceStore.Get(id)
+	inv, err := s.invoiceStore.Get(id)
+	if err != nil {
+		return inv, err
+	}
+	return migrateInvoice(inv), nil
 }
 
+// DeleteInvoice removes a draft. Finalised invoices have to be corrected
+// instead.
 func (s *Storage) DeleteInvoice(id string) error {
-	return s.invoiceStore.Delete(id)
+	s.invoiceMu.Lock()
+	defer s.invoiceMu.Unlock()
+
+	inv, err := s.GetInvoice(id)
+	if err != nil {
+		return err
+	}
+	if !inv.IsDraft() {
+		return fmt.Errorf("%w: %s can not be deleted", ErrFinalized, inv.Number)
+	}
+	if err := s.invoiceStore.Delete(id); err != nil {
+		return err
+	}
+	return s.appendRevision(models.InvoiceRevision{
+		InvoiceID:  inv.ID,
+		Number:     inv.Number,
+		Action:     models.RevisionDeleted,
+		RecordedAt: time.Now(),
+	})
 }
 
 func (s *Storage) ListInvoices() ([]models.Invoice, error) {
-	return listAll(s.invoiceStore, func(inv models.Invoice) string {
+	invoices, err := listAll(s.invoiceStore, func(inv models.Invoice) string {
 		return fmt.Sprintf("%s-%s", inv.IssueDate.Format(time.RFC3339), inv.Number)
 	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range invoices {
+		invoices[i] = migrateInvoice(invoices[i])
+	}
+	return invoices, nil
+}
+
+// FindInvoice resolves an invoice by ID or, case-insensitively, by number.
+func (s *Storage) FindInvoice(ref string) (models.Invoice, error) {
+	if inv, err := s.GetInvoice(ref); !errors.Is(err, ErrNotFound) {
+		return inv, err
+	}
+	invoices, err := s.ListInvoices()
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	for _, inv := range invoices {
+		if strings.EqualFold(inv.Number, ref) {
+			return inv, nil
+		}
+	}
+	return models.Invoice{}, fmt.Errorf("storage: invoice %q: %w", ref, ErrNotFound)
+}
+
+// FindProfile resolves a profile by ID, display name or company name.
+func (s *Storage) FindProfile(ref string) (models.Profile, error) {
+	profiles, err := s.ListProfiles()
+	if err != nil {
+		return models.Profile{}, err
+	}
+	for _, p := range profiles {
+		if p.ID == ref || strings.EqualFold(p.DisplayName, ref) || strings.EqualFold(p.CompanyName, ref) {
+			return p, nil
+		}
+	}
+	return models.Profile{}, fmt.Errorf("storage: profile %q: %w", ref, ErrNotFound)
+}
+
+// FindCustomer resolves a customer by ID or display name.
+func (s *Storage) FindCustomer(ref string) (models.Customer, error) {
+	customers, err := s.ListCustomers()
+	if err != nil {
+		return models.Customer{}, err
+	}
+	for _, c := range customers {
+		if c.ID == ref || strings.EqualFold(c.DisplayName, ref) {
+			return c, nil
+		}
+	}
+	return models.Customer{}, fmt.Errorf("storage: customer %q: %w", ref, ErrNotFound)
+}
+
+// CreateInvoice finalises an invoice: it assigns the next number from the
+// profile's numbering scheme and stores the invoice. The counter only advances
+// after the invoice was written, so a failed save does not leave a gap in the
+// sequence. Credit notes and cancellations are numbered from the profile's
+// credit numbering scheme.
+func (s *Storage) CreateInvoice(inv models.Invoice, profile models.Profile, customer models.Customer) (models.Invoice, error) {
+	s.numberMu.Lock()
+	defer s.numberMu.Unlock()
+
+	key, scheme := invoiceSequenceKey(profile.ID), profile.InvoiceNumbering
+	if inv.IsCorrection() {
+		key, scheme = creditSequenceKey(profile.ID), profile.CreditNumbering
+		if strings.TrimSpace(scheme.Pattern) == "" {
+			scheme.Pattern = numbering.DefaultCreditPattern
+		}
+	}
+	taken, err := s.invoiceNumbers()
+	if err != nil {
+		return inv, err
+	}
+	number, counter, err := s.nextNumber(key, scheme, customer, inv.IssueDate, taken)
+	if err != nil {
+		return inv, err
+	}
+	inv.Number = number
+	if inv.PDFPath == "" {
+		inv.PDFPath = s.InvoicePDFPath(number)
+	}
+	if inv.FinalizedAt.IsZero() {
+		inv.FinalizedAt = time.Now()
+	}
+	if err := s.SaveInvoice(inv); err != nil {
+		return inv, err
+	}
+	if err := s.counterStore.Set(counter.Key, counter); err != nil {
+		return inv, fmt.Errorf("storage: advance counter: %w", err)
+	}
+	return inv, nil
+}
+
+// InvoicePDFPath returns the default location of the PDF for an invoice number.
+func (s *Storage) InvoicePDFPath(number string) string {
+	return filepath.Join(s.baseDir, "pdf", invoiceFileName(number)+".pdf")
+}
+
+// InvoiceXRechnungPath returns where the XRechnung XML of an invoice is written.
+func (s *Storage) InvoiceXRechnungPath(number string) string {
+	return filepath.Join(s.baseDir, "xrechnung", invoiceFileName(number)+".xml")
+}
+
+// ReminderPDFPath returns where the reminder letter of the given level for an
+// invoice number is written.
+func (s *Storage) ReminderPDFPath(number string, level int) string {
+	return filepath.Join(s.baseDir, "reminders", fmt.Sprintf("%s-%d.pdf", invoiceFileName(number), level))
+}
+
+// DirectDebitPath returns where the pain.008 file of a direct debit export
+// is written.
+func (s *Storage) DirectDebitPath(messageID string) string {
+	return filepath.Join(s.baseDir, "sepa", invoiceFileName(messageID)+".xml")
+}
+
+func invoiceFileName(number string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(strings.ToLower(number))
+}
+
+// nextNumber formats the next number of a sequence, skipping numbers that are
+// already taken.
+func (s *Storage) nextNumber(key string, scheme models.NumberingScheme, customer models.Customer, date time.Time, taken map[string]bool) (string, models.SequenceCounter, error) {
+	counter, err := s.counterStore.Get(key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return "", counter, fmt.Errorf("storage: read counter: %w", err)
+	}
+	counter.Key = key
+	if scheme.ResetYearly && counter.Year != date.Year() {
+		counter.Last = 0
+	}
+	counter.Year = date.Year()
+
+	for {
+		counter.Last++
+		number, err := numbering.Format(scheme.Pattern, numbering.Context{
+			Date:     date,
+			Sequence: counter.Last,
+			Customer: customer.DisplayName,
+		})
+		if err != nil {
+			return "", counter, err
+		}
+		if !taken[number] {
+			counter.UpdatedAt = time.Now()
+			return number, counter, nil
+		}
+	}
+}
+
+func (s *Storage) invoiceNumbers() (map[string]bool, error) {
+	invoices, err := s.ListInvoices()
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(invoices))
+	for _, inv := range invoices {
+		taken[inv.Number] = true
+	}
+	return taken, nil
+}
+
+func invoiceSequenceKey(profileID string) string {
+	return profileID + ":invoice"
+}
+
+// creditSequenceKey keeps credit notes and cancellations in their own
+// sequence so invoice numbers stay gapless.
+func creditSequenceKey(profileID string) string {
+	return profileID + ":credit"
+}
+
+// ImportAsset copies a user supplied file such as a font into subdir of the
+// data directory and returns its path relative to BaseDir.
+func (s *Storage) ImportAsset(subdir, name string, r io.Reader) (string, error) {
+	name = filepath.Base(name)
+	if name == "." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("storage: invalid asset name")
+	}
+	dir := filepath.Join(s.baseDir, subdir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("storage: ensure asset directory: %w", err)
+	}
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", fmt.Errorf("storage: create asset: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return "", fmt.Errorf("storage: copy asset: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("storage: close asset: %w", err)
+	}
+	return filepath.Join(subdir, name), nil
 }
 
 // BaseDir returns the root directory that contains the json files.
@@ -112,6 +380,62 @@ func (s *Storage) BaseDir() string {
 	return s.baseDir
 }
 
+// migrateInvoice upgrades records written by older versions. Amounts that were
+// stored as plain floats carry no currency, so they inherit the invoice currency.
+// An invoice-wide tax rate is copied onto every line, keeping the stored totals.
+func migrateInvoice(inv models.Invoice) models.Invoice {
+	if inv.Currency == "" {
+		inv.Currency = money.DefaultCurrency
+	}
+	for i := range inv.Items {
+		inv.Items[i].UnitPrice = inv.Items[i].UnitPrice.WithCurrency(inv.Currency)
+		inv.Items[i].LineTotal = inv.Items[i].LineTotal.WithCurrency(inv.Currency)
+	}
+	inv.Subtotal = inv.Subtotal.WithCurrency(inv.Currency)
+	inv.TaxAmount = inv.TaxAmount.WithCurrency(inv.Currency)
+	inv.Total = inv.Total.WithCurrency(inv.Currency)
+	inv.Credited = inv.Credited.WithCurrency(inv.Currency)
+
+	if !inv.TaxRatePercent.IsZero() {
+		for i := range inv.Items {
+			inv.Items[i].TaxRatePercent = inv.TaxRatePercent
+		}
+		if len(inv.TaxBreakdown) == 0 {
+			inv.TaxBreakdown = []models.TaxLine{{
+				RatePercent: inv.TaxRatePercent,
+				Net:         inv.Subtotal,
+				Tax:         inv.TaxAmount,
+			}}
+		}
+		inv.TaxRatePercent = money.Decimal{}
+	}
+	if len(inv.TaxBreakdown) == 0 && len(inv.Items) > 0 {
+		inv.TaxBreakdown = models.TaxBreakdown(inv.Items, inv.Currency)
+	}
+	for i := range inv.Payments {
+		inv.Payments[i].Amount = inv.Payments[i].Amount.WithCurrency(inv.Currency)
+	}
+	// A paid flag becomes a single payment over the full total.
+	if !inv.PaidAt.IsZero() {
+		if len(inv.Payments) == 0 {
+			inv.Payments = []models.Payment{{
+				ID:     inv.ID + "-paid",
+				Date:   inv.PaidAt,
+				Amount: inv.Total,
+			}}
+		}
+		inv.PaidAt = time.Time{}
+	}
+	// Every invoice was issued on creation before drafts existed.
+	if inv.FinalizedAt.IsZero() && inv.Number != "" {
+		inv.FinalizedAt = inv.CreatedAt
+		if inv.FinalizedAt.IsZero() {
+			inv.FinalizedAt = inv.IssueDate
+		}
+	}
+	return inv
+}
+
 func listAll[T any](store *jsonstore.Store[T], sortKey func(T) string) ([]T, error) {
 	keys, err := store.Keys()
 	if err != nil {
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// invoice returns a draft over 100.00 EUR; finalized sets FinalizedAt and a
+// number.
+func invoice(finalized bool) models.Invoice {
+	inv := models.Invoice{
+		ID:        "inv",
+		IssueDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Currency:  "EUR",
+		Items: []models.InvoiceItem{{
+			Description: "Consulting",
+			Quantity:    money.DecimalFromInt(1),
+			UnitPrice:   money.New(10000, "EUR"),
+		}},
+	}
+	if finalized {
+		inv.Number = "RE-1"
+		inv.FinalizedAt = inv.IssueDate
+	}
+	inv.Recalculate()
+	return inv
+}
+
+func TestCheckFinalized(t *testing.T) {
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		draft  bool
+		change func(*models.Invoice)
+		err    error
+	}{
+		{name: "unchanged", change: func(*models.Invoice) {}},
+		{name: "payment", change: func(inv *models.Invoice) {
+			inv.Payments = []models.Payment{{ID: "p", Date: date, Amount: money.New(10000, "EUR")}}
+		}},
+		{name: "reminder", change: func(inv *models.Invoice) {
+			inv.Reminders = []models.Reminder{{ID: "r", Level: 1, Date: date}}
+		}},
+		{name: "collection", change: func(inv *models.Invoice) {
+			inv.Collection = models.Collection{MessageID: "m", Date: date, Amount: money.New(10000, "EUR")}
+		}},
+		{name: "credit", change: func(inv *models.Invoice) { inv.Credited = money.New(10000, "EUR") }},
+		{name: "PDF path", change: func(inv *models.Invoice) { inv.PDFPath = "invoices/RE-1.pdf" }},
+		{name: "notes", change: func(inv *models.Invoice) { inv.Notes = "changed" }, err: ErrFinalized},
+		{name: "number", change: func(inv *models.Invoice) { inv.Number = "RE-2" }, err: ErrFinalized},
+		{name: "due date", change: func(inv *models.Invoice) { inv.DueDate = date }, err: ErrFinalized},
+		{name: "item price", change: func(inv *models.Invoice) {
+			inv.Items[0].UnitPrice = money.New(20000, "EUR")
+			inv.Recalculate()
+		}, err: ErrFinalized},
+		{name: "draft notes", draft: true, change: func(inv *models.Invoice) { inv.Notes = "changed" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := invoice(!tt.draft)
+			next := invoice(!tt.draft)
+			tt.change(&next)
+			if err := checkFinalized(prev, next); !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestInvoiceRevisions(t *testing.T) {
+	store, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft := invoice(false)
+	finalized := invoice(true)
+	paid := finalized
+	paid.Payments = []models.Payment{{ID: "p", Date: finalized.IssueDate, Amount: money.New(10000, "EUR")}}
+	edited := paid
+	edited.Notes = "changed"
+
+	steps := []struct {
+		name    string
+		save    models.Invoice
+		delete  bool
+		err     error
+		actions []string
+	}{
+		{name: "create draft", save: draft, actions: []string{models.RevisionCreated}},
+		{name: "save unchanged", save: draft, actions: []string{models.RevisionCreated}},
+		{name: "finalise", save: finalized, actions: []string{models.RevisionCreated, models.RevisionFinalized}},
+		{name: "record payment", save: paid, actions: []string{models.RevisionCreated, models.RevisionFinalized, models.RevisionUpdated}},
+		{name: "edit finalised", save: edited, err: ErrFinalized, actions: []string{models.RevisionCreated, models.RevisionFinalized, models.RevisionUpdated}},
+		{name: "delete finalised", delete: true, err: ErrFinalized, actions: []string{models.RevisionCreated, models.RevisionFinalized, models.RevisionUpdated}},
+	}
+	var lines []string
+	for _, step := range steps {
+		if step.delete {
+			err = store.DeleteInvoice("inv")
+		} else {
+			err = store.SaveInvoice(step.save)
+		}
+		if !errors.Is(err, step.err) {
+			t.Fatalf("%s: err = %v, want %v", step.name, err, step.err)
+		}
+		revisions, err := store.InvoiceRevisions("inv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		actions := make([]string, len(revisions))
+		for i, rev := range revisions {
+			actions[i] = rev.Action
+		}
+		if !slices.Equal(actions, step.actions) {
+			t.Errorf("%s: actions = %v, want %v", step.name, actions, step.actions)
+		}
+		// Earlier lines of the history are never rewritten.
+		got := readLines(t, filepath.Join(store.BaseDir(), revisionFile))
+		if len(got) < len(lines) || !slices.Equal(got[:len(lines)], lines) {
+			t.Errorf("%s: earlier revisions were rewritten", step.name)
+		}
+		lines = got
+	}
+
+	revisions, err := store.InvoiceRevisions("inv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := revisions[2].Changes; len(changes) == 0 || changes[0].Field != "payments[p].date" {
+		t.Errorf("payment revision changes = %+v, want payments[p].date first", changes)
+	}
+}
+
+func TestDeleteDraftRecordsRevision(t *testing.T) {
+	store, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveInvoice(invoice(false)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteInvoice("inv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetInvoice("inv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetInvoice: err = %v, want ErrNotFound", err)
+	}
+	revisions, err := store.InvoiceRevisions("inv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[1].Action != models.RevisionDeleted {
+		t.Errorf("revisions = %+v, want created and deleted", revisions)
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
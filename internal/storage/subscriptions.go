@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+func (s *Storage) SaveSubscription(sub models.Subscription) error {
+	sub.UpdatedAt = time.Now()
+	return s.subscriptionStore.Set(sub.ID, sub)
+}
+
+func (s *Storage) GetSubscription(id string) (models.Subscription, error) {
+	return s.subscriptionStore.Get(id)
+}
+
+func (s *Storage) DeleteSubscription(id string) error {
+	return s.subscriptionStore.Delete(id)
+}
+
+func (s *Storage) ListSubscriptions() ([]models.Subscription, error) {
+	return listAll(s.subscriptionStore, func(sub models.Subscription) string {
+		return strings.ToLower(sub.Name) + "-" + sub.ID
+	})
+}
+
+// FindSubscription resolves a subscription by ID or, case-insensitively, by
+// name.
+func (s *Storage) FindSubscription(ref string) (models.Subscription, error) {
+	if sub, err := s.GetSubscription(ref); !errors.Is(err, ErrNotFound) {
+		return sub, err
+	}
+	subs, err := s.ListSubscriptions()
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	for _, sub := range subs {
+		if strings.EqualFold(sub.Name, ref) {
+			return sub, nil
+		}
+	}
+	return models.Subscription{}, fmt.Errorf("storage: subscription %q: %w", ref, ErrNotFound)
+}
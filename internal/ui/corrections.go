@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// openCorrectionDialog issues a cancellation or credit note for the selected
+// invoice. Issued invoices are never rewritten; corrections are separate
+// documents with their own number.
+func (u *UI) openCorrectionDialog() {
+	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
+		return
+	}
+	original := u.invoices[u.selectedInvoice]
+	if original.IsCorrection() || original.IsDraft() {
+		return
+	}
+
+	types := map[string]string{
+		i18n.T("invoices.type.cancellation"): models.DocumentCancellation,
+		i18n.T("invoices.type.creditNote"):   models.DocumentCreditNote,
+	}
+	docType := widget.NewRadioGroup([]string{i18n.T("invoices.type.cancellation"), i18n.T("invoices.type.creditNote")}, nil)
+	docType.Horizontal = true
+	docType.Required = true
+	docType.SetSelected(i18n.T("invoices.type.cancellation"))
+	issueDate := widget.NewEntry()
+	issueDate.SetPlaceHolder(i18n.T("invoices.form.issueDatePlaceholder"))
+	issueDate.SetText(time.Now().Format("2006-01-02"))
+	reason := widget.NewMultiLineEntry()
+	reason.SetPlaceHolder(i18n.T("invoices.correction.reasonPlaceholder"))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(i18n.T("invoices.correction.type"), docType),
+		widget.NewFormItem(i18n.T("invoices.form.issueDate"), issueDate),
+		widget.NewFormItem(i18n.T("invoices.correction.reason"), reason),
+	}
+	dlg := dialog.NewForm(i18n.T("invoices.correction.title", original.Number), i18n.T("invoices.correction.create"), i18n.T("common.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		issue, err := time.Parse("2006-01-02", strings.TrimSpace(issueDate.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.issueDate")), u.win)
+			return
+		}
+		correction, err := invoicing.Correct(u.store, original, types[docType.Selected], strings.TrimSpace(reason.Text), issue)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.correctionFailed", err)), u.win)
+			return
+		}
+		u.refreshInvoices(correction.ID)
+		dialog.ShowInformation(i18n.T("invoices.info.correctionTitle"), i18n.T("invoices.info.correctionBody", correction.Number, original.Number, correction.PDFPath), u.win)
+	}, u.win)
+	dlg.Resize(fyne.NewSize(480, 320))
+	dlg.Show()
+}
+
+// documentTypeLabel names the document type of an invoice record.
+func documentTypeLabel(inv models.Invoice) string {
+	switch inv.Type() {
+	case models.DocumentCreditNote:
+		return i18n.T("invoices.type.creditNote")
+	case models.DocumentCancellation:
+		return i18n.T("invoices.type.cancellation")
+	default:
+		return i18n.T("invoices.type.invoice")
+	}
+}
@@ -87,6 +87,13 @@ func (u *UI) openCustomerDialog(existing *models.Customer) {
 	postalCode := widget.NewEntry()
 	country := widget.NewEntry()
 	notes := widget.NewMultiLineEntry()
+	leitwegID := widget.NewEntry()
+	leitwegID.SetPlaceHolder(i18n.T("customers.form.leitwegIDPlaceholder"))
+	vatID := widget.NewEntry()
+	vatID.SetPlaceHolder(i18n.T("customers.form.vatIDPlaceholder"))
+	terms := newTermsInput(current.Terms)
+	exemption := newExemptionInput(current.TaxExemption)
+	mandate := newMandateInput(current.Mandate)
 
 	if isEdit {
 		displayName.SetText(current.DisplayName)
@@ -99,6 +106,8 @@ func (u *UI) openCustomerDialog(existing *models.Customer) {
 		postalCode.SetText(current.PostalCode)
 		country.SetText(current.Country)
 		notes.SetText(current.Notes)
+		leitwegID.SetText(current.LeitwegID)
+		vatID.SetText(current.VATID)
 	}
 
 	form := widget.NewForm(
@@ -111,6 +120,11 @@ func (u *UI) openCustomerDialog(existing *models.Customer) {
 		widget.NewFormItem(i18n.T("customers.form.city"), city),
 		widget.NewFormItem(i18n.T("customers.form.postalCode"), postalCode),
 		widget.NewFormItem(i18n.T("customers.form.country"), country),
+		widget.NewFormItem(i18n.T("customers.form.leitwegID"), leitwegID),
+		widget.NewFormItem(i18n.T("customers.form.vatID"), vatID),
+		widget.NewFormItem(i18n.T("customers.form.terms"), terms.row),
+		widget.NewFormItem(i18n.T("customers.form.exemption"), exemption.row),
+		widget.NewFormItem(i18n.T("customers.form.mandate"), mandate.row),
 		widget.NewFormItem(i18n.T("customers.form.notes"), notes),
 	)
 
@@ -118,6 +132,14 @@ func (u *UI) openCustomerDialog(existing *models.Customer) {
 		if strings.TrimSpace(displayName.Text) == "" {
 			return fmt.Errorf("%s", i18n.T("customers.error.displayNameRequired"))
 		}
+		paymentTerms, err := terms.value()
+		if err != nil {
+			return err
+		}
+		directDebit, err := mandate.value()
+		if err != nil {
+			return err
+		}
 		now := time.Now()
 		customerID := ""
 		createdAt := now
@@ -139,6 +161,11 @@ func (u *UI) openCustomerDialog(existing *models.Customer) {
 			PostalCode:   strings.TrimSpace(postalCode.Text),
 			Country:      strings.TrimSpace(country.Text),
 			Notes:        strings.TrimSpace(notes.Text),
+			LeitwegID:    strings.TrimSpace(leitwegID.Text),
+			VATID:        strings.TrimSpace(vatID.Text),
+			Terms:        paymentTerms,
+			TaxExemption: exemption.value(),
+			Mandate:      directDebit,
 			CreatedAt:    createdAt,
 			UpdatedAt:    now,
 		}
@@ -181,6 +208,18 @@ func (u *UI) updateCustomerDetail() {
 	}
 	lines = append(lines, i18n.T("customers.detail.cityPostal", strings.TrimSpace(c.PostalCode), strings.TrimSpace(c.City)))
 	lines = append(lines, i18n.T("customers.detail.country", strings.TrimSpace(c.Country)))
+	if c.LeitwegID != "" {
+		lines = append(lines, "", i18n.T("customers.detail.leitwegID", c.LeitwegID))
+	}
+	if c.VATID != "" {
+		lines = append(lines, "", i18n.T("customers.detail.vatID", c.VATID))
+	}
+	if !c.Terms.IsZero() {
+		lines = append(lines, "", i18n.T("customers.detail.termsTitle"))
+		lines = append(lines, termsLines(c.Terms)...)
+	}
+	lines = append(lines, exemptionLines(c.TaxExemption)...)
+	lines = append(lines, mandateLines(c.Mandate)...)
 	if strings.TrimSpace(c.Notes) != "" {
 		lines = append(lines, "", i18n.T("customers.detail.notesTitle"), c.Notes)
 	}
@@ -5,6 +5,7 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/janmarkuslanger/invoiceio/internal/i18n"
@@ -39,3 +40,32 @@ func (u *UI) showFormDialog(title, submitLabel string, form *widget.Form, submit
 	dlg.Resize(fyne.NewSize(520, form.MinSize().Height+120))
 	dlg.Show()
 }
+
+// newAssetPicker returns an entry holding an asset path together with a browse
+// button. Picked files are copied into subdir of the data directory so the
+// profile keeps working if the original file moves.
+func (u *UI) newAssetPicker(subdir string, extensions []string) (*widget.Entry, fyne.CanvasObject) {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(i18n.T("forms.placeholder.default"))
+	browse := widget.NewButton(i18n.T("common.browse"), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialogError(u.win, err)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+			path, err := u.store.ImportAsset(subdir, reader.URI().Name(), reader)
+			if err != nil {
+				dialogError(u.win, err)
+				return
+			}
+			entry.SetText(path)
+		}, u.win)
+		open.SetFilter(storage.NewExtensionFileFilter(extensions))
+		open.Show()
+	})
+	return entry, container.NewBorder(nil, nil, nil, browse, entry)
+}
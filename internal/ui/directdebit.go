@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/sepa"
+)
+
+// mandateInput edits the SEPA direct debit mandate of a customer. Leaving the
+// reference empty removes the mandate.
+type mandateInput struct {
+	current       models.Mandate
+	id            *widget.Entry
+	signatureDate *widget.Entry
+	sequence      *widget.Select
+	iban          *widget.Entry
+	bic           *widget.Entry
+	row           fyne.CanvasObject
+}
+
+func newMandateInput(current models.Mandate) *mandateInput {
+	in := &mandateInput{
+		current:       current,
+		id:            widget.NewEntry(),
+		signatureDate: widget.NewEntry(),
+		sequence:      widget.NewSelect(nil, nil),
+		iban:          widget.NewEntry(),
+		bic:           widget.NewEntry(),
+	}
+	in.id.SetPlaceHolder(i18n.T("mandate.form.idPlaceholder"))
+	in.signatureDate.SetPlaceHolder("YYYY-MM-DD")
+	for _, seq := range models.SequenceTypes {
+		in.sequence.Options = append(in.sequence.Options, sequenceLabel(seq))
+	}
+	in.sequence.SetSelectedIndex(0)
+	if !current.IsZero() {
+		in.id.SetText(current.ID)
+		in.signatureDate.SetText(current.SignatureDate.Format("2006-01-02"))
+		for i, seq := range models.SequenceTypes {
+			if seq == current.SequenceType {
+				in.sequence.SetSelectedIndex(i)
+			}
+		}
+		in.iban.SetText(current.IBAN)
+		in.bic.SetText(current.BIC)
+	}
+	in.row = widget.NewForm(
+		widget.NewFormItem(i18n.T("mandate.form.id"), in.id),
+		widget.NewFormItem(i18n.T("mandate.form.signatureDate"), in.signatureDate),
+		widget.NewFormItem(i18n.T("mandate.form.sequence"), in.sequence),
+		widget.NewFormItem(i18n.T("mandate.form.iban"), in.iban),
+		widget.NewFormItem(i18n.T("mandate.form.bic"), in.bic),
+	)
+	return in
+}
+
+// value returns the entered mandate. The date of the last collection is kept
+// unless the mandate reference changed.
+func (in *mandateInput) value() (models.Mandate, error) {
+	id := strings.TrimSpace(in.id.Text)
+	if id == "" {
+		return models.Mandate{}, nil
+	}
+	mandate := models.Mandate{
+		ID:           id,
+		SequenceType: models.SequenceTypes[max(in.sequence.SelectedIndex(), 0)],
+		IBAN:         sepa.Compact(in.iban.Text),
+		BIC:          sepa.Compact(in.bic.Text),
+	}
+	if id == in.current.ID {
+		mandate.LastCollection = in.current.LastCollection
+	}
+	signed, err := time.Parse("2006-01-02", strings.TrimSpace(in.signatureDate.Text))
+	if err != nil {
+		return mandate, fmt.Errorf("%s", i18n.T("mandate.error.signatureDate"))
+	}
+	mandate.SignatureDate = signed
+	if err := sepa.CheckMandate(mandate); err != nil {
+		return mandate, fmt.Errorf("%s: %w", i18n.T("mandate.error.invalid"), err)
+	}
+	return mandate, nil
+}
+
+func sequenceLabel(sequence string) string {
+	return i18n.T("mandate.sequence." + sequence)
+}
+
+// mandateLines describes a customer's mandate for the detail pane.
+func mandateLines(m models.Mandate) []string {
+	if m.IsZero() {
+		return nil
+	}
+	lines := []string{
+		"",
+		i18n.T("mandate.detail.title"),
+		i18n.T("mandate.detail.id", m.ID, m.SignatureDate.Format("2006-01-02")),
+		i18n.T("mandate.detail.account", m.IBAN, m.BIC),
+		i18n.T("mandate.detail.sequence", sequenceLabel(m.SequenceType)),
+	}
+	if !m.LastCollection.IsZero() {
+		lines = append(lines, i18n.T("mandate.detail.lastCollection", m.LastCollection.Format("2006-01-02")))
+	}
+	return lines
+}
+
+// collectionLines shows the direct debit collection an invoice was exported
+// for.
+func collectionLines(inv models.Invoice) []string {
+	c := inv.Collection
+	if c.IsZero() {
+		return nil
+	}
+	return []string{
+		"",
+		i18n.T("directDebit.detail.title"),
+		i18n.T("directDebit.detail.entry", c.Amount, c.Date.Format("2006-01-02"), sequenceLabel(c.SequenceType), c.MandateID),
+		i18n.T("directDebit.detail.file", c.MessageID, c.ExportedAt.Format("2006-01-02")),
+	}
+}
+
+// openDirectDebitDialog exports open invoices of a profile whose customers
+// signed a mandate into a pain.008 file.
+func (u *UI) openDirectDebitDialog() {
+	if len(u.profiles) == 0 {
+		dialog.ShowInformation(i18n.T("directDebit.dialog.title"), i18n.T("directDebit.dialog.noProfiles"), u.win)
+		return
+	}
+	now := time.Now()
+	var candidates []models.Invoice
+	var checks []*widget.Check
+	list := container.NewVBox()
+
+	names := make([]string, len(u.profiles))
+	for i, p := range u.profiles {
+		names[i] = p.DisplayName
+	}
+	profileSelect := widget.NewSelect(names, nil)
+	profileSelect.OnChanged = func(string) {
+		profile := u.profiles[profileSelect.SelectedIndex()]
+		list.Objects = nil
+		checks = nil
+		var err error
+		if candidates, err = invoicing.DirectDebitCandidates(u.store, profile.ID); err != nil {
+			list.Add(widget.NewLabel(i18n.T("directDebit.error.exportFailed", err)))
+		} else if len(candidates) == 0 {
+			list.Add(widget.NewLabel(i18n.T("directDebit.dialog.empty")))
+		}
+		for _, c := range candidates {
+			customerName := c.CustomerID
+			if cust, ok := u.customerByID(c.CustomerID); ok {
+				customerName = cust.DisplayName
+			}
+			collect := sepa.CollectionDate(c.DueDate, now)
+			check := widget.NewCheck(i18n.T("directDebit.dialog.invoice", c.Number, customerName, c.Outstanding(), collect.Format("2006-01-02")), nil)
+			check.SetChecked(true)
+			checks = append(checks, check)
+			list.Add(check)
+		}
+		list.Refresh()
+	}
+	profileSelect.SetSelectedIndex(0)
+	for i, p := range u.profiles {
+		if p.ID == u.lastProfileID {
+			profileSelect.SetSelectedIndex(i)
+		}
+	}
+
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(560, 240))
+	content := container.NewBorder(container.NewVBox(profileSelect, widget.NewLabel(i18n.T("directDebit.dialog.intro"))), nil, nil, nil, scroll)
+
+	confirm := dialog.NewCustomConfirm(i18n.T("directDebit.dialog.title"), i18n.T("directDebit.dialog.export"), i18n.T("common.cancel"), content, func(ok bool) {
+		if !ok {
+			return
+		}
+		var selected []models.Invoice
+		for i, check := range checks {
+			if check.Checked {
+				selected = append(selected, candidates[i])
+			}
+		}
+		if len(selected) == 0 {
+			return
+		}
+		_, path, err := invoicing.ExportDirectDebit(u.store, selected, now)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("directDebit.error.exportFailed", err)), u.win)
+			return
+		}
+		u.refreshCustomers()
+		u.refreshInvoices()
+		dialog.ShowInformation(i18n.T("directDebit.info.exportedTitle"), i18n.T("directDebit.info.exportedBody", len(selected), path), u.win)
+	}, u.win)
+	confirm.Resize(fyne.NewSize(620, 400))
+	confirm.Show()
+}
+
+// cancelCollection removes the pending direct debit collection of the
+// selected invoice.
+func (u *UI) cancelCollection() {
+	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
+		return
+	}
+	inv := u.invoices[u.selectedInvoice]
+	if !inv.CollectionPending() {
+		return
+	}
+	dialog.ShowConfirm(i18n.T("directDebit.cancel.title"), i18n.T("directDebit.cancel.confirm", inv.Number), func(ok bool) {
+		if !ok {
+			return
+		}
+		if _, err := invoicing.CancelCollection(u.store, inv); err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("directDebit.error.cancelFailed", err)), u.win)
+			return
+		}
+		u.refreshCustomers()
+		u.refreshInvoices(inv.ID)
+	}, u.win)
+}
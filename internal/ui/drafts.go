@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// finalizeDraft numbers the selected draft and locks it against further edits.
+func (u *UI) finalizeDraft() {
+	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
+		return
+	}
+	draft := u.invoices[u.selectedInvoice]
+	if !draft.IsDraft() {
+		return
+	}
+	message := i18n.T("invoices.finalize.confirm")
+	profile, perr := u.store.GetProfile(draft.ProfileID)
+	customer, cerr := u.store.GetCustomer(draft.CustomerID)
+	if perr == nil && cerr == nil {
+		if findings := invoicing.CheckNew(profile, customer, draft); len(findings) > 0 && !findings.HasErrors() {
+			message += "\n\n" + findings.String()
+		}
+	}
+	dialog.ShowConfirm(i18n.T("invoices.finalize.title"), message, func(ok bool) {
+		if !ok {
+			return
+		}
+		invoice, findings, err := invoicing.Finalize(u.store, draft)
+		if errors.Is(err, invoicing.ErrIncomplete) {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.validation.errors", findings.String())), u.win)
+			return
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.finalizeFailed", err)), u.win)
+			return
+		}
+		u.refreshInvoices(invoice.ID)
+		dialog.ShowInformation(i18n.T("invoices.info.createdTitle"), i18n.T("invoices.info.createdBody", invoice.Number, invoice.PDFPath), u.win)
+	}, u.win)
+}
+
+// deleteDraft removes the selected draft. Finalised invoices are corrected
+// with a cancellation instead.
+func (u *UI) deleteDraft() {
+	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
+		return
+	}
+	draft := u.invoices[u.selectedInvoice]
+	if !draft.IsDraft() {
+		return
+	}
+	dialog.ShowConfirm(i18n.T("invoices.delete.title"), i18n.T("invoices.delete.confirm"), func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := u.store.DeleteInvoice(draft.ID); err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.deleteFailed", err)), u.win)
+			return
+		}
+		u.refreshInvoices()
+	}, u.win)
+}
+
+// invoiceNumberLabel returns the number of an invoice, or a placeholder for
+// drafts that are not numbered yet.
+func invoiceNumberLabel(inv models.Invoice) string {
+	if inv.IsDraft() {
+		return i18n.T("invoices.summary.draftNumber")
+	}
+	return inv.Number
+}
+
+// revisionLines renders the change history of an invoice for the detail
+// pane, oldest entry first.
+func (u *UI) revisionLines(inv models.Invoice) []string {
+	revisions, err := u.store.InvoiceRevisions(inv.ID)
+	if err != nil {
+		return []string{i18n.T("invoices.history.title"), i18n.T("invoices.history.loadFailed", err)}
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+	lines := []string{i18n.T("invoices.history.title")}
+	for _, rev := range revisions {
+		entry := i18n.T("invoices.history.entry", rev.RecordedAt.Format("2006-01-02 15:04"), revisionActionLabel(rev.Action))
+		if rev.Action != models.RevisionCreated && len(rev.Changes) > 0 {
+			changes := make([]string, len(rev.Changes))
+			for i, change := range rev.Changes {
+				changes[i] = i18n.T("invoices.history.change", change.Field, orDash(change.Old), orDash(change.New))
+			}
+			entry += ": " + strings.Join(changes, "; ")
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func revisionActionLabel(action string) string {
+	switch action {
+	case models.RevisionCreated, models.RevisionUpdated, models.RevisionFinalized, models.RevisionDeleted:
+		return i18n.T("invoices.history." + action)
+	default:
+		return action
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "–"
+	}
+	return value
+}
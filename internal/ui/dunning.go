@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/interest"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// openReminderDialog sends a reminder for the selected invoice. Other overdue
+// invoices of the customer are offered for the same letter.
+func (u *UI) openReminderDialog() {
+	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
+		return
+	}
+	inv := u.invoices[u.selectedInvoice]
+	now := time.Now()
+	candidates, err := invoicing.ReminderCandidates(u.store, inv, now)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s", i18n.T("dunning.error.sendFailed", err)), u.win)
+		return
+	}
+	profile, _ := u.profileByID(inv.ProfileID)
+	levels := invoicing.DunningLevels(profile)
+
+	checks := make([]*widget.Check, len(candidates))
+	list := container.NewVBox(widget.NewLabel(i18n.T("dunning.dialog.intro")))
+	for i, c := range candidates {
+		level, _ := invoicing.NextReminderLevel(levels, c, now)
+		checks[i] = widget.NewCheck(i18n.T("dunning.dialog.invoice", c.Number, c.DueDate.Format("2006-01-02"), c.Outstanding(), levels[level-1].Name), nil)
+		checks[i].SetChecked(true)
+		if i == 0 {
+			checks[i].Disable()
+		}
+		list.Add(checks[i])
+	}
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(520, 240))
+
+	confirm := dialog.NewCustomConfirm(i18n.T("dunning.dialog.title", inv.Number), i18n.T("dunning.dialog.send"), i18n.T("common.cancel"), scroll, func(ok bool) {
+		if !ok {
+			return
+		}
+		var selected []models.Invoice
+		for i, check := range checks {
+			if check.Checked {
+				selected = append(selected, candidates[i])
+			}
+		}
+		_, path, err := invoicing.SendReminder(u.store, selected, now)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("dunning.error.sendFailed", err)), u.win)
+			return
+		}
+		u.refreshInvoices(inv.ID)
+		dialog.ShowInformation(i18n.T("dunning.info.sentTitle"), i18n.T("dunning.info.sentBody", len(selected), path), u.win)
+	}, u.win)
+	confirm.Resize(fyne.NewSize(580, 360))
+	confirm.Show()
+}
+
+// canRemind reports whether a reminder can be sent for the invoice now.
+func (u *UI) canRemind(inv models.Invoice) bool {
+	profile, _ := u.profileByID(inv.ProfileID)
+	level, _ := invoicing.NextReminderLevel(profile.Dunning.EffectiveLevels(), inv, time.Now())
+	return level > 0
+}
+
+// reminderLines lists the reminders sent for an invoice in the detail pane.
+func (u *UI) reminderLines(inv models.Invoice) []string {
+	if len(inv.Reminders) == 0 {
+		return nil
+	}
+	profile, _ := u.profileByID(inv.ProfileID)
+	levels := invoicing.DunningLevels(profile)
+	lines := []string{"", i18n.T("dunning.detail.title")}
+	for _, r := range inv.Reminders {
+		name := i18n.T("dunning.detail.level", r.Level)
+		if r.Level > 0 && r.Level <= len(levels) {
+			name = levels[r.Level-1].Name
+		}
+		lines = append(lines, i18n.T("dunning.detail.entry", r.Date.Format("2006-01-02"), name, r.Fee, r.Deadline.Format("2006-01-02")))
+	}
+	if fees := inv.DunningFees(); !fees.IsZero() {
+		lines = append(lines, i18n.T("dunning.detail.fees", fees))
+	}
+	if claimed := inv.ClaimedInterest().Add(inv.ClaimedFlatFee()); !claimed.IsZero() {
+		lines = append(lines, i18n.T("dunning.detail.interest", claimed))
+	}
+	return lines
+}
+
+// interestLines shows the late-payment interest accrued up to today, split
+// into the periods of the rate schedule.
+func (u *UI) interestLines(inv models.Invoice) []string {
+	profile, _ := u.profileByID(inv.ProfileID)
+	now := time.Now()
+	accrued := interest.Calculate(inv, profile.Interest, now)
+	if len(accrued.Periods) == 0 || inv.IsSettled() {
+		return nil
+	}
+	lines := []string{"", i18n.T("interest.detail.title")}
+	for _, p := range accrued.Periods {
+		lines = append(lines, i18n.T("interest.detail.period", p.From.Format("2006-01-02"), p.To.Format("2006-01-02"), p.Days, p.Balance, p.RatePercent.StringFixed(2), p.Interest))
+	}
+	lines = append(lines, i18n.T("interest.detail.total", now.Format("2006-01-02"), accrued.Interest))
+	if !accrued.FlatFee.IsZero() {
+		lines = append(lines, i18n.T("interest.detail.flatFee", accrued.FlatFee))
+	}
+	return lines
+}
+
+// dunningLevelInput edits one models.DunningLevel of a profile. The name is
+// kept as configured; it is not offered in the form.
+type dunningLevelInput struct {
+	level       models.DunningLevel
+	days        *widget.Entry
+	fee         *widget.Entry
+	paymentDays *widget.Entry
+	text        *widget.Entry
+	interest    *widget.Check
+	row         fyne.CanvasObject
+}
+
+func newDunningLevelInput(level models.DunningLevel) *dunningLevelInput {
+	in := &dunningLevelInput{
+		level:       level,
+		days:        widget.NewEntry(),
+		fee:         widget.NewEntry(),
+		paymentDays: widget.NewEntry(),
+		text:        widget.NewMultiLineEntry(),
+		interest:    widget.NewCheck(i18n.T("profiles.form.dunningInterest"), nil),
+	}
+	in.days.SetText(strconv.Itoa(level.DaysAfterDue))
+	in.fee.SetText(level.Fee.StringFixed(2))
+	in.paymentDays.SetText(strconv.Itoa(level.PaymentDays))
+	in.text.SetText(level.Text)
+	in.interest.SetChecked(level.Interest)
+	in.text.SetPlaceHolder(i18n.T("profiles.form.dunningTextPlaceholder"))
+	in.text.Wrapping = fyne.TextWrapWord
+	in.text.SetMinRowsVisible(3)
+	numbers := container.NewGridWithColumns(3,
+		widget.NewForm(widget.NewFormItem(i18n.T("profiles.form.dunningDays"), in.days)),
+		widget.NewForm(widget.NewFormItem(i18n.T("profiles.form.dunningFee"), in.fee)),
+		widget.NewForm(widget.NewFormItem(i18n.T("profiles.form.dunningPaymentDays"), in.paymentDays)),
+	)
+	in.row = container.NewVBox(numbers, in.interest, in.text)
+	return in
+}
+
+func (in *dunningLevelInput) value() (models.DunningLevel, error) {
+	level := in.level
+	var err error
+	if level.DaysAfterDue, err = parseDays(in.days.Text); err != nil {
+		return level, err
+	}
+	if level.PaymentDays, err = parseDays(in.paymentDays.Text); err != nil {
+		return level, err
+	}
+	if level.Fee, err = locale.ParseDecimal(in.fee.Text); err != nil || level.Fee.Sign() < 0 {
+		return level, errors.New(i18n.T("profiles.error.dunningFee"))
+	}
+	level.Text = strings.TrimSpace(in.text.Text)
+	level.Interest = in.interest.Checked
+	return level, nil
+}
+
+func parseDays(text string) (int, error) {
+	days, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || days < 0 {
+		return 0, errors.New(i18n.T("profiles.error.dunningDays"))
+	}
+	return days, nil
+}
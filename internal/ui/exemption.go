@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// exemptionInput edits models.TaxExemption: the tax category of items
+// without VAT and the exemption reason printed on the document.
+type exemptionInput struct {
+	category *widget.Select
+	reason   *widget.Entry
+	row      fyne.CanvasObject
+}
+
+func newExemptionInput(current models.TaxExemption) *exemptionInput {
+	labels := make([]string, len(models.TaxCategories))
+	for i, code := range models.TaxCategories {
+		labels[i] = taxCategoryLabel(code)
+	}
+	in := &exemptionInput{category: widget.NewSelect(labels, nil), reason: widget.NewEntry()}
+	in.reason.SetPlaceHolder(i18n.T("exemption.form.reasonPlaceholder"))
+	in.row = container.NewGridWithColumns(2, in.category, in.reason)
+	in.set(current)
+	return in
+}
+
+// set replaces the input with exemption.
+func (in *exemptionInput) set(exemption models.TaxExemption) {
+	in.category.SetSelected(taxCategoryLabel(exemption.CategoryCode()))
+	in.reason.SetText(exemption.Reason)
+}
+
+// value returns the exemption; zero rated without a reason is no exemption.
+func (in *exemptionInput) value() models.TaxExemption {
+	exemption := models.TaxExemption{Reason: strings.TrimSpace(in.reason.Text)}
+	for _, code := range models.TaxCategories {
+		if taxCategoryLabel(code) == in.category.Selected && code != models.TaxZeroRated {
+			exemption.Category = code
+		}
+	}
+	return exemption
+}
+
+func taxCategoryLabel(code string) string {
+	return i18n.T("exemption.category." + code)
+}
+
+// exemptionLines describes a tax exemption in the detail panes.
+func exemptionLines(exemption models.TaxExemption) []string {
+	if exemption.IsZero() {
+		return nil
+	}
+	lines := []string{"", i18n.T("exemption.detail.title"), taxCategoryLabel(exemption.CategoryCode())}
+	if exemption.Reason != "" {
+		lines = append(lines, exemption.Reason)
+	}
+	return lines
+}
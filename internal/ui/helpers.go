@@ -82,13 +82,31 @@ func (u *UI) customerByID(id string) (models.Customer, bool) {
 }
 
 func invoiceBadge(inv models.Invoice) string {
-	if !inv.PaidAt.IsZero() {
+	if inv.IsDraft() {
+		return i18n.T("invoices.badge.draft")
+	}
+	if inv.IsCancelled() {
+		return i18n.T("invoices.badge.cancelled")
+	}
+	if inv.IsSettled() {
 		return i18n.T("invoices.badge.paid")
 	}
+	if inv.IsCorrection() {
+		return documentTypeLabel(inv)
+	}
+	if inv.CollectionPending() {
+		return i18n.T("invoices.badge.collectionPending")
+	}
 	now := time.Now()
 	if inv.DueDate.Before(now) {
+		if level := inv.DunningLevel(); level > 0 {
+			return i18n.T("invoices.badge.reminded", level)
+		}
 		return i18n.T("invoices.badge.overdue")
 	}
+	if inv.PaymentStatus() == models.PaymentPartiallyPaid {
+		return i18n.T("invoices.badge.partiallyPaid")
+	}
 	if inv.DueDate.Sub(now) <= 72*time.Hour {
 		return i18n.T("invoices.badge.dueSoon")
 	}
@@ -108,27 +126,31 @@ func makeHeaderLabel(text string) *widget.Label {
 	return widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 }
 
+// updateInvoiceActionButtons enables editing and deleting for drafts only,
+// payments, export and corrections for finalised invoices only, and
+// reminders for overdue invoices below the last dunning level.
 func (u *UI) updateInvoiceActionButtons() {
-	if u.invoiceEditButton != nil {
-		if u.selectedInvoice >= 0 {
-			u.invoiceEditButton.Enable()
-		} else {
-			u.invoiceEditButton.Disable()
-		}
+	selected := u.selectedInvoice >= 0 && u.selectedInvoice < len(u.invoices)
+	var inv models.Invoice
+	if selected {
+		inv = u.invoices[u.selectedInvoice]
 	}
-	if u.invoicePayButton == nil {
-		return
-	}
-	if u.selectedInvoice >= 0 && u.selectedInvoice < len(u.invoices) {
-		inv := u.invoices[u.selectedInvoice]
-		if inv.PaidAt.IsZero() {
-			u.invoicePayButton.SetText(i18n.T("invoices.button.markPaid"))
+	setEnabled := func(button *widget.Button, enabled bool) {
+		if button == nil {
+			return
+		}
+		if enabled {
+			button.Enable()
 		} else {
-			u.invoicePayButton.SetText(i18n.T("invoices.button.markUnpaid"))
+			button.Disable()
 		}
-		u.invoicePayButton.Enable()
-	} else {
-		u.invoicePayButton.SetText(i18n.T("invoices.button.markPaid"))
-		u.invoicePayButton.Disable()
 	}
+	setEnabled(u.invoiceEditButton, selected && inv.IsDraft())
+	setEnabled(u.invoiceFinalizeButton, selected && inv.IsDraft())
+	setEnabled(u.invoiceDeleteButton, selected && inv.IsDraft())
+	setEnabled(u.invoiceXRechnungButton, selected && !inv.IsDraft())
+	setEnabled(u.invoiceCorrectButton, selected && !inv.IsDraft() && !inv.IsCorrection() && !inv.IsCancelled())
+	setEnabled(u.invoicePayButton, selected && !inv.IsDraft())
+	setEnabled(u.invoiceRemindButton, selected && u.canRemind(inv))
+	setEnabled(u.invoiceUncollectButton, selected && inv.CollectionPending())
 }
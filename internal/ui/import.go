@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+)
+
+// importInvoices reads invoice definitions from a JSON or YAML file, shows a
+// dry run of the import and creates the invoices once confirmed.
+func (u *UI) importInvoices() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialogError(u.win, err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defs, err := invoicing.ParseDefinitions(reader.URI().Name(), reader)
+		reader.Close()
+		if err != nil {
+			dialogError(u.win, err)
+			return
+		}
+
+		preview := invoicing.Import(u.store, defs, true)
+		creatable := 0
+		for _, res := range preview {
+			if res.Err == nil {
+				creatable++
+			}
+		}
+		report := importReport(preview, true)
+		if creatable == 0 {
+			dialog.ShowCustom(i18n.T("invoices.import.previewTitle"), i18n.T("common.close"), report, u.win)
+			return
+		}
+		confirm := dialog.NewCustomConfirm(i18n.T("invoices.import.previewTitle"), i18n.T("invoices.import.confirm", creatable), i18n.T("common.cancel"), report, func(ok bool) {
+			if !ok {
+				return
+			}
+			results := invoicing.Import(u.store, defs, false)
+			u.refreshInvoices()
+			dialog.ShowCustom(i18n.T("invoices.import.resultTitle"), i18n.T("common.close"), importReport(results, false), u.win)
+		}, u.win)
+		confirm.Resize(fyne.NewSize(560, 420))
+		confirm.Show()
+	}, u.win)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".json", ".yaml", ".yml"}))
+	open.Show()
+}
+
+func importReport(results []invoicing.Result, dryRun bool) fyne.CanvasObject {
+	lines := make([]string, 0, len(results))
+	for _, res := range results {
+		switch {
+		case res.Err != nil:
+			lines = append(lines, i18n.T("invoices.import.failed", res.Source, res.Err))
+		case dryRun:
+			lines = append(lines, i18n.T("invoices.import.wouldCreate", res.Source, res.Customer.DisplayName, res.Invoice.Total))
+		default:
+			lines = append(lines, i18n.T("invoices.import.created", res.Source, res.Invoice.Number, res.Customer.DisplayName, res.Invoice.Total))
+		}
+		for _, f := range res.Findings {
+			lines = append(lines, "    "+f.String())
+		}
+	}
+	label := widget.NewLabel(strings.Join(lines, "\n"))
+	label.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(label)
+	scroll.SetMinSize(fyne.NewSize(520, 320))
+	return scroll
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// interestInput edits the models.InterestSettings of a profile. The base rate
+// schedule is entered one period per line as "YYYY-MM-DD rate"; an empty
+// schedule uses models.DefaultBaseRates.
+type interestInput struct {
+	margin   *widget.Entry
+	flatFee  *widget.Entry
+	schedule *widget.Entry
+	row      fyne.CanvasObject
+}
+
+func newInterestInput(current models.InterestSettings) *interestInput {
+	in := &interestInput{
+		margin:   widget.NewEntry(),
+		flatFee:  widget.NewEntry(),
+		schedule: widget.NewMultiLineEntry(),
+	}
+	effective := current.Effective()
+	in.margin.SetText(effective.MarginPoints.String())
+	in.flatFee.SetText(effective.FlatFee.StringFixed(2))
+	in.schedule.SetText(formatSchedule(current.Periods))
+	in.schedule.SetPlaceHolder(i18n.T("profiles.form.interestSchedulePlaceholder", formatSchedule(models.DefaultBaseRates())))
+	in.schedule.SetMinRowsVisible(3)
+	numbers := container.NewGridWithColumns(2,
+		widget.NewForm(widget.NewFormItem(i18n.T("profiles.form.interestMargin"), in.margin)),
+		widget.NewForm(widget.NewFormItem(i18n.T("profiles.form.interestFlatFee"), in.flatFee)),
+	)
+	in.row = container.NewVBox(numbers, in.schedule)
+	return in
+}
+
+func (in *interestInput) value() (models.InterestSettings, error) {
+	var (
+		settings models.InterestSettings
+		err      error
+	)
+	if settings.MarginPoints, err = locale.ParseDecimal(in.margin.Text); err != nil {
+		return settings, errors.New(i18n.T("profiles.error.interestMargin"))
+	}
+	if settings.FlatFee, err = locale.ParseDecimal(in.flatFee.Text); err != nil || settings.FlatFee.Sign() < 0 {
+		return settings, errors.New(i18n.T("profiles.error.interestFlatFee"))
+	}
+	if settings.Periods, err = parseSchedule(in.schedule.Text); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+func formatSchedule(periods []models.InterestPeriod) string {
+	lines := make([]string, len(periods))
+	for i, p := range periods {
+		lines[i] = p.From.Format("2006-01-02") + " " + p.RatePercent.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseSchedule(text string) ([]models.InterestPeriod, error) {
+	var periods []models.InterestPeriod
+	for n, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		invalid := fmt.Errorf("%s", i18n.T("profiles.error.interestSchedule", n+1))
+		if len(fields) != 2 {
+			return nil, invalid
+		}
+		from, err := time.Parse("2006-01-02", fields[0])
+		if err != nil {
+			return nil, invalid
+		}
+		rate, err := locale.ParseDecimal(fields[1])
+		if err != nil {
+			return nil, invalid
+		}
+		periods = append(periods, models.InterestPeriod{From: from, RatePercent: rate})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].From.Before(periods[j].From) })
+	return periods, nil
+}
@@ -2,7 +2,9 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,17 +15,21 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/janmarkuslanger/invoiceio/internal/einvoice"
 	"github.com/janmarkuslanger/invoiceio/internal/i18n"
 	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
 	"github.com/janmarkuslanger/invoiceio/internal/locale"
 	"github.com/janmarkuslanger/invoiceio/internal/models"
-	"github.com/janmarkuslanger/invoiceio/internal/pdf"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
 )
 
 func (u *UI) makeInvoicesTab() fyne.CanvasObject {
 	u.invoiceDetailText = widget.NewRichTextFromMarkdown(i18n.T("invoices.detail.empty"))
 	u.invoiceDetailText.Wrapping = fyne.TextWrapWord
-	detailCard := widget.NewCard(i18n.T("invoices.detail.title"), "", u.invoiceDetailText)
+	u.invoiceHistoryText = widget.NewRichText()
+	u.invoiceHistoryText.Wrapping = fyne.TextWrapWord
+	detailCard := widget.NewCard(i18n.T("invoices.detail.title"), "", container.NewVBox(u.invoiceDetailText, u.makePaymentsSection(), u.invoiceHistoryText))
 	detailScroll := container.NewVScroll(detailCard)
 
 	u.invoiceList = widget.NewList(
@@ -60,12 +66,49 @@ func (u *UI) makeInvoicesTab() fyne.CanvasObject {
 	})
 	u.invoiceEditButton.Disable()
 
-	u.invoicePayButton = widget.NewButton(i18n.T("invoices.button.markPaid"), func() {
-		u.toggleInvoicePaid()
+	u.invoiceXRechnungButton = widget.NewButton(i18n.T("invoices.button.exportXRechnung"), func() {
+		u.exportXRechnung()
 	})
-	u.invoicePayButton.Disable()
+	u.invoiceXRechnungButton.Disable()
 
-	actionBar := container.NewHBox(newButton, u.invoiceEditButton, u.invoicePayButton)
+	u.invoiceCorrectButton = widget.NewButton(i18n.T("invoices.button.correct"), func() {
+		u.openCorrectionDialog()
+	})
+	u.invoiceCorrectButton.Disable()
+
+	u.invoiceFinalizeButton = widget.NewButton(i18n.T("invoices.button.finalize"), func() {
+		u.finalizeDraft()
+	})
+	u.invoiceFinalizeButton.Disable()
+
+	u.invoiceDeleteButton = widget.NewButtonWithIcon(i18n.T("invoices.button.deleteDraft"), theme.DeleteIcon(), func() {
+		u.deleteDraft()
+	})
+	u.invoiceDeleteButton.Disable()
+
+	importButton := widget.NewButtonWithIcon(i18n.T("invoices.button.import"), theme.FolderOpenIcon(), func() {
+		u.importInvoices()
+	})
+
+	u.invoiceRemindButton = widget.NewButtonWithIcon(i18n.T("invoices.button.remind"), theme.MailSendIcon(), func() {
+		u.openReminderDialog()
+	})
+	u.invoiceRemindButton.Disable()
+
+	statementButton := widget.NewButtonWithIcon(i18n.T("invoices.button.importStatement"), theme.UploadIcon(), func() {
+		u.importStatement()
+	})
+
+	directDebitButton := widget.NewButtonWithIcon(i18n.T("invoices.button.directDebit"), theme.DownloadIcon(), func() {
+		u.openDirectDebitDialog()
+	})
+
+	u.invoiceUncollectButton = widget.NewButton(i18n.T("invoices.button.cancelCollection"), func() {
+		u.cancelCollection()
+	})
+	u.invoiceUncollectButton.Disable()
+
+	actionBar := container.NewHBox(newButton, importButton, statementButton, directDebitButton, u.invoiceEditButton, u.invoiceFinalizeButton, u.invoiceDeleteButton, u.invoiceCorrectButton, u.invoiceRemindButton, u.invoiceUncollectButton, u.invoiceXRechnungButton)
 
 	split := container.NewHSplit(
 		container.NewMax(u.invoiceList),
@@ -76,6 +119,8 @@ func (u *UI) makeInvoicesTab() fyne.CanvasObject {
 	return container.NewBorder(actionBar, nil, nil, nil, split)
 }
 
+// openInvoiceDialog creates a new invoice or edits a draft. Drafts are stored
+// without number and PDF; finalising numbers the invoice and locks it.
 func (u *UI) openInvoiceDialog(existing *models.Invoice) {
 	isEdit := existing != nil
 	title := i18n.T("invoices.dialog.newTitle")
@@ -83,7 +128,7 @@ func (u *UI) openInvoiceDialog(existing *models.Invoice) {
 	var current models.Invoice
 	if isEdit {
 		title = i18n.T("invoices.dialog.editTitle")
-		submitLabel = i18n.T("invoices.dialog.update")
+		submitLabel = i18n.T("invoices.dialog.finalize")
 		current = *existing
 	}
 
@@ -100,19 +145,14 @@ func (u *UI) openInvoiceDialog(existing *models.Invoice) {
 	dueDate.SetPlaceHolder(i18n.T("invoices.form.dueDatePlaceholder"))
 	taxRate := widget.NewEntry()
 	taxRate.SetPlaceHolder(i18n.T("invoices.form.taxRatePlaceholder"))
+	currency := widget.NewEntry()
+	currency.SetPlaceHolder(money.DefaultCurrency)
 	notes := widget.NewMultiLineEntry()
-	items := make([]models.InvoiceItem, 0)
-	type lineItemRow struct {
-		descEntry  *widget.Entry
-		qtyEntry   *widget.Entry
-		priceEntry *widget.Entry
-		totalLabel *widget.Label
-	}
-	var lineItemRows []*lineItemRow
 	status := widget.NewLabel("")
 	status.Wrapping = fyne.TextWrapWord
 	status.Hide()
 	statusNumericError := false
+	acknowledgedWarnings := ""
 	showError := func(message string) {
 		status.SetText(message)
 		status.Show()
@@ -147,13 +187,18 @@ func (u *UI) openInvoiceDialog(existing *models.Invoice) {
 		}
 		issueDate.SetText(current.IssueDate.Format("2006-01-02"))
 		dueDate.SetText(current.DueDate.Format("2006-01-02"))
-		taxRate.SetText(fmt.Sprintf("%.2f", current.TaxRatePercent))
+		if len(current.Items) > 0 {
+			taxRate.SetText(current.Items[len(current.Items)-1].TaxRatePercent.StringFixed(2))
+		} else {
+			taxRate.SetText("0")
+		}
+		currency.SetText(current.Currency)
 		notes.SetText(current.Notes)
-		items = append(items, current.Items...)
 	} else {
 		issueDate.SetText(defaultIssue.Format("2006-01-02"))
 		dueDate.SetText(defaultDue.Format("2006-01-02"))
 		taxRate.SetText("0")
+		currency.SetText(money.DefaultCurrency)
 		if len(profileOptions) > 0 {
 			profileSelect.SetSelected(profileOptions[0])
 		}
@@ -178,139 +223,68 @@ func (u *UI) openInvoiceDialog(existing *models.Invoice) {
 		}
 	}
 
-	lineItemsContainer := container.NewVBox()
-	subtotalLabel := widget.NewLabel("")
-	taxLabel := widget.NewLabel("")
-	totalLabel := widget.NewLabel("")
-
-	updateTotals := func() {
-		subtotal := 0.0
-		for _, item := range items {
-			subtotal += item.LineTotal
+	terms := newTermsInput(current.Terms)
+	exemption := newExemptionInput(current.TaxExemption)
+	// New invoices take the terms of the customer or profile and the tax
+	// exemption of the customer; the net days set the due date.
+	updateDueDate := func(paymentTerms models.PaymentTerms) {
+		if issue, err := time.Parse("2006-01-02", strings.TrimSpace(issueDate.Text)); err == nil && paymentTerms.NetDays > 0 {
+			dueDate.SetText(invoicing.DueDate(issue, paymentTerms).Format("2006-01-02"))
 		}
-		taxPercent := 0.0
-		if v := strings.TrimSpace(taxRate.Text); v != "" {
-			if f, err := locale.ParseFloat(v); err == nil {
-				taxPercent = f
-				clearNumericError()
-			} else {
-				showNumericError(i18n.T("invoices.error.taxRateFormat"))
-				return
-			}
+	}
+	applyDefaults := func(string) {
+		profileModel, _ := u.profileByLabel(profileSelect.Selected)
+		customerModel, _ := u.customerByLabel(customerSelect.Selected)
+		defaults := invoicing.DefaultTerms(profileModel, customerModel)
+		terms.set(defaults)
+		updateDueDate(defaults)
+		exemption.set(customerModel.TaxExemption)
+	}
+	if !isEdit {
+		applyDefaults("")
+		profileSelect.OnChanged = applyDefaults
+		customerSelect.OnChanged = applyDefaults
+	}
+	terms.netDays.OnChanged = func(text string) {
+		if days, err := strconv.Atoi(strings.TrimSpace(text)); err == nil {
+			updateDueDate(models.PaymentTerms{NetDays: days})
 		}
-		taxAmount := subtotal * (taxPercent / 100)
-		total := subtotal + taxAmount
-		subtotalLabel.SetText(i18n.T("invoices.summary.subtotal", subtotal))
-		taxLabel.SetText(i18n.T("invoices.summary.tax", taxAmount, taxPercent))
-		totalLabel.SetText(i18n.T("invoices.summary.total", total))
 	}
 
-	taxRate.OnChanged = func(string) {
-		updateTotals()
-	}
-
-	var renderLineItems func()
-	renderLineItems = func() {
-		lineItemsContainer.Objects = nil
-		lineItemRows = lineItemRows[:0]
-		if len(items) == 0 {
-			lineItemRows = nil
-			lineItemsContainer.Add(widget.NewLabel(i18n.T("invoices.lineItems.empty", i18n.T("invoices.lineItems.add"))))
-			updateTotals()
-			lineItemsContainer.Refresh()
-			return
+	selectedCurrency := func() string {
+		if v := strings.ToUpper(strings.TrimSpace(currency.Text)); v != "" {
+			return v
 		}
-		for i := range items {
-			idx := i
-			item := items[idx]
-
-			descEntry := widget.NewEntry()
-			descEntry.SetText(item.Description)
-			descEntry.OnChanged = func(val string) {
-				items[idx].Description = val
-			}
-
-			qtyEntry := widget.NewEntry()
-			qtyEntry.SetText(fmt.Sprintf("%.2f", item.Quantity))
-
-			priceEntry := widget.NewEntry()
-			priceEntry.SetText(fmt.Sprintf("%.2f", item.UnitPrice))
-
-			totalValue := widget.NewLabel(fmt.Sprintf("%.2f", item.LineTotal))
-
-			recalculate := func() {
-				qtyVal, err := locale.ParseFloat(strings.TrimSpace(qtyEntry.Text))
-				if err != nil {
-					showNumericError(i18n.T("invoices.error.lineItemQuantityInvalid", idx+1))
-					return
-				}
-				priceVal, err := locale.ParseFloat(strings.TrimSpace(priceEntry.Text))
-				if err != nil {
-					showNumericError(i18n.T("invoices.error.lineItemUnitPriceInvalid", idx+1))
-					return
-				}
-				items[idx].Quantity = qtyVal
-				items[idx].UnitPrice = priceVal
-				items[idx].LineTotal = qtyVal * priceVal
-				totalValue.SetText(fmt.Sprintf("%.2f", items[idx].LineTotal))
-				updateTotals()
-				clearNumericError()
-			}
-			qtyEntry.OnChanged = func(string) { recalculate() }
-			priceEntry.OnChanged = func(string) { recalculate() }
-
-			removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
-				items = append(items[:idx], items[idx+1:]...)
-				renderLineItems()
-				updateTotals()
-			})
-
-			row := container.NewGridWithColumns(5,
-				descEntry,
-				qtyEntry,
-				priceEntry,
-				totalValue,
-				removeButton,
-			)
-			lineItemsContainer.Add(row)
-			lineItemRows = append(lineItemRows, &lineItemRow{
-				descEntry:  descEntry,
-				qtyEntry:   qtyEntry,
-				priceEntry: priceEntry,
-				totalLabel: totalValue,
-			})
-		}
-		updateTotals()
-		lineItemsContainer.Refresh()
-	}
-
-	addRow := func(initial models.InvoiceItem) {
-		items = append(items, initial)
-		renderLineItems()
-	}
-
-	addItemButton := widget.NewButtonWithIcon(i18n.T("invoices.lineItems.add"), theme.ContentAddIcon(), func() {
-		addRow(models.InvoiceItem{Description: "", Quantity: 1, UnitPrice: 0, LineTotal: 0})
-	})
+		return money.DefaultCurrency
+	}
 
-	headerRow := container.NewGridWithColumns(5,
-		makeHeaderLabel(i18n.T("invoices.table.description")),
-		makeHeaderLabel(i18n.T("invoices.table.quantity")),
-		makeHeaderLabel(i18n.T("invoices.table.unit")),
-		makeHeaderLabel(i18n.T("invoices.table.lineTotal")),
-		widget.NewLabel(""),
-	)
+	defaultTaxRate := func() (money.Decimal, bool) {
+		v := strings.TrimSpace(taxRate.Text)
+		if v == "" {
+			return money.Decimal{}, true
+		}
+		d, err := locale.ParseDecimal(v)
+		if err != nil {
+			showNumericError(i18n.T("invoices.error.taxRateFormat"))
+			return money.Decimal{}, false
+		}
+		clearNumericError()
+		return d, true
+	}
 
-	header := container.NewBorder(nil, nil, nil, addItemButton, headerRow)
-	itemsScroll := container.NewVScroll(lineItemsContainer)
-	itemsScroll.SetMinSize(fyne.NewSize(0, 200))
+	lineItems := newLineItemEditor(current.Items, selectedCurrency, defaultTaxRate, showNumericError, clearNumericError)
 
-	summaryCard := widget.NewCard(i18n.T("invoices.summary.title"), "", container.NewVBox(subtotalLabel, taxLabel, totalLabel))
+	taxRate.OnChanged = func(string) {
+		defaultTaxRate()
+	}
+	currency.OnChanged = func(string) {
+		lineItems.updateTotals()
+	}
 
-	if len(items) == 0 && !isEdit {
-		addRow(models.InvoiceItem{Description: "", Quantity: 1, UnitPrice: 0, LineTotal: 0})
+	if len(current.Items) == 0 && !isEdit {
+		lineItems.add(models.InvoiceItem{Description: "", Quantity: money.DecimalFromInt(1)})
 	} else {
-		renderLineItems()
+		lineItems.render()
 	}
 
 	form := widget.NewForm(
@@ -318,23 +292,20 @@ func (u *UI) openInvoiceDialog(existing *models.Invoice) {
 		widget.NewFormItem(i18n.T("invoices.form.customer"), customerSelect),
 		widget.NewFormItem(i18n.T("invoices.form.issueDate"), issueDate),
 		widget.NewFormItem(i18n.T("invoices.form.dueDate"), dueDate),
+		widget.NewFormItem(i18n.T("invoices.form.terms"), terms.row),
 		widget.NewFormItem(i18n.T("invoices.form.taxRate"), taxRate),
+		widget.NewFormItem(i18n.T("invoices.form.exemption"), exemption.row),
+		widget.NewFormItem(i18n.T("invoices.form.currency"), currency),
 		widget.NewFormItem(i18n.T("invoices.form.notes"), notes),
 	)
 
-	lineItemsSection := container.NewVBox(
-		header,
-		itemsScroll,
-		widget.NewSeparator(),
-		summaryCard,
-	)
-
-	content := container.NewVBox(form, widget.NewSeparator(), lineItemsSection)
+	content := container.NewVBox(form, widget.NewSeparator(), lineItems.container)
 
 	save := widget.NewButton(submitLabel, nil)
+	saveDraft := widget.NewButton(i18n.T("invoices.dialog.saveDraft"), nil)
 	cancel := widget.NewButton(i18n.T("common.cancel"), nil)
 
-	buttons := container.NewHBox(layout.NewSpacer(), cancel, save)
+	buttons := container.NewHBox(layout.NewSpacer(), cancel, saveDraft, save)
 	dialogContent := container.NewBorder(content, container.NewVBox(status, buttons), nil, nil, nil)
 
 	dlg := dialog.NewCustomWithoutButtons(title, dialogContent, u.win)
@@ -343,154 +314,164 @@ func (u *UI) openInvoiceDialog(existing *models.Invoice) {
 		dlg.Hide()
 	}
 
-	validateLineItems := func() bool {
-		for idx := range lineItemRows {
-			row := lineItemRows[idx]
-			qtyVal, err := locale.ParseFloat(strings.TrimSpace(row.qtyEntry.Text))
-			if err != nil {
-				showNumericError(i18n.T("invoices.error.lineItemQuantityInvalid", idx+1))
-				return false
-			}
-			priceVal, err := locale.ParseFloat(strings.TrimSpace(row.priceEntry.Text))
-			if err != nil {
-				showNumericError(i18n.T("invoices.error.lineItemUnitPriceInvalid", idx+1))
-				return false
-			}
-			items[idx].Quantity = qtyVal
-			items[idx].UnitPrice = priceVal
-			items[idx].LineTotal = qtyVal * priceVal
-			row.totalLabel.SetText(fmt.Sprintf("%.2f", items[idx].LineTotal))
-		}
-		clearNumericError()
-		return true
-	}
-
-	save.OnTapped = func() {
+	// build reads the form into an invoice. Drafts may be saved without line
+	// items.
+	build := func(draft bool) (models.Profile, models.Customer, models.Invoice, bool) {
 		if len(profileOptions) == 0 || len(customerOptions) == 0 {
 			showError(i18n.T("invoices.error.setupRequired"))
-			return
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
 		profileLabel := profileSelect.Selected
 		customerLabel := customerSelect.Selected
 		if profileLabel == "" || customerLabel == "" {
 			showError(i18n.T("invoices.error.selectionRequired"))
-			return
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
 		profileModel, ok := u.profileByLabel(profileLabel)
 		if !ok {
 			showError(i18n.T("invoices.error.profileMissing"))
-			return
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
 		customerModel, ok := u.customerByLabel(customerLabel)
 		if !ok {
 			showError(i18n.T("invoices.error.customerMissing"))
-			return
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
-		if len(items) == 0 {
+		items := lineItems.Items()
+		if len(items) == 0 && !draft {
 			showError(i18n.T("invoices.error.noItems"))
-			return
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
-		if !validateLineItems() {
-			return
+		if !lineItems.validate() {
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
+		items = lineItems.Items()
 		issue, err := time.Parse("2006-01-02", strings.TrimSpace(issueDate.Text))
 		if err != nil {
 			showError(i18n.T("invoices.error.issueDate"))
-			return
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
 		due, err := time.Parse("2006-01-02", strings.TrimSpace(dueDate.Text))
 		if err != nil {
 			showError(i18n.T("invoices.error.dueDate"))
-			return
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
-		taxPercent := 0.0
-		if strings.TrimSpace(taxRate.Text) != "" {
-			taxPercent, err = locale.ParseFloat(strings.TrimSpace(taxRate.Text))
-			if err != nil {
-				showNumericError(i18n.T("invoices.error.taxRateFormat"))
-				return
-			}
-			clearNumericError()
-		}
-
-		subtotal := 0.0
-		for _, item := range items {
-			subtotal += item.LineTotal
+		paymentTerms, err := terms.value()
+		if err != nil {
+			showError(err.Error())
+			return models.Profile{}, models.Customer{}, models.Invoice{}, false
 		}
-		taxAmount := subtotal * (taxPercent / 100)
-		total := subtotal + taxAmount
 		now := time.Now()
 
-		invoiceID := ""
-		invoiceNumber := ""
-		pdfPath := ""
+		invoiceID := id.New()
 		createdAt := now
-		paidAt := time.Time{}
 		if isEdit {
 			invoiceID = current.ID
-			invoiceNumber = current.Number
-			pdfPath = current.PDFPath
 			createdAt = current.CreatedAt
-			paidAt = current.PaidAt
-		} else {
-			invoiceID = id.New()
-		}
-		if invoiceNumber == "" {
-			invoiceNumber = fmt.Sprintf("INV-%s-%s", issue.Format("20060102"), id.Short())
-		}
-		if pdfPath == "" {
-			pdfPath = filepath.Join(u.store.BaseDir(), "pdf", fmt.Sprintf("%s.pdf", strings.ToLower(invoiceNumber)))
 		}
 
 		invoice := models.Invoice{
-			ID:             invoiceID,
-			Number:         invoiceNumber,
-			ProfileID:      profileModel.ID,
-			CustomerID:     customerModel.ID,
-			IssueDate:      issue,
-			DueDate:        due,
-			Items:          append([]models.InvoiceItem(nil), items...),
-			Notes:          strings.TrimSpace(notes.Text),
-			TaxRatePercent: taxPercent,
-			Subtotal:       subtotal,
-			TaxAmount:      taxAmount,
-			Total:          total,
-			PDFPath:        pdfPath,
-			PaidAt:         paidAt,
-			CreatedAt:      createdAt,
-			UpdatedAt:      now,
-		}
+			ID:           invoiceID,
+			ProfileID:    profileModel.ID,
+			CustomerID:   customerModel.ID,
+			IssueDate:    issue,
+			DueDate:      due,
+			Currency:     selectedCurrency(),
+			Items:        append([]models.InvoiceItem(nil), items...),
+			Notes:        strings.TrimSpace(notes.Text),
+			Terms:        paymentTerms,
+			TaxExemption: exemption.value(),
+			CreatedAt:    createdAt,
+			UpdatedAt:    now,
+		}
+		invoice.Recalculate()
+		return profileModel, customerModel, invoice, true
+	}
 
-		if err := u.store.SaveInvoice(invoice); err != nil {
-			showError(i18n.T("invoices.error.saveFailed", err))
+	saveDraft.OnTapped = func() {
+		_, _, invoice, ok := build(true)
+		if !ok {
 			return
 		}
-		if err := pdf.CreateInvoicePDF(pdfPath, profileModel, customerModel, invoice); err != nil {
-			showError(i18n.T("invoices.error.pdfFailed", err))
+		if err := u.store.SaveInvoice(invoice); err != nil {
+			showError(i18n.T("invoices.error.saveFailed", err))
 			return
 		}
-
 		u.lastProfileID = invoice.ProfileID
 		u.lastCustomerID = invoice.CustomerID
 		u.refreshInvoices(invoice.ID)
-		if isEdit {
-			dialog.ShowInformation(i18n.T("invoices.info.updatedTitle"), i18n.T("invoices.info.updatedBody", invoice.Number, pdfPath), u.win)
-		} else {
-			dialog.ShowInformation(i18n.T("invoices.info.createdTitle"), i18n.T("invoices.info.createdBody", invoice.Number, pdfPath), u.win)
+		dialog.ShowInformation(i18n.T("invoices.info.draftTitle"), i18n.T("invoices.info.draftBody"), u.win)
+		dlg.Hide()
+	}
+
+	save.OnTapped = func() {
+		profileModel, customerModel, invoice, ok := build(false)
+		if !ok {
+			return
 		}
+		findings := invoicing.CheckNew(profileModel, customerModel, invoice)
+		if len(findings) > 0 {
+			report := findings.String()
+			if findings.HasErrors() {
+				showError(i18n.T("invoices.validation.errors", report))
+				return
+			}
+			if report != acknowledgedWarnings {
+				acknowledgedWarnings = report
+				showError(i18n.T("invoices.validation.warnings", report))
+				return
+			}
+		}
+
+		created, err := invoicing.Create(u.store, profileModel, customerModel, invoice)
+		if err != nil {
+			// Once the invoice is numbered and stored only the PDF is
+			// missing; submitting again would issue a second invoice.
+			if stored, getErr := u.store.GetInvoice(created.ID); getErr != nil || stored.IsDraft() {
+				showError(i18n.T("invoices.error.saveFailed", err))
+				return
+			}
+		}
+
+		u.lastProfileID = created.ProfileID
+		u.lastCustomerID = created.CustomerID
+		u.refreshInvoices(created.ID)
 		dlg.Hide()
+		if err != nil {
+			u.showRenderFailed(profileModel, customerModel, created, err)
+			return
+		}
+		dialog.ShowInformation(i18n.T("invoices.info.createdTitle"), i18n.T("invoices.info.createdBody", created.Number, created.PDFPath), u.win)
 	}
 
 	dlg.Resize(fyne.NewSize(560, 640))
 	dlg.Show()
 }
 
+// showRenderFailed reports that the PDF of a created invoice could not be
+// written and offers to render the same invoice again.
+func (u *UI) showRenderFailed(profile models.Profile, customer models.Customer, invoice models.Invoice, err error) {
+	message := i18n.T("invoices.pdfRetry.body", invoice.Number, err)
+	dialog.ShowConfirm(i18n.T("invoices.pdfRetry.title"), message, func(retry bool) {
+		if !retry {
+			return
+		}
+		if err := invoicing.Render(u.store, profile, customer, invoice); err != nil {
+			u.showRenderFailed(profile, customer, invoice, err)
+			return
+		}
+		dialog.ShowInformation(i18n.T("invoices.info.createdTitle"), i18n.T("invoices.info.createdBody", invoice.Number, invoice.PDFPath), u.win)
+	}, u.win)
+}
+
 func (u *UI) updateInvoiceDetail() {
 	if u.invoiceDetailText == nil {
 		return
 	}
 	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
 		u.invoiceDetailText.ParseMarkdown(i18n.T("invoices.detail.empty"))
+		u.updateInvoicePayments(nil)
+		u.invoiceHistoryText.ParseMarkdown("")
 		return
 	}
 	inv := u.invoices[u.selectedInvoice]
@@ -504,8 +485,14 @@ func (u *UI) updateInvoiceDetail() {
 	}
 	status := invoiceBadge(inv)
 	dueDescriptor := ""
-	if !inv.PaidAt.IsZero() {
-		dueDescriptor = i18n.T("invoices.due.paidOn", inv.PaidAt.Format("2006-01-02"))
+	if inv.IsDraft() {
+		dueDescriptor = i18n.T("invoices.due.draft")
+	} else if inv.IsSettled() && len(inv.Payments) > 0 {
+		dueDescriptor = i18n.T("invoices.due.paidOn", inv.LastPaymentDate().Format("2006-01-02"))
+	} else if inv.IsCorrection() {
+		dueDescriptor = i18n.T("invoices.due.unsettled")
+	} else if inv.CollectionPending() {
+		dueDescriptor = i18n.T("invoices.due.collectionOn", inv.Collection.Date.Format("2006-01-02"))
 	} else {
 		now := time.Now()
 		daysUntilDue := int(inv.DueDate.Sub(now).Hours() / 24)
@@ -516,53 +503,97 @@ func (u *UI) updateInvoiceDetail() {
 		}
 	}
 
-	lines := []string{
-		i18n.T("invoices.detail.invoice", inv.Number),
+	var lines []string
+	if inv.IsCorrection() {
+		lines = append(lines,
+			i18n.T("invoices.detail.document", documentTypeLabel(inv), inv.Number),
+			i18n.T("invoices.detail.corrects", inv.OriginalNumber),
+		)
+	} else {
+		lines = append(lines, i18n.T("invoices.detail.invoice", invoiceNumberLabel(inv)))
+		if corrections, err := invoicing.Corrections(u.store, inv); err == nil && len(corrections) > 0 {
+			numbers := make([]string, len(corrections))
+			for i, c := range corrections {
+				numbers[i] = fmt.Sprintf("%s (%s)", c.Number, documentTypeLabel(c))
+			}
+			lines = append(lines, i18n.T("invoices.detail.correctedBy", strings.Join(numbers, ", ")))
+		}
+	}
+	lines = append(lines,
 		i18n.T("invoices.detail.status", status, dueDescriptor),
 		i18n.T("invoices.detail.profile", profileName),
 		i18n.T("invoices.detail.customer", customerName),
 		i18n.T("invoices.detail.issued", inv.IssueDate.Format("2006-01-02")),
-		i18n.T("invoices.detail.due", inv.DueDate.Format("2006-01-02")),
-		i18n.T("invoices.detail.subtotal", inv.Subtotal),
-		i18n.T("invoices.detail.tax", inv.TaxAmount, inv.TaxRatePercent),
+	)
+	if !inv.IsCorrection() {
+		lines = append(lines, i18n.T("invoices.detail.due", inv.DueDate.Format("2006-01-02")))
+	}
+	lines = append(lines, i18n.T("invoices.detail.subtotal", inv.Subtotal))
+	for _, tax := range inv.TaxBreakdown {
+		lines = append(lines, i18n.T("invoices.detail.tax", tax.RatePercent.StringFixed(2), tax.Net, tax.Tax))
+	}
+	lines = append(lines,
 		i18n.T("invoices.detail.total", inv.Total),
-		i18n.T("invoices.detail.pdf", inv.PDFPath),
+	)
+	if !inv.Credited.IsZero() {
+		lines = append(lines, i18n.T("invoices.detail.credited", inv.Credited))
+	}
+	for _, offer := range inv.DiscountOffers() {
+		lines = append(lines, i18n.T("invoices.detail.discount", offer.Percent.String(), offer.Amount, offer.Deadline.Format("2006-01-02")))
+	}
+	if discount := inv.DiscountGranted(); !discount.IsZero() {
+		lines = append(lines, i18n.T("invoices.detail.discountGranted", discount))
 	}
-	if !inv.PaidAt.IsZero() {
-		lines = append(lines, i18n.T("invoices.detail.paidOn", inv.PaidAt.Format("2006-01-02")))
+	if !inv.IsDraft() {
+		lines = append(lines, i18n.T("invoices.detail.pdf", inv.PDFPath))
 	}
 	lines = append(lines,
 		"",
 		i18n.T("invoices.detail.lineItems"),
 	)
 	for _, item := range inv.Items {
-		lines = append(lines, i18n.T("invoices.detail.lineItem", item.Description, item.Quantity, item.UnitPrice, item.LineTotal))
+		lines = append(lines, i18n.T("invoices.detail.lineItem", item.Description, item.Quantity, item.UnitPrice, item.LineTotal, item.TaxRatePercent))
 	}
 	if strings.TrimSpace(inv.Notes) != "" {
 		lines = append(lines, "", i18n.T("invoices.detail.notesTitle"), inv.Notes)
 	}
+	lines = append(lines, collectionLines(inv)...)
+	lines = append(lines, u.reminderLines(inv)...)
+	lines = append(lines, u.interestLines(inv)...)
 	u.invoiceDetailText.ParseMarkdown(strings.Join(lines, "\n"))
+	u.updateInvoicePayments(&inv)
+	u.invoiceHistoryText.ParseMarkdown(strings.Join(u.revisionLines(inv), "\n"))
 }
 
-func (u *UI) toggleInvoicePaid() {
+// exportXRechnung writes the selected invoice as XRechnung XML into the data
+// directory.
+func (u *UI) exportXRechnung() {
 	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
 		return
 	}
 	inv := u.invoices[u.selectedInvoice]
-	markingPaid := inv.PaidAt.IsZero()
-	if markingPaid {
-		inv.PaidAt = time.Now()
-	} else {
-		inv.PaidAt = time.Time{}
+	profile, err := u.store.GetProfile(inv.ProfileID)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.xrechnungFailed", err)), u.win)
+		return
 	}
-	if err := u.store.SaveInvoice(inv); err != nil {
-		dialog.ShowError(fmt.Errorf(i18n.T("invoices.error.updatePaid", err)), u.win)
+	customer, err := u.store.GetCustomer(inv.CustomerID)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.xrechnungFailed", err)), u.win)
 		return
 	}
-	if markingPaid {
-		dialog.ShowInformation(i18n.T("invoices.info.markedPaidTitle"), i18n.T("invoices.info.markedPaidBody", inv.Number), u.win)
-	} else {
-		dialog.ShowInformation(i18n.T("invoices.info.markedUnpaidTitle"), i18n.T("invoices.info.markedUnpaidBody", inv.Number), u.win)
+	data, err := einvoice.XRechnung(profile, customer, inv)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.xrechnungFailed", err)), u.win)
+		return
+	}
+	path := u.store.InvoiceXRechnungPath(inv.Number)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.error.xrechnungFailed", err)), u.win)
+		return
 	}
-	u.refreshInvoices(inv.ID)
+	dialog.ShowInformation(i18n.T("invoices.info.xrechnungTitle"), i18n.T("invoices.info.xrechnungBody", inv.Number, path), u.win)
 }
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// lineItemEditor edits the items of an invoice or quote and keeps a summary
+// of subtotal, tax per rate and total up to date.
+type lineItemEditor struct {
+	items []models.InvoiceItem
+	rows  []*lineItemRow
+
+	// currency returns the currency selected in the surrounding form.
+	currency func() string
+	// defaultTaxRate returns the rate for new rows; false means the rate
+	// field holds an invalid value.
+	defaultTaxRate func() (money.Decimal, bool)
+	showError      func(string)
+	clearError     func()
+
+	rowsBox   *fyne.Container
+	subtotal  *widget.Label
+	taxLines  *fyne.Container
+	total     *widget.Label
+	container fyne.CanvasObject
+}
+
+type lineItemRow struct {
+	descEntry  *widget.Entry
+	qtyEntry   *widget.Entry
+	priceEntry *widget.Entry
+	taxEntry   *widget.Entry
+	totalLabel *widget.Label
+}
+
+func newLineItemEditor(items []models.InvoiceItem, currency func() string, defaultTaxRate func() (money.Decimal, bool), showError func(string), clearError func()) *lineItemEditor {
+	e := &lineItemEditor{
+		items:          append([]models.InvoiceItem(nil), items...),
+		currency:       currency,
+		defaultTaxRate: defaultTaxRate,
+		showError:      showError,
+		clearError:     clearError,
+		rowsBox:        container.NewVBox(),
+		subtotal:       widget.NewLabel(""),
+		taxLines:       container.NewVBox(),
+		total:          widget.NewLabel(""),
+	}
+
+	addItemButton := widget.NewButtonWithIcon(i18n.T("invoices.lineItems.add"), theme.ContentAddIcon(), func() {
+		rate, ok := e.defaultTaxRate()
+		if !ok {
+			return
+		}
+		e.add(models.InvoiceItem{Description: "", Quantity: money.DecimalFromInt(1), TaxRatePercent: rate})
+	})
+	headerRow := container.NewGridWithColumns(6,
+		makeHeaderLabel(i18n.T("invoices.table.description")),
+		makeHeaderLabel(i18n.T("invoices.table.quantity")),
+		makeHeaderLabel(i18n.T("invoices.table.unit")),
+		makeHeaderLabel(i18n.T("invoices.table.taxRate")),
+		makeHeaderLabel(i18n.T("invoices.table.lineTotal")),
+		widget.NewLabel(""),
+	)
+	header := container.NewBorder(nil, nil, nil, addItemButton, headerRow)
+	itemsScroll := container.NewVScroll(e.rowsBox)
+	itemsScroll.SetMinSize(fyne.NewSize(0, 200))
+	summaryCard := widget.NewCard(i18n.T("invoices.summary.title"), "", container.NewVBox(e.subtotal, e.taxLines, e.total))
+
+	e.container = container.NewVBox(
+		header,
+		itemsScroll,
+		widget.NewSeparator(),
+		summaryCard,
+	)
+	return e
+}
+
+// add appends a row and redraws the editor.
+func (e *lineItemEditor) add(item models.InvoiceItem) {
+	e.items = append(e.items, item)
+	e.render()
+}
+
+// Items returns a copy of the edited items.
+func (e *lineItemEditor) Items() []models.InvoiceItem {
+	return append([]models.InvoiceItem(nil), e.items...)
+}
+
+func (e *lineItemEditor) updateTotals() {
+	draft := models.Invoice{
+		Currency: e.currency(),
+		Items:    e.Items(),
+	}
+	draft.Recalculate()
+	e.subtotal.SetText(i18n.T("invoices.summary.subtotal", draft.Subtotal))
+	e.taxLines.Objects = nil
+	for _, tax := range draft.TaxBreakdown {
+		e.taxLines.Add(widget.NewLabel(i18n.T("invoices.summary.tax", tax.RatePercent.StringFixed(2), tax.Net, tax.Tax)))
+	}
+	e.taxLines.Refresh()
+	e.total.SetText(i18n.T("invoices.summary.total", draft.Total))
+}
+
+func (e *lineItemEditor) render() {
+	e.rowsBox.Objects = nil
+	e.rows = e.rows[:0]
+	if len(e.items) == 0 {
+		e.rows = nil
+		e.rowsBox.Add(widget.NewLabel(i18n.T("invoices.lineItems.empty", i18n.T("invoices.lineItems.add"))))
+		e.updateTotals()
+		e.rowsBox.Refresh()
+		return
+	}
+	for i := range e.items {
+		idx := i
+		item := e.items[idx]
+
+		descEntry := widget.NewEntry()
+		descEntry.SetText(item.Description)
+		descEntry.OnChanged = func(val string) {
+			e.items[idx].Description = val
+		}
+
+		qtyEntry := widget.NewEntry()
+		qtyEntry.SetText(item.Quantity.StringFixed(2))
+
+		priceEntry := widget.NewEntry()
+		priceEntry.SetText(item.UnitPrice.Amount())
+
+		taxEntry := widget.NewEntry()
+		taxEntry.SetText(item.TaxRatePercent.StringFixed(2))
+
+		totalValue := widget.NewLabel(item.LineTotal.Amount())
+
+		row := &lineItemRow{
+			descEntry:  descEntry,
+			qtyEntry:   qtyEntry,
+			priceEntry: priceEntry,
+			taxEntry:   taxEntry,
+			totalLabel: totalValue,
+		}
+		recalculate := func() {
+			if e.parseRow(idx, row) {
+				e.updateTotals()
+				e.clearError()
+			}
+		}
+		qtyEntry.OnChanged = func(string) { recalculate() }
+		priceEntry.OnChanged = func(string) { recalculate() }
+		taxEntry.OnChanged = func(string) { recalculate() }
+
+		removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+			e.items = append(e.items[:idx], e.items[idx+1:]...)
+			e.render()
+			e.updateTotals()
+		})
+
+		e.rowsBox.Add(container.NewGridWithColumns(6,
+			descEntry,
+			qtyEntry,
+			priceEntry,
+			taxEntry,
+			totalValue,
+			removeButton,
+		))
+		e.rows = append(e.rows, row)
+	}
+	e.updateTotals()
+	e.rowsBox.Refresh()
+}
+
+// parseRow reads the numeric fields of a row into its item, reporting the
+// first invalid field.
+func (e *lineItemEditor) parseRow(idx int, row *lineItemRow) bool {
+	qtyVal, err := locale.ParseDecimal(strings.TrimSpace(row.qtyEntry.Text))
+	if err != nil {
+		e.showError(i18n.T("invoices.error.lineItemQuantityInvalid", idx+1))
+		return false
+	}
+	priceVal, err := locale.ParseMoney(strings.TrimSpace(row.priceEntry.Text), e.currency())
+	if err != nil {
+		e.showError(i18n.T("invoices.error.lineItemUnitPriceInvalid", idx+1))
+		return false
+	}
+	taxVal, err := locale.ParseDecimal(strings.TrimSpace(row.taxEntry.Text))
+	if err != nil {
+		e.showError(i18n.T("invoices.error.lineItemTaxRateInvalid", idx+1))
+		return false
+	}
+	e.items[idx].Quantity = qtyVal
+	e.items[idx].UnitPrice = priceVal
+	e.items[idx].TaxRatePercent = taxVal
+	e.items[idx].LineTotal = priceVal.Mul(qtyVal)
+	row.totalLabel.SetText(e.items[idx].LineTotal.Amount())
+	return true
+}
+
+// validate re-reads every row before saving.
+func (e *lineItemEditor) validate() bool {
+	for idx, row := range e.rows {
+		if !e.parseRow(idx, row) {
+			return false
+		}
+	}
+	e.clearError()
+	return true
+}
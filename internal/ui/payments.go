@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+)
+
+// makePaymentsSection builds the payments part of the invoice detail pane.
+// Its rows are filled by updateInvoicePayments.
+func (u *UI) makePaymentsSection() fyne.CanvasObject {
+	u.invoicePayButton = widget.NewButtonWithIcon(i18n.T("invoices.button.recordPayment"), theme.ContentAddIcon(), func() {
+		u.openPaymentDialog()
+	})
+	u.invoicePayButton.Disable()
+	u.invoicePayments = container.NewVBox()
+	header := container.NewBorder(nil, nil, nil, u.invoicePayButton, makeHeaderLabel(i18n.T("payments.title")))
+	u.invoicePaymentsSection = container.NewVBox(widget.NewSeparator(), header, u.invoicePayments)
+	u.invoicePaymentsSection.Hide()
+	return u.invoicePaymentsSection
+}
+
+// updateInvoicePayments lists the payments of a finalised invoice with its
+// outstanding balance. Drafts can not be paid and hide the section.
+func (u *UI) updateInvoicePayments(inv *models.Invoice) {
+	if u.invoicePaymentsSection == nil {
+		return
+	}
+	u.invoicePayments.Objects = nil
+	if inv == nil || inv.IsDraft() {
+		u.invoicePaymentsSection.Hide()
+		return
+	}
+	summary := widget.NewLabel(i18n.T("payments.summary", inv.AmountPaid(), inv.Total, inv.Outstanding(), paymentStatusLabel(inv.PaymentStatus())))
+	summary.Wrapping = fyne.TextWrapWord
+	u.invoicePayments.Add(summary)
+	if discount := inv.DiscountGranted(); !discount.IsZero() {
+		u.invoicePayments.Add(widget.NewLabel(i18n.T("payments.discountGranted", discount)))
+	} else if offer, ok := inv.OpenDiscount(time.Now()); ok {
+		u.invoicePayments.Add(widget.NewLabel(i18n.T("payments.discountOpen", offer.Amount.Sub(inv.AmountPaid()), offer.Deadline.Format("2006-01-02"))))
+	}
+	if len(inv.Payments) == 0 {
+		u.invoicePayments.Add(widget.NewLabel(i18n.T("payments.empty")))
+	}
+	invoice := *inv
+	for _, p := range inv.Payments {
+		payment := p
+		parts := []string{payment.Date.Format("2006-01-02"), payment.Amount.String(), paymentMethodLabel(payment.Method)}
+		if payment.Reference != "" {
+			parts = append(parts, payment.Reference)
+		}
+		if payment.Note != "" {
+			parts = append(parts, payment.Note)
+		}
+		label := widget.NewLabel(strings.Join(parts, " · "))
+		label.Wrapping = fyne.TextWrapWord
+		remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			u.removePayment(invoice, payment)
+		})
+		u.invoicePayments.Add(container.NewBorder(nil, nil, nil, remove, label))
+	}
+	u.invoicePayments.Refresh()
+	u.invoicePaymentsSection.Show()
+}
+
+// openPaymentDialog records a payment on the selected invoice, proposing the
+// outstanding balance, or the discounted amount while a discount is open, as
+// amount.
+func (u *UI) openPaymentDialog() {
+	if u.selectedInvoice < 0 || u.selectedInvoice >= len(u.invoices) {
+		return
+	}
+	inv := u.invoices[u.selectedInvoice]
+	if inv.IsDraft() {
+		return
+	}
+
+	date := widget.NewEntry()
+	date.SetPlaceHolder(i18n.T("invoices.form.issueDatePlaceholder"))
+	date.SetText(time.Now().Format("2006-01-02"))
+	amount := widget.NewEntry()
+	if offer, ok := inv.OpenDiscount(time.Now()); ok {
+		amount.SetText(offer.Amount.Sub(inv.AmountPaid()).Amount())
+	} else if outstanding := inv.Outstanding(); outstanding.Sign() != 0 && outstanding.Sign() == inv.Total.Sign() {
+		amount.SetText(outstanding.Amount())
+	}
+	methodLabels := make([]string, len(models.PaymentMethods))
+	methods := make(map[string]string, len(models.PaymentMethods))
+	for i, m := range models.PaymentMethods {
+		methodLabels[i] = paymentMethodLabel(m)
+		methods[methodLabels[i]] = m
+	}
+	method := widget.NewSelect(methodLabels, nil)
+	method.SetSelected(methodLabels[0])
+	reference := widget.NewEntry()
+	reference.SetPlaceHolder(i18n.T("payments.form.referencePlaceholder"))
+	note := widget.NewEntry()
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(i18n.T("payments.form.date"), date),
+		widget.NewFormItem(i18n.T("payments.form.amount", inv.Currency), amount),
+		widget.NewFormItem(i18n.T("payments.form.method"), method),
+		widget.NewFormItem(i18n.T("payments.form.reference"), reference),
+		widget.NewFormItem(i18n.T("payments.form.note"), note),
+	}
+	dlg := dialog.NewForm(i18n.T("payments.dialog.title", inv.Number), i18n.T("payments.dialog.record"), i18n.T("common.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		paidOn, err := time.Parse("2006-01-02", strings.TrimSpace(date.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("payments.error.date")), u.win)
+			return
+		}
+		value, err := locale.ParseMoney(strings.TrimSpace(amount.Text), inv.Currency)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("payments.error.amount")), u.win)
+			return
+		}
+		updated, err := invoicing.RecordPayment(u.store, inv, models.Payment{
+			Date:      paidOn,
+			Amount:    value,
+			Method:    methods[method.Selected],
+			Reference: reference.Text,
+			Note:      note.Text,
+		})
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("payments.error.saveFailed", err)), u.win)
+			return
+		}
+		u.refreshInvoices(updated.ID)
+	}, u.win)
+	dlg.Resize(fyne.NewSize(460, 360))
+	dlg.Show()
+}
+
+func (u *UI) removePayment(inv models.Invoice, payment models.Payment) {
+	dialog.ShowConfirm(i18n.T("payments.remove.title"), i18n.T("payments.remove.confirm", payment.Amount, payment.Date.Format("2006-01-02")), func(ok bool) {
+		if !ok {
+			return
+		}
+		if _, err := invoicing.RemovePayment(u.store, inv, payment.ID); err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("payments.error.saveFailed", err)), u.win)
+			return
+		}
+		u.refreshInvoices(inv.ID)
+	}, u.win)
+}
+
+func paymentStatusLabel(status string) string {
+	return i18n.T("payments.status." + status)
+}
+
+// paymentMethodLabel names a payment method; payments migrated from the old
+// paid flag have none.
+func paymentMethodLabel(method string) string {
+	switch method {
+	case "":
+		return i18n.T("payments.method.unknown")
+	case models.MethodBankTransfer, models.MethodDirectDebit, models.MethodCard, models.MethodCash, models.MethodOther:
+		return i18n.T("payments.method." + method)
+	default:
+		return method
+	}
+}
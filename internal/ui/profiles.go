@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,9 +12,14 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/janmarkuslanger/invoiceio/internal/einvoice"
 	"github.com/janmarkuslanger/invoiceio/internal/i18n"
 	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
 	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/numbering"
+	"github.com/janmarkuslanger/invoiceio/internal/pdf"
+	"github.com/janmarkuslanger/invoiceio/internal/sepa"
 )
 
 func (u *UI) makeProfilesTab() fyne.CanvasObject {
@@ -89,7 +95,34 @@ func (u *UI) openProfileDialog(existing *models.Profile) {
 	bankName := widget.NewEntry()
 	iban := widget.NewEntry()
 	bic := widget.NewEntry()
+	qrBill := widget.NewCheck(i18n.T("profiles.form.qrBill"), nil)
+	creditorID := widget.NewEntry()
+	creditorID.SetPlaceHolder(i18n.T("profiles.form.creditorIDPlaceholder"))
 	paymentTerms := widget.NewEntry()
+	numberPattern := widget.NewEntry()
+	numberPattern.SetPlaceHolder(numbering.DefaultPattern)
+	creditPattern := widget.NewEntry()
+	creditPattern.SetPlaceHolder(numbering.DefaultCreditPattern)
+	quotePattern := widget.NewEntry()
+	quotePattern.SetPlaceHolder(numbering.DefaultQuotePattern)
+	resetYearly := widget.NewCheck(i18n.T("profiles.form.numberResetYearly"), nil)
+	fontRegular, fontRegularRow := u.newAssetPicker("fonts", []string{".ttf"})
+	fontBold, fontBoldRow := u.newAssetPicker("fonts", []string{".ttf"})
+	facturXOptions := append([]string{i18n.T("profiles.form.facturXNone")}, einvoice.Levels()...)
+	facturX := widget.NewSelect(facturXOptions, nil)
+	facturX.SetSelectedIndex(0)
+	if current.EInvoice.FacturXProfile != "" {
+		facturX.SetSelected(current.EInvoice.FacturXProfile)
+	}
+	logo := u.newImagePlacementInput(current.Branding.Logo)
+	signature := u.newImagePlacementInput(current.Branding.Signature)
+	levelNames := invoicing.DunningLevels(current)
+	dunningLevels := make([]*dunningLevelInput, len(levelNames))
+	for i, level := range current.Dunning.EffectiveLevels() {
+		dunningLevels[i] = newDunningLevelInput(level)
+	}
+	interestSettings := newInterestInput(current.Interest)
+	terms := newTermsInput(current.Terms)
 
 	if isEdit {
 		displayName.SetText(current.DisplayName)
@@ -105,7 +138,15 @@ func (u *UI) openProfileDialog(existing *models.Profile) {
 		bankName.SetText(current.PaymentDetails.BankName)
 		iban.SetText(current.PaymentDetails.IBAN)
 		bic.SetText(current.PaymentDetails.BIC)
+		qrBill.SetChecked(current.PaymentDetails.QRBill)
+		creditorID.SetText(current.PaymentDetails.CreditorID)
 		paymentTerms.SetText(current.PaymentDetails.PaymentTerms)
+		numberPattern.SetText(current.InvoiceNumbering.Pattern)
+		creditPattern.SetText(current.CreditNumbering.Pattern)
+		quotePattern.SetText(current.QuoteNumbering.Pattern)
+		resetYearly.SetChecked(current.InvoiceNumbering.ResetYearly)
+		fontRegular.SetText(current.Fonts.Regular)
+		fontBold.SetText(current.Fonts.Bold)
 	}
 
 	form := widget.NewForm(
@@ -122,13 +163,72 @@ func (u *UI) openProfileDialog(existing *models.Profile) {
 		widget.NewFormItem(i18n.T("profiles.form.bankName"), bankName),
 		widget.NewFormItem(i18n.T("profiles.form.iban"), iban),
 		widget.NewFormItem(i18n.T("profiles.form.bic"), bic),
+		widget.NewFormItem("", qrBill),
+		widget.NewFormItem(i18n.T("profiles.form.creditorID"), creditorID),
 		widget.NewFormItem(i18n.T("profiles.form.paymentTerms"), paymentTerms),
+		widget.NewFormItem(i18n.T("profiles.form.terms"), terms.row),
+		widget.NewFormItem(i18n.T("profiles.form.numberPattern"), numberPattern),
+		widget.NewFormItem(i18n.T("profiles.form.creditPattern"), creditPattern),
+		widget.NewFormItem(i18n.T("profiles.form.quotePattern"), quotePattern),
+		widget.NewFormItem("", resetYearly),
+		widget.NewFormItem(i18n.T("profiles.form.fontRegular"), fontRegularRow),
+		widget.NewFormItem(i18n.T("profiles.form.fontBold"), fontBoldRow),
+		widget.NewFormItem(i18n.T("profiles.form.facturX"), facturX),
+		widget.NewFormItem(i18n.T("profiles.form.logo"), logo.row),
+		widget.NewFormItem(i18n.T("profiles.form.signature"), signature.row),
 	)
+	for i, in := range dunningLevels {
+		form.Append(levelNames[i].Name, in.row)
+	}
+	form.Append(i18n.T("profiles.form.interest"), interestSettings.row)
 
 	u.showFormDialog(title, submitLabel, form, func() error {
 		if strings.TrimSpace(displayName.Text) == "" {
 			return fmt.Errorf("%s", i18n.T("profiles.error.displayNameRequired"))
 		}
+		if err := numbering.Validate(numberPattern.Text); err != nil {
+			return fmt.Errorf("%s: %w", i18n.T("profiles.error.numberPattern"), err)
+		}
+		if err := numbering.Validate(creditPattern.Text); err != nil {
+			return fmt.Errorf("%s: %w", i18n.T("profiles.error.creditPattern"), err)
+		}
+		if err := numbering.Validate(quotePattern.Text); err != nil {
+			return fmt.Errorf("%s: %w", i18n.T("profiles.error.quotePattern"), err)
+		}
+		if id := sepa.Compact(creditorID.Text); id != "" && !sepa.ValidCreditorID(id) {
+			return fmt.Errorf("%s", i18n.T("profiles.error.creditorID"))
+		}
+		facturXProfile := ""
+		if facturX.SelectedIndex() > 0 {
+			facturXProfile = facturX.Selected
+		}
+		for _, font := range []string{fontRegular.Text, fontBold.Text} {
+			if err := pdf.CheckFont(font, u.store.BaseDir()); err != nil {
+				return fmt.Errorf("%s: %w", i18n.T("profiles.error.font"), err)
+			}
+		}
+		logoPlacement, err := logo.placement(u.store.BaseDir())
+		if err != nil {
+			return err
+		}
+		signaturePlacement, err := signature.placement(u.store.BaseDir())
+		if err != nil {
+			return err
+		}
+		levels := make([]models.DunningLevel, len(dunningLevels))
+		for i, in := range dunningLevels {
+			if levels[i], err = in.value(); err != nil {
+				return fmt.Errorf("%s: %w", levelNames[i].Name, err)
+			}
+		}
+		interest, err := interestSettings.value()
+		if err != nil {
+			return err
+		}
+		structuredTerms, err := terms.value()
+		if err != nil {
+			return err
+		}
 		now := time.Now()
 		profileID := ""
 		createdAt := now
@@ -155,7 +255,37 @@ func (u *UI) openProfileDialog(existing *models.Profile) {
 				IBAN:         strings.TrimSpace(iban.Text),
 				BIC:          strings.TrimSpace(bic.Text),
 				PaymentTerms: strings.TrimSpace(paymentTerms.Text),
+				QRBill:       qrBill.Checked,
+				CreditorID:   sepa.Compact(creditorID.Text),
+			},
+			Terms: structuredTerms,
+			InvoiceNumbering: models.NumberingScheme{
+				Pattern:     strings.TrimSpace(numberPattern.Text),
+				ResetYearly: resetYearly.Checked,
+			},
+			CreditNumbering: models.NumberingScheme{
+				Pattern:     strings.TrimSpace(creditPattern.Text),
+				ResetYearly: resetYearly.Checked,
+			},
+			QuoteNumbering: models.NumberingScheme{
+				Pattern:     strings.TrimSpace(quotePattern.Text),
+				ResetYearly: resetYearly.Checked,
+			},
+			Fonts: models.FontPair{
+				Regular: strings.TrimSpace(fontRegular.Text),
+				Bold:    strings.TrimSpace(fontBold.Text),
+			},
+			Branding: models.Branding{
+				Logo:      logoPlacement,
+				Signature: signaturePlacement,
+			},
+			EInvoice: models.EInvoiceSettings{
+				FacturXProfile: facturXProfile,
+			},
+			Dunning: models.DunningSettings{
+				Levels: levels,
 			},
+			Interest:  interest,
 			CreatedAt: createdAt,
 			UpdatedAt: now,
 		}
@@ -210,8 +340,136 @@ func (u *UI) updateProfileDetail() {
 	if val := strings.TrimSpace(p.PaymentDetails.BIC); val != "" {
 		lines = append(lines, i18n.T("profiles.detail.bic", val))
 	}
+	if p.PaymentDetails.QRBill {
+		lines = append(lines, i18n.T("profiles.detail.qrBill"))
+	}
+	if val := p.PaymentDetails.CreditorID; val != "" {
+		lines = append(lines, i18n.T("profiles.detail.creditorID", val))
+	}
 	if val := strings.TrimSpace(p.PaymentDetails.PaymentTerms); val != "" {
 		lines = append(lines, i18n.T("profiles.detail.paymentTerms", val))
 	}
+	lines = append(lines, termsLines(p.Terms)...)
+	pattern := p.InvoiceNumbering.Pattern
+	if pattern == "" {
+		pattern = numbering.DefaultPattern
+	}
+	lines = append(lines, "", i18n.T("profiles.detail.numberingTitle"), i18n.T("profiles.detail.numberPattern", pattern))
+	creditPattern := p.CreditNumbering.Pattern
+	if creditPattern == "" {
+		creditPattern = numbering.DefaultCreditPattern
+	}
+	lines = append(lines, i18n.T("profiles.detail.creditPattern", creditPattern))
+	quotePattern := p.QuoteNumbering.Pattern
+	if quotePattern == "" {
+		quotePattern = numbering.DefaultQuotePattern
+	}
+	lines = append(lines, i18n.T("profiles.detail.quotePattern", quotePattern))
+	if p.InvoiceNumbering.ResetYearly {
+		lines = append(lines, i18n.T("profiles.detail.numberResetYearly"))
+	}
+	if p.Fonts.Regular != "" || p.Fonts.Bold != "" {
+		lines = append(lines, "", i18n.T("profiles.detail.fontsTitle"))
+		if p.Fonts.Regular != "" {
+			lines = append(lines, i18n.T("profiles.detail.fontRegular", p.Fonts.Regular))
+		}
+		if p.Fonts.Bold != "" {
+			lines = append(lines, i18n.T("profiles.detail.fontBold", p.Fonts.Bold))
+		}
+	}
+	if p.EInvoice.FacturXProfile != "" {
+		lines = append(lines, "", i18n.T("profiles.detail.facturX", p.EInvoice.FacturXProfile))
+	}
+	lines = append(lines, "", i18n.T("profiles.detail.dunningTitle"))
+	for _, level := range invoicing.DunningLevels(p) {
+		lines = append(lines, i18n.T("profiles.detail.dunningLevel", level.Name, level.DaysAfterDue, level.Fee.StringFixed(2), level.PaymentDays))
+		if level.Interest {
+			lines = append(lines, i18n.T("profiles.detail.dunningInterest"))
+		}
+	}
+	interest := p.Interest.Effective()
+	lines = append(lines, "", i18n.T("profiles.detail.interestTitle"), i18n.T("profiles.detail.interestRate", interest.MarginPoints.String(), interest.FlatFee.StringFixed(2)))
+	last := interest.Periods[len(interest.Periods)-1]
+	schedule := i18n.T("profiles.detail.interestSchedule", len(interest.Periods))
+	if len(p.Interest.Periods) == 0 {
+		schedule = i18n.T("profiles.detail.interestDefaultSchedule")
+	}
+	lines = append(lines, i18n.T("profiles.detail.interestLatest", schedule, last.RatePercent.String(), last.From.Format("2006-01-02")))
+	if p.Branding.Logo.Path != "" || p.Branding.Signature.Path != "" {
+		lines = append(lines, "", i18n.T("profiles.detail.brandingTitle"))
+		if p.Branding.Logo.Path != "" {
+			lines = append(lines, i18n.T("profiles.detail.logo", p.Branding.Logo.Path))
+		}
+		if p.Branding.Signature.Path != "" {
+			lines = append(lines, i18n.T("profiles.detail.signature", p.Branding.Signature.Path))
+		}
+	}
 	u.profileDetailText.ParseMarkdown(strings.Join(lines, "\n"))
 }
+
+var imagePositions = []string{
+	models.PositionTopLeft,
+	models.PositionTopRight,
+	models.PositionBottomLeft,
+	models.PositionBottomRight,
+}
+
+// imagePlacementInput edits a models.ImagePlacement: the image file, its
+// position on the page and an optional width in points.
+type imagePlacementInput struct {
+	path     *widget.Entry
+	position *widget.Select
+	width    *widget.Entry
+	row      fyne.CanvasObject
+}
+
+func (u *UI) newImagePlacementInput(current models.ImagePlacement) *imagePlacementInput {
+	path, picker := u.newAssetPicker("images", []string{".png", ".jpg", ".jpeg"})
+	path.SetText(current.Path)
+
+	labels := make([]string, len(imagePositions))
+	for i, pos := range imagePositions {
+		labels[i] = i18n.T("profiles.position." + pos)
+	}
+	position := widget.NewSelect(labels, nil)
+	position.PlaceHolder = i18n.T("forms.placeholder.default")
+	for i, pos := range imagePositions {
+		if pos == current.Position {
+			position.SetSelectedIndex(i)
+		}
+	}
+
+	width := widget.NewEntry()
+	width.SetPlaceHolder(i18n.T("profiles.form.imageWidth"))
+	if current.Width > 0 {
+		width.SetText(strconv.Itoa(current.Width))
+	}
+
+	return &imagePlacementInput{
+		path:     path,
+		position: position,
+		width:    width,
+		row:      container.NewBorder(nil, nil, nil, container.NewHBox(position, width), picker),
+	}
+}
+
+// placement validates the input and checks that the image can be embedded.
+func (in *imagePlacementInput) placement(assetDir string) (models.ImagePlacement, error) {
+	placement := models.ImagePlacement{Path: strings.TrimSpace(in.path.Text)}
+	if idx := in.position.SelectedIndex(); idx >= 0 {
+		placement.Position = imagePositions[idx]
+	}
+	if text := strings.TrimSpace(in.width.Text); text != "" {
+		width, err := strconv.Atoi(text)
+		if err != nil || width <= 0 {
+			return placement, fmt.Errorf("%s", i18n.T("profiles.error.imageWidth"))
+		}
+		placement.Width = width
+	}
+	if placement.Path != "" {
+		if err := pdf.CheckImage(placement.Path, assetDir); err != nil {
+			return placement, fmt.Errorf("%s: %w", i18n.T("profiles.error.image"), err)
+		}
+	}
+	return placement, nil
+}
@@ -0,0 +1,399 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// quoteStates are the states a user can set; expiry is derived from the
+// validity date.
+var quoteStates = []string{models.QuoteDraft, models.QuoteSent, models.QuoteAccepted, models.QuoteDeclined}
+
+func (u *UI) makeQuotesTab() fyne.CanvasObject {
+	u.quoteDetailText = widget.NewRichTextFromMarkdown(i18n.T("quotes.detail.empty"))
+	u.quoteDetailText.Wrapping = fyne.TextWrapWord
+	detailCard := widget.NewCard(i18n.T("quotes.detail.title"), "", u.quoteDetailText)
+	detailScroll := container.NewVScroll(detailCard)
+
+	u.quoteList = widget.NewList(
+		func() int { return len(u.quoteSummaries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(u.quoteSummaries) {
+				return
+			}
+			obj.(*widget.Label).SetText(u.quoteSummaries[id])
+		},
+	)
+	u.quoteList.OnSelected = func(id widget.ListItemID) {
+		if id < 0 || id >= len(u.quotes) {
+			u.selectedQuote = -1
+		} else {
+			u.selectedQuote = id
+		}
+		u.updateQuoteDetail()
+		u.updateQuoteActionButtons()
+	}
+
+	newButton := widget.NewButtonWithIcon(i18n.T("quotes.button.new"), themePlusIcon(), func() {
+		u.openQuoteDialog(nil)
+	})
+	u.quoteEditButton = widget.NewButton(i18n.T("button.editSelected"), func() {
+		if u.selectedQuote < 0 || u.selectedQuote >= len(u.quotes) {
+			return
+		}
+		quote := u.quotes[u.selectedQuote]
+		u.openQuoteDialog(&quote)
+	})
+	u.quoteEditButton.Disable()
+
+	u.quoteConvertButton = widget.NewButton(i18n.T("quotes.button.convert"), func() {
+		u.convertQuote()
+	})
+	u.quoteConvertButton.Disable()
+
+	actionBar := container.NewHBox(newButton, u.quoteEditButton, u.quoteConvertButton)
+
+	split := container.NewHSplit(
+		container.NewMax(u.quoteList),
+		container.NewMax(detailScroll),
+	)
+	split.SetOffset(0.34)
+
+	return container.NewBorder(actionBar, nil, nil, nil, split)
+}
+
+func (u *UI) openQuoteDialog(existing *models.Quote) {
+	isEdit := existing != nil
+	title := i18n.T("quotes.dialog.newTitle")
+	submitLabel := i18n.T("quotes.dialog.create")
+	var current models.Quote
+	if isEdit {
+		title = i18n.T("quotes.dialog.editTitle")
+		submitLabel = i18n.T("quotes.dialog.update")
+		current = *existing
+	}
+
+	profileOptions := u.profileOptions()
+	customerOptions := u.customerOptions()
+
+	profileSelect := widget.NewSelect(profileOptions, nil)
+	profileSelect.PlaceHolder = i18n.T("invoices.form.profilePlaceholder")
+	customerSelect := widget.NewSelect(customerOptions, nil)
+	customerSelect.PlaceHolder = i18n.T("invoices.form.customerPlaceholder")
+	issueDate := widget.NewEntry()
+	issueDate.SetPlaceHolder(i18n.T("invoices.form.issueDatePlaceholder"))
+	validUntil := widget.NewEntry()
+	validUntil.SetPlaceHolder(i18n.T("invoices.form.dueDatePlaceholder"))
+	statusLabels := make([]string, len(quoteStates))
+	for i, state := range quoteStates {
+		statusLabels[i] = quoteStatusLabel(state)
+	}
+	stateSelect := widget.NewSelect(statusLabels, nil)
+	taxRate := widget.NewEntry()
+	taxRate.SetPlaceHolder(i18n.T("invoices.form.taxRatePlaceholder"))
+	currency := widget.NewEntry()
+	currency.SetPlaceHolder(money.DefaultCurrency)
+	notes := widget.NewMultiLineEntry()
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	status.Hide()
+	showError := func(message string) {
+		status.SetText(message)
+		status.Show()
+	}
+	clearError := func() {
+		status.Hide()
+	}
+
+	if isEdit {
+		if prof, ok := u.profileByID(current.ProfileID); ok {
+			profileSelect.SetSelected(u.profileLabel(prof))
+		}
+		if cust, ok := u.customerByID(current.CustomerID); ok {
+			customerSelect.SetSelected(u.customerLabel(cust))
+		}
+		issueDate.SetText(current.IssueDate.Format("2006-01-02"))
+		if !current.ValidUntil.IsZero() {
+			validUntil.SetText(current.ValidUntil.Format("2006-01-02"))
+		}
+		stateSelect.SetSelected(quoteStatusLabel(current.Status))
+		if len(current.Items) > 0 {
+			taxRate.SetText(current.Items[len(current.Items)-1].TaxRatePercent.StringFixed(2))
+		} else {
+			taxRate.SetText("0")
+		}
+		currency.SetText(current.Currency)
+		notes.SetText(current.Notes)
+	} else {
+		now := time.Now()
+		issueDate.SetText(now.Format("2006-01-02"))
+		validUntil.SetText(now.AddDate(0, 0, invoicing.DefaultQuoteDays).Format("2006-01-02"))
+		stateSelect.SetSelected(quoteStatusLabel(models.QuoteDraft))
+		taxRate.SetText("0")
+		currency.SetText(money.DefaultCurrency)
+		if len(profileOptions) > 0 {
+			profileSelect.SetSelected(profileOptions[0])
+		}
+		if len(customerOptions) > 0 {
+			customerSelect.SetSelected(customerOptions[0])
+		}
+		if profile, ok := u.profileByID(u.lastProfileID); ok {
+			profileSelect.SetSelected(u.profileLabel(profile))
+		}
+		if customer, ok := u.customerByID(u.lastCustomerID); ok {
+			customerSelect.SetSelected(u.customerLabel(customer))
+		}
+	}
+
+	selectedCurrency := func() string {
+		if v := strings.ToUpper(strings.TrimSpace(currency.Text)); v != "" {
+			return v
+		}
+		return money.DefaultCurrency
+	}
+	defaultTaxRate := func() (money.Decimal, bool) {
+		v := strings.TrimSpace(taxRate.Text)
+		if v == "" {
+			return money.Decimal{}, true
+		}
+		d, err := locale.ParseDecimal(v)
+		if err != nil {
+			showError(i18n.T("invoices.error.taxRateFormat"))
+			return money.Decimal{}, false
+		}
+		clearError()
+		return d, true
+	}
+
+	lineItems := newLineItemEditor(current.Items, selectedCurrency, defaultTaxRate, showError, clearError)
+	taxRate.OnChanged = func(string) {
+		defaultTaxRate()
+	}
+	currency.OnChanged = func(string) {
+		lineItems.updateTotals()
+	}
+	if len(current.Items) == 0 && !isEdit {
+		lineItems.add(models.InvoiceItem{Description: "", Quantity: money.DecimalFromInt(1)})
+	} else {
+		lineItems.render()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("invoices.form.profile"), profileSelect),
+		widget.NewFormItem(i18n.T("invoices.form.customer"), customerSelect),
+		widget.NewFormItem(i18n.T("quotes.form.issueDate"), issueDate),
+		widget.NewFormItem(i18n.T("quotes.form.validUntil"), validUntil),
+		widget.NewFormItem(i18n.T("quotes.form.status"), stateSelect),
+		widget.NewFormItem(i18n.T("invoices.form.taxRate"), taxRate),
+		widget.NewFormItem(i18n.T("invoices.form.currency"), currency),
+		widget.NewFormItem(i18n.T("invoices.form.notes"), notes),
+	)
+	content := container.NewVBox(form, widget.NewSeparator(), lineItems.container)
+
+	save := widget.NewButton(submitLabel, nil)
+	cancel := widget.NewButton(i18n.T("common.cancel"), nil)
+	buttons := container.NewHBox(layout.NewSpacer(), cancel, save)
+	dlg := dialog.NewCustomWithoutButtons(title, container.NewBorder(content, container.NewVBox(status, buttons), nil, nil, nil), u.win)
+
+	cancel.OnTapped = func() {
+		dlg.Hide()
+	}
+
+	save.OnTapped = func() {
+		profileModel, ok := u.profileByLabel(profileSelect.Selected)
+		if !ok {
+			showError(i18n.T("invoices.error.selectionRequired"))
+			return
+		}
+		customerModel, ok := u.customerByLabel(customerSelect.Selected)
+		if !ok {
+			showError(i18n.T("invoices.error.selectionRequired"))
+			return
+		}
+		if !lineItems.validate() {
+			return
+		}
+		items := lineItems.Items()
+		if len(items) == 0 {
+			showError(i18n.T("invoices.error.noItems"))
+			return
+		}
+		issue, err := time.Parse("2006-01-02", strings.TrimSpace(issueDate.Text))
+		if err != nil {
+			showError(i18n.T("invoices.error.issueDate"))
+			return
+		}
+		var valid time.Time
+		if v := strings.TrimSpace(validUntil.Text); v != "" {
+			if valid, err = time.Parse("2006-01-02", v); err != nil {
+				showError(i18n.T("quotes.error.validUntil"))
+				return
+			}
+		}
+		state := models.QuoteDraft
+		if idx := stateSelect.SelectedIndex(); idx >= 0 {
+			state = quoteStates[idx]
+		}
+
+		quote := current
+		if !isEdit {
+			quote.ID = id.New()
+			quote.CreatedAt = time.Now()
+		}
+		quote.ProfileID = profileModel.ID
+		quote.CustomerID = customerModel.ID
+		quote.IssueDate = issue
+		quote.ValidUntil = valid
+		quote.Status = state
+		quote.Currency = selectedCurrency()
+		quote.Items = items
+		quote.Notes = strings.TrimSpace(notes.Text)
+
+		if isEdit {
+			quote.Recalculate()
+			if quote.PDFPath == "" {
+				quote.PDFPath = u.store.QuotePDFPath(quote.Number)
+			}
+			err = u.store.SaveQuote(quote)
+			if err == nil {
+				err = invoicing.RenderQuote(u.store, profileModel, customerModel, quote)
+			}
+		} else {
+			quote, err = invoicing.CreateQuote(u.store, profileModel, customerModel, quote)
+		}
+		if err != nil {
+			showError(i18n.T("quotes.error.saveFailed", err))
+			return
+		}
+
+		u.lastProfileID = quote.ProfileID
+		u.lastCustomerID = quote.CustomerID
+		u.refreshQuotes(quote.ID)
+		dialog.ShowInformation(i18n.T("quotes.info.savedTitle"), i18n.T("quotes.info.savedBody", quote.Number, quote.PDFPath), u.win)
+		dlg.Hide()
+	}
+
+	dlg.Resize(fyne.NewSize(560, 640))
+	dlg.Show()
+}
+
+func (u *UI) updateQuoteDetail() {
+	if u.quoteDetailText == nil {
+		return
+	}
+	if u.selectedQuote < 0 || u.selectedQuote >= len(u.quotes) {
+		u.quoteDetailText.ParseMarkdown(i18n.T("quotes.detail.empty"))
+		return
+	}
+	q := u.quotes[u.selectedQuote]
+	customerName := q.CustomerID
+	if cust, ok := u.customerByID(q.CustomerID); ok {
+		customerName = cust.DisplayName
+	}
+	profileName := q.ProfileID
+	if prof, ok := u.profileByID(q.ProfileID); ok {
+		profileName = prof.DisplayName
+	}
+
+	lines := []string{
+		i18n.T("quotes.detail.quote", q.Number),
+		i18n.T("quotes.detail.status", quoteStatusLabel(q.CurrentStatus(time.Now()))),
+		i18n.T("invoices.detail.profile", profileName),
+		i18n.T("invoices.detail.customer", customerName),
+		i18n.T("invoices.detail.issued", q.IssueDate.Format("2006-01-02")),
+	}
+	if !q.ValidUntil.IsZero() {
+		lines = append(lines, i18n.T("quotes.detail.validUntil", q.ValidUntil.Format("2006-01-02")))
+	}
+	if q.InvoiceID != "" {
+		invoiceNumber := q.InvoiceID
+		if inv, err := u.store.GetInvoice(q.InvoiceID); err == nil {
+			invoiceNumber = inv.Number
+		}
+		lines = append(lines, i18n.T("quotes.detail.invoice", invoiceNumber))
+	}
+	lines = append(lines, i18n.T("invoices.detail.subtotal", q.Subtotal))
+	for _, tax := range q.TaxBreakdown {
+		lines = append(lines, i18n.T("invoices.detail.tax", tax.RatePercent.StringFixed(2), tax.Net, tax.Tax))
+	}
+	lines = append(lines,
+		i18n.T("invoices.detail.total", q.Total),
+		i18n.T("invoices.detail.pdf", q.PDFPath),
+		"",
+		i18n.T("invoices.detail.lineItems"),
+	)
+	for _, item := range q.Items {
+		lines = append(lines, i18n.T("invoices.detail.lineItem", item.Description, item.Quantity, item.UnitPrice, item.LineTotal, item.TaxRatePercent))
+	}
+	if strings.TrimSpace(q.Notes) != "" {
+		lines = append(lines, "", i18n.T("invoices.detail.notesTitle"), q.Notes)
+	}
+	u.quoteDetailText.ParseMarkdown(strings.Join(lines, "\n"))
+}
+
+func (u *UI) updateQuoteActionButtons() {
+	selected := u.selectedQuote >= 0 && u.selectedQuote < len(u.quotes)
+	if u.quoteEditButton != nil {
+		if selected {
+			u.quoteEditButton.Enable()
+		} else {
+			u.quoteEditButton.Disable()
+		}
+	}
+	if u.quoteConvertButton != nil {
+		if selected && u.quotes[u.selectedQuote].InvoiceID == "" && u.quotes[u.selectedQuote].Status != models.QuoteDeclined {
+			u.quoteConvertButton.Enable()
+		} else {
+			u.quoteConvertButton.Disable()
+		}
+	}
+}
+
+// convertQuote turns the selected quote into an invoice issued today.
+func (u *UI) convertQuote() {
+	if u.selectedQuote < 0 || u.selectedQuote >= len(u.quotes) {
+		return
+	}
+	quote := u.quotes[u.selectedQuote]
+	dialog.ShowConfirm(i18n.T("quotes.convert.title"), i18n.T("quotes.convert.confirm", quote.Number), func(ok bool) {
+		if !ok {
+			return
+		}
+		invoice, findings, err := invoicing.ConvertQuote(u.store, quote, time.Now())
+		if errors.Is(err, invoicing.ErrIncomplete) {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("invoices.validation.errors", findings.String())), u.win)
+			return
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s", i18n.T("quotes.error.convertFailed", err)), u.win)
+			return
+		}
+		u.refreshQuotes(quote.ID)
+		u.refreshInvoices(invoice.ID)
+		dialog.ShowInformation(i18n.T("quotes.convert.doneTitle"), i18n.T("quotes.convert.doneBody", invoice.Number, quote.Number, invoice.PDFPath), u.win)
+	}, u.win)
+}
+
+func quoteStatusLabel(status string) string {
+	if status == "" {
+		status = models.QuoteDraft
+	}
+	return i18n.T("quotes.status." + status)
+}
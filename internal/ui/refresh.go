@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/janmarkuslanger/invoiceio/internal/i18n"
 )
@@ -115,7 +116,7 @@ func (u *UI) refreshInvoices(selectedIDs ...string) {
 			customerLabel = cust.DisplayName
 		}
 		status := invoiceBadge(inv)
-		u.invoiceSummaries[idx] = i18n.T("invoices.summary.listEntry", status, inv.Number, customerLabel, inv.Total)
+		u.invoiceSummaries[idx] = i18n.T("invoices.summary.listEntry", status, invoiceNumberLabel(inv), customerLabel, inv.Total)
 	}
 
 	u.selectedInvoice = -1
@@ -140,3 +141,92 @@ func (u *UI) refreshInvoices(selectedIDs ...string) {
 	}
 	u.updateInvoiceActionButtons()
 }
+
+func (u *UI) refreshQuotes(selectedIDs ...string) {
+	quotes, err := u.store.ListQuotes()
+	if err != nil {
+		dialogError(u.win, fmt.Errorf("%s: %v", i18n.T("errors.loadQuotes"), err))
+		return
+	}
+	targetID := ""
+	if len(selectedIDs) > 0 {
+		targetID = selectedIDs[0]
+	} else if u.selectedQuote >= 0 && u.selectedQuote < len(u.quotes) {
+		targetID = u.quotes[u.selectedQuote].ID
+	}
+
+	now := time.Now()
+	u.quotes = quotes
+	u.quoteSummaries = make([]string, len(quotes))
+	for idx, q := range quotes {
+		customerLabel := q.CustomerID
+		if cust, err := u.store.GetCustomer(q.CustomerID); err == nil {
+			customerLabel = cust.DisplayName
+		}
+		u.quoteSummaries[idx] = i18n.T("invoices.summary.listEntry", quoteStatusLabel(q.CurrentStatus(now)), q.Number, customerLabel, q.Total)
+	}
+
+	u.selectedQuote = -1
+	if u.quoteList != nil {
+		u.quoteList.Refresh()
+	}
+	for idx, quote := range quotes {
+		if targetID != "" && quote.ID == targetID {
+			u.selectedQuote = idx
+			break
+		}
+	}
+
+	if u.selectedQuote >= 0 && u.quoteList != nil {
+		u.quoteList.Select(u.selectedQuote)
+	} else {
+		u.updateQuoteDetail()
+	}
+	u.updateQuoteActionButtons()
+}
+
+func (u *UI) refreshSubscriptions(selectedIDs ...string) {
+	subs, err := u.store.ListSubscriptions()
+	if err != nil {
+		dialogError(u.win, fmt.Errorf("%s: %v", i18n.T("errors.loadSubscriptions"), err))
+		return
+	}
+	targetID := ""
+	if len(selectedIDs) > 0 {
+		targetID = selectedIDs[0]
+	} else if u.selectedSubscription >= 0 && u.selectedSubscription < len(u.subscriptions) {
+		targetID = u.subscriptions[u.selectedSubscription].ID
+	}
+
+	u.subscriptions = subs
+	u.subscriptionSummaries = make([]string, len(subs))
+	for idx, s := range subs {
+		customerLabel := s.CustomerID
+		if cust, err := u.store.GetCustomer(s.CustomerID); err == nil {
+			customerLabel = cust.DisplayName
+		}
+		next := subscriptionStatusLabel(s)
+		if date, ok := s.NextRun(); ok && s.IsActive() {
+			next = date.Format("2006-01-02")
+		}
+		u.subscriptionSummaries[idx] = i18n.T("subscriptions.summary.listEntry", s.Name, customerLabel, intervalLabel(s.Interval), next)
+	}
+
+	u.selectedSubscription = -1
+	if u.subscriptionList != nil {
+		u.subscriptionList.Refresh()
+	}
+	for idx, s := range subs {
+		if targetID != "" && s.ID == targetID {
+			u.selectedSubscription = idx
+			break
+		}
+	}
+
+	if u.selectedSubscription >= 0 && u.subscriptionList != nil {
+		u.subscriptionList.Select(u.selectedSubscription)
+	} else {
+		u.updateSubscriptionDetail()
+	}
+	u.updateSubscriptionActionButtons()
+}
@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/statement"
+)
+
+// importStatement reads a bank statement, asking for the column layout of
+// CSV exports, and shows the proposed payment matches for review.
+func (u *UI) importStatement() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialogError(u.win, err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		name := reader.URI().Name()
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			dialogError(u.win, err)
+			return
+		}
+		load := func(format statement.CSVFormat) {
+			txs, err := statement.Parse(name, bytes.NewReader(data), format)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s", i18n.T("statement.error.parse", err)), u.win)
+				return
+			}
+			matches, err := invoicing.ProposeMatches(u.store, txs)
+			if err != nil {
+				dialogError(u.win, err)
+				return
+			}
+			u.reviewStatement(matches)
+		}
+		if statement.DetectFormat(name, data) == statement.FormatCSV {
+			u.openCSVFormatDialog(load)
+			return
+		}
+		load(statement.CSVFormat{})
+	}, u.win)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".xml", ".sta", ".mt940", ".940", ".csv", ".txt"}))
+	open.Show()
+}
+
+// openCSVFormatDialog asks which columns of a CSV export hold the booking
+// data. Empty fields use the usual bank headers.
+func (u *UI) openCSVFormatDialog(done func(statement.CSVFormat)) {
+	newEntry := func() *widget.Entry {
+		e := widget.NewEntry()
+		e.SetPlaceHolder(i18n.T("statement.csv.auto"))
+		return e
+	}
+	delimiter, date, amount, counterparty, remittance, dateLayout := newEntry(), newEntry(), newEntry(), newEntry(), newEntry(), newEntry()
+	currency := widget.NewEntry()
+	currency.SetPlaceHolder("EUR")
+	decimalSeparator, thousandsSeparator := widget.NewEntry(), widget.NewEntry()
+	decimalSeparator.SetPlaceHolder(",")
+	thousandsSeparator.SetPlaceHolder(".")
+	items := []*widget.FormItem{
+		widget.NewFormItem(i18n.T("statement.csv.delimiter"), delimiter),
+		widget.NewFormItem(i18n.T("statement.csv.dateColumn"), date),
+		widget.NewFormItem(i18n.T("statement.csv.amountColumn"), amount),
+		widget.NewFormItem(i18n.T("statement.csv.counterpartyColumn"), counterparty),
+		widget.NewFormItem(i18n.T("statement.csv.remittanceColumn"), remittance),
+		widget.NewFormItem(i18n.T("statement.csv.dateLayout"), dateLayout),
+		widget.NewFormItem(i18n.T("statement.csv.currency"), currency),
+		widget.NewFormItem(i18n.T("statement.csv.decimalSeparator"), decimalSeparator),
+		widget.NewFormItem(i18n.T("statement.csv.thousandsSeparator"), thousandsSeparator),
+	}
+	dlg := dialog.NewForm(i18n.T("statement.csv.title"), i18n.T("statement.csv.read"), i18n.T("common.cancel"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		format := statement.CSVFormat{
+			DateColumn:         strings.TrimSpace(date.Text),
+			AmountColumn:       strings.TrimSpace(amount.Text),
+			CounterpartyColumn: strings.TrimSpace(counterparty.Text),
+			RemittanceColumn:   strings.TrimSpace(remittance.Text),
+			DateLayout:         strings.TrimSpace(dateLayout.Text),
+			Currency:           strings.ToUpper(strings.TrimSpace(currency.Text)),
+		}
+		for _, sep := range []struct {
+			text  string
+			value *rune
+		}{
+			{delimiter.Text, &format.Delimiter},
+			{decimalSeparator.Text, &format.DecimalSeparator},
+			{thousandsSeparator.Text, &format.ThousandsSeparator},
+		} {
+			text := sep.text
+			if text == "" {
+				continue
+			}
+			if text == `\t` {
+				text = "\t"
+			}
+			r, size := utf8.DecodeRuneInString(text)
+			if size != len(text) {
+				dialog.ShowError(fmt.Errorf("%s", i18n.T("statement.error.delimiter")), u.win)
+				return
+			}
+			*sep.value = r
+		}
+		done(format)
+	}, u.win)
+	dlg.Resize(fyne.NewSize(460, 500))
+	dlg.Show()
+}
+
+// reviewStatement lists the credit transactions with the proposed invoice
+// preselected. Each assignment can be changed or cleared before the payments
+// are recorded.
+func (u *UI) reviewStatement(matches []statement.Match) {
+	if len(matches) == 0 {
+		dialog.ShowInformation(i18n.T("statement.review.title"), i18n.T("statement.review.noCredits"), u.win)
+		return
+	}
+	unassigned := i18n.T("statement.review.unassigned")
+	type row struct {
+		tx      statement.Transaction
+		choice  *widget.Select
+		targets map[string]string
+	}
+	var rows []row
+	list := container.NewVBox()
+	for _, m := range matches {
+		tx := m.Transaction
+		text := strings.Join(nonEmpty(tx.Date.Format("2006-01-02"), tx.Amount.String(), tx.Counterparty, tx.Remittance), " · ")
+		label := widget.NewLabel(text)
+		label.Wrapping = fyne.TextWrapWord
+		if m.Recorded {
+			status := widget.NewLabel(i18n.T("statement.review.recorded", m.Invoice.Number))
+			list.Add(container.NewVBox(label, status, widget.NewSeparator()))
+			continue
+		}
+
+		options := []string{unassigned}
+		targets := make(map[string]string)
+		for _, inv := range u.invoices {
+			if inv.IsDraft() || inv.IsSettled() || inv.Total.Sign() <= 0 || !strings.EqualFold(inv.Currency, tx.Amount.Currency) {
+				continue
+			}
+			option := u.statementInvoiceLabel(inv)
+			options = append(options, option)
+			targets[option] = inv.ID
+		}
+		choice := widget.NewSelect(options, nil)
+		choice.SetSelected(unassigned)
+		hint := widget.NewLabel(i18n.T("statement.review.noProposal"))
+		if m.Invoice != nil {
+			option := u.statementInvoiceLabel(*m.Invoice)
+			if _, ok := targets[option]; !ok {
+				choice.Options = append(choice.Options, option)
+				targets[option] = m.Invoice.ID
+			}
+			choice.SetSelected(option)
+			reasons := make([]string, len(m.Reasons))
+			for i, reason := range m.Reasons {
+				reasons[i] = i18n.T("statement.reason." + reason)
+			}
+			hint.SetText(i18n.T("statement.review.proposed", m.Score, strings.Join(reasons, ", ")))
+		}
+		rows = append(rows, row{tx: tx, choice: choice, targets: targets})
+		list.Add(container.NewVBox(label, container.NewBorder(nil, nil, nil, hint, choice), widget.NewSeparator()))
+	}
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(640, 420))
+
+	if len(rows) == 0 {
+		dialog.ShowCustom(i18n.T("statement.review.title"), i18n.T("common.close"), scroll, u.win)
+		return
+	}
+	confirm := dialog.NewCustomConfirm(i18n.T("statement.review.title"), i18n.T("statement.review.record"), i18n.T("common.cancel"), scroll, func(ok bool) {
+		if !ok {
+			return
+		}
+		var (
+			recorded int
+			failures []string
+		)
+		for _, r := range rows {
+			invoiceID, ok := r.targets[r.choice.Selected]
+			if !ok {
+				continue
+			}
+			if _, err := invoicing.RecordTransaction(u.store, invoiceID, r.tx); err != nil {
+				failures = append(failures, i18n.T("statement.result.failed", r.tx.Amount, r.tx.Counterparty, err))
+				continue
+			}
+			recorded++
+		}
+		u.refreshInvoices()
+		message := i18n.T("statement.result.recorded", recorded)
+		if len(failures) > 0 {
+			message += "\n\n" + strings.Join(failures, "\n")
+		}
+		dialog.ShowInformation(i18n.T("statement.review.title"), message, u.win)
+	}, u.win)
+	confirm.Resize(fyne.NewSize(700, 540))
+	confirm.Show()
+}
+
+// statementInvoiceLabel names an open invoice with its customer and
+// outstanding balance.
+func (u *UI) statementInvoiceLabel(inv models.Invoice) string {
+	customer := inv.CustomerID
+	if c, ok := u.customerByID(inv.CustomerID); ok {
+		customer = c.DisplayName
+	}
+	return i18n.T("statement.review.invoice", inv.Number, customer, inv.Outstanding())
+}
+
+func nonEmpty(values ...string) []string {
+	out := values[:0]
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,485 @@
+package ui
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/id"
+	"github.com/janmarkuslanger/invoiceio/internal/invoicing"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func (u *UI) makeSubscriptionsTab() fyne.CanvasObject {
+	u.subscriptionDetailText = widget.NewRichTextFromMarkdown(i18n.T("subscriptions.detail.empty"))
+	u.subscriptionDetailText.Wrapping = fyne.TextWrapWord
+	detailCard := widget.NewCard(i18n.T("subscriptions.detail.title"), "", u.subscriptionDetailText)
+	detailScroll := container.NewVScroll(detailCard)
+
+	u.subscriptionList = widget.NewList(
+		func() int { return len(u.subscriptionSummaries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id < 0 || id >= len(u.subscriptionSummaries) {
+				return
+			}
+			obj.(*widget.Label).SetText(u.subscriptionSummaries[id])
+		},
+	)
+	u.subscriptionList.OnSelected = func(id widget.ListItemID) {
+		if id < 0 || id >= len(u.subscriptions) {
+			u.selectedSubscription = -1
+		} else {
+			u.selectedSubscription = id
+		}
+		u.updateSubscriptionDetail()
+		u.updateSubscriptionActionButtons()
+	}
+
+	newButton := widget.NewButtonWithIcon(i18n.T("subscriptions.button.new"), themePlusIcon(), func() {
+		if len(u.profiles) == 0 || len(u.customers) == 0 {
+			dialog.ShowInformation(i18n.T("messages.setupRequired.title"), i18n.T("messages.setupRequired.body"), u.win)
+			return
+		}
+		u.openSubscriptionDialog(nil)
+	})
+	u.subscriptionEditButton = widget.NewButton(i18n.T("button.editSelected"), func() {
+		if u.selectedSubscription < 0 || u.selectedSubscription >= len(u.subscriptions) {
+			return
+		}
+		sub := u.subscriptions[u.selectedSubscription]
+		u.openSubscriptionDialog(&sub)
+	})
+	u.subscriptionEditButton.Disable()
+
+	u.subscriptionDeleteButton = widget.NewButton(i18n.T("subscriptions.button.delete"), func() {
+		u.deleteSubscription()
+	})
+	u.subscriptionDeleteButton.Disable()
+
+	runButton := widget.NewButton(i18n.T("subscriptions.button.run"), func() {
+		u.runSubscriptions(false)
+	})
+
+	actionBar := container.NewHBox(newButton, u.subscriptionEditButton, u.subscriptionDeleteButton, runButton)
+
+	split := container.NewHSplit(
+		container.NewMax(u.subscriptionList),
+		container.NewMax(detailScroll),
+	)
+	split.SetOffset(0.34)
+
+	return container.NewBorder(actionBar, nil, nil, nil, split)
+}
+
+func (u *UI) openSubscriptionDialog(existing *models.Subscription) {
+	isEdit := existing != nil
+	title := i18n.T("subscriptions.dialog.newTitle")
+	submitLabel := i18n.T("subscriptions.dialog.create")
+	var current models.Subscription
+	if isEdit {
+		title = i18n.T("subscriptions.dialog.editTitle")
+		submitLabel = i18n.T("subscriptions.dialog.update")
+		current = *existing
+	}
+
+	profileOptions := u.profileOptions()
+	customerOptions := u.customerOptions()
+
+	name := widget.NewEntry()
+	name.SetPlaceHolder(i18n.T("subscriptions.form.namePlaceholder"))
+	profileSelect := widget.NewSelect(profileOptions, nil)
+	profileSelect.PlaceHolder = i18n.T("invoices.form.profilePlaceholder")
+	customerSelect := widget.NewSelect(customerOptions, nil)
+	customerSelect.PlaceHolder = i18n.T("invoices.form.customerPlaceholder")
+	intervalLabels := make([]string, len(models.Intervals))
+	for i, interval := range models.Intervals {
+		intervalLabels[i] = intervalLabel(interval)
+	}
+	intervalSelect := widget.NewSelect(intervalLabels, nil)
+	dayOfMonth := widget.NewEntry()
+	dayOfMonth.SetPlaceHolder(i18n.T("subscriptions.form.dayOfMonthPlaceholder"))
+	startDate := widget.NewEntry()
+	startDate.SetPlaceHolder("YYYY-MM-DD")
+	endDate := widget.NewEntry()
+	endDate.SetPlaceHolder(i18n.T("subscriptions.form.endDatePlaceholder"))
+	drafts := widget.NewCheck(i18n.T("subscriptions.form.drafts"), nil)
+	paused := widget.NewCheck(i18n.T("subscriptions.form.paused"), nil)
+	taxRate := widget.NewEntry()
+	taxRate.SetPlaceHolder(i18n.T("invoices.form.taxRatePlaceholder"))
+	currency := widget.NewEntry()
+	currency.SetPlaceHolder(money.DefaultCurrency)
+	notes := widget.NewMultiLineEntry()
+	placeholders := widget.NewLabel(i18n.T("subscriptions.form.placeholders", strings.Join(invoicing.Placeholders, " ")))
+	placeholders.Wrapping = fyne.TextWrapWord
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	status.Hide()
+	showError := func(message string) {
+		status.SetText(message)
+		status.Show()
+	}
+	clearError := func() {
+		status.Hide()
+	}
+
+	if isEdit {
+		name.SetText(current.Name)
+		if prof, ok := u.profileByID(current.ProfileID); ok {
+			profileSelect.SetSelected(u.profileLabel(prof))
+		}
+		if cust, ok := u.customerByID(current.CustomerID); ok {
+			customerSelect.SetSelected(u.customerLabel(cust))
+		}
+		intervalSelect.SetSelected(intervalLabel(current.Interval))
+		if current.DayOfMonth > 0 {
+			dayOfMonth.SetText(strconv.Itoa(current.DayOfMonth))
+		}
+		startDate.SetText(current.StartDate.Format("2006-01-02"))
+		if !current.EndDate.IsZero() {
+			endDate.SetText(current.EndDate.Format("2006-01-02"))
+		}
+		drafts.SetChecked(current.Drafts)
+		paused.SetChecked(current.Paused)
+		if len(current.Items) > 0 {
+			taxRate.SetText(current.Items[len(current.Items)-1].TaxRatePercent.StringFixed(2))
+		} else {
+			taxRate.SetText("0")
+		}
+		currency.SetText(current.Currency)
+		notes.SetText(current.Notes)
+	} else {
+		now := time.Now()
+		intervalSelect.SetSelected(intervalLabel(models.IntervalMonthly))
+		startDate.SetText(time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"))
+		taxRate.SetText("0")
+		currency.SetText(money.DefaultCurrency)
+		if len(profileOptions) > 0 {
+			profileSelect.SetSelected(profileOptions[0])
+		}
+		if len(customerOptions) > 0 {
+			customerSelect.SetSelected(customerOptions[0])
+		}
+		if profile, ok := u.profileByID(u.lastProfileID); ok {
+			profileSelect.SetSelected(u.profileLabel(profile))
+		}
+		if customer, ok := u.customerByID(u.lastCustomerID); ok {
+			customerSelect.SetSelected(u.customerLabel(customer))
+		}
+	}
+
+	selectedCurrency := func() string {
+		if v := strings.ToUpper(strings.TrimSpace(currency.Text)); v != "" {
+			return v
+		}
+		return money.DefaultCurrency
+	}
+	defaultTaxRate := func() (money.Decimal, bool) {
+		v := strings.TrimSpace(taxRate.Text)
+		if v == "" {
+			return money.Decimal{}, true
+		}
+		d, err := locale.ParseDecimal(v)
+		if err != nil {
+			showError(i18n.T("invoices.error.taxRateFormat"))
+			return money.Decimal{}, false
+		}
+		clearError()
+		return d, true
+	}
+
+	lineItems := newLineItemEditor(current.Items, selectedCurrency, defaultTaxRate, showError, clearError)
+	taxRate.OnChanged = func(string) {
+		defaultTaxRate()
+	}
+	currency.OnChanged = func(string) {
+		lineItems.updateTotals()
+	}
+	if len(current.Items) == 0 && !isEdit {
+		lineItems.add(models.InvoiceItem{Description: "", Quantity: money.DecimalFromInt(1)})
+	} else {
+		lineItems.render()
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("subscriptions.form.name"), name),
+		widget.NewFormItem(i18n.T("invoices.form.profile"), profileSelect),
+		widget.NewFormItem(i18n.T("invoices.form.customer"), customerSelect),
+		widget.NewFormItem(i18n.T("subscriptions.form.interval"), intervalSelect),
+		widget.NewFormItem(i18n.T("subscriptions.form.dayOfMonth"), dayOfMonth),
+		widget.NewFormItem(i18n.T("subscriptions.form.startDate"), startDate),
+		widget.NewFormItem(i18n.T("subscriptions.form.endDate"), endDate),
+		widget.NewFormItem("", drafts),
+		widget.NewFormItem("", paused),
+		widget.NewFormItem(i18n.T("invoices.form.taxRate"), taxRate),
+		widget.NewFormItem(i18n.T("invoices.form.currency"), currency),
+		widget.NewFormItem(i18n.T("invoices.form.notes"), notes),
+	)
+	content := container.NewVBox(form, placeholders, widget.NewSeparator(), lineItems.container)
+
+	save := widget.NewButton(submitLabel, nil)
+	cancel := widget.NewButton(i18n.T("common.cancel"), nil)
+	buttons := container.NewHBox(layout.NewSpacer(), cancel, save)
+	dlg := dialog.NewCustomWithoutButtons(title, container.NewBorder(nil, container.NewVBox(status, buttons), nil, nil, container.NewVScroll(content)), u.win)
+
+	cancel.OnTapped = func() {
+		dlg.Hide()
+	}
+
+	save.OnTapped = func() {
+		profileModel, ok := u.profileByLabel(profileSelect.Selected)
+		if !ok {
+			showError(i18n.T("invoices.error.selectionRequired"))
+			return
+		}
+		customerModel, ok := u.customerByLabel(customerSelect.Selected)
+		if !ok {
+			showError(i18n.T("invoices.error.selectionRequired"))
+			return
+		}
+		if strings.TrimSpace(name.Text) == "" {
+			showError(i18n.T("subscriptions.error.name"))
+			return
+		}
+		if !lineItems.validate() {
+			return
+		}
+		items := lineItems.Items()
+		if len(items) == 0 {
+			showError(i18n.T("invoices.error.noItems"))
+			return
+		}
+		start, err := time.Parse("2006-01-02", strings.TrimSpace(startDate.Text))
+		if err != nil {
+			showError(i18n.T("subscriptions.error.startDate"))
+			return
+		}
+		var end time.Time
+		if v := strings.TrimSpace(endDate.Text); v != "" {
+			if end, err = time.Parse("2006-01-02", v); err != nil {
+				showError(i18n.T("subscriptions.error.endDate"))
+				return
+			}
+		}
+		day := 0
+		if v := strings.TrimSpace(dayOfMonth.Text); v != "" {
+			if day, err = strconv.Atoi(v); err != nil || day < 1 || day > 31 {
+				showError(i18n.T("subscriptions.error.dayOfMonth"))
+				return
+			}
+		}
+
+		sub := current
+		if !isEdit {
+			sub.ID = id.New()
+			sub.CreatedAt = time.Now()
+		}
+		sub.Name = strings.TrimSpace(name.Text)
+		sub.ProfileID = profileModel.ID
+		sub.CustomerID = customerModel.ID
+		sub.Interval = models.Intervals[max(intervalSelect.SelectedIndex(), 0)]
+		sub.DayOfMonth = day
+		sub.StartDate = start
+		sub.EndDate = end
+		sub.Drafts = drafts.Checked
+		sub.Paused = paused.Checked
+		sub.Currency = selectedCurrency()
+		sub.Items = items
+		sub.Notes = strings.TrimSpace(notes.Text)
+		if err := invoicing.CheckSubscription(sub); err != nil {
+			showError(i18n.T("subscriptions.error.saveFailed", err))
+			return
+		}
+		if err := u.store.SaveSubscription(sub); err != nil {
+			showError(i18n.T("subscriptions.error.saveFailed", err))
+			return
+		}
+
+		u.lastProfileID = sub.ProfileID
+		u.lastCustomerID = sub.CustomerID
+		u.refreshSubscriptions(sub.ID)
+		dlg.Hide()
+	}
+
+	dlg.Resize(fyne.NewSize(600, 720))
+	dlg.Show()
+}
+
+func (u *UI) updateSubscriptionDetail() {
+	if u.subscriptionDetailText == nil {
+		return
+	}
+	if u.selectedSubscription < 0 || u.selectedSubscription >= len(u.subscriptions) {
+		u.subscriptionDetailText.ParseMarkdown(i18n.T("subscriptions.detail.empty"))
+		return
+	}
+	s := u.subscriptions[u.selectedSubscription]
+	customerName := s.CustomerID
+	if cust, ok := u.customerByID(s.CustomerID); ok {
+		customerName = cust.DisplayName
+	}
+	profileName := s.ProfileID
+	if prof, ok := u.profileByID(s.ProfileID); ok {
+		profileName = prof.DisplayName
+	}
+
+	schedule := intervalLabel(s.Interval)
+	if s.DayOfMonth > 0 && s.Interval != models.IntervalWeekly {
+		schedule = i18n.T("subscriptions.detail.onDay", schedule, s.DayOfMonth)
+	}
+	lines := []string{
+		i18n.T("subscriptions.detail.name", s.Name),
+		i18n.T("subscriptions.detail.status", subscriptionStatusLabel(s)),
+		i18n.T("invoices.detail.profile", profileName),
+		i18n.T("invoices.detail.customer", customerName),
+		i18n.T("subscriptions.detail.schedule", schedule),
+		i18n.T("subscriptions.detail.start", s.StartDate.Format("2006-01-02")),
+	}
+	if !s.EndDate.IsZero() {
+		lines = append(lines, i18n.T("subscriptions.detail.end", s.EndDate.Format("2006-01-02")))
+	}
+	if next, ok := s.NextRun(); ok && !s.Paused {
+		lines = append(lines, i18n.T("subscriptions.detail.nextRun", next.Format("2006-01-02")))
+	}
+	if s.Drafts {
+		lines = append(lines, i18n.T("subscriptions.detail.drafts"))
+	}
+	lines = append(lines, "", i18n.T("invoices.detail.lineItems"))
+	for _, item := range s.Items {
+		lines = append(lines, i18n.T("invoices.detail.lineItem", item.Description, item.Quantity, item.UnitPrice, item.LineTotal, item.TaxRatePercent))
+	}
+	if strings.TrimSpace(s.Notes) != "" {
+		lines = append(lines, "", i18n.T("invoices.detail.notesTitle"), s.Notes)
+	}
+
+	lines = append(lines, "", i18n.T("subscriptions.detail.history"))
+	if len(s.Runs) == 0 {
+		lines = append(lines, i18n.T("subscriptions.detail.noRuns"))
+	}
+	for i := len(s.Runs) - 1; i >= 0; i-- {
+		run := s.Runs[i]
+		inv, err := u.store.GetInvoice(run.InvoiceID)
+		if err != nil {
+			lines = append(lines, i18n.T("subscriptions.detail.runMissing", run.Date.Format("2006-01-02"), run.Number))
+			continue
+		}
+		lines = append(lines, i18n.T("subscriptions.detail.run", run.Date.Format("2006-01-02"), invoiceNumberLabel(inv), inv.Total, invoiceBadge(inv)))
+	}
+	u.subscriptionDetailText.ParseMarkdown(strings.Join(lines, "\n"))
+}
+
+func (u *UI) updateSubscriptionActionButtons() {
+	selected := u.selectedSubscription >= 0 && u.selectedSubscription < len(u.subscriptions)
+	for _, button := range []*widget.Button{u.subscriptionEditButton, u.subscriptionDeleteButton} {
+		if button == nil {
+			continue
+		}
+		if selected {
+			button.Enable()
+		} else {
+			button.Disable()
+		}
+	}
+}
+
+// deleteSubscription removes the selected subscription. Invoices it already
+// generated are kept.
+func (u *UI) deleteSubscription() {
+	if u.selectedSubscription < 0 || u.selectedSubscription >= len(u.subscriptions) {
+		return
+	}
+	sub := u.subscriptions[u.selectedSubscription]
+	dialog.ShowConfirm(i18n.T("subscriptions.delete.title"), i18n.T("subscriptions.delete.confirm", sub.Name), func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := u.store.DeleteSubscription(sub.ID); err != nil {
+			dialog.ShowError(errors.New(i18n.T("subscriptions.error.deleteFailed", err)), u.win)
+			return
+		}
+		u.selectedSubscription = -1
+		u.refreshSubscriptions()
+	}, u.win)
+}
+
+// maxListedRuns limits the runs listed when asking to catch up.
+const maxListedRuns = 10
+
+// RunDueSubscriptions generates the invoices of all due subscription runs,
+// including runs missed while the application was closed. It is meant to be
+// called once on start and only reports back when something happened. A
+// single due run is generated right away; if more are due, e.g. after a long
+// absence or with a start date in the past, the user is asked first.
+func (u *UI) RunDueSubscriptions() {
+	subs, err := u.store.ListSubscriptions()
+	if err != nil {
+		dialog.ShowError(errors.New(i18n.T("subscriptions.error.runFailed", err)), u.win)
+		return
+	}
+	now := time.Now()
+	var runs []string
+	for _, sub := range subs {
+		for _, date := range invoicing.DueRuns(sub, now) {
+			runs = append(runs, i18n.T("subscriptions.confirm.entry", sub.Name, date.Format("2006-01-02")))
+		}
+	}
+	switch {
+	case len(runs) == 0:
+		return
+	case len(runs) == 1:
+		u.runSubscriptions(true)
+		return
+	}
+	listed := runs
+	if len(runs) > maxListedRuns {
+		listed = append(runs[:maxListedRuns:maxListedRuns], i18n.T("subscriptions.confirm.more", len(runs)-maxListedRuns))
+	}
+	dialog.ShowConfirm(i18n.T("subscriptions.confirm.title"), i18n.T("subscriptions.confirm.body", len(runs), strings.Join(listed, "\n")), func(ok bool) {
+		if ok {
+			u.runSubscriptions(true)
+		}
+	}, u.win)
+}
+
+func (u *UI) runSubscriptions(quiet bool) {
+	generated, err := invoicing.RunSubscriptions(u.store, time.Now())
+	if len(generated) > 0 {
+		lines := make([]string, len(generated))
+		for i, g := range generated {
+			lines[i] = i18n.T("subscriptions.info.entry", g.Subscription.Name, g.Date.Format("2006-01-02"), invoiceNumberLabel(g.Invoice), g.Invoice.Total)
+		}
+		u.refreshInvoices()
+		dialog.ShowInformation(i18n.T("subscriptions.info.generatedTitle"), i18n.T("subscriptions.info.generatedBody", len(generated), strings.Join(lines, "\n")), u.win)
+	} else if err == nil && !quiet {
+		dialog.ShowInformation(i18n.T("subscriptions.info.generatedTitle"), i18n.T("subscriptions.info.nothingDue"), u.win)
+	}
+	if err != nil {
+		dialog.ShowError(errors.New(i18n.T("subscriptions.error.runFailed", err)), u.win)
+	}
+	u.refreshSubscriptions()
+}
+
+func intervalLabel(interval string) string {
+	return i18n.T("subscriptions.interval." + interval)
+}
+
+func subscriptionStatusLabel(s models.Subscription) string {
+	switch {
+	case s.Paused:
+		return i18n.T("subscriptions.status.paused")
+	case !s.IsActive():
+		return i18n.T("subscriptions.status.ended")
+	}
+	return i18n.T("subscriptions.status.active")
+}
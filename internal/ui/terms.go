@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/locale"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// termsInput edits models.PaymentTerms: the net days and any number of
+// early-payment discount tiers.
+type termsInput struct {
+	netDays *widget.Entry
+	tiers   []*discountTierInput
+	list    *fyne.Container
+	row     fyne.CanvasObject
+}
+
+type discountTierInput struct {
+	percent *widget.Entry
+	days    *widget.Entry
+	row     fyne.CanvasObject
+}
+
+func newTermsInput(current models.PaymentTerms) *termsInput {
+	in := &termsInput{netDays: widget.NewEntry(), list: container.NewVBox()}
+	in.netDays.SetPlaceHolder(i18n.T("terms.form.netDaysPlaceholder"))
+	add := widget.NewButtonWithIcon(i18n.T("terms.form.addDiscount"), theme.ContentAddIcon(), func() {
+		in.addTier(models.DiscountTier{})
+	})
+	netDays := widget.NewForm(widget.NewFormItem(i18n.T("terms.form.netDays"), in.netDays))
+	in.row = container.NewVBox(container.NewBorder(nil, nil, nil, add, netDays), in.list)
+	in.set(current)
+	return in
+}
+
+// set replaces the input with terms.
+func (in *termsInput) set(terms models.PaymentTerms) {
+	in.netDays.SetText("")
+	if terms.NetDays > 0 {
+		in.netDays.SetText(strconv.Itoa(terms.NetDays))
+	}
+	in.tiers = nil
+	in.list.Objects = nil
+	for _, tier := range terms.Discounts {
+		in.addTier(tier)
+	}
+	in.list.Refresh()
+}
+
+func (in *termsInput) addTier(tier models.DiscountTier) {
+	t := &discountTierInput{percent: widget.NewEntry(), days: widget.NewEntry()}
+	t.percent.SetPlaceHolder(i18n.T("terms.form.discountPercent"))
+	t.days.SetPlaceHolder(i18n.T("terms.form.discountDays"))
+	if tier.Percent.Sign() > 0 {
+		t.percent.SetText(tier.Percent.String())
+		t.days.SetText(strconv.Itoa(tier.Days))
+	}
+	remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		for i, other := range in.tiers {
+			if other == t {
+				in.tiers = append(in.tiers[:i], in.tiers[i+1:]...)
+				break
+			}
+		}
+		in.list.Remove(t.row)
+	})
+	t.row = container.NewBorder(nil, nil, nil, remove, container.NewGridWithColumns(2, t.percent, t.days))
+	in.tiers = append(in.tiers, t)
+	in.list.Add(t.row)
+}
+
+// value validates the input. Tiers left completely empty are ignored.
+func (in *termsInput) value() (models.PaymentTerms, error) {
+	var terms models.PaymentTerms
+	if text := strings.TrimSpace(in.netDays.Text); text != "" {
+		days, err := strconv.Atoi(text)
+		if err != nil || days < 0 {
+			return terms, errors.New(i18n.T("terms.error.netDays"))
+		}
+		terms.NetDays = days
+	}
+	for _, t := range in.tiers {
+		percentText, daysText := strings.TrimSpace(t.percent.Text), strings.TrimSpace(t.days.Text)
+		if percentText == "" && daysText == "" {
+			continue
+		}
+		percent, err := locale.ParseDecimal(strings.TrimSuffix(percentText, "%"))
+		if err != nil || percent.Sign() <= 0 || percent.Cmp(money.DecimalFromInt(100)) >= 0 {
+			return terms, errors.New(i18n.T("terms.error.discountPercent"))
+		}
+		days, err := strconv.Atoi(daysText)
+		if err != nil || days < 0 {
+			return terms, errors.New(i18n.T("terms.error.discountDays"))
+		}
+		if terms.NetDays > 0 && days > terms.NetDays {
+			return terms, errors.New(i18n.T("terms.error.discountAfterNet"))
+		}
+		terms.Discounts = append(terms.Discounts, models.DiscountTier{Percent: percent, Days: days})
+	}
+	return terms, nil
+}
+
+// termsLines describes payment terms in the detail panes.
+func termsLines(terms models.PaymentTerms) []string {
+	var lines []string
+	for _, tier := range terms.Discounts {
+		lines = append(lines, i18n.T("terms.detail.discount", tier.Percent.String(), tier.Days))
+	}
+	if terms.NetDays > 0 {
+		lines = append(lines, i18n.T("terms.detail.netDays", terms.NetDays))
+	}
+	return lines
+}
@@ -22,6 +22,11 @@ type UI struct {
 	customers        []models.Customer
 	invoices         []models.Invoice
 	invoiceSummaries []string
+	quotes           []models.Quote
+	quoteSummaries   []string
+
+	subscriptions         []models.Subscription
+	subscriptionSummaries []string
 
 	profileList       *widget.List
 	profileDetailText *widget.RichText
@@ -33,11 +38,32 @@ type UI struct {
 	customerEditButton *widget.Button
 	selectedCustomer   int
 
-	invoiceList       *widget.List
-	invoiceDetailText *widget.RichText
-	invoiceEditButton *widget.Button
-	invoicePayButton  *widget.Button
-	selectedInvoice   int
+	invoiceList            *widget.List
+	invoiceDetailText      *widget.RichText
+	invoiceHistoryText     *widget.RichText
+	invoicePayments        *fyne.Container
+	invoicePaymentsSection *fyne.Container
+	invoiceEditButton      *widget.Button
+	invoicePayButton       *widget.Button
+	invoiceXRechnungButton *widget.Button
+	invoiceCorrectButton   *widget.Button
+	invoiceFinalizeButton  *widget.Button
+	invoiceDeleteButton    *widget.Button
+	invoiceRemindButton    *widget.Button
+	invoiceUncollectButton *widget.Button
+	selectedInvoice        int
+
+	quoteList          *widget.List
+	quoteDetailText    *widget.RichText
+	quoteEditButton    *widget.Button
+	quoteConvertButton *widget.Button
+	selectedQuote      int
+
+	subscriptionList         *widget.List
+	subscriptionDetailText   *widget.RichText
+	subscriptionEditButton   *widget.Button
+	subscriptionDeleteButton *widget.Button
+	selectedSubscription     int
 
 	lastProfileID  string
 	lastCustomerID string
@@ -51,6 +77,9 @@ func New(store *storage.Storage, win fyne.Window) *UI {
 		selectedProfile:  -1,
 		selectedCustomer: -1,
 		selectedInvoice:  -1,
+		selectedQuote:    -1,
+
+		selectedSubscription: -1,
 	}
 }
 
@@ -58,9 +87,11 @@ func New(store *storage.Storage, win fyne.Window) *UI {
 func (u *UI) Build() fyne.CanvasObject {
 	profilesTab := container.NewTabItem(i18n.T("tabs.profiles"), u.makeProfilesTab())
 	customersTab := container.NewTabItem(i18n.T("tabs.customers"), u.makeCustomersTab())
+	quotesTab := container.NewTabItem(i18n.T("tabs.quotes"), u.makeQuotesTab())
 	invoicesTab := container.NewTabItem(i18n.T("tabs.invoices"), u.makeInvoicesTab())
+	subscriptionsTab := container.NewTabItem(i18n.T("tabs.subscriptions"), u.makeSubscriptionsTab())
 
-	tabs := container.NewAppTabs(profilesTab, customersTab, invoicesTab)
+	tabs := container.NewAppTabs(profilesTab, customersTab, quotesTab, invoicesTab, subscriptionsTab)
 	tabs.SetTabLocation(container.TabLocationTop)
 
 	newProfileButton := widget.NewButtonWithIcon(i18n.T("toolbar.newProfile"), theme.AccountIcon(), func() {
@@ -104,7 +135,9 @@ func (u *UI) Build() fyne.CanvasObject {
 
 	u.refreshProfiles()
 	u.refreshCustomers()
+	u.refreshQuotes()
 	u.refreshInvoices()
+	u.refreshSubscriptions()
 
 	return container.NewBorder(top, nil, nil, nil, tabs)
 }
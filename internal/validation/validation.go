@@ -0,0 +1,312 @@
+// Package validation checks invoices against the mandatory-field and
+// calculation rules of EN 16931 and, for German issuers, §14 UStG.
+package validation
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/janmarkuslanger/invoiceio/internal/einvoice"
+	"github.com/janmarkuslanger/invoiceio/internal/i18n"
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+// Severity tells blocking findings apart from hints.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule identifiers. BR-* rules come from EN 16931-1, UStG-* rules from
+// §14 Abs. 4 UStG and BR-DE-* and PEPPOL-* rules from the XRechnung CIUS.
+// INVOICEIO-* rules are limits of this application.
+const (
+	RuleInvoiceNumber   = "BR-02"
+	RuleIssueDate       = "BR-03"
+	RuleCurrency        = "BR-05"
+	RuleSellerName      = "BR-06"
+	RuleBuyerName       = "BR-07"
+	RuleSellerCountry   = "BR-09"
+	RuleBuyerCountry    = "BR-11"
+	RuleLines           = "BR-16"
+	RuleLineQuantity    = "BR-22"
+	RuleLineAmount      = "BR-24"
+	RuleLineName        = "BR-25"
+	RuleLinePrice       = "BR-27"
+	RuleLineSum         = "BR-CO-10"
+	RuleTaxSum          = "BR-CO-14"
+	RuleGrandTotal      = "BR-CO-15"
+	RuleTaxCalculation  = "BR-CO-17"
+	RuleTaxBreakdown    = "BR-CO-18"
+	RulePaymentTerms    = "BR-CO-25"
+	RuleSellerTaxID     = "BR-S-02"
+	RuleCategoryBasis   = "BR-S-08"
+	RuleTaxCategory     = "BR-CL-18"
+	RuleExemptReason    = "BR-E-10"
+	RuleReverseCharge   = "BR-AE-02"
+	RuleReverseReason   = "BR-AE-10"
+	RuleIntraCommunity  = "BR-IC-02"
+	RuleIntraReason     = "BR-IC-10"
+	RuleExportReason    = "BR-G-10"
+	RuleNotSubject      = "BR-O-10"
+	RuleAddress         = "UStG-14-4-1"
+	RuleIssuerTaxNumber = "UStG-14-4-2"
+	RuleExemptionNote   = "UStG-14-4-8"
+	RulePaymentMeans    = "BR-DE-1"
+	RuleContactName     = "BR-DE-5"
+	RuleContactPhone    = "BR-DE-6"
+	RuleContactEmail    = "BR-DE-7"
+	RuleBuyerEndpoint   = "PEPPOL-EN16931-R010"
+	RuleSellerEndpoint  = "PEPPOL-EN16931-R020"
+	RuleAmountRange     = "INVOICEIO-RANGE"
+)
+
+// Finding is a single rule violation. Args fill the placeholders of the
+// localised message.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Args     []any
+}
+
+// Message returns the localised description of the finding.
+func (f Finding) Message() string {
+	return i18n.T("validation.rule."+f.Rule, f.Args...)
+}
+
+// String formats the finding with its severity and rule for display.
+func (f Finding) String() string {
+	return i18n.T("validation.severity."+string(f.Severity), f.Rule, f.Message())
+}
+
+// Findings is the result of a validation run.
+type Findings []Finding
+
+// HasErrors reports whether any finding blocks the invoice.
+func (fs Findings) HasErrors() bool {
+	for _, f := range fs {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Without drops all findings of the given rule.
+func (fs Findings) Without(rule string) Findings {
+	out := make(Findings, 0, len(fs))
+	for _, f := range fs {
+		if f.Rule != rule {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// String lists the findings one per line.
+func (fs Findings) String() string {
+	lines := make([]string, len(fs))
+	for i, f := range fs {
+		lines[i] = f.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+type checker struct {
+	findings Findings
+}
+
+func (c *checker) fail(ok bool, rule string, args ...any) {
+	if !ok {
+		c.findings = append(c.findings, Finding{Rule: rule, Severity: SeverityError, Args: args})
+	}
+}
+
+func (c *checker) warn(ok bool, rule string, args ...any) {
+	if !ok {
+		c.findings = append(c.findings, Finding{Rule: rule, Severity: SeverityWarning, Args: args})
+	}
+}
+
+// Check runs all rules against the invoice as it would be issued by profile
+// to customer. The stored totals are compared with a fresh calculation, so
+// amounts edited outside the application are caught as well.
+func Check(profile models.Profile, customer models.Customer, invoice models.Invoice) Findings {
+	c := &checker{}
+	german := einvoice.CountryCode(profile.Country) == "DE"
+
+	c.fail(strings.TrimSpace(invoice.Number) != "", RuleInvoiceNumber)
+	c.fail(!invoice.IssueDate.IsZero(), RuleIssueDate)
+	c.fail(validCurrency(invoice.Currency), RuleCurrency, invoice.Currency)
+
+	c.fail(!blank(profile.CompanyName, profile.DisplayName), RuleSellerName)
+	c.fail(strings.TrimSpace(customer.DisplayName) != "", RuleBuyerName)
+	// Structured invoices carry the country codes; a plain PDF prints the
+	// country as entered.
+	country := c.warn
+	if profile.EInvoice.FacturXProfile != "" || !blank(customer.LeitwegID) {
+		country = c.fail
+	}
+	country(einvoice.CountryCode(profile.Country) != "", RuleSellerCountry, profile.Country)
+	country(einvoice.CountryCode(customer.Country) != "", RuleBuyerCountry, customer.Country)
+	if german {
+		c.fail(!blank(profile.AddressLine1) && !blank(profile.PostalCode) && !blank(profile.City), RuleAddress, i18n.T("validation.role.seller"))
+		c.fail(!blank(customer.AddressLine1) && !blank(customer.PostalCode) && !blank(customer.City), RuleAddress, i18n.T("validation.role.buyer"))
+	}
+	// Customers with a Leitweg-ID receive an XRechnung, which addresses both
+	// parties by their email addresses, names a seller contact (BR-DE-2) and
+	// states the account to pay to.
+	if !blank(customer.LeitwegID) {
+		c.fail(!blank(profile.Email), RuleSellerEndpoint)
+		c.fail(!blank(customer.Email), RuleBuyerEndpoint)
+		c.fail(!blank(profile.DisplayName), RuleContactName)
+		c.fail(!blank(profile.Phone), RuleContactPhone)
+		c.fail(!blank(profile.Email), RuleContactEmail)
+		c.fail(!blank(profile.PaymentDetails.IBAN), RulePaymentMeans)
+	}
+
+	c.fail(len(invoice.Items) > 0, RuleLines)
+	standardRated, zeroRated := false, false
+	for i, item := range invoice.Items {
+		line := i + 1
+		c.fail(strings.TrimSpace(item.Description) != "", RuleLineName, line)
+		c.warn(!item.Quantity.IsZero(), RuleLineQuantity, line)
+		c.fail(item.UnitPrice.Sign() >= 0, RuleLinePrice, line)
+		c.fail(item.LineTotal.Cmp(item.UnitPrice.Mul(item.Quantity)) == 0, RuleLineAmount, line)
+		if item.TaxRatePercent.IsZero() {
+			zeroRated = true
+		} else {
+			standardRated = true
+		}
+	}
+
+	if len(invoice.Items) > 0 {
+		checkTotals(c, invoice)
+	}
+
+	hasTaxID := !blank(profile.TaxID)
+	c.fail(hasTaxID || !standardRated, RuleSellerTaxID)
+	if zeroRated {
+		checkExemption(c, profile, customer, invoice.TaxExemption)
+	}
+	if german {
+		c.fail(hasTaxID || standardRated, RuleIssuerTaxNumber)
+		c.warn(!zeroRated || !blank(invoice.Notes, invoice.TaxExemption.Reason), RuleExemptionNote)
+	}
+	c.fail(invoice.Total.Sign() <= 0 || !invoice.DueDate.IsZero() || !blank(profile.PaymentDetails.PaymentTerms), RulePaymentTerms)
+
+	return c.findings
+}
+
+// reasonRules are the rules requiring an exemption reason, by category.
+var reasonRules = map[string]string{
+	models.TaxExempt:         RuleExemptReason,
+	models.TaxReverseCharge:  RuleReverseReason,
+	models.TaxIntraCommunity: RuleIntraReason,
+	models.TaxExport:         RuleExportReason,
+	models.TaxNotSubject:     RuleNotSubject,
+}
+
+// checkExemption checks the tax category of the items without VAT. Reverse
+// charge and intra-community supplies name the VAT IDs of both parties.
+func checkExemption(c *checker, profile models.Profile, customer models.Customer, exemption models.TaxExemption) {
+	category := exemption.CategoryCode()
+	known := false
+	for _, code := range models.TaxCategories {
+		known = known || code == category
+	}
+	c.fail(known, RuleTaxCategory, category)
+	if rule, ok := reasonRules[category]; ok {
+		c.fail(!blank(exemption.Reason), rule)
+	}
+	vatIDs := isVATID(profile.TaxID) && isVATID(customer.VATID)
+	switch category {
+	case models.TaxReverseCharge:
+		c.fail(vatIDs, RuleReverseCharge)
+	case models.TaxIntraCommunity:
+		c.fail(vatIDs, RuleIntraCommunity)
+	}
+}
+
+// isVATID reports whether id looks like a VAT identification number, which
+// starts with a country prefix.
+func isVATID(id string) bool {
+	id = strings.TrimSpace(id)
+	return len(id) > 2 && unicode.IsLetter(rune(id[0])) && unicode.IsLetter(rune(id[1]))
+}
+
+// inRange reports whether every amount of a calculated invoice can be added
+// up without overflowing.
+func inRange(invoice models.Invoice) bool {
+	for _, item := range invoice.Items {
+		if !item.LineTotal.InRange() {
+			return false
+		}
+	}
+	for _, tax := range invoice.TaxBreakdown {
+		if !tax.Net.InRange() || !tax.Tax.InRange() {
+			return false
+		}
+	}
+	return invoice.Total.InRange()
+}
+
+// checkTotals compares the stored sums with the items they derive from.
+func checkTotals(c *checker, invoice models.Invoice) {
+	expected := models.Invoice{Currency: invoice.Currency, Items: append([]models.InvoiceItem(nil), invoice.Items...)}
+	expected.Recalculate()
+
+	c.fail(inRange(expected), RuleAmountRange)
+
+	c.fail(invoice.Subtotal.Cmp(expected.Subtotal) == 0, RuleLineSum, expected.Subtotal, invoice.Subtotal)
+	c.fail(len(invoice.TaxBreakdown) > 0, RuleTaxBreakdown)
+
+	taxSum := money.Zero(invoice.Currency)
+	for _, tax := range invoice.TaxBreakdown {
+		taxSum = taxSum.Add(tax.Tax)
+		c.fail(tax.Tax.Cmp(tax.Net.Percent(tax.RatePercent)) == 0, RuleTaxCalculation, tax.RatePercent.String())
+		_, ok := breakdownNet(expected.TaxBreakdown, tax.RatePercent)
+		c.fail(ok, RuleCategoryBasis, tax.RatePercent.String())
+	}
+	for _, want := range expected.TaxBreakdown {
+		net, ok := breakdownNet(invoice.TaxBreakdown, want.RatePercent)
+		c.fail(ok && net.Cmp(want.Net) == 0, RuleCategoryBasis, want.RatePercent.String())
+	}
+	c.fail(invoice.TaxAmount.Cmp(taxSum) == 0, RuleTaxSum, taxSum, invoice.TaxAmount)
+	c.fail(invoice.Total.Cmp(invoice.Subtotal.Add(invoice.TaxAmount)) == 0, RuleGrandTotal)
+}
+
+func breakdownNet(lines []models.TaxLine, rate money.Decimal) (money.Money, bool) {
+	for _, line := range lines {
+		if line.RatePercent.Cmp(rate) == 0 {
+			return line.Net, true
+		}
+	}
+	return money.Money{}, false
+}
+
+// validCurrency accepts three letter ISO 4217 style codes.
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if !unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// blank reports whether all values are empty after trimming.
+func blank(values ...string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package validation
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/janmarkuslanger/invoiceio/internal/models"
+	"github.com/janmarkuslanger/invoiceio/internal/money"
+)
+
+func fixture(rate string, exemption models.TaxExemption) (models.Profile, models.Customer, models.Invoice) {
+	profile := models.Profile{
+		DisplayName:    "Erika Muster",
+		CompanyName:    "Seller GmbH",
+		AddressLine1:   "Hauptstraße 1",
+		PostalCode:     "10115",
+		City:           "Berlin",
+		Country:        "DE",
+		Phone:          "+49 30 123456",
+		TaxID:          "DE123456789",
+		PaymentDetails: models.PaymentDetails{IBAN: "DE89370400440532013000"},
+	}
+	customer := models.Customer{
+		DisplayName:  "Buyer AB",
+		AddressLine1: "Storgatan 1",
+		PostalCode:   "11122",
+		City:         "Stockholm",
+		Country:      "SE",
+		VATID:        "SE123456789701",
+	}
+	invoice := models.Invoice{
+		Number:       "INV-1",
+		IssueDate:    time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:      time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		Currency:     "EUR",
+		TaxExemption: exemption,
+		Items: []models.InvoiceItem{{
+			Description:    "Consulting",
+			Quantity:       money.DecimalFromInt(1),
+			UnitPrice:      money.New(10000, "EUR"),
+			TaxRatePercent: money.MustParseDecimal(rate),
+		}},
+	}
+	invoice.Recalculate()
+	return profile, customer, invoice
+}
+
+func rules(findings Findings) []string {
+	out := make([]string, len(findings))
+	for i, f := range findings {
+		out[i] = f.Rule
+	}
+	return out
+}
+
+func TestCheckExemption(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate       string
+		exemption  models.TaxExemption
+		buyerVATID string
+		want       []string
+	}{
+		{name: "standard rated ignores the exemption", rate: "19", exemption: models.TaxExemption{Category: models.TaxExempt}},
+		{name: "zero rated without note", rate: "0", want: []string{RuleExemptionNote}},
+		{name: "exempt with reason", rate: "0", exemption: models.TaxExemption{Category: models.TaxExempt, Reason: "§ 4 Nr. 21 UStG"}},
+		{name: "exempt without reason", rate: "0", exemption: models.TaxExemption{Category: models.TaxExempt}, want: []string{RuleExemptReason, RuleExemptionNote}},
+		{name: "reverse charge", rate: "0", exemption: models.TaxExemption{Category: models.TaxReverseCharge, Reason: "Reverse charge"}},
+		{name: "reverse charge without buyer VAT ID", rate: "0", exemption: models.TaxExemption{Category: models.TaxReverseCharge, Reason: "Reverse charge"}, buyerVATID: "-", want: []string{RuleReverseCharge}},
+		{name: "intra-community without reason", rate: "0", exemption: models.TaxExemption{Category: models.TaxIntraCommunity}, want: []string{RuleIntraReason, RuleExemptionNote}},
+		{name: "intra-community with national tax number", rate: "0", exemption: models.TaxExemption{Category: models.TaxIntraCommunity, Reason: "Intra-community supply"}, buyerVATID: "123/456", want: []string{RuleIntraCommunity}},
+		{name: "export without reason", rate: "0", exemption: models.TaxExemption{Category: models.TaxExport}, want: []string{RuleExportReason, RuleExemptionNote}},
+		{name: "not subject without reason", rate: "0", exemption: models.TaxExemption{Category: models.TaxNotSubject}, want: []string{RuleNotSubject, RuleExemptionNote}},
+		{name: "unknown category", rate: "0", exemption: models.TaxExemption{Category: "X", Reason: "?"}, want: []string{RuleTaxCategory}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture(tt.rate, tt.exemption)
+			switch tt.buyerVATID {
+			case "":
+			case "-":
+				customer.VATID = ""
+			default:
+				customer.VATID = tt.buyerVATID
+			}
+			got := rules(Check(profile, customer, invoice))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEndpoints(t *testing.T) {
+	tests := []struct {
+		name                     string
+		leitwegID, seller, buyer string
+		want                     []string
+	}{
+		{name: "no XRechnung recipient", seller: "", buyer: ""},
+		{name: "both addresses", leitwegID: "991-12345-67", seller: "billing@seller.example", buyer: "ap@buyer.example"},
+		{name: "seller missing", leitwegID: "991-12345-67", buyer: "ap@buyer.example", want: []string{RuleSellerEndpoint, RuleContactEmail}},
+		{name: "both missing", leitwegID: "991-12345-67", seller: " ", want: []string{RuleSellerEndpoint, RuleBuyerEndpoint, RuleContactEmail}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture("19", models.TaxExemption{})
+			customer.LeitwegID, profile.Email, customer.Email = tt.leitwegID, tt.seller, tt.buyer
+			got := rules(Check(profile, customer, invoice))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckXRechnungSeller(t *testing.T) {
+	tests := []struct {
+		name                 string
+		leitwegID            string
+		contact, phone, iban string
+		want                 []string
+	}{
+		{name: "complete", leitwegID: "991-12345-67", contact: "Erika Muster", phone: "+49 30 123456", iban: "DE89370400440532013000"},
+		{name: "no XRechnung recipient", contact: " "},
+		{name: "contact name missing", leitwegID: "991-12345-67", phone: "+49 30 123456", iban: "DE89370400440532013000", want: []string{RuleContactName}},
+		{name: "phone missing", leitwegID: "991-12345-67", contact: "Erika Muster", iban: "DE89370400440532013000", want: []string{RuleContactPhone}},
+		{name: "IBAN missing", leitwegID: "991-12345-67", contact: "Erika Muster", phone: "+49 30 123456", want: []string{RulePaymentMeans}},
+		{name: "all missing", leitwegID: "991-12345-67", want: []string{RuleContactName, RuleContactPhone, RulePaymentMeans}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture("19", models.TaxExemption{})
+			profile.Email, customer.Email, customer.LeitwegID = "billing@seller.example", "ap@buyer.example", tt.leitwegID
+			profile.DisplayName, profile.Phone, profile.PaymentDetails.IBAN = tt.contact, tt.phone, tt.iban
+			findings := Check(profile, customer, invoice)
+			if got := rules(findings); !slices.Equal(got, tt.want) {
+				t.Errorf("rules = %v, want %v", got, tt.want)
+			}
+			if findings.HasErrors() != (len(tt.want) > 0) {
+				t.Errorf("blocking = %v, want %v", findings.HasErrors(), len(tt.want) > 0)
+			}
+		})
+	}
+}
+
+func TestCheckAmountRange(t *testing.T) {
+	tests := []struct {
+		name            string
+		price, quantity string
+		rate            string
+		want            bool
+	}{
+		{name: "large invoice", price: "1000000000", quantity: "1000", rate: "19"},
+		{name: "line total too large", price: "1000000000", quantity: "1000000000", rate: "19", want: true},
+		{name: "tax too large", price: "1000000000", quantity: "1000", rate: "1000000000", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture(tt.rate, models.TaxExemption{})
+			invoice.Items[0].UnitPrice = money.FromDecimal(money.MustParseDecimal(tt.price), "EUR")
+			invoice.Items[0].Quantity = money.MustParseDecimal(tt.quantity)
+			invoice.Recalculate()
+			got := slices.Contains(rules(Check(profile, customer, invoice)), RuleAmountRange)
+			if got != tt.want {
+				t.Errorf("%s reported = %v, want %v", RuleAmountRange, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCountries(t *testing.T) {
+	tests := []struct {
+		name            string
+		seller, buyer   string
+		facturX, leitID string
+		want            []string
+		blocking        bool
+	}{
+		{name: "codes and names", seller: "Deutschland", buyer: "SE"},
+		{name: "UK is Great Britain", seller: "DE", buyer: "UK"},
+		{name: "unknown country on a plain PDF", seller: "DE", buyer: "Atlantis", want: []string{RuleBuyerCountry}},
+		{name: "missing countries on a plain PDF", want: []string{RuleSellerCountry, RuleBuyerCountry}},
+		{name: "unknown code with Factur-X", seller: "DE", buyer: "XX", facturX: "EN16931", want: []string{RuleBuyerCountry}, blocking: true},
+		{name: "missing country for XRechnung", seller: "", buyer: "SE", leitID: "991-12345-67", want: []string{RuleSellerCountry}, blocking: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, customer, invoice := fixture("19", models.TaxExemption{})
+			profile.Country, customer.Country = tt.seller, tt.buyer
+			profile.EInvoice.FacturXProfile, customer.LeitwegID = tt.facturX, tt.leitID
+			profile.Email, customer.Email = "billing@seller.example", "ap@buyer.example"
+			findings := Check(profile, customer, invoice).Without(RuleAddress)
+			if got := rules(findings); !slices.Equal(got, tt.want) {
+				t.Errorf("rules = %v, want %v", got, tt.want)
+			}
+			if findings.HasErrors() != tt.blocking {
+				t.Errorf("blocking = %v, want %v", findings.HasErrors(), tt.blocking)
+			}
+		})
+	}
+}